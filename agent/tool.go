@@ -0,0 +1,132 @@
+// Package agent exposes the video analyzer as a tool for LLM agent
+// frameworks such as langchaingo.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Tool is a generic agent tool. Its Name/Description/Call methods match
+// langchaingo's tools.Tool interface, so any Tool can be passed to a
+// langchaingo agent directly; Schema additionally describes the input for
+// function-calling style frameworks.
+type Tool interface {
+	Name() string
+	Description() string
+	Schema() json.RawMessage
+	Call(ctx context.Context, input string) (string, error)
+}
+
+// VideoInput is the JSON input accepted by VideoTool
+type VideoInput struct {
+	Path      string `json:"path"`                 // Local file, .h264 stream or http(s) URL
+	Question  string `json:"question"`             // What to ask about the video
+	MaxFrames int    `json:"max_frames,omitempty"` // Frames to sample (default: 8)
+}
+
+// videoSchema is the JSON schema of VideoInput
+const videoSchema = `{
+  "type": "object",
+  "properties": {
+    "path": {"type": "string", "description": "Local video file path, raw .h264 stream path, or http(s) URL"},
+    "question": {"type": "string", "description": "Question to answer about the video"},
+    "max_frames": {"type": "integer", "description": "Number of frames to sample (default 8)", "minimum": 1}
+  },
+  "required": ["path", "question"]
+}`
+
+// VideoTool answers questions about videos using the SDK client
+type VideoTool struct {
+	Client          *client.Client
+	ToolName        string // Default: analyze_video
+	ToolDescription string // Optional override of the tool description
+}
+
+// NewVideoTool creates the analyze_video tool
+func NewVideoTool(c *client.Client) *VideoTool {
+	return &VideoTool{Client: c, ToolName: "analyze_video"}
+}
+
+// Name implements Tool
+func (t *VideoTool) Name() string {
+	if t.ToolName == "" {
+		return "analyze_video"
+	}
+	return t.ToolName
+}
+
+// Description implements Tool
+func (t *VideoTool) Description() string {
+	if t.ToolDescription != "" {
+		return t.ToolDescription
+	}
+	return `Analyze a video and answer a question about its content. ` +
+		`Input is JSON: {"path": "<file path or URL>", "question": "<question>"}. ` +
+		`Returns the answer as text.`
+}
+
+// Schema implements Tool
+func (t *VideoTool) Schema() json.RawMessage {
+	return json.RawMessage(videoSchema)
+}
+
+// Call implements Tool. The input is VideoInput JSON; as a fallback for
+// agents that do not emit JSON, "path | question" is also accepted.
+func (t *VideoTool) Call(ctx context.Context, input string) (string, error) {
+	in, err := parseInput(input)
+	if err != nil {
+		return "", err
+	}
+
+	var resp *models.ChatResponse
+	path := in.Path
+	lower := strings.ToLower(path)
+
+	switch {
+	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
+		resp, err = t.Client.AnalyzeVideoURLDownloadWithContext(ctx, path, in.Question, &client.URLDownloadOptions{MaxFrames: in.MaxFrames})
+	case strings.HasSuffix(lower, ".h264") || strings.HasSuffix(lower, ".264"):
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, readErr)
+		}
+		resp, err = t.Client.AnalyzeH264StreamWithContext(ctx, data, in.Question, nil)
+	default:
+		resp, err = t.Client.AnalyzeVideoFileWithContext(ctx, path, in.Question, in.MaxFrames, nil)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty response")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// parseInput decodes VideoInput JSON or the "path | question" fallback
+func parseInput(input string) (VideoInput, error) {
+	var in VideoInput
+	input = strings.TrimSpace(input)
+
+	if strings.HasPrefix(input, "{") {
+		if err := json.Unmarshal([]byte(input), &in); err != nil {
+			return in, fmt.Errorf("invalid tool input: %w", err)
+		}
+	} else if parts := strings.SplitN(input, "|", 2); len(parts) == 2 {
+		in.Path = strings.TrimSpace(parts[0])
+		in.Question = strings.TrimSpace(parts[1])
+	}
+
+	if in.Path == "" || in.Question == "" {
+		return in, fmt.Errorf("tool input requires path and question")
+	}
+	return in, nil
+}
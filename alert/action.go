@@ -0,0 +1,197 @@
+package alert
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Action is executed when a rule fires
+type Action interface {
+	Fire(ctx context.Context, a Alert) error
+}
+
+// ActionFunc adapts a function to the Action interface
+type ActionFunc func(ctx context.Context, a Alert) error
+
+// Fire implements Action
+func (f ActionFunc) Fire(ctx context.Context, a Alert) error {
+	return f(ctx, a)
+}
+
+// WebhookAction posts the alert as JSON to a URL
+type WebhookAction struct {
+	URL        string
+	Headers    map[string]string
+	HTTPClient *http.Client
+}
+
+// NewWebhookAction creates a webhook action with a 10s timeout
+func NewWebhookAction(url string) *WebhookAction {
+	return &WebhookAction{
+		URL:        url,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Fire implements Action
+func (w *WebhookAction) Fire(ctx context.Context, a Alert) error {
+	if _, err := postJSON(ctx, w.HTTPClient, w.URL, w.Headers, a); err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	return nil
+}
+
+// EmailAction sends the alert as a plain-text email over SMTP
+type EmailAction struct {
+	Addr    string    // SMTP server address, host:port
+	Auth    smtp.Auth // Optional authentication
+	From    string
+	To      []string
+	Subject string        // Optional subject prefix (default: "[alert]")
+	Message *Message      // Optional body template (default: rule, severity, source, times, reason and content)
+	Timeout time.Duration // Limit on the whole SMTP exchange (default: 30s), shortened by the ctx deadline
+}
+
+// Fire implements Action
+func (e *EmailAction) Fire(ctx context.Context, a Alert) error {
+	prefix := e.Subject
+	if prefix == "" {
+		prefix = "[alert]"
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", headerValue(e.From))
+	fmt.Fprintf(&msg, "To: %s\r\n", headerValue(strings.Join(e.To, ", ")))
+	subject := headerValue(fmt.Sprintf("%s %s (%s) %s", prefix, a.Rule, a.Severity, a.Source))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	if e.Message != nil {
+		body, err := e.Message.Render(a)
+		if err != nil {
+			return err
+		}
+		msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n") + "\r\n")
+	} else {
+		fmt.Fprintf(&msg, "Rule: %s\r\nSeverity: %s\r\nSource: %s\r\nTime: %s\r\n",
+			a.Rule, a.Severity, a.Source, a.Time.Format(time.RFC3339))
+		if !a.At.IsZero() {
+			fmt.Fprintf(&msg, "Footage time: %s\r\n", a.At.Format(time.RFC3339))
+		}
+		fmt.Fprintf(&msg, "Reason: %s\r\n\r\n%s\r\n", a.Reason, a.Content)
+	}
+
+	if err := e.send(ctx, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// send does what smtp.SendMail does, but dials with ctx and bounds the
+// exchange with a connection deadline, so an unresponsive server cannot
+// block the alert pipeline
+func (e *EmailAction) send(ctx context.Context, msg []byte) error {
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	host, _, err := net.SplitHostPort(e.Addr)
+	if err != nil {
+		return err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.Addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	// Cancelling ctx aborts an exchange in progress
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if e.Auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("server doesn't support AUTH")
+		}
+		if err := c.Auth(e.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// headerValue strips line breaks, which would otherwise let rule names or
+// sources inject extra mail headers
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// MQTTPublisher is the minimal interface needed from an MQTT client
+// (e.g. a thin adapter around eclipse/paho.mqtt.golang)
+type MQTTPublisher interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+}
+
+// MQTTAction publishes the alert as JSON to an MQTT topic
+type MQTTAction struct {
+	Publisher MQTTPublisher
+	Topic     string
+}
+
+// Fire implements Action
+func (m *MQTTAction) Fire(ctx context.Context, a Alert) error {
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	topic := strings.NewReplacer("{rule}", a.Rule, "{source}", a.Source).Replace(m.Topic)
+	if err := m.Publisher.Publish(ctx, topic, payload); err != nil {
+		return fmt.Errorf("failed to publish to MQTT: %w", err)
+	}
+	return nil
+}
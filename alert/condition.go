@@ -0,0 +1,214 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Condition decides whether an analysis result should trigger a rule
+type Condition interface {
+	// Match reports whether the input matches and a short human readable reason
+	Match(in Input) (bool, string)
+}
+
+// ConditionFunc adapts a function to the Condition interface
+type ConditionFunc func(in Input) (bool, string)
+
+// Match implements Condition
+func (f ConditionFunc) Match(in Input) (bool, string) {
+	return f(in)
+}
+
+// keywordCondition matches when any keyword appears in the content
+type keywordCondition struct {
+	keywords []string
+}
+
+// Keyword matches when the content contains any of the keywords (case-insensitive)
+func Keyword(keywords ...string) Condition {
+	lowered := make([]string, len(keywords))
+	for i, k := range keywords {
+		lowered[i] = strings.ToLower(k)
+	}
+	return &keywordCondition{keywords: lowered}
+}
+
+// Match implements Condition
+func (c *keywordCondition) Match(in Input) (bool, string) {
+	content := strings.ToLower(in.Content)
+	for _, k := range c.keywords {
+		if k != "" && strings.Contains(content, k) {
+			return true, fmt.Sprintf("keyword %q detected", k)
+		}
+	}
+	return false, ""
+}
+
+// regexCondition matches the content against a regular expression
+type regexCondition struct {
+	re *regexp.Regexp
+}
+
+// Regex matches when the content matches the regular expression
+func Regex(pattern string) (Condition, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", pattern, err)
+	}
+	return &regexCondition{re: re}, nil
+}
+
+// Match implements Condition
+func (c *regexCondition) Match(in Input) (bool, string) {
+	if m := c.re.FindString(in.Content); m != "" {
+		return true, fmt.Sprintf("pattern matched %q", m)
+	}
+	return false, ""
+}
+
+// thresholdCondition compares a numeric JSON field from structured output
+type thresholdCondition struct {
+	field string
+	op    string
+	value float64
+}
+
+// Threshold matches when a numeric field in the JSON output satisfies the
+// comparison, e.g. Threshold("person_count", ">", 10).
+// Nested fields use dot notation ("stats.vehicles"). Supported operators:
+// >, >=, <, <=, ==, !=.
+func Threshold(field, op string, value float64) (Condition, error) {
+	switch op {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+	return &thresholdCondition{field: field, op: op, value: value}, nil
+}
+
+// Match implements Condition
+func (c *thresholdCondition) Match(in Input) (bool, string) {
+	fields, ok := in.JSON()
+	if !ok {
+		return false, ""
+	}
+
+	raw, ok := lookup(fields, c.field)
+	if !ok {
+		return false, ""
+	}
+
+	var v float64
+	switch n := raw.(type) {
+	case float64:
+		v = n
+	case bool:
+		if n {
+			v = 1
+		}
+	case string:
+		parsed, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return false, ""
+		}
+		v = parsed
+	default:
+		return false, ""
+	}
+
+	var matched bool
+	switch c.op {
+	case ">":
+		matched = v > c.value
+	case ">=":
+		matched = v >= c.value
+	case "<":
+		matched = v < c.value
+	case "<=":
+		matched = v <= c.value
+	case "==":
+		matched = v == c.value
+	case "!=":
+		matched = v != c.value
+	}
+
+	if !matched {
+		return false, ""
+	}
+	return true, fmt.Sprintf("%s = %g (%s %g)", c.field, v, c.op, c.value)
+}
+
+// HasLabels matches inputs carrying every label of selector, e.g. to scope
+// a rule to one site or tenant: All(HasLabels(models.Labels{"site": "hq"}), Keyword("火"))
+func HasLabels(selector models.Labels) Condition {
+	return ConditionFunc(func(in Input) (bool, string) {
+		if !in.Labels.Matches(selector) {
+			return false, ""
+		}
+		return true, "labels " + selector.String()
+	})
+}
+
+// All matches when every condition matches
+func All(conditions ...Condition) Condition {
+	return ConditionFunc(func(in Input) (bool, string) {
+		reasons := make([]string, 0, len(conditions))
+		for _, cond := range conditions {
+			ok, reason := cond.Match(in)
+			if !ok {
+				return false, ""
+			}
+			reasons = append(reasons, reason)
+		}
+		return len(conditions) > 0, strings.Join(reasons, "; ")
+	})
+}
+
+// Any matches when at least one condition matches
+func Any(conditions ...Condition) Condition {
+	return ConditionFunc(func(in Input) (bool, string) {
+		for _, cond := range conditions {
+			if ok, reason := cond.Match(in); ok {
+				return true, reason
+			}
+		}
+		return false, ""
+	})
+}
+
+// lookup resolves a dot separated path inside decoded JSON
+func lookup(fields map[string]interface{}, path string) (interface{}, bool) {
+	var cur interface{} = fields
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// extractJSON finds the first JSON object in model output, which is often
+// wrapped in a ```json fenced block or surrounded by prose
+func extractJSON(content string) (map[string]interface{}, bool) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end <= start {
+		return nil, false
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &fields); err != nil {
+		return nil, false
+	}
+	return fields, true
+}
@@ -0,0 +1,266 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Destination declares where alerts are sent. String fields may reference
+// environment variables as ${NAME}, so tokens and passwords stay out of
+// config files.
+type Destination struct {
+	Type        string            `json:"type"`                   // webhook, email, sms, dingtalk, feishu, wecom or slack
+	URL         string            `json:"url,omitempty"`          // Webhook, robot or SMS gateway URL
+	Secret      string            `json:"secret,omitempty"`       // DingTalk/Feishu signing secret
+	Headers     map[string]string `json:"headers,omitempty"`      // Extra headers for webhook and SMS gateway
+	Template    string            `json:"template,omitempty"`     // Message template (see NewMessage)
+	MinSeverity string            `json:"min_severity,omitempty"` // Only send alerts at or above this severity
+	Timeout     Duration          `json:"timeout,omitempty"`      // HTTP timeout (default 10s)
+
+	// Email
+	SMTP     string   `json:"smtp,omitempty"` // host:port
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"` // Email addresses, or phone numbers for SMS
+	Subject  string   `json:"subject,omitempty"`
+
+	AtMobiles []string `json:"at_mobiles,omitempty"` // DingTalk members to @
+}
+
+// Duration is a time.Duration written as "30s" or "5m" in config files
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration %s: %w", b, err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Action builds the notifier described by d
+func (d Destination) Action() (Action, error) {
+	expand := os.ExpandEnv
+	msg, err := NewMessage(d.Template)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(d.Timeout)
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	httpClient := &http.Client{Timeout: timeout}
+	headers := make(map[string]string, len(d.Headers))
+	for k, v := range d.Headers {
+		headers[k] = expand(v)
+	}
+	needURL := func() error {
+		if d.URL == "" {
+			return fmt.Errorf("%s destination requires url", d.Type)
+		}
+		return nil
+	}
+
+	var action Action
+	switch d.Type {
+	case "webhook":
+		if err := needURL(); err != nil {
+			return nil, err
+		}
+		action = &WebhookAction{URL: expand(d.URL), Headers: headers, HTTPClient: httpClient}
+	case "dingtalk":
+		if err := needURL(); err != nil {
+			return nil, err
+		}
+		action = &DingTalkAction{Webhook: expand(d.URL), Secret: expand(d.Secret), Message: msg, AtMobiles: d.AtMobiles, HTTPClient: httpClient}
+	case "feishu", "lark":
+		if err := needURL(); err != nil {
+			return nil, err
+		}
+		action = &FeishuAction{Webhook: expand(d.URL), Secret: expand(d.Secret), Message: msg, HTTPClient: httpClient}
+	case "wecom":
+		if err := needURL(); err != nil {
+			return nil, err
+		}
+		action = &WeComAction{Webhook: expand(d.URL), Message: msg, HTTPClient: httpClient}
+	case "slack":
+		if err := needURL(); err != nil {
+			return nil, err
+		}
+		action = &SlackAction{Webhook: expand(d.URL), Message: msg, HTTPClient: httpClient}
+	case "sms":
+		if err := needURL(); err != nil {
+			return nil, err
+		}
+		if len(d.To) == 0 {
+			return nil, fmt.Errorf("sms destination requires to")
+		}
+		sms := &SMSAction{Sender: &HTTPSMSSender{URL: expand(d.URL), Headers: headers, HTTPClient: httpClient}, To: d.To}
+		if d.Template != "" {
+			sms.Message = msg
+		}
+		action = sms
+	case "email":
+		if d.SMTP == "" || d.From == "" || len(d.To) == 0 {
+			return nil, fmt.Errorf("email destination requires smtp, from and to")
+		}
+		email := &EmailAction{Addr: expand(d.SMTP), From: expand(d.From), To: d.To, Subject: d.Subject}
+		if d.Username != "" {
+			host, _, _ := strings.Cut(email.Addr, ":")
+			email.Auth = smtp.PlainAuth("", expand(d.Username), expand(d.Password), host)
+		}
+		if d.Template != "" {
+			email.Message = msg
+		}
+		action = email
+	default:
+		return nil, fmt.Errorf("unknown destination type %q", d.Type)
+	}
+
+	if d.MinSeverity != "" {
+		if _, ok := severityRank[d.MinSeverity]; !ok {
+			return nil, fmt.Errorf("unknown severity %q", d.MinSeverity)
+		}
+		action = MinSeverity(d.MinSeverity, action)
+	}
+	return action, nil
+}
+
+// RuleConfig declares a rule; the conditions that are set must all match
+type RuleConfig struct {
+	Name      string           `json:"name"`
+	Severity  string           `json:"severity,omitempty"`
+	Cooldown  Duration         `json:"cooldown,omitempty"`
+	Keywords  []string         `json:"keywords,omitempty"`  // Any of these words
+	Regex     string           `json:"regex,omitempty"`     // Content matches this expression
+	Threshold *ThresholdConfig `json:"threshold,omitempty"` // Numeric JSON field comparison
+	Labels    models.Labels    `json:"labels,omitempty"`    // Inputs must carry these labels
+	Notify    []string         `json:"notify"`              // Destination names
+}
+
+// ThresholdConfig declares a Threshold condition
+type ThresholdConfig struct {
+	Field string  `json:"field"`
+	Op    string  `json:"op"`
+	Value float64 `json:"value"`
+}
+
+// Config declares the destinations and rules of an engine:
+//
+//	{
+//	  "dedup_window": "10m",
+//	  "destinations": {
+//	    "ops": {"type": "dingtalk", "url": "https://oapi.dingtalk.com/robot/send?access_token=${DINGTALK_TOKEN}", "secret": "${DINGTALK_SECRET}"},
+//	    "oncall": {"type": "sms", "url": "https://sms.example.com/send", "to": ["13800000000"], "min_severity": "critical"}
+//	  },
+//	  "rules": [
+//	    {"name": "fire", "severity": "critical", "keywords": ["火", "烟"], "cooldown": "5m", "notify": ["ops", "oncall"]}
+//	  ]
+//	}
+type Config struct {
+	DedupWindow  *Duration              `json:"dedup_window,omitempty"` // Default 10m; "0s" disables
+	Destinations map[string]Destination `json:"destinations"`
+	Rules        []RuleConfig           `json:"rules"`
+}
+
+// LoadConfig reads a JSON Config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse alert config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// Engine builds an engine from the config
+func (cfg *Config) Engine() (*Engine, error) {
+	actions := make(map[string]Action, len(cfg.Destinations))
+	for name, d := range cfg.Destinations {
+		a, err := d.Action()
+		if err != nil {
+			return nil, fmt.Errorf("destination %s: %w", name, err)
+		}
+		actions[name] = a
+	}
+
+	e := NewEngine()
+	if cfg.DedupWindow != nil {
+		e.WithDedupWindow(time.Duration(*cfg.DedupWindow))
+	}
+	for _, rc := range cfg.Rules {
+		rule, err := rc.rule(actions)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rc.Name, err)
+		}
+		if err := e.AddRule(rule); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// rule converts the config to a Rule using the named destinations
+func (rc RuleConfig) rule(actions map[string]Action) (Rule, error) {
+	if rc.Severity != "" {
+		if _, ok := severityRank[rc.Severity]; !ok {
+			return Rule{}, fmt.Errorf("unknown severity %q", rc.Severity)
+		}
+	}
+	var conditions []Condition
+	if len(rc.Keywords) > 0 {
+		conditions = append(conditions, Keyword(rc.Keywords...))
+	}
+	if rc.Regex != "" {
+		c, err := Regex(rc.Regex)
+		if err != nil {
+			return Rule{}, err
+		}
+		conditions = append(conditions, c)
+	}
+	if t := rc.Threshold; t != nil {
+		c, err := Threshold(t.Field, t.Op, t.Value)
+		if err != nil {
+			return Rule{}, err
+		}
+		conditions = append(conditions, c)
+	}
+	if len(conditions) == 0 {
+		return Rule{}, fmt.Errorf("keywords, regex or threshold is required")
+	}
+	if len(rc.Labels) > 0 {
+		conditions = append(conditions, HasLabels(rc.Labels))
+	}
+
+	rule := Rule{Name: rc.Name, Severity: rc.Severity, Cooldown: time.Duration(rc.Cooldown), Condition: All(conditions...)}
+	for _, name := range rc.Notify {
+		a, ok := actions[name]
+		if !ok {
+			return Rule{}, fmt.Errorf("unknown destination %q", name)
+		}
+		rule.Actions = append(rule.Actions, a)
+	}
+	return rule, nil
+}
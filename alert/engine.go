@@ -0,0 +1,285 @@
+package alert
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/events"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Severity levels for rules
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Input is one analysis result evaluated by the engine
+type Input struct {
+	Source  string        // Stream, camera or task that produced the result
+	Content string        // Model output text
+	Time    time.Time     // When the analysis completed
+	At      time.Time     // Optional wall-clock time of the analyzed footage (see models.WallClock)
+	Labels  models.Labels // Origin tags; defaults to the labels of the Evaluate context
+
+	parsed bool
+	fields map[string]interface{}
+}
+
+// JSON returns the first JSON object found in the content, if any
+func (in *Input) JSON() (map[string]interface{}, bool) {
+	if !in.parsed {
+		in.fields, _ = extractJSON(in.Content)
+		in.parsed = true
+	}
+	return in.fields, in.fields != nil
+}
+
+// Rule triggers actions when its condition matches
+type Rule struct {
+	Name      string        // Unique rule name
+	Condition Condition     // When the rule fires
+	Actions   []Action      // What to do when it fires
+	Severity  string        // info, warning or critical (default: warning)
+	Cooldown  time.Duration // Minimum time between alerts per source
+}
+
+// Alert is emitted when a rule fires
+type Alert struct {
+	Rule        string        `json:"rule"`
+	Severity    string        `json:"severity"`
+	Source      string        `json:"source"`
+	Reason      string        `json:"reason"`
+	Content     string        `json:"content"`
+	Time        time.Time     `json:"time"`
+	At          time.Time     `json:"at,omitempty"` // When the footage was recorded, if known
+	Fingerprint string        `json:"fingerprint"`
+	Labels      models.Labels `json:"labels,omitempty"`
+}
+
+// Engine evaluates rules against analysis results
+type Engine struct {
+	rules       []Rule
+	dedupWindow time.Duration
+	onError     func(rule string, action Action, err error)
+
+	mu       sync.Mutex
+	lastFire map[string]time.Time // rule|source -> last fire time
+	seen     map[string]time.Time // fingerprint -> last seen time
+}
+
+// NewEngine creates an alerting engine with the given rules
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{
+		rules:       rules,
+		dedupWindow: 10 * time.Minute,
+		lastFire:    make(map[string]time.Time),
+		seen:        make(map[string]time.Time),
+	}
+}
+
+// WithDedupWindow sets how long identical alerts are suppressed (0 disables)
+func (e *Engine) WithDedupWindow(d time.Duration) *Engine {
+	e.dedupWindow = d
+	return e
+}
+
+// WithErrorHandler sets a callback for failed actions
+func (e *Engine) WithErrorHandler(fn func(rule string, action Action, err error)) *Engine {
+	e.onError = fn
+	return e
+}
+
+// AddRule registers an additional rule
+func (e *Engine) AddRule(rule Rule) error {
+	if err := rule.validate(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = append(e.rules, rule)
+	return nil
+}
+
+// SetRules replaces all rules, e.g. after a config reload; it is safe to
+// call while Evaluate runs. Cooldowns of rules that keep their name carry
+// over.
+func (e *Engine) SetRules(rules ...Rule) error {
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	kept := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		kept[rule.Name] = true
+	}
+	for _, rule := range e.rules {
+		if !kept[rule.Name] {
+			e.forget(rule.Name)
+		}
+	}
+	e.rules = append([]Rule(nil), rules...)
+	return nil
+}
+
+// RemoveRule removes the rules with the given name and reports whether
+// any existed
+func (e *Engine) RemoveRule(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rules := e.rules[:0:0]
+	for _, rule := range e.rules {
+		if rule.Name != name {
+			rules = append(rules, rule)
+		}
+	}
+	removed := len(rules) < len(e.rules)
+	e.rules = rules
+	e.forget(name)
+	return removed
+}
+
+// forget drops the cooldown state of a rule; e.mu must be held
+func (e *Engine) forget(name string) {
+	for key := range e.lastFire {
+		if strings.HasPrefix(key, name+"|") {
+			delete(e.lastFire, key)
+		}
+	}
+}
+
+// validate checks the fields every rule needs
+func (r Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if r.Condition == nil {
+		return fmt.Errorf("rule %s: condition is required", r.Name)
+	}
+	return nil
+}
+
+// Evaluate checks all rules against the input, fires the actions of every
+// matching rule that is not in cooldown or deduplicated, and returns the
+// alerts that were raised
+func (e *Engine) Evaluate(ctx context.Context, in Input) []Alert {
+	if in.Time.IsZero() {
+		in.Time = time.Now()
+	}
+	if in.Labels == nil {
+		in.Labels = models.LabelsFrom(ctx)
+	}
+	// Conditions receive Input by value; parsing here lets every copy share
+	// the result instead of each threshold re-parsing the content
+	in.JSON()
+
+	e.mu.Lock()
+	rules := append([]Rule(nil), e.rules...)
+	e.mu.Unlock()
+
+	var alerts []Alert
+	for _, rule := range rules {
+		if rule.Condition == nil {
+			continue
+		}
+		ok, reason := rule.Condition.Match(in)
+		if !ok {
+			continue
+		}
+
+		severity := rule.Severity
+		if severity == "" {
+			severity = SeverityWarning
+		}
+
+		a := Alert{
+			Rule:        rule.Name,
+			Severity:    severity,
+			Source:      in.Source,
+			Reason:      reason,
+			Content:     in.Content,
+			Time:        in.Time,
+			At:          in.At,
+			Fingerprint: fingerprint(rule.Name, in.Source, reason),
+			Labels:      in.Labels,
+		}
+
+		if !e.admit(rule, a) {
+			continue
+		}
+
+		events.Publish(ctx, events.Alert{Rule: a.Rule, Severity: a.Severity, Source: a.Source, Reason: a.Reason, Content: a.Content})
+		for _, action := range rule.Actions {
+			if err := action.Fire(ctx, a); err != nil && e.onError != nil {
+				e.onError(rule.Name, action, err)
+			}
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts
+}
+
+// EvaluateResponse is a convenience wrapper evaluating the first choice of a
+// chat response
+func (e *Engine) EvaluateResponse(ctx context.Context, source string, resp *models.ChatResponse) []Alert {
+	if resp == nil || len(resp.Choices) == 0 {
+		return nil
+	}
+	return e.Evaluate(ctx, Input{
+		Source:  source,
+		Content: resp.Choices[0].Message.Content,
+		Labels:  resp.Labels,
+	})
+}
+
+// admit applies cooldown and deduplication, recording the alert if admitted
+func (e *Engine) admit(rule Rule, a Alert) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := rule.Name + "|" + a.Source
+	if rule.Cooldown > 0 {
+		if last, ok := e.lastFire[key]; ok && a.Time.Sub(last) < rule.Cooldown {
+			return false
+		}
+	}
+
+	if e.dedupWindow > 0 {
+		if last, ok := e.seen[a.Fingerprint]; ok && a.Time.Sub(last) < e.dedupWindow {
+			return false
+		}
+		e.seen[a.Fingerprint] = a.Time
+
+		// Drop stale fingerprints so the map does not grow forever
+		for fp, t := range e.seen {
+			if a.Time.Sub(t) >= e.dedupWindow {
+				delete(e.seen, fp)
+			}
+		}
+	}
+
+	e.lastFire[key] = a.Time
+	return true
+}
+
+// fingerprint identifies an alert for deduplication
+func fingerprint(parts ...string) string {
+	h := sha1.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
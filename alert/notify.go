@@ -0,0 +1,315 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultMessageTemplate renders alerts for chat notifiers and SMS; it is
+// a text/template executed with the Alert
+const DefaultMessageTemplate = `[{{.Severity}}] {{.Rule}} - {{.Source}}
+{{.Time.Format "2006-01-02 15:04:05"}}{{if not .At.IsZero}} (footage {{.At.Format "15:04:05"}}){{end}}
+{{.Reason}}
+{{.Content}}`
+
+// Message renders alerts as text for notifiers
+type Message struct {
+	tmpl *template.Template
+}
+
+// NewMessage parses a text/template executed with the Alert; "" uses
+// DefaultMessageTemplate
+func NewMessage(text string) (*Message, error) {
+	if text == "" {
+		text = DefaultMessageTemplate
+	}
+	tmpl, err := template.New("alert").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse alert template: %w", err)
+	}
+	return &Message{tmpl: tmpl}, nil
+}
+
+// defaultMessage is used by notifiers without a Message
+var defaultMessage, _ = NewMessage("")
+
+// Render executes the template; a nil Message uses the default
+func (m *Message) Render(a Alert) (string, error) {
+	if m == nil {
+		m = defaultMessage
+	}
+	var b strings.Builder
+	if err := m.tmpl.Execute(&b, a); err != nil {
+		return "", fmt.Errorf("failed to render alert: %w", err)
+	}
+	return b.String(), nil
+}
+
+// postJSON posts payload to url and returns the response body; non-2xx
+// statuses are errors
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, string(msg))
+	}
+	return msg, nil
+}
+
+// checkErrCode reports the error of DingTalk/WeCom/Feishu style replies,
+// which return HTTP 200 with a non-zero code on failure
+func checkErrCode(body []byte) error {
+	var reply struct {
+		ErrCode *int   `json:"errcode"` // DingTalk, WeCom
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"` // Feishu
+		Msg     string `json:"msg"`
+	}
+	if json.Unmarshal(body, &reply) != nil {
+		return nil
+	}
+	switch {
+	case reply.ErrCode != nil && *reply.ErrCode != 0:
+		return fmt.Errorf("error %d: %s", *reply.ErrCode, reply.ErrMsg)
+	case reply.Code != nil && *reply.Code != 0:
+		return fmt.Errorf("error %d: %s", *reply.Code, reply.Msg)
+	}
+	return nil
+}
+
+// hmacBase64 returns base64(HMAC-SHA256(key, msg))
+func hmacBase64(key, msg string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(msg))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// DingTalkAction posts alerts to a DingTalk group robot as markdown. With
+// Secret set, requests are signed ("加签" security setting).
+type DingTalkAction struct {
+	Webhook    string // https://oapi.dingtalk.com/robot/send?access_token=...
+	Secret     string // Optional signing secret (SEC...)
+	Message    *Message
+	AtMobiles  []string // Optional members to @
+	HTTPClient *http.Client
+}
+
+// Fire implements Action
+func (d *DingTalkAction) Fire(ctx context.Context, a Alert) error {
+	text, err := d.Message.Render(a)
+	if err != nil {
+		return err
+	}
+	endpoint := d.Webhook
+	if d.Secret != "" {
+		ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		sign := hmacBase64(d.Secret, ts+"\n"+d.Secret)
+		sep := "?"
+		if strings.Contains(endpoint, "?") {
+			sep = "&"
+		}
+		endpoint += sep + "timestamp=" + ts + "&sign=" + url.QueryEscape(sign)
+	}
+	for _, m := range d.AtMobiles {
+		text += " @" + m
+	}
+	payload := map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"title": a.Rule, "text": markdownLines(text)},
+		"at":       map[string]interface{}{"atMobiles": d.AtMobiles},
+	}
+	body, err := postJSON(ctx, d.HTTPClient, endpoint, nil, payload)
+	if err == nil {
+		err = checkErrCode(body)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to notify DingTalk: %w", err)
+	}
+	return nil
+}
+
+// markdownLines keeps line breaks in DingTalk/WeCom markdown, which joins
+// single newlines
+func markdownLines(text string) string {
+	return strings.ReplaceAll(text, "\n", "  \n")
+}
+
+// FeishuAction posts alerts to a Feishu (Lark) group bot as text. With
+// Secret set, requests are signed.
+type FeishuAction struct {
+	Webhook    string // https://open.feishu.cn/open-apis/bot/v2/hook/...
+	Secret     string // Optional signing secret
+	Message    *Message
+	HTTPClient *http.Client
+}
+
+// Fire implements Action
+func (f *FeishuAction) Fire(ctx context.Context, a Alert) error {
+	text, err := f.Message.Render(a)
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"msg_type": "text",
+		"content":  map[string]string{"text": text},
+	}
+	if f.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		// Feishu signs an empty message with timestamp+"\n"+secret as the key
+		payload["timestamp"] = ts
+		payload["sign"] = hmacBase64(ts+"\n"+f.Secret, "")
+	}
+	body, err := postJSON(ctx, f.HTTPClient, f.Webhook, nil, payload)
+	if err == nil {
+		err = checkErrCode(body)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to notify Feishu: %w", err)
+	}
+	return nil
+}
+
+// WeComAction posts alerts to a WeCom (企业微信) group robot as markdown
+type WeComAction struct {
+	Webhook    string // https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=...
+	Message    *Message
+	HTTPClient *http.Client
+}
+
+// Fire implements Action
+func (w *WeComAction) Fire(ctx context.Context, a Alert) error {
+	text, err := w.Message.Render(a)
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": markdownLines(text)},
+	}
+	body, err := postJSON(ctx, w.HTTPClient, w.Webhook, nil, payload)
+	if err == nil {
+		err = checkErrCode(body)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to notify WeCom: %w", err)
+	}
+	return nil
+}
+
+// SlackAction posts alerts to a Slack incoming webhook
+type SlackAction struct {
+	Webhook    string // https://hooks.slack.com/services/...
+	Message    *Message
+	HTTPClient *http.Client
+}
+
+// Fire implements Action
+func (s *SlackAction) Fire(ctx context.Context, a Alert) error {
+	text, err := s.Message.Render(a)
+	if err != nil {
+		return err
+	}
+	if _, err := postJSON(ctx, s.HTTPClient, s.Webhook, nil, map[string]string{"text": text}); err != nil {
+		return fmt.Errorf("failed to notify Slack: %w", err)
+	}
+	return nil
+}
+
+// SMSSender delivers a text message to phone numbers, e.g. a thin adapter
+// around a cloud SMS SDK
+type SMSSender interface {
+	Send(ctx context.Context, to []string, text string) error
+}
+
+// SMSSenderFunc adapts a function to SMSSender
+type SMSSenderFunc func(ctx context.Context, to []string, text string) error
+
+// Send implements SMSSender
+func (f SMSSenderFunc) Send(ctx context.Context, to []string, text string) error {
+	return f(ctx, to, text)
+}
+
+// HTTPSMSSender posts {"to": [...], "text": "..."} to an SMS gateway
+type HTTPSMSSender struct {
+	URL        string
+	Headers    map[string]string
+	HTTPClient *http.Client
+}
+
+// Send implements SMSSender
+func (h *HTTPSMSSender) Send(ctx context.Context, to []string, text string) error {
+	_, err := postJSON(ctx, h.HTTPClient, h.URL, h.Headers, map[string]interface{}{"to": to, "text": text})
+	return err
+}
+
+// SMSAction texts alerts to phone numbers; SMS is short and costly, so
+// the default message leaves out the model output
+type SMSAction struct {
+	Sender  SMSSender
+	To      []string
+	Message *Message // Default: severity, rule, source and reason
+}
+
+// smsMessage is the default SMS template
+var smsMessage, _ = NewMessage(`[{{.Severity}}] {{.Rule}} - {{.Source}}: {{.Reason}}`)
+
+// Fire implements Action
+func (s *SMSAction) Fire(ctx context.Context, a Alert) error {
+	msg := s.Message
+	if msg == nil {
+		msg = smsMessage
+	}
+	text, err := msg.Render(a)
+	if err != nil {
+		return err
+	}
+	if err := s.Sender.Send(ctx, s.To, text); err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	return nil
+}
+
+// severityRank orders severities for MinSeverity
+var severityRank = map[string]int{SeverityInfo: 0, SeverityWarning: 1, SeverityCritical: 2}
+
+// MinSeverity fires action only for alerts at or above severity, e.g. to
+// page by SMS for critical alerts only
+func MinSeverity(severity string, action Action) Action {
+	return ActionFunc(func(ctx context.Context, a Alert) error {
+		if severityRank[a.Severity] < severityRank[severity] {
+			return nil
+		}
+		return action.Fire(ctx, a)
+	})
+}
@@ -0,0 +1,300 @@
+// Package archive writes extracted frames to disk or object storage under
+// templated paths, with a JSONL index next to them, so the evidence behind
+// every analysis can be browsed independently of the model results:
+//
+//	sink := archive.NewSink(archive.Dir("/var/lib/zhipu/frames"), "")
+//	task.Grab = sink.Grabber("cam1", task.Grab)
+//
+// With the default template frames land in cam1/2025-06-01/080512.340-0.jpg
+// and each day directory holds an index.jsonl describing its frames.
+//
+// Bundle packages a single analysis run (frames, prompt, responses and
+// metadata) as a ZIP or TAR archive, and Index keeps a searchable SQLite
+// history of the analyses themselves.
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/objstore"
+	"github.com/t8y2/zhipu-video-sdk/scheduler"
+)
+
+// DefaultTemplate is the path template used when none is configured
+const DefaultTemplate = "{camera}/{date}/{time}-{index}.{ext}"
+
+// IndexName is the name of the index file written next to each frame
+const IndexName = "index.jsonl"
+
+// Storage stores archived files by slash-separated relative path
+type Storage interface {
+	Put(ctx context.Context, name string, data []byte, contentType string) error
+	// Append adds data to the end of name, creating it if needed
+	Append(ctx context.Context, name string, data []byte) error
+}
+
+// Dir stores files below a local directory
+func Dir(root string) Storage {
+	return dirStorage{root: root}
+}
+
+type dirStorage struct{ root string }
+
+func (d dirStorage) path(name string) (string, error) {
+	p := filepath.Join(d.root, filepath.FromSlash(name))
+	if rel, err := filepath.Rel(d.root, p); err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("archive path %q escapes %s", name, d.root)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	return p, nil
+}
+
+func (d dirStorage) Put(_ context.Context, name string, data []byte, _ string) error {
+	p, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func (d dirStorage) Append(_ context.Context, name string, data []byte) error {
+	p, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to append to %s: %w", name, err)
+	}
+	return f.Close()
+}
+
+// Object stores files in a bucket below prefix. Object storage cannot
+// append, so index files are re-uploaded whole on every append; they are
+// loaded once per process, which assumes a single writer per index path.
+func Object(store *objstore.Store, bucket, prefix string) Storage {
+	return &objectStorage{store: store, bucket: bucket, prefix: strings.Trim(prefix, "/"), indexes: make(map[string][]byte)}
+}
+
+type objectStorage struct {
+	store  *objstore.Store
+	bucket string
+	prefix string
+
+	mu      sync.Mutex
+	indexes map[string][]byte
+}
+
+func (o *objectStorage) key(name string) string {
+	if o.prefix == "" {
+		return name
+	}
+	return o.prefix + "/" + name
+}
+
+func (o *objectStorage) Put(ctx context.Context, name string, data []byte, contentType string) error {
+	return o.store.Put(ctx, o.bucket, o.key(name), data, contentType)
+}
+
+func (o *objectStorage) Append(ctx context.Context, name string, data []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	content, ok := o.indexes[name]
+	if !ok {
+		rc, err := o.store.OpenRange(ctx, o.bucket, o.key(name), 0, 0)
+		switch {
+		case errors.Is(err, objstore.ErrNotFound):
+		case err != nil:
+			return fmt.Errorf("failed to load %s: %w", name, err)
+		default:
+			content, err = io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return fmt.Errorf("failed to load %s: %w", name, err)
+			}
+		}
+	}
+	content = append(content, data...)
+	if err := o.store.Put(ctx, o.bucket, o.key(name), content, "application/x-ndjson"); err != nil {
+		return err
+	}
+	o.indexes[name] = content
+	return nil
+}
+
+// Entry is one line of an index file
+type Entry struct {
+	Path   string        `json:"path"` // Relative to the storage root
+	Camera string        `json:"camera"`
+	Time   time.Time     `json:"time"`
+	Index  int           `json:"index"` // Position within the grabbed window
+	Bytes  int           `json:"bytes"`
+	Labels models.Labels `json:"labels,omitempty"`
+	Note   string        `json:"note,omitempty"` // E.g. the analysis the frame belongs to
+}
+
+// Sink archives frames under paths built from Template. Placeholders are
+// {camera}, {date} (2006-01-02), {hour} (15), {time} (150405.000),
+// {timestamp} (20060102T150405.000), {unix} (milliseconds), {index}, {ext}
+// and any label key, e.g. {site}. Unknown placeholders are left as is.
+type Sink struct {
+	Storage  Storage
+	Template string         // Path template (default DefaultTemplate)
+	Location *time.Location // Time zone of {date}/{time} (default local)
+	NoIndex  bool           // Skip writing index.jsonl files
+
+	// OnError is called when a Grabber fails to archive a window (optional;
+	// archiving errors never fail the grab itself)
+	OnError func(camera string, err error)
+
+	mu sync.Mutex
+}
+
+// NewSink creates a sink; an empty template selects DefaultTemplate
+func NewSink(storage Storage, template string) *Sink {
+	return &Sink{Storage: storage, Template: template}
+}
+
+// Write archives frames captured by camera at the given time, frame i taken
+// at at + i*interval, and returns their paths
+func (s *Sink) Write(ctx context.Context, camera string, at time.Time, interval time.Duration, frames [][]byte, note string) ([]string, error) {
+	labels := models.LabelsFrom(ctx)
+	paths := make([]string, 0, len(frames))
+	var index strings.Builder
+	indexPath := ""
+	for i, frame := range frames {
+		t := at.Add(time.Duration(i) * interval)
+		contentType := http.DetectContentType(frame)
+		name := s.expand(camera, t, i, extension(contentType), labels)
+		if err := s.Storage.Put(ctx, name, frame, contentType); err != nil {
+			return paths, fmt.Errorf("failed to archive frame %d: %w", i, err)
+		}
+		paths = append(paths, name)
+		if s.NoIndex {
+			continue
+		}
+
+		dir := path.Join(path.Dir(name), IndexName)
+		if indexPath != "" && dir != indexPath {
+			if err := s.appendIndex(ctx, indexPath, index.String()); err != nil {
+				return paths, err
+			}
+			index.Reset()
+		}
+		indexPath = dir
+		line, err := json.Marshal(Entry{Path: name, Camera: camera, Time: t, Index: i, Bytes: len(frame), Labels: labels, Note: note})
+		if err != nil {
+			return paths, fmt.Errorf("failed to marshal index entry: %w", err)
+		}
+		index.Write(line)
+		index.WriteByte('\n')
+	}
+	if index.Len() > 0 {
+		if err := s.appendIndex(ctx, indexPath, index.String()); err != nil {
+			return paths, err
+		}
+	}
+	return paths, nil
+}
+
+// appendIndex adds lines to an index file
+func (s *Sink) appendIndex(ctx context.Context, name, lines string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Storage.Append(ctx, name, []byte(lines)); err != nil {
+		return fmt.Errorf("failed to update index %s: %w", name, err)
+	}
+	return nil
+}
+
+// Grabber wraps a scheduler grabber so every grabbed window is archived
+// before it is analyzed. Frames are stamped with the grab time.
+func (s *Sink) Grabber(camera string, grab scheduler.Grabber) scheduler.Grabber {
+	return func(ctx context.Context) ([][]byte, error) {
+		at := time.Now()
+		frames, err := grab(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.Write(ctx, camera, at, 0, frames, ""); err != nil && s.OnError != nil {
+			s.OnError(camera, err)
+		}
+		return frames, nil
+	}
+}
+
+// expand fills the path template for one frame
+func (s *Sink) expand(camera string, t time.Time, index int, ext string, labels models.Labels) string {
+	tmpl := s.Template
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	if s.Location != nil {
+		t = t.In(s.Location)
+	}
+
+	// Built-in placeholders come first so they win over same-named labels
+	pairs := []string{
+		"{camera}", sanitize(camera),
+		"{date}", t.Format("2006-01-02"),
+		"{hour}", t.Format("15"),
+		"{time}", t.Format("150405.000"),
+		"{timestamp}", t.Format("20060102T150405.000"),
+		"{unix}", strconv.FormatInt(t.UnixMilli(), 10),
+		"{index}", strconv.Itoa(index),
+		"{ext}", ext,
+	}
+	for k, v := range labels {
+		pairs = append(pairs, "{"+k+"}", sanitize(v))
+	}
+	return strings.TrimLeft(path.Clean(strings.NewReplacer(pairs...).Replace(tmpl)), "/")
+}
+
+// sanitize keeps a placeholder value inside a single path segment
+func sanitize(s string) string {
+	s = strings.Trim(s, ".")
+	if s == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// extension returns the file extension for a sniffed content type
+func extension(contentType string) string {
+	switch contentType {
+	case "image/png":
+		return "png"
+	case "image/webp":
+		return "webp"
+	default:
+		return "jpg"
+	}
+}
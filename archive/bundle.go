@@ -0,0 +1,247 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/audit"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Bundle is a complete analysis run packaged for tickets or auditors: the
+// frames, the prompt, the raw and parsed responses and metadata. Written
+// as a ZIP or TAR archive it contains:
+//
+//	metadata.json   run metadata and a manifest with frame checksums
+//	prompt.txt      the prompt
+//	answer.txt      the answer text
+//	response.json   the raw API response
+//	parsed.json     the parsed structured output (if any)
+//	frames/000.jpg  the analyzed frames
+type Bundle struct {
+	Prompt   string
+	Frames   [][]byte
+	Response *models.ChatResponse
+	Parsed   interface{}            // Structured output, e.g. the value filled by AnalyzeFramesInto (optional)
+	Source   string                 // Video or stream the frames came from (optional)
+	Time     time.Time              // When the analysis ran (default: now)
+	Extra    map[string]interface{} // Additional metadata (optional)
+}
+
+// BundleMetadata is the content of metadata.json
+type BundleMetadata struct {
+	Created  time.Time              `json:"created"`
+	Time     time.Time              `json:"time"`
+	Source   string                 `json:"source,omitempty"`
+	Model    string                 `json:"model,omitempty"`
+	ID       string                 `json:"id,omitempty"` // Response ID
+	Usage    *models.Usage          `json:"usage,omitempty"`
+	Labels   models.Labels          `json:"labels,omitempty"`
+	Frames   []BundleFrame          `json:"frames"`
+	Extra    map[string]interface{} `json:"extra,omitempty"`
+	Contents []string               `json:"contents"` // Files in the archive
+}
+
+// BundleFrame describes one frame file of a bundle
+type BundleFrame struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// BundleFromRecord builds a bundle from an audit record. The frames are
+// whatever the auditor kept: full frames, thumbnails, or none with
+// FramesHash (their checksums stay available in the record).
+func BundleFromRecord(rec *audit.Record) (*Bundle, error) {
+	b := &Bundle{
+		Prompt:   rec.Prompt,
+		Response: rec.Response,
+		Time:     rec.Time,
+		Extra: map[string]interface{}{
+			"audit_id":    rec.ID,
+			"frame_count": rec.FrameCount,
+			"latency_ms":  rec.Latency.Milliseconds(),
+			"cost":        rec.Cost,
+		},
+	}
+	if rec.Error != "" {
+		b.Extra["error"] = rec.Error
+	}
+	for i, f := range rec.Frames {
+		data := f.Data
+		if data == "" {
+			data = f.Thumbnail
+		}
+		if data == "" {
+			continue
+		}
+		frame, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode frame %d of record %s: %w", i, rec.ID, err)
+		}
+		b.Frames = append(b.Frames, frame)
+	}
+	return b, nil
+}
+
+// bundleFile is one file of a bundle
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+// files renders the bundle contents in archive order
+func (b *Bundle) files() ([]bundleFile, error) {
+	created := time.Now()
+	meta := BundleMetadata{
+		Created: created,
+		Time:    b.Time,
+		Source:  b.Source,
+		Extra:   b.Extra,
+		Frames:  make([]BundleFrame, len(b.Frames)),
+	}
+	if meta.Time.IsZero() {
+		meta.Time = created
+	}
+
+	files := []bundleFile{{name: "prompt.txt", data: []byte(b.Prompt)}}
+	if b.Response != nil {
+		meta.Model, meta.ID, meta.Labels = b.Response.Model, b.Response.ID, b.Response.Labels
+		usage := b.Response.Usage
+		meta.Usage = &usage
+		raw, err := json.MarshalIndent(b.Response, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		files = append(files,
+			bundleFile{name: "answer.txt", data: []byte(b.Response.Text())},
+			bundleFile{name: "response.json", data: raw},
+		)
+	}
+	if b.Parsed != nil {
+		parsed, err := json.MarshalIndent(b.Parsed, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal parsed output: %w", err)
+		}
+		files = append(files, bundleFile{name: "parsed.json", data: parsed})
+	}
+	for i, frame := range b.Frames {
+		sum := sha256.Sum256(frame)
+		name := fmt.Sprintf("frames/%03d.%s", i, extension(http.DetectContentType(frame)))
+		meta.Frames[i] = BundleFrame{Name: name, Size: len(frame), SHA256: hex.EncodeToString(sum[:])}
+		files = append(files, bundleFile{name: name, data: frame})
+	}
+
+	meta.Contents = append(meta.Contents, "metadata.json")
+	for _, f := range files {
+		meta.Contents = append(meta.Contents, f.name)
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return append([]bundleFile{{name: "metadata.json", data: data}}, files...), nil
+}
+
+// WriteZip writes the bundle as a ZIP archive
+func (b *Bundle) WriteZip(w io.Writer) error {
+	files, err := b.files()
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		method := zip.Deflate
+		if strings.HasPrefix(f.name, "frames/") {
+			method = zip.Store // Already compressed
+		}
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: method, Modified: time.Now()})
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", f.name, err)
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return fmt.Errorf("failed to add %s: %w", f.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish zip: %w", err)
+	}
+	return nil
+}
+
+// WriteTar writes the bundle as a TAR archive, gzip-compressed if compress
+// is set
+func (b *Bundle) WriteTar(w io.Writer, compress bool) error {
+	files, err := b.files()
+	if err != nil {
+		return err
+	}
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	now := time.Now()
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), ModTime: now, Format: tar.FormatPAX}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to add %s: %w", f.name, err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return fmt.Errorf("failed to add %s: %w", f.name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish tar: %w", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("failed to finish gzip: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteFile writes the bundle to path, choosing the format from the
+// extension: .zip, .tar, or .tar.gz / .tgz
+func (b *Bundle) WriteFile(path string) error {
+	var write func(io.Writer) error
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		write = b.WriteZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		write = func(w io.Writer) error { return b.WriteTar(w, true) }
+	case strings.HasSuffix(lower, ".tar"):
+		write = func(w io.Writer) error { return b.WriteTar(w, false) }
+	default:
+		return fmt.Errorf("unsupported bundle format %q (use .zip, .tar or .tar.gz)", filepath.Ext(path))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/detect"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/scheduler"
+)
+
+// Analysis is one indexed analysis
+type Analysis struct {
+	ID         int64              `json:"id"`
+	Source     string             `json:"source"`           // File path, URL, stream or task name
+	Camera     string             `json:"camera,omitempty"` // Defaults to the camera label, then Source
+	Start      time.Time          `json:"start"`
+	End        time.Time          `json:"end"`
+	Summary    string             `json:"summary"` // Model answer
+	Labels     models.Labels      `json:"labels,omitempty"`
+	Detections []detect.Detection `json:"detections,omitempty"`
+	Frames     int                `json:"frames"`
+	Model      string             `json:"model,omitempty"`
+	Tokens     int                `json:"tokens"`
+}
+
+// TimeRange is a wall-clock interval; a zero bound is open
+type TimeRange struct {
+	From, To time.Time
+}
+
+// Query selects indexed analyses; zero fields match everything
+type Query struct {
+	Camera    string
+	Range     TimeRange     // Analyses overlapping the range
+	Keyword   string        // Substring of the summary
+	Labels    models.Labels // Analyses carrying all of these labels
+	Detection string        // Analyses with a detection of this label
+	Limit     int           // Maximum results, newest first (default 100)
+}
+
+// Index is a searchable history of analyses stored in a SQL database, so
+// accumulated results can be queried by camera, time and content instead
+// of grepping JSON files. It is written for SQLite; the application opens
+// the database with the driver of its choice, which keeps this module free
+// of cgo and driver dependencies:
+//
+//	db, err := sql.Open("sqlite", "analyses.db") // modernc.org/sqlite
+//	index, err := archive.OpenIndex(ctx, db)
+//	sched := scheduler.NewScheduler(c, index) // index every scheduled run
+//	hits, err := index.FindAnalyses(ctx, "cam1", archive.TimeRange{From: since}, "叉车")
+//
+// Keyword search uses LIKE rather than FTS5, which is not built into every
+// SQLite distribution and does not segment Chinese text anyway.
+type Index struct {
+	db *sql.DB
+}
+
+// indexSchema creates the index tables; times are Unix milliseconds so
+// every driver stores them the same way
+const indexSchema = `
+CREATE TABLE IF NOT EXISTS analyses (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	source     TEXT    NOT NULL DEFAULT '',
+	camera     TEXT    NOT NULL DEFAULT '',
+	start_ms   INTEGER NOT NULL,
+	end_ms     INTEGER NOT NULL,
+	summary    TEXT    NOT NULL DEFAULT '',
+	labels     TEXT    NOT NULL DEFAULT '{}',
+	detections TEXT    NOT NULL DEFAULT '[]',
+	frames     INTEGER NOT NULL DEFAULT 0,
+	model      TEXT    NOT NULL DEFAULT '',
+	tokens     INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS analyses_camera_start ON analyses (camera, start_ms);
+CREATE INDEX IF NOT EXISTS analyses_start ON analyses (start_ms);
+CREATE TABLE IF NOT EXISTS analysis_labels (
+	analysis_id INTEGER NOT NULL REFERENCES analyses (id) ON DELETE CASCADE,
+	key         TEXT    NOT NULL,
+	value       TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS analysis_labels_key_value ON analysis_labels (key, value);
+CREATE TABLE IF NOT EXISTS analysis_detections (
+	analysis_id INTEGER NOT NULL REFERENCES analyses (id) ON DELETE CASCADE,
+	label       TEXT    NOT NULL,
+	confidence  REAL    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS analysis_detections_label ON analysis_detections (label);
+`
+
+// OpenIndex creates the index tables in db if needed
+func OpenIndex(ctx context.Context, db *sql.DB) (*Index, error) {
+	for _, stmt := range strings.Split(indexSchema, ";") {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("failed to create analysis index: %w", err)
+		}
+	}
+	return &Index{db: db}, nil
+}
+
+// Add indexes a and returns its ID
+func (x *Index) Add(ctx context.Context, a Analysis) (int64, error) {
+	if a.Camera == "" {
+		a.Camera = a.Labels[models.LabelCamera]
+	}
+	if a.Camera == "" {
+		a.Camera = a.Source
+	}
+	if a.End.Before(a.Start) {
+		a.End = a.Start
+	}
+	labels, err := json.Marshal(a.Labels)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode labels: %w", err)
+	}
+	detections, err := json.Marshal(a.Detections)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode detections: %w", err)
+	}
+
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO analyses (source, camera, start_ms, end_ms, summary, labels, detections, frames, model, tokens)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.Source, a.Camera, a.Start.UnixMilli(), a.End.UnixMilli(), a.Summary,
+		string(labels), string(detections), a.Frames, a.Model, a.Tokens)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert analysis: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get analysis id: %w", err)
+	}
+	for k, v := range a.Labels {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO analysis_labels (analysis_id, key, value) VALUES (?, ?, ?)`, id, k, v); err != nil {
+			return 0, fmt.Errorf("failed to insert label: %w", err)
+		}
+	}
+	for _, d := range a.Detections {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO analysis_detections (analysis_id, label, confidence) VALUES (?, ?, ?)`, id, d.Label, d.Confidence); err != nil {
+			return 0, fmt.Errorf("failed to insert detection: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit analysis: %w", err)
+	}
+	return id, nil
+}
+
+// AddResult indexes an analysis result; the time range comes from its
+// frame times (ApplyClock), or is the moment of the call
+func (x *Index) AddResult(ctx context.Context, r *models.AnalysisResult) (int64, error) {
+	a := Analysis{
+		Source: r.Source,
+		Labels: r.Labels,
+		Frames: r.Frames,
+	}
+	if r.Response != nil {
+		a.Summary = r.Text()
+		a.Model = r.Response.Model
+		a.Tokens = r.Response.Usage.TotalTokens
+	}
+	if len(r.FrameTimes) > 0 {
+		a.Start, a.End = r.FrameTimes[0], r.FrameTimes[0]
+		for _, t := range r.FrameTimes {
+			if t.Before(a.Start) {
+				a.Start = t
+			}
+			if t.After(a.End) {
+				a.End = t
+			}
+		}
+	} else {
+		a.Start = time.Now()
+		a.End = a.Start
+	}
+	return x.Add(ctx, a)
+}
+
+// Record implements scheduler.Recorder. Failed, skipped and duplicate runs
+// are not analyses worth searching and are left out.
+func (x *Index) Record(r scheduler.Result) error {
+	if r.Error != "" || r.Skipped || r.Duplicate {
+		return nil
+	}
+	a := Analysis{
+		Source:  r.Task,
+		Start:   r.StartedAt,
+		End:     r.StartedAt.Add(r.Duration),
+		Summary: r.Content,
+		Labels:  r.Labels,
+		Frames:  r.Frames,
+	}
+	if r.Response != nil {
+		a.Model = r.Response.Model
+		a.Tokens = r.Response.Usage.TotalTokens
+	}
+	_, err := x.Add(context.Background(), a)
+	return err
+}
+
+// FindAnalyses returns the analyses of camera (all cameras if empty)
+// overlapping r whose summary contains keyword (any if empty), newest first
+func (x *Index) FindAnalyses(ctx context.Context, camera string, r TimeRange, keyword string) ([]Analysis, error) {
+	return x.Find(ctx, Query{Camera: camera, Range: r, Keyword: keyword})
+}
+
+// Find returns the analyses matching q, newest first
+func (x *Index) Find(ctx context.Context, q Query) ([]Analysis, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if q.Camera != "" {
+		where = append(where, "camera = ?")
+		args = append(args, q.Camera)
+	}
+	if !q.Range.From.IsZero() {
+		where = append(where, "end_ms >= ?")
+		args = append(args, q.Range.From.UnixMilli())
+	}
+	if !q.Range.To.IsZero() {
+		where = append(where, "start_ms <= ?")
+		args = append(args, q.Range.To.UnixMilli())
+	}
+	if q.Keyword != "" {
+		where = append(where, `summary LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(q.Keyword)+"%")
+	}
+	keys := make([]string, 0, len(q.Labels))
+	for k := range q.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		where = append(where, "EXISTS (SELECT 1 FROM analysis_labels l WHERE l.analysis_id = analyses.id AND l.key = ? AND l.value = ?)")
+		args = append(args, k, q.Labels[k])
+	}
+	if q.Detection != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM analysis_detections d WHERE d.analysis_id = analyses.id AND d.label = ?)")
+		args = append(args, q.Detection)
+	}
+
+	query := "SELECT id, source, camera, start_ms, end_ms, summary, labels, detections, frames, model, tokens FROM analyses"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query += " ORDER BY start_ms DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := x.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analyses: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Analysis
+	for rows.Next() {
+		var (
+			a                  Analysis
+			start, end         int64
+			labels, detections string
+		)
+		if err := rows.Scan(&a.ID, &a.Source, &a.Camera, &start, &end, &a.Summary, &labels, &detections, &a.Frames, &a.Model, &a.Tokens); err != nil {
+			return nil, fmt.Errorf("failed to read analysis: %w", err)
+		}
+		a.Start, a.End = time.UnixMilli(start), time.UnixMilli(end)
+		if err := json.Unmarshal([]byte(labels), &a.Labels); err != nil {
+			return nil, fmt.Errorf("failed to decode labels of analysis %d: %w", a.ID, err)
+		}
+		if err := json.Unmarshal([]byte(detections), &a.Detections); err != nil {
+			return nil, fmt.Errorf("failed to decode detections of analysis %d: %w", a.ID, err)
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query analyses: %w", err)
+	}
+	return out, nil
+}
+
+// Delete removes the analyses that ended before cutoff, for retention,
+// and returns how many were removed
+func (x *Index) Delete(ctx context.Context, cutoff time.Time) (int64, error) {
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Foreign key cascades are off by default in SQLite
+	ms := cutoff.UnixMilli()
+	for _, table := range []string{"analysis_labels", "analysis_detections"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE analysis_id IN (SELECT id FROM analyses WHERE end_ms < ?)", ms); err != nil {
+			return 0, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM analyses WHERE end_ms < ?", ms)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete analyses: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted analyses: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit deletion: %w", err)
+	}
+	return n, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"time"
+
+	"golang.org/x/image/draw"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// FrameMode controls how frames are persisted in audit records
+type FrameMode int
+
+const (
+	FramesHash      FrameMode = iota // Store SHA-256 and size only (default)
+	FramesThumbnail                  // Store hash plus a small JPEG thumbnail
+	FramesFull                       // Store the full JPEG data
+	FramesNone                       // Store nothing about frames except the count
+)
+
+// FrameRecord describes one frame sent to the model
+type FrameRecord struct {
+	SHA256    string `json:"sha256,omitempty"`
+	Size      int    `json:"size"`
+	Thumbnail string `json:"thumbnail,omitempty"` // base64 JPEG
+	Data      string `json:"data,omitempty"`      // base64 JPEG (FramesFull)
+}
+
+// Record is one audited request/response exchange
+type Record struct {
+	ID          string               `json:"id"`
+	Time        time.Time            `json:"time"`
+	Model       string               `json:"model"`
+	URL         string               `json:"url"`
+	Prompt      string               `json:"prompt"`
+	Request     *models.ChatRequest  `json:"request,omitempty"` // Request with image payloads stripped
+	FrameCount  int                  `json:"frame_count"`
+	Frames      []FrameRecord        `json:"frames,omitempty"`
+	StatusCode  int                  `json:"status_code,omitempty"`
+	Response    *models.ChatResponse `json:"response,omitempty"`
+	Error       string               `json:"error,omitempty"`
+	Latency     time.Duration        `json:"latency"`
+	TotalTokens int                  `json:"total_tokens"`
+	Cost        float64              `json:"cost"`
+	Labels      models.Labels        `json:"labels,omitempty"`
+}
+
+// CostFunc computes the cost of a call from its usage
+type CostFunc func(model string, promptTokens, completionTokens int) float64
+
+// Auditor turns client exchanges into records and appends them to a store
+type Auditor struct {
+	Store  Store
+	Frames FrameMode
+	Cost   CostFunc // Optional cost calculator
+}
+
+// NewAuditor creates an auditor that stores frame hashes only and prices
+// calls with the model registry
+func NewAuditor(store Store) *Auditor {
+	return &Auditor{Store: store, Frames: FramesHash, Cost: models.ModelCost}
+}
+
+// Exchange is the raw material of a record, provided by the client
+type Exchange struct {
+	URL        string
+	Request    *models.ChatRequest
+	Prompt     string
+	Frames     [][]byte
+	StatusCode int
+	Response   *models.ChatResponse
+	Err        error
+	Started    time.Time
+	Latency    time.Duration
+	Labels     models.Labels
+}
+
+// Record builds a record from the exchange and appends it to the store
+func (a *Auditor) Record(ex Exchange) error {
+	rec := Record{
+		ID:         newID(),
+		Time:       ex.Started,
+		URL:        ex.URL,
+		Prompt:     ex.Prompt,
+		Request:    stripImages(ex.Request),
+		FrameCount: len(ex.Frames),
+		StatusCode: ex.StatusCode,
+		Response:   ex.Response,
+		Latency:    ex.Latency,
+		Labels:     ex.Labels,
+	}
+	if ex.Request != nil {
+		rec.Model = ex.Request.Model
+	}
+	if ex.Err != nil {
+		rec.Error = ex.Err.Error()
+	}
+	if ex.Response != nil {
+		rec.TotalTokens = ex.Response.Usage.TotalTokens
+		if a.Cost != nil {
+			rec.Cost = a.Cost(rec.Model, ex.Response.Usage.PromptTokens, ex.Response.Usage.CompletionTokens)
+		}
+	}
+
+	if a.Frames != FramesNone {
+		for _, frame := range ex.Frames {
+			rec.Frames = append(rec.Frames, a.frameRecord(frame))
+		}
+	}
+
+	return a.Store.Append(&rec)
+}
+
+// frameRecord converts a frame according to the frame mode
+func (a *Auditor) frameRecord(frame []byte) FrameRecord {
+	sum := sha256.Sum256(frame)
+	fr := FrameRecord{
+		SHA256: hex.EncodeToString(sum[:]),
+		Size:   len(frame),
+	}
+
+	switch a.Frames {
+	case FramesThumbnail:
+		if thumb, err := thumbnail(frame, 160); err == nil {
+			fr.Thumbnail = base64.StdEncoding.EncodeToString(thumb)
+		}
+	case FramesFull:
+		fr.Data = base64.StdEncoding.EncodeToString(frame)
+	}
+	return fr
+}
+
+// stripImages copies the request replacing image data URIs with a marker,
+// keeping records small; frames are described by FrameRecord instead
+func stripImages(req *models.ChatRequest) *models.ChatRequest {
+	if req == nil {
+		return nil
+	}
+
+	cp := *req
+	cp.Messages = make([]models.Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		cp.Messages[i] = msg
+		cp.Messages[i].Content = make([]models.Content, len(msg.Content))
+		for j, content := range msg.Content {
+			if content.ImageURL != nil {
+				img := *content.ImageURL
+				if len(img.URL) > 5 && img.URL[:5] == "data:" {
+					img.URL = fmt.Sprintf("data:<%d bytes stripped>", len(img.URL))
+				}
+				content.ImageURL = &img
+			}
+			cp.Messages[i].Content[j] = content
+		}
+	}
+	return &cp
+}
+
+// thumbnail downsizes a JPEG frame to the given width
+func thumbnail(frame []byte, width int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	if b.Dx() <= width {
+		return frame, nil
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, b.Dy()*width/b.Dx()))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 70}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newID returns a time-ordered random record ID
+func newID() string {
+	var b [6]byte
+	rand.Read(b[:])
+	return fmt.Sprintf("%x-%s", time.Now().UnixNano(), hex.EncodeToString(b[:]))
+}
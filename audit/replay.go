@@ -0,0 +1,262 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// ErrNoFrameData is returned when a record's frames cannot be replayed
+// because only their hashes were stored (see FramesFull and FramesThumbnail)
+var ErrNoFrameData = errors.New("record has no frame data")
+
+// FrameData decodes the stored frames of the record. Full frames are
+// preferred; thumbnails are used when that is all the record has, in which
+// case degraded is true.
+func (r *Record) FrameData() (frames [][]byte, degraded bool, err error) {
+	for i, fr := range r.Frames {
+		encoded := fr.Data
+		if encoded == "" {
+			encoded = fr.Thumbnail
+			degraded = true
+		}
+		if encoded == "" {
+			return nil, false, fmt.Errorf("frame %d: %w", i, ErrNoFrameData)
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+		frames = append(frames, data)
+	}
+	if len(frames) < r.FrameCount {
+		return nil, false, ErrNoFrameData
+	}
+	return frames, degraded, nil
+}
+
+// Rebuild reconstructs the original request, putting the stored frames back
+// in place of the stripped image payloads
+func (r *Record) Rebuild() (*models.ChatRequest, error) {
+	frames, _, err := r.FrameData()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Request == nil {
+		contents := append([]models.Content{models.Text(r.Prompt)}, models.Frames(frames)...)
+		return &models.ChatRequest{Model: r.Model, Messages: []models.Message{models.UserMessage(contents...)}}, nil
+	}
+
+	req := *r.Request
+	req.Messages = make([]models.Message, len(r.Request.Messages))
+	next := 0
+	for i, msg := range r.Request.Messages {
+		req.Messages[i] = msg
+		req.Messages[i].Content = make([]models.Content, len(msg.Content))
+		for j, content := range msg.Content {
+			if content.ImageURL != nil && strings.HasPrefix(content.ImageURL.URL, "data:<") {
+				if next >= len(frames) {
+					return nil, fmt.Errorf("record has more images than stored frames")
+				}
+				content = models.ImageBase64(frames[next], content.ImageURL.Detail)
+				next++
+			}
+			req.Messages[i].Content[j] = content
+		}
+	}
+	return &req, nil
+}
+
+// Chatter sends chat requests; implemented by client.Client. Use a client
+// without an Auditor, or replays are audited into the store being replayed.
+type Chatter interface {
+	Chat(ctx context.Context, req *models.ChatRequest) (*models.ChatResponse, error)
+}
+
+// Replayer re-runs recorded requests against another model or prompt
+// version and diffs the answers, e.g. to test a migration to a new model
+type Replayer struct {
+	Chatter Chatter
+	Model   string // Model to replay against (empty keeps the recorded model)
+	Prompt  string // Prompt template; {prompt} is replaced by the recorded prompt. Empty keeps the recorded prompt.
+}
+
+// ReplayResult compares a recorded answer with its replay
+type ReplayResult struct {
+	RecordID   string               `json:"record_id"`
+	Time       time.Time            `json:"time"`
+	OldModel   string               `json:"old_model"`
+	NewModel   string               `json:"new_model"`
+	Prompt     string               `json:"prompt"`
+	Old        string               `json:"old"`
+	New        string               `json:"new"`
+	Similarity float64              `json:"similarity"`         // 1 for identical answers
+	Degraded   bool                 `json:"degraded,omitempty"` // Replayed from thumbnails
+	Response   *models.ChatResponse `json:"response,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// Changed reports whether the replayed answer differs from the recorded one
+func (r *ReplayResult) Changed() bool {
+	return r.Error != "" || r.Old != r.New
+}
+
+// Diff returns a line diff of the recorded and replayed answers
+func (r *ReplayResult) Diff() string {
+	return Diff(r.Old, r.New)
+}
+
+// Replay re-runs one record. Failed replays are reported in the result's
+// Error; the returned error is only set for records that cannot be rebuilt.
+func (p *Replayer) Replay(ctx context.Context, rec *Record) (*ReplayResult, error) {
+	req, err := rec.Rebuild()
+	if err != nil {
+		return nil, fmt.Errorf("record %s: %w", rec.ID, err)
+	}
+	_, degraded, _ := rec.FrameData()
+
+	if p.Model != "" {
+		req.Model = p.Model
+	}
+	prompt := rec.Prompt
+	if p.Prompt != "" {
+		prompt = strings.ReplaceAll(p.Prompt, "{prompt}", rec.Prompt)
+		replacePrompt(req, rec.Prompt, prompt)
+	}
+
+	res := &ReplayResult{
+		RecordID: rec.ID,
+		Time:     rec.Time,
+		OldModel: rec.Model,
+		NewModel: req.Model,
+		Prompt:   prompt,
+		Old:      rec.Response.Text(),
+		Degraded: degraded,
+	}
+	resp, err := p.Chatter.Chat(ctx, req)
+	if err != nil {
+		res.Error = err.Error()
+		return res, nil
+	}
+	res.Response = resp
+	res.New = resp.Text()
+	res.Similarity = Similarity(res.Old, res.New)
+	return res, nil
+}
+
+// ReplayAll replays every record of the store matching q that has frame
+// data, calling fn with each result; records without frame data are skipped
+func (p *Replayer) ReplayAll(ctx context.Context, s Store, q Query, fn func(*ReplayResult) error) error {
+	records, err := Find(s, q)
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if rec.Error != "" {
+			continue
+		}
+		res, err := p.Replay(ctx, rec)
+		if errors.Is(err, ErrNoFrameData) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replacePrompt swaps the recorded prompt text for the new one in the last
+// user message
+func replacePrompt(req *models.ChatRequest, old, prompt string) {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		msg := &req.Messages[i]
+		if msg.Role != models.RoleUser {
+			continue
+		}
+		for j := range msg.Content {
+			if msg.Content[j].Type == models.ContentTypeText && msg.Content[j].Text == old {
+				msg.Content[j].Text = prompt
+				return
+			}
+		}
+	}
+}
+
+// Similarity returns 2*LCS/(len(a)+len(b)) over the runes of a and b: 1
+// for identical texts, 0 for texts sharing no characters
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra)+len(rb) == 0 {
+		return 1
+	}
+	return 2 * float64(lcsLen(ra, rb)) / float64(len(ra)+len(rb))
+}
+
+// lcsLen returns the length of the longest common subsequence
+func lcsLen(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				cur[j] = prev[j-1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j-1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// Diff returns a line diff of a and b: unchanged lines are prefixed with
+// two spaces, removed lines with "- " and added lines with "+ "
+func Diff(a, b string) string {
+	la, lb := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	// table[i][j] is the LCS length of la[i:] and lb[j:]
+	table := make([][]int, len(la)+1)
+	for i := range table {
+		table[i] = make([]int, len(lb)+1)
+	}
+	for i := len(la) - 1; i >= 0; i-- {
+		for j := len(lb) - 1; j >= 0; j-- {
+			if la[i] == lb[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(la) || j < len(lb) {
+		switch {
+		case i < len(la) && j < len(lb) && la[i] == lb[j]:
+			out.WriteString("  " + la[i] + "\n")
+			i++
+			j++
+		case i < len(la) && (j == len(lb) || table[i+1][j] >= table[i][j+1]):
+			out.WriteString("- " + la[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + lb[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/retention"
+)
+
+// Enforce implements retention.Target: records older than p.Results are
+// deleted, frame thumbnails and data older than p.Frames are stripped
+// (hashes are kept), and the oldest records go until the file fits in
+// p.MaxBytes. The file is rewritten atomically.
+func (s *FileStore) Enforce(ctx context.Context, p retention.Policy, now time.Time) (retention.Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return retention.Stats{}, fmt.Errorf("audit store is closed")
+	}
+
+	var records []*Record
+	if err := s.Iterate(func(rec *Record) error {
+		records = append(records, rec)
+		return ctx.Err()
+	}); err != nil {
+		return retention.Stats{}, err
+	}
+	kept, stats := applyPolicy(records, p, now)
+	if stats == (retention.Stats{}) {
+		return stats, nil
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return retention.Stats{}, fmt.Errorf("failed to create audit file: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, rec := range kept {
+		if err := enc.Encode(rec); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return retention.Stats{}, fmt.Errorf("failed to write audit record: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return retention.Stats{}, fmt.Errorf("failed to sync audit file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return retention.Stats{}, err
+	}
+
+	// Windows cannot replace a file that is still open
+	s.f.Close()
+	renameErr := os.Rename(tmp, s.path)
+	s.f, err = os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if renameErr != nil {
+		os.Remove(tmp)
+		return retention.Stats{}, fmt.Errorf("failed to replace audit file: %w", renameErr)
+	}
+	if err != nil {
+		return stats, fmt.Errorf("failed to reopen audit file: %w", err)
+	}
+	return stats, nil
+}
+
+// Enforce implements retention.Target
+func (s *MemoryStore) Enforce(ctx context.Context, p retention.Policy, now time.Time) (retention.Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept, stats := applyPolicy(s.records, p, now)
+	s.records = kept
+	return stats, nil
+}
+
+// applyPolicy returns the records to keep, in order; stripped records are
+// copies so readers holding the originals are unaffected
+func applyPolicy(records []*Record, p retention.Policy, now time.Time) ([]*Record, retention.Stats) {
+	var stats retention.Stats
+	results, frames := p.ResultCutoff(now), p.FrameCutoff(now)
+
+	kept := make([]*Record, 0, len(records))
+	sizes := make([]int64, 0, len(records))
+	var total int64
+	for _, rec := range records {
+		size := recordSize(rec)
+		if !results.IsZero() && rec.Time.Before(results) {
+			stats.Removed++
+			stats.Freed += size
+			continue
+		}
+		if !frames.IsZero() && rec.Time.Before(frames) && hasFrameData(rec) {
+			stripped := *rec
+			stripped.Frames = make([]FrameRecord, len(rec.Frames))
+			for i, fr := range rec.Frames {
+				stripped.Frames[i] = FrameRecord{SHA256: fr.SHA256, Size: fr.Size}
+			}
+			rec = &stripped
+			newSize := recordSize(rec)
+			stats.Stripped++
+			stats.Freed += size - newSize
+			size = newSize
+		}
+		kept = append(kept, rec)
+		sizes = append(sizes, size)
+		total += size
+	}
+
+	drop := 0
+	for p.MaxBytes > 0 && total > p.MaxBytes && drop < len(kept) {
+		total -= sizes[drop]
+		stats.Removed++
+		stats.Freed += sizes[drop]
+		drop++
+	}
+	return kept[drop:], stats
+}
+
+// hasFrameData reports whether the record stores any frame images
+func hasFrameData(rec *Record) bool {
+	for _, fr := range rec.Frames {
+		if fr.Thumbnail != "" || fr.Data != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// recordSize returns the size of the record's JSON line
+func recordSize(rec *Record) int64 {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data)) + 1
+}
@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrStop can be returned from an Iterate callback to stop early
+var ErrStop = errors.New("stop iteration")
+
+// Store is an append-only record store
+type Store interface {
+	Append(rec *Record) error
+	// Iterate calls fn for every record in append order
+	Iterate(fn func(rec *Record) error) error
+}
+
+// FileStore appends records as JSON lines to a file
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// OpenFileStore opens (or creates) an append-only JSONL audit file
+func OpenFileStore(path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &FileStore{path: path, f: f}, nil
+}
+
+// Append implements Store; each record is written and synced atomically
+func (s *FileStore) Append(rec *Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return fmt.Errorf("audit store is closed")
+	}
+	if _, err := s.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return s.f.Sync()
+}
+
+// Iterate implements Store by reading the file from the beginning
+func (s *FileStore) Iterate(fn func(rec *Record) error) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer f.Close()
+	return ReadRecords(f, fn)
+}
+
+// Close closes the underlying file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// ReadRecords decodes JSONL audit records from r
+func ReadRecords(r io.Reader, fn func(rec *Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("invalid audit record at line %d: %w", line, err)
+		}
+		if err := fn(&rec); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Query filters records when reading a store
+type Query struct {
+	Since  time.Time // Only records at or after Since
+	Until  time.Time // Only records before Until
+	Model  string    // Only records for this model
+	Errors bool      // Only failed requests
+	Limit  int       // Max records returned (0: unlimited)
+}
+
+// Find returns the records of a store matching the query
+func Find(s Store, q Query) ([]*Record, error) {
+	var out []*Record
+	err := s.Iterate(func(rec *Record) error {
+		if !q.Since.IsZero() && rec.Time.Before(q.Since) {
+			return nil
+		}
+		if !q.Until.IsZero() && !rec.Time.Before(q.Until) {
+			return nil
+		}
+		if q.Model != "" && rec.Model != q.Model {
+			return nil
+		}
+		if q.Errors && rec.Error == "" {
+			return nil
+		}
+		out = append(out, rec)
+		if q.Limit > 0 && len(out) >= q.Limit {
+			return ErrStop
+		}
+		return nil
+	})
+	return out, err
+}
+
+// MemoryStore keeps records in memory, useful for tests and short sessions
+type MemoryStore struct {
+	mu      sync.Mutex
+	records []*Record
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Append implements Store
+func (s *MemoryStore) Append(rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, rec)
+	return nil
+}
+
+// Iterate implements Store
+func (s *MemoryStore) Iterate(fn func(rec *Record) error) error {
+	s.mu.Lock()
+	records := append([]*Record(nil), s.records...)
+	s.mu.Unlock()
+
+	for _, rec := range records {
+		if err := fn(rec); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
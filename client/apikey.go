@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// APIKeyProvider 提供 API Key，每次请求都会调用，便于长期运行的服务在不重启的情况下轮换密钥
+type APIKeyProvider interface {
+	Key(ctx context.Context) (string, error)
+}
+
+// APIKeyFunc 将函数适配为 APIKeyProvider
+type APIKeyFunc func(ctx context.Context) (string, error)
+
+// Key 实现 APIKeyProvider
+func (f APIKeyFunc) Key(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// invalidator 由带缓存的 Provider 实现：API 返回鉴权失败时清除缓存，下次请求重新获取
+type invalidator interface {
+	Invalidate()
+}
+
+// StaticAPIKey 返回固定的 API Key
+func StaticAPIKey(key string) APIKeyProvider {
+	return APIKeyFunc(func(context.Context) (string, error) {
+		return key, nil
+	})
+}
+
+// APIKeyFromEnv 每次从环境变量读取 API Key（name 为空时使用 ZHIPU_API_KEY）
+func APIKeyFromEnv(name string) APIKeyProvider {
+	if name == "" {
+		name = EnvAPIKey
+	}
+	return APIKeyFunc(func(context.Context) (string, error) {
+		return os.Getenv(name), nil
+	})
+}
+
+// fileKey 从文件读取 API Key，文件修改时间变化时重新读取
+type fileKey struct {
+	path    string
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+}
+
+// APIKeyFromFile 从文件读取 API Key（如 Kubernetes Secret 挂载的文件），
+// 文件更新后自动生效，密钥不需要出现在进程参数或环境变量中
+func APIKeyFromFile(path string) APIKeyProvider {
+	return &fileKey{path: path}
+}
+
+// Key 实现 APIKeyProvider
+func (f *fileKey) Key(context.Context) (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat API key file: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.key != "" && info.ModTime().Equal(f.modTime) {
+		return f.key, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %w", err)
+	}
+	f.key = strings.TrimSpace(string(data))
+	f.modTime = info.ModTime()
+	return f.key, nil
+}
+
+// Invalidate 强制下次重新读取文件
+func (f *fileKey) Invalidate() {
+	f.mu.Lock()
+	f.key = ""
+	f.mu.Unlock()
+}
+
+// refreshingKey 缓存动态获取的 API Key，过期后刷新
+type refreshingKey struct {
+	fetch     func(ctx context.Context) (string, error)
+	ttl       time.Duration
+	mu        sync.Mutex
+	key       string
+	fetchedAt time.Time
+}
+
+// RefreshingAPIKey 通过 fetch 动态获取 API Key（如从 Vault 或密钥管理服务读取），
+// 结果缓存 ttl；刷新失败时继续使用旧密钥，直到获取成功
+func RefreshingAPIKey(fetch func(ctx context.Context) (string, error), ttl time.Duration) APIKeyProvider {
+	return &refreshingKey{fetch: fetch, ttl: ttl}
+}
+
+// Key 实现 APIKeyProvider
+func (r *refreshingKey) Key(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.key != "" && time.Since(r.fetchedAt) < r.ttl {
+		return r.key, nil
+	}
+	key, err := r.fetch(ctx)
+	if err != nil {
+		if r.key != "" {
+			return r.key, nil
+		}
+		return "", fmt.Errorf("failed to fetch API key: %w", err)
+	}
+	r.key, r.fetchedAt = key, time.Now()
+	return key, nil
+}
+
+// Invalidate 强制下次重新获取
+func (r *refreshingKey) Invalidate() {
+	r.mu.Lock()
+	r.fetchedAt = time.Time{}
+	r.mu.Unlock()
+}
+
+// ResolveAPIKey 返回本次请求使用的 API Key：优先使用 Keys，否则使用 APIKey
+func (c *Client) ResolveAPIKey(ctx context.Context) (string, error) {
+	key := c.APIKey
+	if c.Keys != nil {
+		var err error
+		if key, err = c.Keys.Key(ctx); err != nil {
+			return "", err
+		}
+	}
+	if key == "" {
+		return "", fmt.Errorf("%w: API key is not configured (set %s)", errdefs.ErrAuth, EnvAPIKey)
+	}
+	return key, nil
+}
+
+// invalidateAPIKey 在鉴权失败后让带缓存的 Provider 重新获取密钥
+func (c *Client) invalidateAPIKey() {
+	if inv, ok := c.Keys.(invalidator); ok {
+		inv.Invalidate()
+	}
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Transcriber 语音识别（ASR）：把音轨转写为文本，可在文本中自行附带时间戳
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio *processor.AudioPCM) (string, error)
+}
+
+// TranscriberFunc 将函数适配为 Transcriber
+type TranscriberFunc func(ctx context.Context, audio *processor.AudioPCM) (string, error)
+
+// Transcribe 实现 Transcriber
+func (f TranscriberFunc) Transcribe(ctx context.Context, audio *processor.AudioPCM) (string, error) {
+	return f(ctx, audio)
+}
+
+// audioFallbackInstruction 画面不可用时基于语音转写回答的提示词
+const audioFallbackInstruction = `该视频的画面无法解码，只能获得音轨的语音转写内容。请仅根据转写内容回答下面的问题；无法从语音中判断的内容请明确说明，不要推测画面。
+
+语音转写：
+%s
+
+问题：%s`
+
+// videoUndecodable 判断抽帧失败是否由于视频轨无法解码（而不是缺少 ffmpeg、超限或被取消）
+func videoUndecodable(err error) bool {
+	if pe, ok := errdefs.AsPartial(err); ok && len(pe.Frames) > 0 {
+		return false // 已抽到部分帧，交给调用方处理
+	}
+	return errors.Is(err, errdefs.ErrStreamCorrupt) || errors.Is(err, errdefs.ErrNoFrames)
+}
+
+// analyzeAudio 画面无法解码时转写音轨并仅根据语音回答，结果的 Fallback 字段标明降级原因；
+// 没有音轨时返回原始的抽帧错误
+func (c *Client) analyzeAudio(ctx context.Context, src processor.Source, prompt string, t Transcriber, options *ChatOptions, videoErr error) (*models.ChatResponse, error) {
+	fmt.Printf("视频画面无法解码，改为分析音轨: %v\n", videoErr)
+	pcm, err := c.StreamProcessor.ExtractAudio(ctx, src, 0)
+	if err != nil {
+		if errors.Is(err, errdefs.ErrNoAudio) {
+			return nil, videoErr
+		}
+		return nil, fmt.Errorf("%w (audio fallback also failed: %v)", videoErr, err)
+	}
+	transcript, err := t.Transcribe(ctx, pcm)
+	if err != nil {
+		return nil, fmt.Errorf("%w (failed to transcribe audio: %v)", videoErr, err)
+	}
+	if strings.TrimSpace(transcript) == "" {
+		return nil, fmt.Errorf("%w (audio has no speech)", videoErr)
+	}
+
+	resp, err := c.analyzeFrames(ctx, fmt.Sprintf(audioFallbackInstruction, transcript, prompt), nil, options, 0)
+	if err != nil {
+		return nil, err
+	}
+	resp.Fallback = &models.Fallback{Mode: models.FallbackAudio, Reason: strings.TrimSpace(videoErr.Error()), Transcript: transcript}
+	return resp, nil
+}
@@ -0,0 +1,309 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/quota"
+	"github.com/t8y2/zhipu-video-sdk/usage"
+)
+
+const batchInstruction = `以下包含 %d 组相互独立的视频画面，每组画面之前给出了针对该组的问题。请分别回答每组的问题，不要混淆不同组的画面。
+只返回 JSON：{"answers": [第 1 组的回答, 第 2 组的回答, ...]}，answers 的数量必须为 %d；问题要求 JSON 格式时，对应的回答直接使用 JSON 对象。`
+
+// Batcher 合并短时间内的多次帧分析调用为一次 API 请求，减少边缘设备的网络唤醒与请求开销。
+// 实现了 monitor.Analyzer 与 scheduler.Analyzer，可直接替换 Client：
+//
+//	b := client.NewBatcher(c, 4, 5*time.Second)
+//	s := scheduler.NewScheduler(b, recorder)
+//
+// 只有 ChatOptions 相同（同一指针）且 ctx 上的租户、标签与用量来源相同的调用才会合并，
+// 合并请求使用该组调用的 ctx 计费与归属，组内所有调用都取消后才中止；
+// 合并请求的回答无法解析时，自动退回逐个请求。各调用的 Usage 为整批用量的均摊值。
+type Batcher struct {
+	Client    *Client
+	Size      int           // 每批最多合并的调用数（默认 4）
+	Delay     time.Duration // 调用最多等待多久凑批（默认 2 秒）
+	MaxFrames int           // 每批最多帧数（0 表示不限）
+
+	mu      sync.Mutex
+	pending []*batchCall
+	frames  int
+	timer   *time.Timer
+}
+
+// batchCall 一次等待合并的调用
+type batchCall struct {
+	ctx     context.Context
+	prompt  string
+	frames  [][]byte
+	options *ChatOptions
+	done    chan batchResult
+}
+
+// batchResult 一次调用的结果
+type batchResult struct {
+	resp *models.ChatResponse
+	err  error
+}
+
+// NewBatcher 创建请求合并器，size 与 delay 为 0 时使用默认值
+func NewBatcher(c *Client, size int, delay time.Duration) *Batcher {
+	return &Batcher{Client: c, Size: size, Delay: delay}
+}
+
+// AnalyzeFramesWithOptions 排队等待合并并返回本次调用对应的回答
+func (b *Batcher) AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *ChatOptions) (*models.ChatResponse, error) {
+	return b.Analyze(context.Background(), prompt, frames, options)
+}
+
+// Analyze 同 AnalyzeFramesWithOptions，ctx 结束时停止等待；合并请求在组内所有调用都结束等待后才取消
+func (b *Batcher) Analyze(ctx context.Context, prompt string, frames [][]byte, options *ChatOptions) (*models.ChatResponse, error) {
+	c := b.Client
+	if c.DryRunMode || (options != nil && options.Stream) {
+		return c.analyzeFrames(ctx, prompt, frames, options, 0)
+	}
+	if c.PreFilter != nil && len(frames) > 0 {
+		// 预过滤逐个执行，被跳过的调用不占用批次
+		present, err := c.PreFilter.Present(ctx, frames)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run pre-filter: %w", err)
+		}
+		if !present {
+			return nil, errdefs.ErrSkipped
+		}
+	}
+
+	call := &batchCall{ctx: ctx, prompt: prompt, frames: frames, options: options, done: make(chan batchResult, 1)}
+	b.enqueue(call)
+
+	select {
+	case r := <-call.done:
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Flush 立即发送所有排队的调用
+func (b *Batcher) Flush() {
+	b.mu.Lock()
+	calls := b.take()
+	b.mu.Unlock()
+	b.send(calls)
+}
+
+// enqueue 加入队列，凑满一批时立即发送，否则在 Delay 后发送
+func (b *Batcher) enqueue(call *batchCall) {
+	size := b.Size
+	if size <= 0 {
+		size = 4
+	}
+	delay := b.Delay
+	if delay <= 0 {
+		delay = 2 * time.Second
+	}
+
+	b.mu.Lock()
+	// 加入后超出帧数上限时，先发送已排队的调用
+	var full []*batchCall
+	if b.MaxFrames > 0 && len(b.pending) > 0 && b.frames+len(call.frames) > b.MaxFrames {
+		full = b.take()
+	}
+	b.pending = append(b.pending, call)
+	b.frames += len(call.frames)
+	var ready []*batchCall
+	if len(b.pending) >= size {
+		ready = b.take()
+	} else if b.timer == nil {
+		b.timer = time.AfterFunc(delay, b.Flush)
+	}
+	b.mu.Unlock()
+
+	if full != nil {
+		go b.send(full)
+	}
+	if ready != nil {
+		go b.send(ready)
+	}
+}
+
+// take 取出全部排队的调用，调用方持有锁
+func (b *Batcher) take() []*batchCall {
+	calls := b.pending
+	b.pending = nil
+	b.frames = 0
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return calls
+}
+
+// batchKey 决定哪些调用可以合并：对话参数相同，且计费与归属相同
+type batchKey struct {
+	options *ChatOptions
+	tenant  string
+	source  string
+	labels  string
+}
+
+// keyOf 返回调用的分组键
+func keyOf(call *batchCall) batchKey {
+	return batchKey{
+		options: call.options,
+		tenant:  quota.TenantFrom(call.ctx),
+		source:  usage.SourceFrom(call.ctx),
+		labels:  models.LabelsFrom(call.ctx).String(),
+	}
+}
+
+// send 按 ChatOptions 与租户、标签分组发送
+func (b *Batcher) send(calls []*batchCall) {
+	var order []batchKey
+	groups := make(map[batchKey][]*batchCall)
+	for _, call := range calls {
+		key := keyOf(call)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], call)
+	}
+	for _, key := range order {
+		b.sendGroup(groups[key], key.options)
+	}
+}
+
+// groupContext 返回合并请求使用的 ctx：携带第一个调用的值（组内租户、标签相同），
+// 在组内所有调用的 ctx 都结束后取消
+func groupContext(calls []*batchCall) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(calls[0].ctx))
+	var wg sync.WaitGroup
+	wg.Add(len(calls))
+	for _, call := range calls {
+		stop := context.AfterFunc(call.ctx, wg.Done)
+		go func() {
+			<-ctx.Done()
+			if stop() {
+				wg.Done()
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+	return ctx, cancel
+}
+
+// sendGroup 发送一组调用：单个调用直接请求，多个调用合并为一次请求
+func (b *Batcher) sendGroup(calls []*batchCall, options *ChatOptions) {
+	if len(calls) == 1 {
+		resp, err := b.Client.sendFrames(calls[0].ctx, "", calls[0].prompt, calls[0].frames, options, 0)
+		calls[0].done <- batchResult{resp, err}
+		return
+	}
+	ctx, cancel := groupContext(calls)
+	defer cancel()
+
+	answers, resp, err := b.batch(ctx, calls, options)
+	if err != nil && resp == nil {
+		for _, call := range calls {
+			call.done <- batchResult{nil, err}
+		}
+		return
+	}
+	if err != nil {
+		// 回答无法对应到各调用时，退回逐个请求
+		for _, call := range calls {
+			resp, err := b.Client.sendFrames(call.ctx, "", call.prompt, call.frames, options, 0)
+			call.done <- batchResult{resp, err}
+		}
+		return
+	}
+
+	usage := splitUsage(resp.Usage, len(calls))
+	for i, call := range calls {
+		r := *resp
+		r.Choices = []models.Choice{{
+			Message:      models.ResponseMessage{Role: models.RoleAssistant, Content: answers[i]},
+			FinishReason: resp.FinishReason(),
+		}}
+		r.Usage = usage[i]
+		r.Estimate = EstimateUsage(call.prompt, call.frames)
+		call.done <- batchResult{&r, nil}
+	}
+}
+
+// batch 发送合并请求并按顺序返回各调用的回答；请求成功但回答无法解析时同时返回响应与错误
+func (b *Batcher) batch(ctx context.Context, calls []*batchCall, options *ChatOptions) ([]string, *models.ChatResponse, error) {
+	// 提示词上下文按调用注入各自的问题，各调用的字段（时间、摄像头等）可能不同
+	prompt := fmt.Sprintf(batchInstruction, len(calls), len(calls))
+	contents := []models.Content{models.Text(prompt)}
+	var frames [][]byte
+	for i, call := range calls {
+		question, err := b.Client.injectContext(call.ctx, call.prompt)
+		if err != nil {
+			return nil, nil, err
+		}
+		contents = append(contents, models.Text(fmt.Sprintf("第 %d 组问题：%s", i+1, question)))
+		contents = append(contents, b.Client.FrameCache.Frames(call.frames)...)
+		frames = append(frames, call.frames...)
+	}
+
+	opts := ChatOptions{}
+	if options != nil {
+		opts = *options
+	}
+	opts.ResponseFormat = models.JSONObjectFormat()
+	req := b.Client.buildChatRequest(prompt, nil, &opts)
+	req.Messages = []models.Message{models.UserMessage(contents...)}
+
+	resp, err := b.Client.execute(ctx, req, prompt, frames, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out struct {
+		Answers []json.RawMessage `json:"answers"`
+	}
+	if err := DecodeJSON(resp.Text(), &out); err != nil {
+		return nil, resp, err
+	}
+	if len(out.Answers) != len(calls) {
+		return nil, resp, fmt.Errorf("expected %d answers, got %d", len(calls), len(out.Answers))
+	}
+
+	answers := make([]string, len(out.Answers))
+	for i, raw := range out.Answers {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			answers[i] = s
+		} else {
+			answers[i] = strings.TrimSpace(string(raw))
+		}
+	}
+	return answers, resp, nil
+}
+
+// splitUsage 将用量均摊到 n 个调用，余数计入第一个
+func splitUsage(u models.Usage, n int) []models.Usage {
+	out := make([]models.Usage, n)
+	for i := range out {
+		out[i] = models.Usage{
+			PromptTokens:     u.PromptTokens / n,
+			CompletionTokens: u.CompletionTokens / n,
+			TotalTokens:      u.TotalTokens / n,
+		}
+	}
+	out[0].PromptTokens += u.PromptTokens % n
+	out[0].CompletionTokens += u.CompletionTokens % n
+	out[0].TotalTokens += u.TotalTokens % n
+	return out
+}
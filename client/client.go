@@ -2,52 +2,97 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"os"
 	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/t8y2/zhipu-video-sdk/audit"
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/events"
 	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/postprocess"
+	"github.com/t8y2/zhipu-video-sdk/presence"
 	"github.com/t8y2/zhipu-video-sdk/processor"
+	"github.com/t8y2/zhipu-video-sdk/promptctx"
+	"github.com/t8y2/zhipu-video-sdk/quota"
+	"github.com/t8y2/zhipu-video-sdk/usage"
 )
 
-func init() {
-	// 加载 .env 配置
-	_ = godotenv.Load()
-	_ = godotenv.Load("../.env")
-	_ = godotenv.Load("../../.env")
-}
-
 const (
 	DefaultAPIURL = "https://open.bigmodel.cn/api/paas/v4/chat/completions"
 	DefaultModel  = "glm-4.5v"
 	EnvAPIKey     = "ZHIPU_API_KEY"
+	EnvAPIKeyFile = "ZHIPU_API_KEY_FILE" // 包含 API Key 的文件路径（如 Kubernetes Secret），文件更新后自动生效
 )
 
 // Client GLM-4.5V 客户端 (专注于 H.264/AVC 视频流处理)
 type Client struct {
 	APIKey          string
+	Keys            APIKeyProvider // 可选：动态 API Key 来源（文件、Vault 等），设置后优先于 APIKey
 	APIURL          string
 	Model           string
 	HTTPClient      *http.Client
 	StreamProcessor *processor.StreamProcessor // H.264/AVC 流处理器
+	Auditor         *audit.Auditor             // 可选：审计记录器，记录每次请求与响应
+	Quota           *quota.Manager             // 可选：配额管理，发送前检查预算并在完成后记账（租户通过 quota.WithTenant 标记 context）
+	Usage           *usage.Exporter            // 可选：用量导出，每次调用写入一行 CSV/JSONL（来源通过 usage.WithSource 标记 context）
+
+	// 试运行模式：执行抽帧与预处理，估算 token 与费用，但不调用 API
+	DryRunMode bool
+	DryRunDir  string              // 试运行时写出帧的目录（可选）
+	OnDryRun   func(*DryRunReport) // 试运行报告回调（可选，默认打印到标准输出）
+
+	// 本地预过滤：发送前判断画面中是否有人/人脸等，没有时返回 errdefs.ErrSkipped 而不调用 API
+	PreFilter presence.Detector
+
+	// 结果后处理：在结果返回调用方之前依次执行（去除 markdown、正则提取、翻译、追加免责声明等）
+	OnResult postprocess.Chain
+
+	// 耗时诊断：每次调用结束后回调各阶段耗时；总耗时超过阈值时打印最慢阶段
+	OnTimings         func(*models.Timings)
+	SlowCallThreshold time.Duration
+
+	// 截止时间预算：context 带截止时间时 Analyze 按阶段分配时间（默认启用，nil 使用默认参数）
+	Budget *DeadlineBudget
+	pace   *pace
+
+	Retries       int          // 限流与服务端错误的重试次数（默认 0，不重试）
+	Options       *ChatOptions // 默认对话参数，调用时传入的选项中未设置的字段取此值（可选）
+	DefaultFrames int          // Analyze 未指定 MaxFrames 时的采样帧数（默认 8）
+
+	// 帧编码缓存：同一帧在多个提示词中重复发送时复用其 base64 编码（可选，会话默认自带）
+	FrameCache *models.EncodingCache
+
+	// 提示词上下文：自动在提示词前注入摄像头、位置、时段、天气等背景信息（可选）
+	PromptContext *promptctx.Injector
 }
 
-// NewClient 创建客户端，apiKey 为空时从环境变量 ZHIPU_API_KEY 读取
+// NewClient 创建客户端，apiKey 为空时从环境变量 ZHIPU_API_KEY 读取，
+// 仍为空时使用 ZHIPU_API_KEY_FILE 指向的文件
+// 不会自动加载 .env 文件，需要时请先调用 LoadDotEnv，或使用 NewClientWithConfig
 func NewClient(apiKey string) *Client {
+	var keys APIKeyProvider
 	if apiKey == "" {
 		apiKey = os.Getenv(EnvAPIKey)
 	}
+	if path := os.Getenv(EnvAPIKeyFile); apiKey == "" && path != "" {
+		keys = APIKeyFromFile(path)
+	}
 
 	return &Client{
+		Keys:            keys,
 		APIKey:          apiKey,
 		APIURL:          DefaultAPIURL,
 		Model:           DefaultModel,
 		StreamProcessor: processor.NewStreamProcessor(),
+		pace:            &pace{},
 		HTTPClient: &http.Client{
 			Timeout: 60 * time.Second,
 		},
@@ -64,78 +109,352 @@ func (c *Client) AnalyzeFrames(prompt string, frames [][]byte) (*models.ChatResp
 
 // AnalyzeFramesWithOptions 使用自定义选项分析图像帧
 func (c *Client) AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *ChatOptions) (*models.ChatResponse, error) {
-	// 构造请求内容
-	contents := []models.Content{
-		{
-			Type: "text",
-			Text: prompt,
-		},
+	return c.AnalyzeFramesWithContext(context.Background(), prompt, frames, options)
+}
+
+// AnalyzeFramesWithContext 同 AnalyzeFramesWithOptions，ctx 取消或超时会中止 HTTP 请求与重试等待；
+// options 可以为 nil
+func (c *Client) AnalyzeFramesWithContext(ctx context.Context, prompt string, frames [][]byte, options *ChatOptions) (*models.ChatResponse, error) {
+	return c.analyzeFrames(ctx, prompt, frames, options, 0)
+}
+
+// analyzeFrames 发送帧并记录编码耗时，extract 为调用方已测量的抽帧耗时
+func (c *Client) analyzeFrames(ctx context.Context, prompt string, frames [][]byte, options *ChatOptions, extract time.Duration) (*models.ChatResponse, error) {
+	return c.analyzeFramesModel(ctx, "", prompt, frames, options, extract)
+}
+
+// analyzeFramesModel 同 analyzeFrames，model 不为空时覆盖客户端模型
+func (c *Client) analyzeFramesModel(ctx context.Context, model, prompt string, frames [][]byte, options *ChatOptions, extract time.Duration) (*models.ChatResponse, error) {
+	if c.PreFilter != nil && len(frames) > 0 {
+		present, err := c.PreFilter.Present(ctx, frames)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run pre-filter: %w", err)
+		}
+		if !present {
+			return nil, errdefs.ErrSkipped
+		}
+	}
+
+	return c.sendFrames(ctx, model, prompt, frames, options, extract)
+}
+
+// sendFrames 构造并发送帧分析请求（不经过预过滤）
+func (c *Client) sendFrames(ctx context.Context, model, prompt string, frames [][]byte, options *ChatOptions, extract time.Duration) (*models.ChatResponse, error) {
+	prompt, err := c.injectContext(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	if c.DryRunMode {
+		return c.dryRunResponse(prompt, frames, options)
 	}
 
-	// 添加图像帧（使用 base64 编码的 data URI）
-	for _, frame := range frames {
-		base64Image := base64.StdEncoding.EncodeToString(frame)
-		contents = append(contents, models.Content{
-			Type: "image_url",
-			ImageURL: &models.ImageURL{
-				URL:    fmt.Sprintf("data:image/jpeg;base64,%s", base64Image),
-				Detail: "high", // 使用高细节模式获得最佳分析效果
-			},
+	start := time.Now()
+	req := c.buildChatRequest(prompt, frames, options)
+	if model != "" {
+		req.Model = model
+	}
+	timings := &models.Timings{Extraction: extract, Encode: time.Since(start)}
+	resp, err := c.execute(ctx, req, prompt, frames, timings)
+	if err != nil {
+		return nil, err
+	}
+	resp.Estimate = EstimateUsage(prompt, frames)
+	return resp, nil
+}
+
+// Chat 发送自定义的对话请求（支持多轮消息），适用于需要完全控制请求内容的场景
+func (c *Client) Chat(ctx context.Context, req *models.ChatRequest) (*models.ChatResponse, error) {
+	if req.Model == "" {
+		req.Model = c.Model
+	}
+
+	var prompt string
+	for _, msg := range req.Messages {
+		for _, content := range msg.Content {
+			if content.Type == models.ContentTypeText {
+				prompt = content.Text
+			}
+		}
+	}
+	return c.execute(ctx, req, prompt, nil, nil)
+}
+
+// NewRequest 创建使用客户端模型的请求构建器，Build 时会在发送前校验参数
+// 用法：req, err := c.NewRequest().WithPrompt(prompt).AddFrames(frames).Build()
+func (c *Client) NewRequest() *models.RequestBuilder {
+	return models.NewRequestBuilder(c.Model)
+}
+
+// ValidateFrames 在调用 API 之前按当前模型的限制检查帧（可解码性、尺寸、大小与数量）
+func (c *Client) ValidateFrames(frames [][]byte) *models.FrameValidation {
+	return models.ValidateFramesForModel(frames, c.Model)
+}
+
+// execute 检查配额、发送请求并写入审计记录
+// timings 可携带调用方已测量的前置阶段（抽帧、编码等），为 nil 时新建
+func (c *Client) execute(ctx context.Context, req *models.ChatRequest, prompt string, frames [][]byte, timings *models.Timings) (*models.ChatResponse, error) {
+	if timings == nil {
+		timings = &models.Timings{}
+	}
+	callStart := time.Now()
+	defer func() {
+		timings.Total += time.Since(callStart)
+		c.reportTimings(timings)
+	}()
+
+	// 预留预估用量，完成后按实际用量结算；失败或提前返回时释放
+	var reservation *quota.Reservation
+	if c.Quota != nil {
+		key, _ := c.ResolveAPIKey(ctx)
+		var err error
+		reservation, err = c.Quota.Acquire(ctx, req.Model, EstimateUsage(prompt, frames).Total(), quota.Scopes(ctx, key)...)
+		if err != nil {
+			return nil, err
+		}
+		defer reservation.Release()
+	}
+
+	// 流式请求开始输出后不再重试，避免回调收到重复内容
+	onChunk, streaming := chunkHandlerFrom(ctx)
+	if streaming {
+		req.Stream = true
+	}
+	delivered := false
+	send := func() (*models.ChatResponse, int, error) {
+		if !req.Stream {
+			return c.sendChat(ctx, req, timings)
+		}
+		return c.sendChatStream(ctx, req, timings, func(chunk *models.ChatCompletionChunk) error {
+			delivered = true
+			if onChunk == nil {
+				return nil
+			}
+			return onChunk(chunk)
 		})
 	}
 
-	req := models.ChatRequest{
-		Model: c.Model,
-		Messages: []models.Message{
-			{
-				Role:    "user",
-				Content: contents,
-			},
-		},
+	events.Publish(ctx, events.AnalysisStarted{Model: req.Model, Frames: len(frames), Prompt: prompt})
+	start := time.Now()
+	resp, statusCode, err := send()
+	for attempt := 1; err != nil && !delivered && attempt <= c.Retries && errdefs.IsTemporary(err); attempt++ {
+		wait := retryDelay(err, attempt)
+		fmt.Printf("请求失败，%v 后重试（第 %d 次）: %v\n", wait, attempt, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		resp, statusCode, err = send()
+	}
+	if resp != nil {
+		resp.Timings = timings
+		resp.Labels = models.LabelsFrom(ctx).Clone()
+	}
+	if err == nil {
+		reservation.Commit(resp.Usage)
+	}
+	if c.Auditor != nil {
+		ex := audit.Exchange{
+			URL:        c.APIURL,
+			Request:    req,
+			Prompt:     prompt,
+			Frames:     frames,
+			StatusCode: statusCode,
+			Response:   resp,
+			Err:        err,
+			Started:    start,
+			Latency:    time.Since(start),
+			Labels:     models.LabelsFrom(ctx),
+		}
+		if auditErr := c.Auditor.Record(ex); auditErr != nil {
+			fmt.Printf("写入审计记录失败: %v\n", auditErr)
+		}
+	}
+	c.exportUsage(ctx, req.Model, resp, statusCode, err, time.Since(start))
+	completed := events.AnalysisCompleted{Model: req.Model, Frames: len(frames), Latency: time.Since(start), Err: err}
+	if resp != nil {
+		completed.Usage = resp.Usage
+	}
+	events.Publish(ctx, completed)
+	if err != nil {
+		return nil, err
+	}
+	c.stats().observeAPI(len(frames), time.Since(start))
+	if err := c.postProcess(ctx, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// retryDelay 返回第 attempt 次重试前的等待时间：优先使用服务端的 Retry-After，否则线性退避
+func retryDelay(err error, attempt int) time.Duration {
+	var apiErr *errdefs.APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+	return time.Duration(attempt) * time.Second
+}
+
+// postProcess 对响应执行 OnResult 钩子链（ctx 被 postprocess.WithoutHooks 标记时跳过）
+func (c *Client) postProcess(ctx context.Context, resp *models.ChatResponse) error {
+	if len(c.OnResult) == 0 || postprocess.Skipped(ctx) {
+		return nil
+	}
+	return c.OnResult.ApplyResponse(ctx, resp)
+}
+
+// exportUsage 将本次调用的用量写入 Usage 导出器（未配置时忽略）
+func (c *Client) exportUsage(ctx context.Context, model string, resp *models.ChatResponse, statusCode int, err error, latency time.Duration) {
+	if c.Usage == nil {
+		return
+	}
+	if exportErr := c.Usage.Export(usage.NewRecord(ctx, model, resp, statusCode, err, latency)); exportErr != nil {
+		fmt.Printf("写入用量记录失败: %v\n", exportErr)
+	}
+}
+
+// reportTimings 调用耗时回调，并在超过 SlowCallThreshold 时输出最慢阶段
+func (c *Client) reportTimings(t *models.Timings) {
+	if c.OnTimings != nil {
+		c.OnTimings(t)
+	}
+	if c.SlowCallThreshold > 0 && t.Total > c.SlowCallThreshold {
+		slowest := t.Slowest()
+		fmt.Printf("慢调用: 总耗时 %v，最慢阶段 %s (%v)，明细: %s\n",
+			t.Total.Round(time.Millisecond), slowest.Name, slowest.Duration.Round(time.Millisecond), t)
+	}
+}
+
+// buildChatRequest 构造包含提示词与图像帧的对话请求
+func (c *Client) buildChatRequest(prompt string, frames [][]byte, options *ChatOptions) *models.ChatRequest {
+	// 构造请求内容：提示词 + 图像帧（使用高细节模式的 base64 data URI）
+	contents := append([]models.Content{models.Text(prompt)}, c.FrameCache.Frames(frames)...)
+
+	req := &models.ChatRequest{
+		Model:    c.Model,
+		Messages: []models.Message{models.UserMessage(contents...)},
 	}
 
 	// 应用自定义选项
+	options = c.chatOptions(options)
 	if options != nil {
 		req.Temperature = options.Temperature
 		req.TopP = options.TopP
 		req.MaxTokens = options.MaxTokens
 		req.Stream = options.Stream
+		req.ResponseFormat = options.ResponseFormat
+		if options.Detail != "" {
+			for _, content := range req.Messages[0].Content {
+				if content.ImageURL != nil {
+					content.ImageURL.Detail = options.Detail
+				}
+			}
+		}
+	}
+
+	return req
+}
+
+// injectContext 按 PromptContext 在提示词前注入背景信息
+func (c *Client) injectContext(ctx context.Context, prompt string) (string, error) {
+	prompt, err := c.PromptContext.Apply(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to inject prompt context: %w", err)
 	}
+	return prompt, nil
+}
+
+// chatOptions 用客户端的默认对话参数补全 options 中未设置的字段
+func (c *Client) chatOptions(options *ChatOptions) *ChatOptions {
+	if c.Options == nil {
+		return options
+	}
+	if options == nil {
+		return c.Options
+	}
+	merged := *options
+	if merged.Temperature == nil {
+		merged.Temperature = c.Options.Temperature
+	}
+	if merged.TopP == nil {
+		merged.TopP = c.Options.TopP
+	}
+	if merged.MaxTokens == nil {
+		merged.MaxTokens = c.Options.MaxTokens
+	}
+	if merged.ResponseFormat == nil {
+		merged.ResponseFormat = c.Options.ResponseFormat
+	}
+	if merged.Detail == "" {
+		merged.Detail = c.Options.Detail
+	}
+	return &merged
+}
 
+// sendChat 发送对话请求并解析响应，同时返回 HTTP 状态码，并将各阶段耗时写入 t
+func (c *Client) sendChat(ctx context.Context, req *models.ChatRequest, t *models.Timings) (*models.ChatResponse, int, error) {
+	stage := time.Now()
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, 0, fmt.Errorf("failed to marshal request: %w", err)
 	}
+	t.Encode += time.Since(stage)
+
+	// 通过 httptrace 区分上传、服务端处理与下载耗时
+	stage = time.Now()
+	var wrote, firstByte time.Time
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest:         func(httptrace.WroteRequestInfo) { wrote = time.Now() },
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	})
 
-	httpReq, err := http.NewRequest("POST", c.APIURL, bytes.NewBuffer(reqBody))
+	apiKey, err := c.ResolveAPIKey(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.APIURL, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 
 	resp, err := c.HTTPClient.Do(httpReq)
+	if !wrote.IsZero() {
+		t.Upload += wrote.Sub(stage)
+		if !firstByte.IsZero() {
+			t.Server += firstByte.Sub(wrote)
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, 0, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	stage = time.Now()
 	body, err := io.ReadAll(resp.Body)
+	t.Download += time.Since(stage)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		apiErr := errdefs.NewAPIError(resp.StatusCode, resp.Header, body)
+		if errors.Is(apiErr, errdefs.ErrAuth) {
+			c.invalidateAPIKey()
+		}
+		return nil, resp.StatusCode, apiErr
 	}
 
+	stage = time.Now()
 	var chatResp models.ChatResponse
 	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, resp.StatusCode, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	t.Parse += time.Since(stage)
 
-	return &chatResp, nil
+	return &chatResp, resp.StatusCode, nil
 }
 
 // ChatOptions 包含可选的对话参数
@@ -143,7 +462,10 @@ type ChatOptions struct {
 	Temperature *float64 // 0.0-1.0, 控制随机性
 	TopP        *float64 // 0.0-1.0, 核采样参数
 	MaxTokens   *int     // 最大生成 token 数
-	Stream      bool     // 是否启用流式响应
+	Stream      bool     // 是否启用流式响应（增量块可通过 AnalyzeFramesStream 的回调获取）
+
+	ResponseFormat *models.ResponseFormat // 结构化输出格式（可选）
+	Detail         models.Detail          // 图像细节级别（默认 high）
 }
 
 // AnalyzeH264Stream 分析 H.264/AVC 编码的视频流
@@ -156,8 +478,28 @@ func (c *Client) AnalyzeH264Stream(h264Data []byte, prompt string) (*models.Chat
 
 // AnalyzeH264StreamWithOptions 使用自定义选项分析 H.264 视频流
 func (c *Client) AnalyzeH264StreamWithOptions(h264Data []byte, prompt string, options *ChatOptions) (*models.ChatResponse, error) {
-	// 使用 StreamProcessor 处理 H.264 流
-	base64Frames, err := c.StreamProcessor.ProcessH264Stream(h264Data)
+	return c.AnalyzeH264StreamWithContext(context.Background(), h264Data, prompt, options)
+}
+
+// AnalyzeH264StreamWithContext 同 AnalyzeH264StreamWithOptions，ctx 贯穿 ffmpeg 抽帧与 API 调用：
+// 取消或超时会结束 ffmpeg 进程并中止 HTTP 请求；options 可以为 nil
+func (c *Client) AnalyzeH264StreamWithContext(ctx context.Context, h264Data []byte, prompt string, options *ChatOptions) (*models.ChatResponse, error) {
+	start := time.Now()
+	frames, err := c.extractH264Frames(ctx, h264Data)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("正在调用 GLM-4.5V API 进行视频分析...")
+	return c.analyzeFrames(ctx, prompt, frames, options, time.Since(start))
+}
+
+// extractH264Frames 使用 StreamProcessor 处理 H.264 流并返回 JPEG 帧
+func (c *Client) extractH264Frames(ctx context.Context, h264Data []byte) ([][]byte, error) {
+	if err := c.StreamProcessor.RequireTools(); err != nil {
+		return nil, err
+	}
+	base64Frames, err := c.StreamProcessor.ProcessH264StreamWithContext(ctx, h264Data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process H.264 stream: %w", err)
 	}
@@ -172,9 +514,7 @@ func (c *Client) AnalyzeH264StreamWithOptions(h264Data []byte, prompt string, op
 		}
 		frames[i] = frameData
 	}
-
-	fmt.Println("正在调用 GLM-4.5V API 进行视频分析...")
-	return c.AnalyzeFramesWithOptions(prompt, frames, options)
+	return frames, nil
 }
 
 // ConfigureStreamProcessor 配置 H.264 流处理器
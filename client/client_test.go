@@ -0,0 +1,104 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/t8y2/zhipu-video-sdk/clienttest"
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/videotest"
+)
+
+func testFrames(t *testing.T) [][]byte {
+	t.Helper()
+	frames, err := videotest.Frames(videotest.Options{Frames: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frames
+}
+
+func TestRetryOnRateLimit(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.Respond("一辆车驶过")
+	srv.InjectFault(clienttest.Fault{Kind: clienttest.FaultRateLimit})
+
+	c := srv.Client()
+	c.Retries = 1
+	resp, err := c.AnalyzeFramesWithContext(context.Background(), "描述画面", testFrames(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Text(); got != "一辆车驶过" {
+		t.Errorf("Text() = %q", got)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+}
+
+func TestRateLimitWithoutRetries(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(clienttest.Fault{Kind: clienttest.FaultRateLimit})
+
+	_, err := srv.Client().AnalyzeFramesWithContext(context.Background(), "描述画面", testFrames(t), nil)
+	if !errors.Is(err, errdefs.ErrRateLimited) {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+}
+
+func TestMalformedJSON(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(clienttest.Fault{Kind: clienttest.FaultMalformedJSON})
+
+	c := srv.Client()
+	c.Retries = 2
+	resp, err := c.AnalyzeFramesWithContext(context.Background(), "描述画面", testFrames(t), nil)
+	if err == nil {
+		t.Fatalf("expected an error, got response %+v", resp)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Errorf("malformed response was retried: %d requests", n)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	content := "画面中有一个白色方块从左向右移动，背景为彩条。"
+	srv.Respond(content)
+
+	var chunks []string
+	resp, err := srv.Client().AnalyzeFramesStream(context.Background(), "描述画面", testFrames(t), nil,
+		func(chunk *models.ChatCompletionChunk) error {
+			chunks = append(chunks, chunk.Text())
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) < 2 {
+		t.Errorf("got %d chunks, want the answer in several", len(chunks))
+	}
+	if got := strings.Join(chunks, ""); got != content {
+		t.Errorf("chunks = %q, want %q", got, content)
+	}
+	if got := resp.Text(); got != content {
+		t.Errorf("Text() = %q, want %q", got, content)
+	}
+	if resp.Usage.TotalTokens == 0 {
+		t.Error("usage of the last chunk was not kept")
+	}
+	if reqs := srv.Requests(); len(reqs) != 1 || !reqs[0].Stream {
+		t.Errorf("request was not sent as a stream")
+	}
+}
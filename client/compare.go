@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// TimeRange 视频中的时间区间
+type TimeRange struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// CompareOptions 时间窗口对比选项
+type CompareOptions struct {
+	FramesPerWindow int          // 每个窗口采样帧数（默认 4）
+	ChatOptions     *ChatOptions // 透传的对话参数
+}
+
+const compareInstruction = `前 %d 张图片来自时间窗口 A（%s - %s），后 %d 张图片来自时间窗口 B（%s - %s），均为同一场景。
+%s
+请对比两个窗口，只返回 JSON：
+{"appeared": ["B 中新出现的物品"], "missing": ["A 中有但 B 中消失的物品"], "changed": ["布局、位置或状态的变化"], "summary": "一句话总结"}`
+
+// CompareTimeWindows 从同一视频源的两个时间窗口抽帧，让模型对比差异
+// 适用于货架巡检、施工进度跟踪等场景
+// source: 视频文件路径或 ffmpeg 可读取的流地址
+// prompt: 额外的关注点说明（可为空）
+func (c *Client) CompareTimeWindows(source string, t1, t2 TimeRange, prompt string, opts *CompareOptions) (*models.ChangeReport, error) {
+	return c.CompareTimeWindowsWithContext(context.Background(), source, t1, t2, prompt, opts)
+}
+
+// CompareTimeWindowsWithContext 支持 context 的时间窗口对比
+func (c *Client) CompareTimeWindowsWithContext(ctx context.Context, source string, t1, t2 TimeRange, prompt string, opts *CompareOptions) (*models.ChangeReport, error) {
+	if err := c.StreamProcessor.RequireTools(); err != nil {
+		return nil, err
+	}
+
+	n := 4
+	var chatOpts *ChatOptions
+	if opts != nil {
+		if opts.FramesPerWindow > 0 {
+			n = opts.FramesPerWindow
+		}
+		chatOpts = opts.ChatOptions
+	}
+
+	fmt.Println("正在提取对比窗口 A 的帧...")
+	framesA, err := c.extractRange(ctx, source, t1, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract window A: %w", err)
+	}
+
+	fmt.Println("正在提取对比窗口 B 的帧...")
+	framesB, err := c.extractRange(ctx, source, t2, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract window B: %w", err)
+	}
+
+	fullPrompt := fmt.Sprintf(compareInstruction,
+		len(framesA), formatTimestamp(t1.Start), formatTimestamp(t1.End),
+		len(framesB), formatTimestamp(t2.Start), formatTimestamp(t2.End),
+		prompt)
+
+	frames := append(append([][]byte{}, framesA...), framesB...)
+	resp, err := c.AnalyzeFramesWithContext(ctx, fullPrompt, frames, chatOpts)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+
+	return parseChangeReport(resp.Choices[0].Message.Content), nil
+}
+
+// extractRange 从指定时间区间采样帧
+func (c *Client) extractRange(ctx context.Context, source string, r TimeRange, n int) ([][]byte, error) {
+	if r.End <= r.Start {
+		return nil, fmt.Errorf("invalid time range %s - %s", formatTimestamp(r.Start), formatTimestamp(r.End))
+	}
+	return c.StreamProcessor.ExtractVideoSegment(ctx, source, r.Start, r.End-r.Start, n)
+}
+
+// parseChangeReport 解析模型返回的对比结果，无法解析时将原文放入 Summary
+func parseChangeReport(content string) *models.ChangeReport {
+	report := &models.ChangeReport{Raw: content}
+
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end <= start || json.Unmarshal([]byte(content[start:end+1]), report) != nil {
+		report.Summary = strings.TrimSpace(content)
+	}
+	return report
+}
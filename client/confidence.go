@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// DefaultConfidenceThreshold 默认的低置信度阈值
+const DefaultConfidenceThreshold = 0.6
+
+const confidenceInstruction = `%s
+
+同时给出你对回答的把握程度 confidence（0 到 1）：画面模糊、遮挡、目标过小或信息不足时给出较低的值，并在 reason 中说明原因。`
+
+// ConfidentAnswer 带置信度的结构化回答
+type ConfidentAnswer struct {
+	Answer     string  `json:"answer" description:"对问题的回答"`
+	Confidence float64 `json:"confidence" description:"对回答的把握程度，0 到 1 之间"`
+	Reason     string  `json:"reason,omitempty" description:"置信度较低时说明原因"`
+}
+
+// LowConfidence 一次低于阈值的回答，交给升级策略处理
+type LowConfidence struct {
+	Prompt   string
+	Frames   [][]byte
+	Answer   ConfidentAnswer
+	Response *models.ChatResponse
+	Attempt  int // 第几次升级，从 1 开始
+}
+
+// Escalator 低置信度升级策略：返回新的帧则用这些帧重新提问（如更多帧、更高分辨率），
+// 返回 nil 则停止升级并保留当前结果（如已转交人工复核）
+type Escalator interface {
+	Escalate(ctx context.Context, lc *LowConfidence) ([][]byte, error)
+}
+
+// EscalatorFunc 将函数适配为 Escalator
+type EscalatorFunc func(ctx context.Context, lc *LowConfidence) ([][]byte, error)
+
+// Escalate 实现 Escalator
+func (f EscalatorFunc) Escalate(ctx context.Context, lc *LowConfidence) ([][]byte, error) {
+	return f(ctx, lc)
+}
+
+// MoreFrames 从视频源重新抽帧后再次提问，每次升级帧数翻倍（首次为 frames 的两倍）
+func MoreFrames(sp *processor.StreamProcessor, src processor.Source, frames int) Escalator {
+	return EscalatorFunc(func(ctx context.Context, lc *LowConfidence) ([][]byte, error) {
+		return sp.ExtractSource(ctx, src, frames<<lc.Attempt)
+	})
+}
+
+// ToReview 把低置信度结果交给人工复核（如写入队列或发送通知），不再重新提问
+func ToReview(sink func(ctx context.Context, lc *LowConfidence) error) Escalator {
+	return EscalatorFunc(func(ctx context.Context, lc *LowConfidence) ([][]byte, error) {
+		return nil, sink(ctx, lc)
+	})
+}
+
+// ConfidenceOptions AnalyzeWithConfidence 的选项
+type ConfidenceOptions struct {
+	Threshold   float64      // 置信度低于该值时升级（默认 DefaultConfidenceThreshold）
+	Escalate    []Escalator  // 依次使用的升级策略，第 i 次低置信度使用第 i 个；用尽后返回最后的结果
+	ChatOptions *ChatOptions // 透传的对话参数
+}
+
+// ConfidentResult 带置信度的分析结果
+type ConfidentResult struct {
+	ConfidentAnswer
+	Response    *models.ChatResponse
+	Escalations int  // 实际执行的升级次数
+	Low         bool // 最终回答仍低于阈值
+}
+
+// AnalyzeWithConfidence 要求模型在结构化输出中给出置信度，低于阈值时按 Escalate 依次升级：
+// 用更多帧重新提问，或转交人工复核
+//
+//	res, err := c.AnalyzeWithConfidence(ctx, "车牌号是多少？", frames, &client.ConfidenceOptions{
+//		Escalate: []client.Escalator{
+//			client.MoreFrames(c.StreamProcessor, src, 8),
+//			client.ToReview(func(ctx context.Context, lc *client.LowConfidence) error { return queue.Push(lc) }),
+//		},
+//	})
+func (c *Client) AnalyzeWithConfidence(ctx context.Context, prompt string, frames [][]byte, opts *ConfidenceOptions) (*ConfidentResult, error) {
+	o := ConfidenceOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = DefaultConfidenceThreshold
+	}
+
+	fullPrompt := fmt.Sprintf(confidenceInstruction, prompt)
+	result := &ConfidentResult{}
+	for {
+		var answer ConfidentAnswer
+		resp, err := c.AnalyzeFramesInto(ctx, fullPrompt, frames, &answer, o.ChatOptions)
+		if err != nil {
+			return nil, err
+		}
+		answer.Confidence = min(max(answer.Confidence, 0), 1)
+		result.ConfidentAnswer = answer
+		result.Response = resp
+		result.Low = answer.Confidence < o.Threshold
+		if !result.Low || result.Escalations >= len(o.Escalate) {
+			return result, nil
+		}
+
+		lc := &LowConfidence{
+			Prompt:   prompt,
+			Frames:   frames,
+			Answer:   answer,
+			Response: resp,
+			Attempt:  result.Escalations + 1,
+		}
+		next, err := o.Escalate[result.Escalations].Escalate(ctx, lc)
+		result.Escalations++
+		if err != nil {
+			return result, fmt.Errorf("failed to escalate low-confidence result: %w", err)
+		}
+		if len(next) == 0 {
+			return result, nil
+		}
+		frames = next
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// 其他可选的环境变量
+const (
+	EnvAPIURL = "ZHIPU_API_URL"
+	EnvModel  = "ZHIPU_MODEL"
+	EnvPreset = "ZHIPU_PRESET" // 场景预设名称：edge、batch、accurate
+)
+
+// Config 客户端配置，空字段表示使用默认值
+type Config struct {
+	APIKey string `json:"api_key"`
+	APIURL string `json:"api_url,omitempty"`
+	Model  string `json:"model,omitempty"`
+	Preset string `json:"preset,omitempty"` // 场景预设名称（见 ParsePreset）
+}
+
+// ConfigProvider 提供客户端配置（环境变量、配置文件、密钥管理服务等）
+type ConfigProvider interface {
+	Load(ctx context.Context) (Config, error)
+}
+
+// ConfigProviderFunc 将函数适配为 ConfigProvider，可用于接入密钥管理服务
+type ConfigProviderFunc func(ctx context.Context) (Config, error)
+
+// Load 实现 ConfigProvider
+func (f ConfigProviderFunc) Load(ctx context.Context) (Config, error) {
+	return f(ctx)
+}
+
+// StaticConfig 返回固定配置
+func StaticConfig(cfg Config) ConfigProvider {
+	return ConfigProviderFunc(func(context.Context) (Config, error) {
+		return cfg, nil
+	})
+}
+
+// EnvConfig 从环境变量 ZHIPU_API_KEY、ZHIPU_API_URL、ZHIPU_MODEL、ZHIPU_PRESET 读取配置
+func EnvConfig() ConfigProvider {
+	return ConfigProviderFunc(func(context.Context) (Config, error) {
+		return Config{
+			APIKey: os.Getenv(EnvAPIKey),
+			APIURL: os.Getenv(EnvAPIURL),
+			Model:  os.Getenv(EnvModel),
+			Preset: os.Getenv(EnvPreset),
+		}, nil
+	})
+}
+
+// FileConfig 从文件读取配置：.json 文件按 Config 的 JSON 字段解析，
+// 其他文件按 .env 格式解析（不会修改进程环境变量）；文件不存在时返回空配置
+func FileConfig(path string) ConfigProvider {
+	return ConfigProviderFunc(func(context.Context) (Config, error) {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return Config{}, nil
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		var cfg Config
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return Config{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+			}
+			return cfg, nil
+		}
+
+		env, err := godotenv.UnmarshalBytes(data)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		return Config{APIKey: env[EnvAPIKey], APIURL: env[EnvAPIURL], Model: env[EnvModel], Preset: env[EnvPreset]}, nil
+	})
+}
+
+// ChainConfig 按顺序合并多个配置来源，每个字段取第一个非空值
+func ChainConfig(providers ...ConfigProvider) ConfigProvider {
+	return ConfigProviderFunc(func(ctx context.Context) (Config, error) {
+		var merged Config
+		for _, p := range providers {
+			cfg, err := p.Load(ctx)
+			if err != nil {
+				return Config{}, err
+			}
+			if merged.APIKey == "" {
+				merged.APIKey = cfg.APIKey
+			}
+			if merged.APIURL == "" {
+				merged.APIURL = cfg.APIURL
+			}
+			if merged.Model == "" {
+				merged.Model = cfg.Model
+			}
+			if merged.Preset == "" {
+				merged.Preset = cfg.Preset
+			}
+		}
+		return merged, nil
+	})
+}
+
+// NewClientWithConfig 使用配置来源创建客户端，未配置 API Key 时返回 errdefs.ErrAuth
+func NewClientWithConfig(ctx context.Context, provider ConfigProvider) (*Client, error) {
+	cfg, err := provider.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("%w: API key is not configured (set %s)", errdefs.ErrAuth, EnvAPIKey)
+	}
+
+	c := NewClient(cfg.APIKey)
+	if cfg.Preset != "" {
+		p, err := ParsePreset(cfg.Preset)
+		if err != nil {
+			return nil, err
+		}
+		c.WithPreset(p)
+	}
+	if cfg.APIURL != "" {
+		c.APIURL = cfg.APIURL
+	}
+	if cfg.Model != "" {
+		c.Model = cfg.Model
+	}
+	return c, nil
+}
+
+// LoadDotEnv 将 .env 文件加载到进程环境变量（不覆盖已有变量），默认读取当前目录的 .env；
+// 不存在的文件会被忽略。库不会自动调用它，应用可在 main 中按需调用
+func LoadDotEnv(paths ...string) error {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+	for _, p := range paths {
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			continue
+		}
+		if err := godotenv.Load(p); err != nil {
+			return fmt.Errorf("failed to load %s: %w", p, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// DeadlineBudget 调用方的 context 带截止时间时，Analyze 按阶段（探测与抽帧、上传与推理）分配剩余时间：
+// 根据近期实际耗时估算每个阶段所需时间，时间不足时减少帧数（实时流缩短抓取窗口），
+// 仍不足时提前返回 *DeadlineError，而不是在 ffmpeg 或 API 调用中途超时、一无所获
+type DeadlineBudget struct {
+	Reserve   time.Duration // 预留给调用方的余量（默认 500ms）
+	MinFrames int           // 帧数下限，低于该值时放弃（默认 1）
+	Disabled  bool          // 关闭预算，行为与不带截止时间时相同
+}
+
+// DeadlineError 剩余时间不足以完成某个阶段时提前返回的错误，errors.Is(err, context.DeadlineExceeded) 成立
+type DeadlineError struct {
+	Stage     string        // 放弃的阶段："extract" 或 "upload"
+	Needed    time.Duration // 该阶段（以最少帧数计）的预计耗时
+	Remaining time.Duration // 放弃时距截止时间的剩余时间
+	Frames    [][]byte      // 已抽取的帧（在抽帧阶段放弃时为空），可用于稍后重试上传
+}
+
+// Error 实现 error
+func (e *DeadlineError) Error() string {
+	return fmt.Sprintf("not enough time before deadline for %s: need ~%v, have %v",
+		e.Stage, e.Needed.Round(time.Millisecond), e.Remaining.Round(time.Millisecond))
+}
+
+// Unwrap 使 errors.Is(err, context.DeadlineExceeded) 成立
+func (e *DeadlineError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// pace 记录各阶段的近期耗时（指数滑动平均），用于估算截止时间前能处理的帧数
+type pace struct {
+	mu              sync.Mutex
+	probe           time.Duration // 每个视频源的固定开销（打开、探测）
+	extractPerFrame time.Duration
+	apiBase         time.Duration // 与帧数无关的请求耗时（推理、生成）
+	apiPerFrame     time.Duration // 每帧增加的上传与推理耗时
+}
+
+// paceAlpha 新观测值的权重
+const paceAlpha = 0.3
+
+// defaults 填充尚无观测值时的保守默认值；调用方持有 p.mu
+func (p *pace) defaults() {
+	if p.probe == 0 {
+		p.probe = 500 * time.Millisecond
+		p.extractPerFrame = 200 * time.Millisecond
+		p.apiBase = 3 * time.Second
+		p.apiPerFrame = 400 * time.Millisecond
+	}
+}
+
+// estimate 返回抽取并分析 n 帧的预计耗时
+func (p *pace) estimate(n int) (extract, api time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.defaults()
+	return p.probe + time.Duration(n)*p.extractPerFrame, p.apiBase + time.Duration(n)*p.apiPerFrame
+}
+
+// fit 返回 remaining 内最多能抽取并分析的帧数（不超过 max）
+func (p *pace) fit(remaining time.Duration, max int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.defaults()
+	left := remaining - p.probe - p.apiBase
+	if left < 0 {
+		return 0
+	}
+	return min(int(left/(p.extractPerFrame+p.apiPerFrame)), max)
+}
+
+// fitUpload 返回 remaining 内最多能上传分析的帧数（不超过 max）
+func (p *pace) fitUpload(remaining time.Duration, max int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.defaults()
+	left := remaining - p.apiBase
+	if left < 0 {
+		return 0
+	}
+	return min(int(left/p.apiPerFrame), max)
+}
+
+// observeExtract 记录一次抽取 n 帧的耗时
+func (p *pace) observeExtract(n int, d time.Duration) {
+	if n <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.defaults()
+	perFrame := max(d-p.probe, 0) / time.Duration(n)
+	p.extractPerFrame = ewma(p.extractPerFrame, perFrame)
+}
+
+// observeAPI 记录一次携带 n 帧的请求耗时；基础耗时与每帧耗时按当前比例分摊
+func (p *pace) observeAPI(n int, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.defaults()
+	expected := p.apiBase + time.Duration(n)*p.apiPerFrame
+	scale := float64(d) / float64(expected)
+	p.apiBase = ewma(p.apiBase, time.Duration(float64(p.apiBase)*scale))
+	p.apiPerFrame = ewma(p.apiPerFrame, time.Duration(float64(p.apiPerFrame)*scale))
+}
+
+func ewma(old, sample time.Duration) time.Duration {
+	return time.Duration((1-paceAlpha)*float64(old) + paceAlpha*float64(sample))
+}
+
+// sharedPace 供未经 NewClient 创建的客户端使用
+var sharedPace pace
+
+// stats 返回客户端的耗时统计（复制的客户端共享同一份统计）
+func (c *Client) stats() *pace {
+	if c.pace != nil {
+		return c.pace
+	}
+	return &sharedPace
+}
+
+// budget 返回生效的预算配置；ctx 没有截止时间或预算被关闭时返回 false
+func (c *Client) budget(ctx context.Context) (DeadlineBudget, time.Time, bool) {
+	b := DeadlineBudget{}
+	if c.Budget != nil {
+		b = *c.Budget
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok || b.Disabled {
+		return b, deadline, false
+	}
+	if b.Reserve <= 0 {
+		b.Reserve = 500 * time.Millisecond
+	}
+	if b.MinFrames <= 0 {
+		b.MinFrames = 1
+	}
+	return b, deadline.Add(-b.Reserve), true
+}
+
+// extractWithin 在截止时间预算内从视频源抽帧：按预计耗时减少帧数，并为抽帧设置
+// 不侵占上传阶段的截止时间；没有截止时间时直接抽取 maxFrames 帧
+func (c *Client) extractWithin(ctx context.Context, src processor.Source, maxFrames int) ([][]byte, time.Duration, error) {
+	b, deadline, ok := c.budget(ctx)
+	start := time.Now()
+	if !ok {
+		frames, err := c.StreamProcessor.ExtractSource(ctx, src, maxFrames)
+		return frames, time.Since(start), err
+	}
+
+	remaining := time.Until(deadline)
+	n := c.stats().fit(remaining, maxFrames)
+	if n < b.MinFrames {
+		extract, api := c.stats().estimate(b.MinFrames)
+		return nil, 0, &DeadlineError{Stage: "extract", Needed: extract + api, Remaining: remaining}
+	}
+	if n < maxFrames {
+		fmt.Printf("距截止时间 %v，帧数由 %d 降为 %d\n", remaining.Round(time.Millisecond), maxFrames, n)
+	}
+
+	_, api := c.stats().estimate(n)
+	extractCtx, cancel := context.WithDeadline(ctx, deadline.Add(-api))
+	defer cancel()
+	frames, err := c.StreamProcessor.ExtractSource(extractCtx, src, n)
+	elapsed := time.Since(start)
+	if err != nil {
+		if ctx.Err() == nil && extractCtx.Err() != nil {
+			// 抽帧超出了分配的时间，但调用方的截止时间尚未到达；保留超时前已解码的帧
+			de := &DeadlineError{Stage: "extract", Needed: elapsed, Remaining: time.Until(deadline)}
+			if pe, ok := errdefs.AsPartial(err); ok {
+				de.Frames = pe.Frames
+				return nil, elapsed, &errdefs.PartialError{Stage: errdefs.StageExtract, Err: de, Frames: pe.Frames}
+			}
+			return nil, elapsed, de
+		}
+		return nil, elapsed, err
+	}
+	c.stats().observeExtract(len(frames), elapsed)
+	return frames, elapsed, nil
+}
+
+// fitUpload 在截止时间前剩余的时间内均匀保留能上传分析的帧；一帧都来不及时返回 *DeadlineError
+func (c *Client) fitUpload(ctx context.Context, frames [][]byte) ([][]byte, error) {
+	b, deadline, ok := c.budget(ctx)
+	if !ok {
+		return frames, nil
+	}
+	remaining := time.Until(deadline)
+	n := c.stats().fitUpload(remaining, len(frames))
+	if n < min(b.MinFrames, len(frames)) {
+		_, api := c.stats().estimate(b.MinFrames)
+		return nil, &DeadlineError{Stage: "upload", Needed: api, Remaining: remaining, Frames: frames}
+	}
+	if n < len(frames) {
+		fmt.Printf("距截止时间 %v，上传帧数由 %d 降为 %d\n", remaining.Round(time.Millisecond), len(frames), n)
+		frames = pickFrames(frames, n)
+	}
+	return frames, nil
+}
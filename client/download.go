@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// URLDownloadOptions 远程视频下载与分析选项
+type URLDownloadOptions struct {
+	MaxBytes     int64        // 最大下载字节数（默认 500MB）
+	AllowedTypes []string     // 允许的 Content-Type 前缀（默认 video/ 与 application/octet-stream）
+	MaxRetries   int          // 断点续传的最大重试次数（默认 3）
+	MaxFrames    int          // 采样帧数（默认 8）
+	ChatOptions  *ChatOptions // 透传的对话参数
+}
+
+// AnalyzeVideoURLDownload 下载远程视频（支持 Range 断点续传、大小限制与类型检查）并分析
+func (c *Client) AnalyzeVideoURLDownload(url, prompt string) (*models.ChatResponse, error) {
+	return c.AnalyzeVideoURLDownloadWithContext(context.Background(), url, prompt, nil)
+}
+
+// AnalyzeVideoURLDownloadWithOptions 使用自定义选项下载并分析远程视频
+func (c *Client) AnalyzeVideoURLDownloadWithOptions(url, prompt string, opts *URLDownloadOptions) (*models.ChatResponse, error) {
+	return c.AnalyzeVideoURLDownloadWithContext(context.Background(), url, prompt, opts)
+}
+
+// AnalyzeVideoURLDownloadWithContext 带 context 的远程视频下载与分析，取消或超时会中止下载、抽帧与请求
+func (c *Client) AnalyzeVideoURLDownloadWithContext(ctx context.Context, url, prompt string, opts *URLDownloadOptions) (*models.ChatResponse, error) {
+	// 先确认可以抽帧，避免下载完才失败
+	if err := c.StreamProcessor.RequireTools(); err != nil {
+		return nil, err
+	}
+
+	o := URLDownloadOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = 500 << 20
+	}
+	if o.MaxFrames <= 0 {
+		o.MaxFrames = 8
+	}
+
+	f, err := os.CreateTemp("", "zhipu-video-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	fmt.Printf("正在下载视频: %s\n", url)
+	if err := c.DownloadVideo(ctx, url, path, &o); err != nil {
+		return nil, err
+	}
+
+	return c.analyzeVideoFile(ctx, path, prompt, o.MaxFrames, o.ChatOptions)
+}
+
+// DownloadVideo 将远程视频下载到本地文件
+// 网络中断时，如果服务器支持 Range 请求，会从已下载位置继续；续传时用 If-Range 校验
+// 远程文件未变化，并检查 Content-Range，不一致时从头重新下载
+func (c *Client) DownloadVideo(ctx context.Context, url, path string, opts *URLDownloadOptions) error {
+	o := URLDownloadOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = 500 << 20
+	}
+
+	// 下载可能远超 API 请求超时，使用不带超时的客户端，由 ctx 控制
+	return processor.Download(ctx, url, path, processor.DownloadOptions{
+		MaxBytes:     o.MaxBytes,
+		AllowedTypes: o.AllowedTypes,
+		MaxRetries:   o.MaxRetries,
+		Transport:    c.HTTPClient.Transport,
+	})
+}
+
+// AnalyzeVideoFile 分析本地视频文件（任意 ffmpeg 支持的容器与编码），均匀采样 8 帧
+func (c *Client) AnalyzeVideoFile(path, prompt string) (*models.ChatResponse, error) {
+	return c.AnalyzeVideoFileWithContext(context.Background(), path, prompt, 8, nil)
+}
+
+// AnalyzeVideoFileWithOptions 使用自定义采样帧数与对话参数分析本地视频文件
+func (c *Client) AnalyzeVideoFileWithOptions(path, prompt string, maxFrames int, options *ChatOptions) (*models.ChatResponse, error) {
+	return c.AnalyzeVideoFileWithContext(context.Background(), path, prompt, maxFrames, options)
+}
+
+// AnalyzeVideoFileWithContext 带 context 的本地视频文件分析，取消或超时会中止抽帧与请求
+func (c *Client) AnalyzeVideoFileWithContext(ctx context.Context, path, prompt string, maxFrames int, options *ChatOptions) (*models.ChatResponse, error) {
+	if maxFrames <= 0 {
+		maxFrames = 8
+	}
+	return c.analyzeVideoFile(ctx, path, prompt, maxFrames, options)
+}
+
+// analyzeVideoFile 从本地视频文件中均匀采样帧并分析
+func (c *Client) analyzeVideoFile(ctx context.Context, path, prompt string, maxFrames int, options *ChatOptions) (*models.ChatResponse, error) {
+	if err := c.StreamProcessor.RequireTools(); err != nil {
+		return nil, err
+	}
+
+	duration, err := c.StreamProcessor.ProbeDuration(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video: %w", err)
+	}
+
+	fmt.Println("正在从视频中提取帧...")
+	frames, err := c.StreamProcessor.ExtractVideoSegment(ctx, path, 0, duration, maxFrames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract frames: %w", err)
+	}
+
+	fmt.Println("正在调用 GLM-4.5V API 进行视频分析...")
+	return c.AnalyzeFramesWithContext(ctx, prompt, frames, options)
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/jpeg" // 注册 JPEG 解码器用于读取帧尺寸
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// 未在模型注册表中登记价格时使用的默认价格（元 / 百万 token），以官方定价为准
+const (
+	DefaultInputPricePerMillion  = 2.0
+	DefaultOutputPricePerMillion = 6.0
+)
+
+// DryRunReport 试运行报告：描述将要发送的请求，但不调用 API
+type DryRunReport struct {
+	Model                 string   `json:"model"`
+	Frames                int      `json:"frames"`
+	FrameWidth            int      `json:"frame_width"`
+	FrameHeight           int      `json:"frame_height"`
+	PayloadBytes          int      `json:"payload_bytes"`           // 请求体 JSON 大小
+	EstimatedImageTokens  int      `json:"estimated_image_tokens"`  // 估算的图像 token
+	EstimatedPromptTokens int      `json:"estimated_prompt_tokens"` // 估算的输入 token（含文本）
+	EstimatedCost         float64  `json:"estimated_cost"`          // 估算的输入费用（元）
+	Files                 []string `json:"files,omitempty"`         // 写出的帧文件
+}
+
+// EstimateImageTokens 估算单张图像的 token 数
+// GLM-4V 以 28x28 像素为一个 patch，并将 2x2 patch 合并为一个 token
+func EstimateImageTokens(width, height int) int {
+	tokens := (width / 28) * (height / 28) / 4
+	if tokens < 1 {
+		tokens = 1
+	}
+	return tokens
+}
+
+// EstimateTextTokens 粗略估算文本 token 数（中文约 1 字 1 token，英文约 4 字符 1 token）
+func EstimateTextTokens(text string) int {
+	runes := utf8.RuneCountInString(text)
+	if runes == len(text) {
+		return (len(text) + 3) / 4
+	}
+	return runes
+}
+
+// DryRun 生成试运行报告：估算帧数、请求大小、token 与费用，不调用 API
+// 如果 dir 不为空，会将帧写入该目录供人工检查
+func (c *Client) DryRun(prompt string, frames [][]byte, options *ChatOptions, dir string) (*DryRunReport, error) {
+	req := c.buildChatRequest(prompt, frames, options)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	report := &DryRunReport{
+		Model:        c.Model,
+		Frames:       len(frames),
+		PayloadBytes: len(body),
+	}
+
+	for i, frame := range frames {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(frame))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+		if i == 0 {
+			report.FrameWidth, report.FrameHeight = cfg.Width, cfg.Height
+		}
+		report.EstimatedImageTokens += EstimateImageTokens(cfg.Width, cfg.Height)
+	}
+	report.EstimatedPromptTokens = report.EstimatedImageTokens + EstimateTextTokens(prompt)
+	price := DefaultInputPricePerMillion
+	if info, ok := models.LookupModel(c.Model); ok {
+		price = info.InputPrice
+	}
+	report.EstimatedCost = float64(report.EstimatedPromptTokens) / 1e6 * price
+
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create dry-run dir: %w", err)
+		}
+		for i, frame := range frames {
+			path := filepath.Join(dir, fmt.Sprintf("frame_%04d.jpg", i))
+			if err := os.WriteFile(path, frame, 0644); err != nil {
+				return nil, fmt.Errorf("failed to write frame %d: %w", i, err)
+			}
+			report.Files = append(report.Files, path)
+		}
+	}
+
+	return report, nil
+}
+
+// DryRunH264Stream 对 H.264 流执行抽帧与预处理，并返回试运行报告
+func (c *Client) DryRunH264Stream(h264Data []byte, prompt string, dir string) (*DryRunReport, error) {
+	return c.DryRunH264StreamWithContext(context.Background(), h264Data, prompt, dir)
+}
+
+// DryRunH264StreamWithContext 同 DryRunH264Stream，ctx 取消时终止抽帧
+func (c *Client) DryRunH264StreamWithContext(ctx context.Context, h264Data []byte, prompt string, dir string) (*DryRunReport, error) {
+	frames, err := c.extractH264Frames(ctx, h264Data)
+	if err != nil {
+		return nil, err
+	}
+	return c.DryRun(prompt, frames, nil, dir)
+}
+
+// dryRunResponse 在 DryRunMode 下代替真实响应，使上层流程可以继续执行
+func (c *Client) dryRunResponse(prompt string, frames [][]byte, options *ChatOptions) (*models.ChatResponse, error) {
+	report, err := c.DryRun(prompt, frames, options, c.DryRunDir)
+	if err != nil {
+		return nil, err
+	}
+	if c.OnDryRun != nil {
+		c.OnDryRun(report)
+	} else {
+		fmt.Printf("[dry-run] 帧数: %d, 请求大小: %d 字节, 估算输入 token: %d, 估算费用: ¥%.6f\n",
+			report.Frames, report.PayloadBytes, report.EstimatedPromptTokens, report.EstimatedCost)
+	}
+
+	summary, _ := json.Marshal(report)
+	resp := &models.ChatResponse{
+		ID:      "dry-run",
+		Created: time.Now().Unix(),
+		Model:   c.Model,
+	}
+	resp.Choices = []models.Choice{{
+		Message: models.ResponseMessage{
+			Role:    models.RoleAssistant,
+			Content: string(summary),
+		},
+		FinishReason: "dry_run",
+	}}
+	resp.Usage.PromptTokens = report.EstimatedPromptTokens
+	resp.Usage.TotalTokens = report.EstimatedPromptTokens
+	return resp, nil
+}
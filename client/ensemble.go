@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// DefaultEnsembleModels 集成分析默认使用的模型
+var DefaultEnsembleModels = []string{DefaultModel, "glm-4v-plus-0111"}
+
+const reconcileInstruction = `以下是 %d 个视觉模型针对同一组视频画面与同一问题给出的回答。
+问题：%s
+
+%s
+请对比这些回答：一致的内容直接合并；存在分歧时不要臆断哪一个正确，而是逐条列出分歧。只返回 JSON：
+{"answer": "合并后的回答（分歧处注明不确定）", "agree": true, "disagreements": ["分歧描述"]}`
+
+// EnsembleOptions 集成分析选项
+type EnsembleOptions struct {
+	Models      []string     // 参与的模型（默认 DefaultEnsembleModels）
+	Reconcile   bool         // 是否再调用一次模型合并各回答并标记分歧
+	Judge       string       // 执行合并的模型（默认客户端模型）
+	ChatOptions *ChatOptions // 透传给各模型的对话参数
+}
+
+// EnsembleAnswer 单个模型的回答
+type EnsembleAnswer struct {
+	Model    string               `json:"model"`
+	Response *models.ChatResponse `json:"response,omitempty"`
+	Error    string               `json:"error,omitempty"`
+}
+
+// EnsembleResult 集成分析结果
+type EnsembleResult struct {
+	Answers []EnsembleAnswer `json:"answers"`
+
+	// 以下字段仅在 Reconcile 时填写；只有一个模型成功时 Answer 即为该模型的回答
+	Answer         string               `json:"answer,omitempty"`
+	Agree          bool                 `json:"agree"`
+	Disagreements  []string             `json:"disagreements,omitempty"`
+	Reconciliation *models.ChatResponse `json:"reconciliation,omitempty"`
+}
+
+// Ensemble 将同一组帧并发发送给多个模型（如 glm-4.5v 与 glm-4v-plus），返回全部回答；
+// Reconcile 为 true 时再用一次文本请求合并回答并标记分歧，适用于高风险的检测任务
+// 只要有一个模型成功即返回结果，全部失败时返回错误
+func (c *Client) Ensemble(ctx context.Context, prompt string, frames [][]byte, opts *EnsembleOptions) (*EnsembleResult, error) {
+	o := EnsembleOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.Models) == 0 {
+		o.Models = DefaultEnsembleModels
+	}
+
+	result := &EnsembleResult{Answers: make([]EnsembleAnswer, len(o.Models))}
+	errs := make([]error, len(o.Models))
+	var wg sync.WaitGroup
+	for i, model := range o.Models {
+		wg.Add(1)
+		go func(i int, model string) {
+			defer wg.Done()
+			resp, err := c.analyzeFramesModel(ctx, model, prompt, frames, o.ChatOptions, 0)
+			result.Answers[i] = EnsembleAnswer{Model: model, Response: resp}
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", model, err)
+				result.Answers[i].Error = err.Error()
+			}
+		}(i, model)
+	}
+	wg.Wait()
+
+	var ok []EnsembleAnswer
+	for _, a := range result.Answers {
+		if a.Error == "" {
+			ok = append(ok, a)
+		}
+	}
+	if len(ok) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	if !o.Reconcile {
+		return result, nil
+	}
+	if len(ok) == 1 {
+		result.Answer = ok[0].Response.Text()
+		result.Agree = true
+		return result, nil
+	}
+
+	if err := c.reconcile(ctx, prompt, ok, o.Judge, result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// reconcile 用文本请求合并多个回答，结果写入 result
+func (c *Client) reconcile(ctx context.Context, prompt string, answers []EnsembleAnswer, judge string, result *EnsembleResult) error {
+	var b strings.Builder
+	for i, a := range answers {
+		fmt.Fprintf(&b, "回答 %d（%s）：\n%s\n\n", i+1, a.Model, strings.TrimSpace(a.Response.Text()))
+	}
+	fullPrompt := fmt.Sprintf(reconcileInstruction, len(answers), prompt, b.String())
+
+	req := c.buildChatRequest(fullPrompt, nil, &ChatOptions{ResponseFormat: models.JSONObjectFormat()})
+	if judge != "" {
+		req.Model = judge
+	}
+	resp, err := c.execute(ctx, req, fullPrompt, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile answers: %w", err)
+	}
+	result.Reconciliation = resp
+
+	var merged struct {
+		Answer        string   `json:"answer"`
+		Agree         bool     `json:"agree"`
+		Disagreements []string `json:"disagreements"`
+	}
+	if err := DecodeJSON(resp.Text(), &merged); err != nil {
+		return err
+	}
+	result.Answer = merged.Answer
+	result.Agree = merged.Agree && len(merged.Disagreements) == 0
+	result.Disagreements = merged.Disagreements
+	return nil
+}
@@ -0,0 +1,361 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+	"github.com/t8y2/zhipu-video-sdk/promptctx"
+)
+
+const defaultReducePrompt = "以下是视频各片段按时间顺序的分析结果。请将它们合并为一份完整、连贯的结果，去除重复内容，保留关键事件及其时间点。\n\n{results}"
+
+// MapReduceAnalyzer 通用的"分段分析再合并"编排：按 MapPrompt 逐段抽帧分析，
+// 再按 ReducePrompt 合并各段结果（结果过多时逐级合并）。片段切分、并发、排序与
+// 上下文传递由 SDK 处理。片段并发上传，结果始终按时间顺序重组；单个片段遇到限流或
+// 服务端错误时只重试该片段，不影响其他片段。
+//
+// 提示词模板中的占位符：
+//   - MapPrompt：{start}、{end}（片段起止时间 HH:MM:SS）、{index}（从 1 开始）、{total}、
+//     {previous}（上一片段的结果，仅 PassContext 时有值）
+//   - ReducePrompt：{results}（带时间戳的片段结果），未包含时追加在提示词末尾
+type MapReduceAnalyzer struct {
+	Client         *Client
+	MapPrompt      string        // 片段提示词模板（必填）
+	ReducePrompt   string        // 合并提示词模板（默认合并为连贯的结果）
+	ChunkDuration  time.Duration // 片段时长（默认 60 秒）
+	MaxChunks      int           // 片段数上限（默认 20），视频较长时自动加大片段时长
+	FramesPerChunk int           // 每个片段采样帧数（默认 8）
+	Parallelism    int           // 同时分析的片段数（默认 4）
+	PassContext    bool          // 将上一片段的结果传入下一片段的 {previous}，此时片段串行分析
+	FanIn          int           // 每次合并的结果数（默认 10）
+	Retries        int           // 单个片段遇到临时错误时的重试次数（默认 2，负数不重试），已抽取的帧在重试间复用
+	ChatOptions    *ChatOptions  // 透传的对话参数
+
+	// OnChunk 片段完成时按时间顺序回调（可选）：后面的片段先完成时会等到之前的片段都完成
+	// 后再回调，回调不会并发执行。Resume 时此前已完成的片段不再回调
+	OnChunk func(ChunkResult)
+}
+
+// ChunkResult 单个片段的分析结果
+type ChunkResult struct {
+	Index  int           `json:"index"`
+	Start  time.Duration `json:"start"`
+	End    time.Duration `json:"end"`
+	Frames int           `json:"frames"`
+	Text   string        `json:"text"`
+	Tokens int           `json:"tokens"`
+	Done   bool          `json:"done"`            // 已完成分析（包括被预过滤跳过的片段）
+	Error  string        `json:"error,omitempty"` // 分析失败的原因
+}
+
+// MapReduceResult 分段分析与合并的结果
+type MapReduceResult struct {
+	Source      string        `json:"source,omitempty"`
+	Duration    time.Duration `json:"duration"`
+	Chunks      []ChunkResult `json:"chunks"`
+	Result      string        `json:"result"`
+	TotalTokens int           `json:"total_tokens"`
+}
+
+// NewMapReduceAnalyzer 创建使用默认参数的分段分析器
+func NewMapReduceAnalyzer(c *Client, mapPrompt, reducePrompt string) *MapReduceAnalyzer {
+	return &MapReduceAnalyzer{Client: c, MapPrompt: mapPrompt, ReducePrompt: reducePrompt}
+}
+
+// Analyze 分段分析本地视频文件并合并结果
+func (m *MapReduceAnalyzer) Analyze(ctx context.Context, videoPath string) (*MapReduceResult, error) {
+	return m.AnalyzeSource(ctx, processor.FileSource(videoPath))
+}
+
+// AnalyzeSource 分段分析录制好的视频源（文件、内存数据、URL 等；不支持实时流与裸 H.264）。
+// 部分片段分析失败或合并失败时返回 *errdefs.PartialError，其 Partial 为已完成片段的
+// *MapReduceResult，可传给 Resume 只重试未完成的部分
+func (m *MapReduceAnalyzer) AnalyzeSource(ctx context.Context, src processor.Source) (*MapReduceResult, error) {
+	return m.run(ctx, src, nil)
+}
+
+// Resume 继续一次失败的分段分析：只重新分析 partial 中未完成的片段，再合并全部结果。
+// src 须与首次分析时相同
+func (m *MapReduceAnalyzer) Resume(ctx context.Context, src processor.Source, partial *MapReduceResult) (*MapReduceResult, error) {
+	if partial == nil {
+		return nil, fmt.Errorf("partial result is required")
+	}
+	return m.run(ctx, src, partial)
+}
+
+// run 打开视频源，分析 result 中未完成的片段并合并；result 为 nil 时按视频时长重新切分
+func (m *MapReduceAnalyzer) run(ctx context.Context, src processor.Source, result *MapReduceResult) (*MapReduceResult, error) {
+	if m.MapPrompt == "" {
+		return nil, fmt.Errorf("map prompt is required")
+	}
+	sp := m.Client.StreamProcessor
+	if err := sp.RequireTools(); err != nil {
+		return nil, err
+	}
+
+	s, err := src.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	if s.Live || s.Format == processor.FormatH264 {
+		return nil, fmt.Errorf("map-reduce analysis needs a recorded container, got %s", s.Name)
+	}
+
+	if result == nil {
+		duration, err := sp.ProbeDuration(ctx, s.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to probe video duration: %w", err)
+		}
+		result = &MapReduceResult{Source: s.Name, Duration: duration, Chunks: m.plan(duration)}
+	}
+
+	err = m.mapChunks(ctx, s.Path, result)
+	result.TotalTokens = 0
+	for _, ch := range result.Chunks {
+		result.TotalTokens += ch.Tokens
+	}
+	if err != nil {
+		return nil, &errdefs.PartialError{Stage: errdefs.StageMap, Err: err, Partial: result}
+	}
+
+	text, tokens, err := m.reduce(ctx, result.Chunks)
+	if err != nil {
+		// 片段结果都已就绪，Resume 时只需重新合并
+		return nil, &errdefs.PartialError{Stage: errdefs.StageReduce, Err: err, Partial: result}
+	}
+	result.Result = text
+	result.TotalTokens += tokens
+	return result, nil
+}
+
+// plan 按时长切分片段，片段数超过 MaxChunks 时加大片段时长
+func (m *MapReduceAnalyzer) plan(duration time.Duration) []ChunkResult {
+	size := m.ChunkDuration
+	if size <= 0 {
+		size = 60 * time.Second
+	}
+	maxChunks := m.MaxChunks
+	if maxChunks <= 0 {
+		maxChunks = 20
+	}
+	if n := (duration + size - 1) / size; int(n) > maxChunks {
+		size = (duration + time.Duration(maxChunks) - 1) / time.Duration(maxChunks)
+	}
+
+	var chunks []ChunkResult
+	for start := time.Duration(0); start < duration; start += size {
+		end := min(start+size, duration)
+		chunks = append(chunks, ChunkResult{Index: len(chunks), Start: start, End: end})
+	}
+	return chunks
+}
+
+// mapChunks 分析未完成的片段，结果按片段顺序写回 result.Chunks；任一片段失败即取消其余片段
+func (m *MapReduceAnalyzer) mapChunks(ctx context.Context, path string, result *MapReduceResult) error {
+	frames := m.FramesPerChunk
+	if frames <= 0 {
+		frames = 8
+	}
+	parallel := m.Parallelism
+	if parallel <= 0 {
+		parallel = 4
+	}
+	if m.PassContext {
+		parallel = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks := result.Chunks
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		sem      = make(chan struct{}, parallel)
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	done := m.emitter(chunks)
+
+	for i := range chunks {
+		if chunks[i].Done {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		previous := ""
+		if m.PassContext && i > 0 {
+			// 串行执行，上一片段已经完成
+			previous = chunks[i-1].Text
+		}
+
+		wg.Add(1)
+		go func(ch *ChunkResult, previous string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ch.Error = ""
+			data, err := m.Client.StreamProcessor.ExtractVideoSegment(ctx, path, ch.Start, ch.End-ch.Start, frames)
+			if err != nil {
+				ch.Error = err.Error()
+				fail(fmt.Errorf("failed to extract chunk at %s: %w", formatTimestamp(ch.Start), err))
+				return
+			}
+			prompt := strings.NewReplacer(
+				"{start}", formatTimestamp(ch.Start),
+				"{end}", formatTimestamp(ch.End),
+				"{index}", strconv.Itoa(ch.Index+1),
+				"{total}", strconv.Itoa(len(chunks)),
+				"{previous}", previous,
+			).Replace(m.MapPrompt)
+
+			ch.Frames = len(data)
+			resp, err := m.analyzeChunk(ctx, ch, prompt, data)
+			if errors.Is(err, errdefs.ErrSkipped) {
+				ch.Done = true
+				done(ch.Index)
+				return // 预过滤认为片段没有值得分析的内容，合并时跳过
+			}
+			if err != nil {
+				ch.Error = err.Error()
+				fail(fmt.Errorf("failed to analyze chunk at %s: %w", formatTimestamp(ch.Start), err))
+				return
+			}
+			ch.Text = strings.TrimSpace(resp.Text())
+			ch.Tokens = resp.Usage.TotalTokens
+			ch.Done = true
+			done(ch.Index)
+		}(&chunks[i], previous)
+
+		if m.PassContext {
+			wg.Wait()
+		}
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// analyzeChunk 分析单个片段；限流与服务端错误按 Retries 重试，重试只发生在该片段内
+func (m *MapReduceAnalyzer) analyzeChunk(ctx context.Context, ch *ChunkResult, prompt string, frames [][]byte) (*models.ChatResponse, error) {
+	retries := m.Retries
+	if retries == 0 {
+		retries = 2
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := m.Client.analyzeFrames(ctx, prompt, frames, m.ChatOptions, 0)
+		if err == nil || attempt >= retries || !errdefs.IsTemporary(err) {
+			return resp, err
+		}
+
+		wait := retryDelay(err, attempt+1)
+		fmt.Printf("片段 %s 分析失败，%v 后重试（第 %d 次）: %v\n", formatTimestamp(ch.Start), wait, attempt+1, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// emitter 返回片段 i 完成时调用的函数：按片段顺序回调 OnChunk 已连续完成的片段
+func (m *MapReduceAnalyzer) emitter(chunks []ChunkResult) func(i int) {
+	if m.OnChunk == nil {
+		return func(int) {}
+	}
+	// 完成状态单独记录，避免读取仍在分析中的片段；开始前已完成的片段（Resume）不再回调
+	finished := make([]bool, len(chunks))
+	seen := make([]bool, len(chunks))
+	for i := range chunks {
+		finished[i], seen[i] = chunks[i].Done, chunks[i].Done
+	}
+	var (
+		mu   sync.Mutex
+		next int
+	)
+	return func(i int) {
+		mu.Lock()
+		defer mu.Unlock()
+		finished[i] = true
+		for ; next < len(chunks) && finished[next]; next++ {
+			if !seen[next] {
+				m.OnChunk(chunks[next])
+			}
+		}
+	}
+}
+
+// reduce 逐级合并片段结果，每次最多合并 FanIn 条
+func (m *MapReduceAnalyzer) reduce(ctx context.Context, chunks []ChunkResult) (string, int, error) {
+	fanIn := m.FanIn
+	if fanIn <= 1 {
+		fanIn = 10
+	}
+	template := m.ReducePrompt
+	if template == "" {
+		template = defaultReducePrompt
+	}
+	if !strings.Contains(template, "{results}") {
+		template += "\n\n{results}"
+	}
+
+	var level []timedText
+	for _, ch := range chunks {
+		if ch.Text != "" {
+			level = append(level, timedText{ch.Start.Seconds(), ch.End.Seconds(), ch.Text})
+		}
+	}
+	if len(level) == 0 {
+		return "", 0, nil
+	}
+
+	tokens := 0
+	for len(level) > 1 {
+		var next []timedText
+		for i := 0; i < len(level); i += fanIn {
+			group := level[i:min(i+fanIn, len(level))]
+			if len(group) == 1 {
+				next = append(next, group[0])
+				continue
+			}
+
+			var b strings.Builder
+			for _, e := range group {
+				fmt.Fprintf(&b, "[%s - %s] %s\n", formatSeconds(e.start), formatSeconds(e.end), e.text)
+			}
+			prompt := strings.ReplaceAll(template, "{results}", b.String())
+			// 合并的是已注入背景信息的分段结果，不再重复注入
+			resp, err := m.Client.analyzeFrames(promptctx.Without(ctx), prompt, nil, m.ChatOptions, 0)
+			if err != nil {
+				return "", tokens, fmt.Errorf("failed to reduce results: %w", err)
+			}
+			tokens += resp.Usage.TotalTokens
+			next = append(next, timedText{group[0].start, group[len(group)-1].end, strings.TrimSpace(resp.Text())})
+		}
+		level = next
+	}
+	return level[0].text, tokens, nil
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Preset 面向典型场景的端到端配置：抽帧帧率、分辨率与 JPEG 质量，采样策略与帧数，
+// 请求超时与重试，以及默认对话参数。一次调用即可让各层的默认值保持一致：
+//
+//	c := client.NewClient("").WithPreset(client.EdgeLowLatency)
+//
+// 应用预设后仍可单独修改任意字段；零值字段保持客户端原有设置
+type Preset struct {
+	Name string
+
+	// 抽帧
+	FPS           int               // 抽帧帧率
+	Width, Height int               // 目标分辨率（须为 28 的倍数）
+	Quality       int               // JPEG 质量
+	Sampler       processor.Sampler // 采样策略
+	Frames        int               // Analyze 默认采样帧数
+
+	// 请求
+	Timeout time.Duration // 单次 HTTP 请求超时
+	Retries int           // 限流与服务端错误的重试次数
+
+	// 默认对话参数
+	Detail      models.Detail
+	Temperature *float64
+	MaxTokens   *int
+}
+
+var (
+	// EdgeLowLatency 边缘设备上的实时告警：少量低分辨率帧、低细节、短回答，不重试，
+	// 单次调用通常在数秒内完成
+	EdgeLowLatency = Preset{
+		Name:        "edge",
+		FPS:         1,
+		Width:       672,
+		Height:      672,
+		Quality:     75,
+		Sampler:     processor.Uniform(),
+		Frames:      4,
+		Timeout:     15 * time.Second,
+		Retries:     0,
+		Detail:      models.DetailLow,
+		Temperature: ptr(0.1),
+		MaxTokens:   ptr(256),
+	}
+
+	// BatchArchive 离线批量处理录像：按场景切换采样，限流时耐心重试，吞吐优先于延迟
+	BatchArchive = Preset{
+		Name:      "batch",
+		FPS:       1,
+		Width:     1120,
+		Height:    1120,
+		Quality:   85,
+		Sampler:   processor.SceneChange(0.3),
+		Frames:    16,
+		Timeout:   3 * time.Minute,
+		Retries:   5,
+		Detail:    models.DetailHigh,
+		MaxTokens: ptr(2048),
+	}
+
+	// HighAccuracy 取证与高风险判断：更多、更清晰的帧，高细节，确定性输出
+	HighAccuracy = Preset{
+		Name:        "accurate",
+		FPS:         2,
+		Width:       1120,
+		Height:      1120,
+		Quality:     95,
+		Sampler:     processor.TopNSharpest(),
+		Frames:      24,
+		Timeout:     2 * time.Minute,
+		Retries:     3,
+		Detail:      models.DetailHigh,
+		Temperature: ptr(0.0),
+	}
+)
+
+// presets 可按名称查找的预设
+var presets = map[string]Preset{
+	EdgeLowLatency.Name: EdgeLowLatency,
+	BatchArchive.Name:   BatchArchive,
+	HighAccuracy.Name:   HighAccuracy,
+}
+
+// ParsePreset 按名称（edge、batch、accurate，不区分大小写）查找预设
+func ParsePreset(name string) (Preset, error) {
+	if p, ok := presets[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return p, nil
+	}
+	names := make([]string, 0, len(presets))
+	for n := range presets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return Preset{}, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// WithPreset 将预设应用到客户端及其流处理器
+func (c *Client) WithPreset(p Preset) *Client {
+	sp := c.StreamProcessor
+	if sp == nil {
+		sp = processor.NewStreamProcessor()
+		c.StreamProcessor = sp
+	}
+	if p.FPS > 0 {
+		sp.WithFPS(p.FPS)
+	}
+	if p.Width > 0 && p.Height > 0 {
+		sp.WithResolution(p.Width, p.Height)
+	}
+	if p.Quality > 0 {
+		sp.WithQuality(p.Quality)
+	}
+	if p.Sampler != nil {
+		sp.WithSampler(p.Sampler)
+	}
+
+	if p.Frames > 0 {
+		c.DefaultFrames = p.Frames
+	}
+	if p.Timeout > 0 {
+		// 复制后再修改，HTTPClient 可能与其他客户端共享
+		hc := &http.Client{}
+		if c.HTTPClient != nil {
+			*hc = *c.HTTPClient
+		}
+		hc.Timeout = p.Timeout
+		c.HTTPClient = hc
+	}
+	c.Retries = p.Retries
+
+	opts := &ChatOptions{}
+	if c.Options != nil {
+		*opts = *c.Options
+	}
+	if p.Detail != "" {
+		opts.Detail = p.Detail
+	}
+	if p.Temperature != nil {
+		opts.Temperature = p.Temperature
+	}
+	if p.MaxTokens != nil {
+		opts.MaxTokens = p.MaxTokens
+	}
+	c.Options = opts
+	return c
+}
+
+// defaultFrames 返回 Analyze 的默认采样帧数
+func (c *Client) defaultFrames() int {
+	if c.DefaultFrames > 0 {
+		return c.DefaultFrames
+	}
+	return 8
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// AnalyzeH264StreamResult 分析 H.264 视频流，并返回包含抽帧信息与耗时的完整结果
+func (c *Client) AnalyzeH264StreamResult(ctx context.Context, h264Data []byte, prompt string, options *ChatOptions) (*models.AnalysisResult, error) {
+	if err := c.StreamProcessor.RequireTools(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	// 时间戳按各帧在解码序列中的位置计算，与采样策略实际选中的帧一致
+	frames, timestamps, err := c.StreamProcessor.ExtractH264Timed(ctx, h264Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process H.264 stream: %w", err)
+	}
+
+	result := &models.AnalysisResult{
+		Source:            "h264",
+		ExtractionLatency: time.Since(start),
+		FrameTimestamps:   timestamps,
+		Timings:           &models.Timings{},
+	}
+	result.Timings.Extraction = result.ExtractionLatency
+
+	fmt.Println("正在调用 GLM-4.5V API 进行视频分析...")
+	if err := c.analyzeInto(ctx, result, prompt, frames, options); err != nil {
+		return nil, err
+	}
+	result.TotalLatency = time.Since(start)
+	return result, nil
+}
+
+// AnalyzeVideoFileResult 分析本地视频文件，并返回包含抽帧信息与耗时的完整结果
+func (c *Client) AnalyzeVideoFileResult(ctx context.Context, path, prompt string, maxFrames int, options *ChatOptions) (*models.AnalysisResult, error) {
+	if err := c.StreamProcessor.RequireTools(); err != nil {
+		return nil, err
+	}
+
+	if maxFrames <= 0 {
+		maxFrames = 8
+	}
+
+	start := time.Now()
+	duration, err := c.StreamProcessor.ProbeDuration(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video: %w", err)
+	}
+	probe := time.Since(start)
+
+	fmt.Println("正在从视频中提取帧...")
+	// 按真实时间戳抽帧，可变帧率与断流的视频时间标注仍然准确
+	frames, timestamps, timing, err := c.StreamProcessor.ExtractTimed(ctx, path, duration, maxFrames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract frames: %w", err)
+	}
+
+	result := &models.AnalysisResult{
+		Source:            path,
+		ExtractionLatency: time.Since(start),
+		FrameTimestamps:   timestamps,
+		Timings:           &models.Timings{Probe: probe},
+	}
+	result.Timings.Extraction = result.ExtractionLatency - probe
+	if timing != nil {
+		result.Gaps = timing.Gaps
+		result.VariableRate = timing.Variable
+		if len(timing.Gaps) > 0 {
+			fmt.Printf("视频中有 %d 段无画面的间隔，已跳过\n", len(timing.Gaps))
+		}
+	}
+
+	fmt.Println("正在调用 GLM-4.5V API 进行视频分析...")
+	if err := c.analyzeInto(ctx, result, prompt, frames, options); err != nil {
+		return nil, err
+	}
+	result.TotalLatency = time.Since(start)
+	return result, nil
+}
+
+// analyzeInto 发送帧并把响应、请求大小与请求耗时写入 result
+func (c *Client) analyzeInto(ctx context.Context, result *models.AnalysisResult, prompt string, frames [][]byte, options *ChatOptions) error {
+	result.Frames = len(frames)
+	result.Labels = models.LabelsFrom(ctx).Clone()
+	for _, frame := range frames {
+		result.FrameBytes += len(frame)
+	}
+
+	prompt, err := c.injectContext(ctx, prompt)
+	if err != nil {
+		return err
+	}
+	stage := time.Now()
+	req := c.buildChatRequest(prompt, frames, options)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	result.PayloadBytes = len(body)
+	result.Timings.Encode += time.Since(stage)
+	result.Timings.Total = result.ExtractionLatency + result.Timings.Encode
+
+	start := time.Now()
+	var resp *models.ChatResponse
+	if c.DryRunMode {
+		resp, err = c.dryRunResponse(prompt, frames, options)
+	} else {
+		resp, err = c.execute(ctx, req, prompt, frames, result.Timings)
+	}
+	result.RequestLatency = time.Since(start)
+	if err != nil {
+		return err
+	}
+
+	resp.Estimate = EstimateUsage(prompt, frames)
+	result.Response = resp
+	return nil
+}
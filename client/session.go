@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/memory"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/promptctx"
+)
+
+const sessionSummaryInstruction = `以下是一段关于视频的多轮对话。请将其中的关键信息（已确认的事实、用户关注的问题及结论）合并进已有摘要，输出新的摘要，不超过 300 字，只输出摘要。
+
+已有摘要：
+%s
+
+对话：
+%s`
+
+// ChatSession 针对同一组帧的多轮对话：帧随每次请求的第一条用户消息发送，问题与回答作为对话历史复用。
+// 配置 Memory 后每轮对话都会持久化，进程重启后用相同的 Key 创建会话即可接着对话；
+// 超过 MaxTurns 的早期轮次会合并进滚动摘要，避免上下文无限增长。
+type ChatSession struct {
+	Client      *Client
+	Key         string        // 会话标识，也是 Memory 中的键
+	Memory      memory.Memory // 可选的持久化存储，nil 时历史只保存在内存中
+	MaxTurns    int           // 保留原文的最近轮数（默认 10），更早的轮次合并进摘要
+	ChatOptions *ChatOptions  // 透传的对话参数
+
+	mu      sync.Mutex
+	frames  [][]byte
+	conv    *memory.Conversation
+	encoded *models.EncodingCache // 每轮都会重发同一组帧，编码只做一次
+}
+
+// NewChatSession 创建多轮对话会话；mem 中已有 key 的历史时从中恢复。
+// 帧不会写入 Memory（体积过大），恢复会话时需重新传入同一组帧
+func (c *Client) NewChatSession(ctx context.Context, key string, frames [][]byte, mem memory.Memory) (*ChatSession, error) {
+	s := &ChatSession{Client: c, Key: key, Memory: mem, frames: frames, conv: &memory.Conversation{Key: key}}
+	if mem == nil {
+		return s, nil
+	}
+	conv, err := mem.Load(ctx, key)
+	switch {
+	case errors.Is(err, memory.ErrNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("failed to load session %s: %w", key, err)
+	default:
+		s.conv = conv
+	}
+	return s, nil
+}
+
+// Ask 提问并返回回答，问答随后加入对话历史
+func (s *ChatSession) Ask(ctx context.Context, question string) (*models.ChatResponse, error) {
+	return s.AskStream(ctx, question, nil)
+}
+
+// AskStream 同 Ask，onChunk 不为 nil 时以流式方式输出回答。
+// 回答成功但保存历史失败时，同时返回响应与错误
+func (s *ChatSession) AskStream(ctx context.Context, question string, onChunk func(*models.ChatCompletionChunk) error) (*models.ChatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := models.UserMessage(models.Text(question))
+	req := s.request(msg)
+	var (
+		resp *models.ChatResponse
+		err  error
+	)
+	if onChunk != nil {
+		resp, err = s.Client.ChatStream(ctx, req, onChunk)
+	} else {
+		req.Stream = false
+		resp, err = s.Client.Chat(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.conv.Messages = append(s.conv.Messages, msg, models.AssistantMessage(resp.Text()))
+	s.compact(ctx)
+	s.conv.Updated = time.Now()
+	if s.Memory != nil {
+		if err := s.Memory.Save(ctx, s.conv); err != nil {
+			return resp, fmt.Errorf("failed to save session %s: %w", s.Key, err)
+		}
+	}
+	return resp, nil
+}
+
+// request 构造本轮请求：摘要（如有）+ 对话历史 + 新问题，帧附在第一条用户消息后
+func (s *ChatSession) request(msg models.Message) *models.ChatRequest {
+	var messages []models.Message
+	if s.conv.Summary != "" {
+		messages = append(messages, models.SystemMessage("此前对话的摘要："+s.conv.Summary))
+	}
+	messages = append(messages, s.conv.Messages...)
+	messages = append(messages, msg)
+
+	if len(s.frames) > 0 {
+		for i, m := range messages {
+			if m.Role == models.RoleUser {
+				withFrames := models.Message{Role: m.Role, Content: append([]models.Content(nil), m.Content...)}
+				messages[i] = withFrames.Append(s.frameCache().Frames(s.frames)...)
+				break
+			}
+		}
+	}
+
+	req := s.Client.buildChatRequest("", nil, s.ChatOptions)
+	req.Messages = messages
+	return req
+}
+
+// frameCache 返回帧编码缓存：优先使用客户端的 FrameCache，否则使用会话自己的缓存
+func (s *ChatSession) frameCache() *models.EncodingCache {
+	if s.Client.FrameCache != nil {
+		return s.Client.FrameCache
+	}
+	if s.encoded == nil {
+		s.encoded = models.NewEncodingCache(len(s.frames))
+	}
+	return s.encoded
+}
+
+// compact 将超出 MaxTurns 的早期轮次合并进滚动摘要；摘要失败时保留完整历史，下一轮重试
+func (s *ChatSession) compact(ctx context.Context) {
+	maxTurns := s.MaxTurns
+	if maxTurns <= 0 {
+		maxTurns = 10
+	}
+	excess := len(s.conv.Messages) - maxTurns*2
+	if excess <= 0 {
+		return
+	}
+
+	var b strings.Builder
+	for _, m := range s.conv.Messages[:excess] {
+		role := "用户"
+		if m.Role == models.RoleAssistant {
+			role = "助手"
+		}
+		for _, content := range m.Content {
+			if content.Type == models.ContentTypeText {
+				fmt.Fprintf(&b, "%s：%s\n", role, content.Text)
+			}
+		}
+	}
+	summary := s.conv.Summary
+	if summary == "" {
+		summary = "（无）"
+	}
+	resp, err := s.Client.analyzeFrames(promptctx.Without(ctx), fmt.Sprintf(sessionSummaryInstruction, summary, b.String()), nil, nil, 0)
+	if err != nil {
+		return
+	}
+	s.conv.Summary = strings.TrimSpace(resp.Text())
+	s.conv.Messages = append([]models.Message(nil), s.conv.Messages[excess:]...)
+}
+
+// History 返回保留原文的对话历史（不含帧）
+func (s *ChatSession) History() []models.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Message(nil), s.conv.Messages...)
+}
+
+// Summary 返回早期轮次的滚动摘要
+func (s *ChatSession) Summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conv.Summary
+}
+
+// Reset 清空对话历史与摘要，并从 Memory 中删除
+func (s *ChatSession) Reset(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conv = &memory.Conversation{Key: s.Key}
+	if s.Memory != nil {
+		if err := s.Memory.Delete(ctx, s.Key); err != nil {
+			return fmt.Errorf("failed to reset session %s: %w", s.Key, err)
+		}
+	}
+	return nil
+}
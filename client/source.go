@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// AnalyzeOptions 统一分析入口 Analyze 的选项
+type AnalyzeOptions struct {
+	MaxFrames   int          // 采样帧数（默认 Client.DefaultFrames，未设置时为 8）
+	ChatOptions *ChatOptions // 透传的对话参数
+
+	// AudioFallback 视频轨无法解码但有音轨时，转写语音并仅根据语音回答，
+	// 响应的 Fallback 字段标明结果未参考画面（可选，未设置时直接返回错误）
+	AudioFallback Transcriber
+}
+
+// Analyze 统一的视频分析入口：从任意 processor.Source（本地文件、内存数据、
+// io.Reader、HTTP URL、RTSP 等实时流、原始 H.264）抽帧并分析
+// 通过 processor.Labeled 附加的标签会写入 context，并随响应、用量与审计记录输出；
+// ctx 带截止时间时按 Client.Budget 在抽帧与上传之间分配时间，必要时减少帧数或提前返回 *DeadlineError。
+// 抽帧之后的阶段失败（或抽帧中途失败）时返回 *errdefs.PartialError，其中带有已抽取的帧
+//
+//	c.Analyze(ctx, processor.FileSource("clip.mp4"), prompt, nil)
+//	c.Analyze(ctx, processor.RTSPSource("rtsp://camera/stream", 10*time.Second), prompt, nil)
+func (c *Client) Analyze(ctx context.Context, src processor.Source, prompt string, opts *AnalyzeOptions) (*models.ChatResponse, error) {
+	o := AnalyzeOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxFrames <= 0 {
+		o.MaxFrames = c.defaultFrames()
+	}
+
+	ctx = models.WithLabels(ctx, processor.SourceLabels(src))
+	fmt.Println("正在从视频源中提取帧...")
+	frames, extract, err := c.extractWithin(ctx, src, o.MaxFrames)
+	if err != nil {
+		err = fmt.Errorf("failed to extract frames: %w", err)
+		if o.AudioFallback != nil && videoUndecodable(err) {
+			return c.analyzeAudio(ctx, src, prompt, o.AudioFallback, o.ChatOptions, err)
+		}
+		return nil, err
+	}
+	all := frames
+	if frames, err = c.fitUpload(ctx, frames); err != nil {
+		return nil, &errdefs.PartialError{Stage: errdefs.StageUpload, Err: err, Frames: all}
+	}
+
+	fmt.Println("正在调用 GLM-4.5V API 进行视频分析...")
+	resp, err := c.analyzeFrames(ctx, prompt, frames, o.ChatOptions, extract)
+	if err != nil && !errors.Is(err, errdefs.ErrSkipped) {
+		// 帧已抽取成功，调用方可用 Frames 只重试 API 调用
+		return nil, &errdefs.PartialError{Stage: errdefs.StageAnalyze, Err: err, Frames: frames}
+	}
+	return resp, err
+}
+
+// Snapshot 从视频源截取一帧标准化的 JPEG（at 为偏移，或 processor.Latest 表示最新画面），
+// prompt 不为空时立即分析该帧；适用于缩略图、监控面板与"7 号摄像头现在看到了什么"之类的查询
+func (c *Client) Snapshot(ctx context.Context, src processor.Source, at time.Duration, prompt string, options *ChatOptions) ([]byte, *models.ChatResponse, error) {
+	ctx = models.WithLabels(ctx, processor.SourceLabels(src))
+	start := time.Now()
+	frame, err := c.StreamProcessor.Snapshot(ctx, src, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	if prompt == "" {
+		return frame, nil, nil
+	}
+
+	resp, err := c.analyzeFrames(ctx, prompt, [][]byte{frame}, options, time.Since(start))
+	if err != nil {
+		return frame, nil, err
+	}
+	return frame, resp, nil
+}
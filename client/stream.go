@@ -0,0 +1,164 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// ChatStream 以流式（SSE）方式发送对话请求，每收到一个增量块调用一次 onChunk，
+// 结束后返回聚合的完整响应；onChunk 返回错误时中止流
+func (c *Client) ChatStream(ctx context.Context, req *models.ChatRequest, onChunk func(*models.ChatCompletionChunk) error) (*models.ChatResponse, error) {
+	return c.Chat(withChunkHandler(ctx, onChunk), req)
+}
+
+// AnalyzeFramesStream 同 AnalyzeFramesWithContext，回答以流式（SSE）方式输出：
+// 每收到一个增量块调用一次 onChunk，结束后返回聚合的完整响应（用量取自最后一块）。
+// onChunk 返回错误时中止流；开始输出后失败不再自动重试
+func (c *Client) AnalyzeFramesStream(ctx context.Context, prompt string, frames [][]byte, options *ChatOptions, onChunk func(*models.ChatCompletionChunk) error) (*models.ChatResponse, error) {
+	return c.analyzeFrames(withChunkHandler(ctx, onChunk), prompt, frames, options, 0)
+}
+
+// AnalyzeVideoStream 同 Analyze，从视频源抽帧后以流式方式输出回答，适合边生成边展示的场景
+//
+//	c.AnalyzeVideoStream(ctx, processor.FileSource("clip.mp4"), "描述视频内容", nil, func(chunk *models.ChatCompletionChunk) error {
+//	    fmt.Print(chunk.Text())
+//	    return nil
+//	})
+func (c *Client) AnalyzeVideoStream(ctx context.Context, src processor.Source, prompt string, opts *AnalyzeOptions, onChunk func(*models.ChatCompletionChunk) error) (*models.ChatResponse, error) {
+	return c.Analyze(withChunkHandler(ctx, onChunk), src, prompt, opts)
+}
+
+// StreamEvents 在后台执行流式调用，把增量块依次写入返回的通道，最后写入带完整响应的
+// Done 事件（失败时为带 Err 的事件）后关闭通道。提前停止读取时须取消 ctx
+//
+//	events := client.StreamEvents(ctx, func(ctx context.Context, onChunk func(*models.ChatCompletionChunk) error) (*models.ChatResponse, error) {
+//	    return c.AnalyzeFramesStream(ctx, prompt, frames, nil, onChunk)
+//	})
+//	for ev := range events { ... }
+func StreamEvents(ctx context.Context, run func(ctx context.Context, onChunk func(*models.ChatCompletionChunk) error) (*models.ChatResponse, error)) <-chan models.StreamEvent {
+	ch := make(chan models.StreamEvent, 16)
+	go func() {
+		defer close(ch)
+		resp, err := run(ctx, func(chunk *models.ChatCompletionChunk) error {
+			select {
+			case ch <- models.StreamEvent{Chunk: chunk}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		last := models.StreamEvent{Done: true, Response: resp}
+		if err != nil {
+			last = models.StreamEvent{Err: err}
+		}
+		select {
+		case ch <- last:
+		case <-ctx.Done():
+		}
+	}()
+	return ch
+}
+
+type chunkHandlerKey struct{}
+
+// chunkHandler 接收流式响应的增量块
+type chunkHandler func(*models.ChatCompletionChunk) error
+
+// withChunkHandler 使 ctx 内发出的分析请求以流式方式发送，增量块交给 onChunk（可以为 nil）
+func withChunkHandler(ctx context.Context, onChunk func(*models.ChatCompletionChunk) error) context.Context {
+	return context.WithValue(ctx, chunkHandlerKey{}, chunkHandler(onChunk))
+}
+
+// chunkHandlerFrom 返回 ctx 中的增量块回调，ok 表示请求应以流式方式发送
+func chunkHandlerFrom(ctx context.Context) (onChunk chunkHandler, ok bool) {
+	onChunk, ok = ctx.Value(chunkHandlerKey{}).(chunkHandler)
+	return onChunk, ok
+}
+
+// sendChatStream 发送流式请求并逐块解析 SSE 数据，将各阶段耗时写入 t：
+// Server 为收到响应头之前的时间，Download 为读取整个事件流的时间
+func (c *Client) sendChatStream(ctx context.Context, req *models.ChatRequest, t *models.Timings, onChunk func(*models.ChatCompletionChunk) error) (*models.ChatResponse, int, error) {
+	stage := time.Now()
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	t.Encode += time.Since(stage)
+
+	apiKey, err := c.ResolveAPIKey(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.APIURL, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+
+	// 流式响应可能持续较久，不受 HTTPClient.Timeout 限制，由 ctx 控制取消
+	httpClient := *c.HTTPClient
+	httpClient.Timeout = 0
+	stage = time.Now()
+	resp, err := httpClient.Do(httpReq)
+	t.Server += time.Since(stage)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	stage = time.Now()
+	defer func() { t.Download += time.Since(stage) }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		apiErr := errdefs.NewAPIError(resp.StatusCode, resp.Header, body)
+		if errors.Is(apiErr, errdefs.ErrAuth) {
+			c.invalidateAPIKey()
+		}
+		return nil, resp.StatusCode, apiErr
+	}
+
+	var acc models.StreamAccumulator
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == models.StreamDone {
+			return acc.Response(), resp.StatusCode, nil
+		}
+
+		var chunk models.ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return acc.Response(), resp.StatusCode, fmt.Errorf("%w: invalid stream chunk: %v", errdefs.ErrStreamCorrupt, err)
+		}
+		acc.Add(&chunk)
+		if onChunk != nil {
+			if err := onChunk(&chunk); err != nil {
+				return acc.Response(), resp.StatusCode, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return acc.Response(), resp.StatusCode, fmt.Errorf("failed to read stream: %w", err)
+	}
+	// 部分实现不发送 [DONE]，连接关闭即视为结束
+	return acc.Response(), resp.StatusCode, nil
+}
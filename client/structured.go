@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+const structuredInstruction = `%s
+
+只返回符合以下 JSON Schema 的 JSON，不要输出其他内容：
+%s`
+
+// AnalyzeFramesInto 结构化输出模式：根据 out 的 Go 类型生成 JSON Schema，
+// 要求模型按该结构返回，并将结果解析到 out（必须是指针）
+//
+//	var r struct {
+//		People int    `json:"people" description:"画面中的人数"`
+//		Scene  string `json:"scene" enum:"indoor,outdoor"`
+//	}
+//	resp, err := c.AnalyzeFramesInto(ctx, "描述画面", frames, &r, nil)
+func (c *Client) AnalyzeFramesInto(ctx context.Context, prompt string, frames [][]byte, out interface{}, options *ChatOptions) (*models.ChatResponse, error) {
+	if v := reflect.ValueOf(out); v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, fmt.Errorf("out must be a non-nil pointer")
+	}
+
+	format, err := models.JSONSchemaFormat(reflect.TypeOf(out).Elem().Name(), out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive schema: %w", err)
+	}
+	if format.JSONSchema.Name == "" {
+		format.JSONSchema.Name = "result"
+	}
+
+	opts := ChatOptions{}
+	if options != nil {
+		opts = *options
+	}
+	opts.Stream = false
+	opts.ResponseFormat = format
+
+	prompt, err = c.injectContext(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	// 同时在提示词中给出 Schema，兼容仅支持 json_object 的模型
+	fullPrompt := fmt.Sprintf(structuredInstruction, prompt, format.JSONSchema.Schema)
+	req := c.buildChatRequest(fullPrompt, frames, &opts)
+
+	resp, err := c.execute(ctx, req, fullPrompt, frames, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Estimate = EstimateUsage(fullPrompt, frames)
+
+	if err := DecodeJSON(resp.Text(), out); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// DecodeJSON 从模型回答中提取 JSON（忽略 Markdown 代码块与前后说明文字）并解析到 out
+func DecodeJSON(content string, out interface{}) error {
+	start := strings.IndexAny(content, "{[")
+	end := strings.LastIndexAny(content, "}]")
+	if start == -1 || end <= start {
+		return fmt.Errorf("no JSON found in response: %q", content)
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), out); err != nil {
+		return fmt.Errorf("failed to decode structured output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// SummarizeOptions 长视频分层摘要选项
+type SummarizeOptions struct {
+	SegmentDuration    time.Duration // 每个片段时长（默认 60 秒）
+	FramesPerSegment   int           // 每个片段采样帧数（默认 8）
+	SegmentsPerChapter int           // 每章包含的片段数，同时也是逐级归并的分组大小（默认 5）
+	SegmentPrompt      string        // 片段分析提示词（可选）
+	ChapterPrompt      string        // 章节/全片归并提示词（可选）
+	Language           string        // 输出语言提示，如 "中文"、"English"（默认中文）
+	ChatOptions        *ChatOptions  // 透传的对话参数
+}
+
+const (
+	defaultSegmentPrompt = "这是视频 %s 至 %s 片段中按时间顺序采样的帧。请用%s简要概括该片段中发生的主要事件。"
+	defaultChapterPrompt = "以下是视频中若干连续片段的摘要（带时间戳）。请用%s将它们合并为一段连贯的摘要，保留关键事件及其时间点。\n\n%s"
+)
+
+// Summarize 对长视频进行分层摘要：
+// 1. 将视频切分为固定时长的片段，逐段抽帧分析；
+// 2. 将相邻片段摘要归并为章节摘要；
+// 3. 递归归并章节摘要，直至得到全片摘要。
+func (c *Client) Summarize(videoPath string, opts *SummarizeOptions) (*models.Summary, error) {
+	return c.SummarizeWithContext(context.Background(), videoPath, opts)
+}
+
+// SummarizeWithContext 支持 context 的分层摘要
+func (c *Client) SummarizeWithContext(ctx context.Context, videoPath string, opts *SummarizeOptions) (*models.Summary, error) {
+	if err := c.StreamProcessor.RequireTools(); err != nil {
+		return nil, err
+	}
+
+	o := SummarizeOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.SegmentDuration <= 0 {
+		o.SegmentDuration = 60 * time.Second
+	}
+	if o.FramesPerSegment <= 0 {
+		o.FramesPerSegment = 8
+	}
+	if o.SegmentsPerChapter <= 1 {
+		o.SegmentsPerChapter = 5
+	}
+	if o.Language == "" {
+		o.Language = "中文"
+	}
+
+	duration, err := c.StreamProcessor.ProbeDuration(ctx, videoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video duration: %w", err)
+	}
+
+	summary := &models.Summary{
+		Source:   videoPath,
+		Duration: duration.Seconds(),
+	}
+
+	// 1. 片段级摘要
+	var segments []models.Segment
+	for start := time.Duration(0); start < duration; start += o.SegmentDuration {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		length := o.SegmentDuration
+		if start+length > duration {
+			length = duration - start
+		}
+
+		fmt.Printf("正在分析片段 %s - %s...\n", formatTimestamp(start), formatTimestamp(start+length))
+		frames, err := c.StreamProcessor.ExtractVideoSegment(ctx, videoPath, start, length, o.FramesPerSegment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract segment at %s: %w", formatTimestamp(start), err)
+		}
+
+		prompt := o.SegmentPrompt
+		if prompt == "" {
+			prompt = fmt.Sprintf(defaultSegmentPrompt, formatTimestamp(start), formatTimestamp(start+length), o.Language)
+		}
+
+		text, tokens, err := c.summarizeCall(ctx, prompt, frames, o.ChatOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize segment at %s: %w", formatTimestamp(start), err)
+		}
+		summary.TotalTokens += tokens
+
+		segments = append(segments, models.Segment{
+			Start:   start.Seconds(),
+			End:     (start + length).Seconds(),
+			Frames:  len(frames),
+			Summary: text,
+		})
+	}
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("video %s has no content to summarize", videoPath)
+	}
+
+	// 2. 章节级摘要
+	fmt.Println("正在生成章节摘要...")
+	for i := 0; i < len(segments); i += o.SegmentsPerChapter {
+		end := i + o.SegmentsPerChapter
+		if end > len(segments) {
+			end = len(segments)
+		}
+		group := segments[i:end]
+
+		chapter := models.Chapter{
+			Start:    group[0].Start,
+			End:      group[len(group)-1].End,
+			Segments: group,
+		}
+
+		if len(group) == 1 {
+			chapter.Summary = group[0].Summary
+		} else {
+			entries := make([]timedText, len(group))
+			for j, s := range group {
+				entries[j] = timedText{s.Start, s.End, s.Summary}
+			}
+			text, tokens, err := c.mergeSummaries(ctx, entries, &o)
+			if err != nil {
+				return nil, fmt.Errorf("failed to summarize chapter at %s: %w", formatSeconds(chapter.Start), err)
+			}
+			summary.TotalTokens += tokens
+			chapter.Summary = text
+		}
+
+		summary.Chapters = append(summary.Chapters, chapter)
+	}
+
+	// 3. 递归归并为全片摘要
+	fmt.Println("正在生成全片摘要...")
+	level := make([]timedText, len(summary.Chapters))
+	for i, ch := range summary.Chapters {
+		level[i] = timedText{ch.Start, ch.End, ch.Summary}
+	}
+
+	for len(level) > 1 {
+		var next []timedText
+		for i := 0; i < len(level); i += o.SegmentsPerChapter {
+			end := i + o.SegmentsPerChapter
+			if end > len(level) {
+				end = len(level)
+			}
+			group := level[i:end]
+			if len(group) == 1 {
+				next = append(next, group[0])
+				continue
+			}
+
+			text, tokens, err := c.mergeSummaries(ctx, group, &o)
+			if err != nil {
+				return nil, fmt.Errorf("failed to merge summaries: %w", err)
+			}
+			summary.TotalTokens += tokens
+			next = append(next, timedText{group[0].start, group[len(group)-1].end, text})
+		}
+		level = next
+	}
+	summary.Overview = level[0].text
+
+	return summary, nil
+}
+
+// timedText 带时间范围的摘要文本
+type timedText struct {
+	start, end float64
+	text       string
+}
+
+// mergeSummaries 将若干带时间戳的摘要归并为一段摘要
+func (c *Client) mergeSummaries(ctx context.Context, entries []timedText, o *SummarizeOptions) (string, int, error) {
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "[%s - %s] %s\n", formatSeconds(e.start), formatSeconds(e.end), e.text)
+	}
+
+	prompt := fmt.Sprintf(defaultChapterPrompt, o.Language, b.String())
+	if o.ChapterPrompt != "" {
+		prompt = o.ChapterPrompt + "\n\n" + b.String()
+	}
+	return c.summarizeCall(ctx, prompt, nil, o.ChatOptions)
+}
+
+// summarizeCall 执行一次分析请求并返回首个回答及消耗的 token
+func (c *Client) summarizeCall(ctx context.Context, prompt string, frames [][]byte, options *ChatOptions) (string, int, error) {
+	resp, err := c.AnalyzeFramesWithContext(ctx, prompt, frames, options)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(resp.Choices) == 0 {
+		return "", resp.Usage.TotalTokens, fmt.Errorf("empty response")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), resp.Usage.TotalTokens, nil
+}
+
+// formatTimestamp 将时长格式化为 HH:MM:SS
+func formatTimestamp(d time.Duration) string {
+	total := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
+
+// formatSeconds 将秒数格式化为 HH:MM:SS
+func formatSeconds(seconds float64) string {
+	return formatTimestamp(time.Duration(seconds * float64(time.Second)))
+}
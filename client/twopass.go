@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+const triageInstruction = `这是一次快速初筛，之后可能会针对这段视频详细回答以下问题：
+%s
+
+请根据这几张缩略图判断：%s
+interesting 为 true 表示值得详细分析，并在 reason 中简要说明原因。`
+
+// DefaultTriageCriterion 默认的初筛标准
+const DefaultTriageCriterion = "画面中是否出现与该问题相关的人员、车辆、物体或异常活动？没有把握时也判断为值得详细分析。"
+
+// TriageAnswer 初筛的结构化回答
+type TriageAnswer struct {
+	Interesting bool   `json:"interesting" description:"是否值得详细分析"`
+	Reason      string `json:"reason,omitempty" description:"判断依据"`
+}
+
+// TwoPass 两阶段分析：先用少量低细节缩略帧和廉价的初筛提示词判断画面是否值得关注，
+// 只有初筛通过时才用完整帧、高细节重新分析。对大部分时间无事发生的画面可节省一个数量级的费用。
+// TwoPass 实现了 scheduler.Analyzer，可直接替换调度器中的客户端：
+//
+//	tp := client.NewTwoPass(c)
+//	s := scheduler.NewScheduler(tp, recorder) // 初筛未通过的窗口记录为 skipped
+type TwoPass struct {
+	Client    *Client
+	Criterion string // 初筛标准（默认 DefaultTriageCriterion）
+
+	TriageFrames int // 初筛使用的帧数，从完整帧中均匀选取（默认 4）
+	TriageWidth  int // 初筛缩略图尺寸（默认 448x448，约为 1120x1120 帧的 1/6 token）
+	TriageHeight int
+	Frames       int // Analyze 从视频源抽取的完整帧数（默认 16）
+
+	// OnTriage 每次初筛后回调（可选），用于统计初筛通过率与费用
+	OnTriage func(answer TriageAnswer, resp *models.ChatResponse)
+}
+
+// TwoPassResult 两阶段分析的结果
+type TwoPassResult struct {
+	Triage         TriageAnswer
+	TriageResponse *models.ChatResponse
+	Escalated      bool                 // 初筛通过并执行了详细分析
+	Response       *models.ChatResponse // 详细分析的响应，未升级时为 nil
+}
+
+// NewTwoPass 使用默认参数创建两阶段分析器
+func NewTwoPass(c *Client) *TwoPass {
+	return &TwoPass{
+		Client:       c,
+		Criterion:    DefaultTriageCriterion,
+		TriageFrames: 4,
+		TriageWidth:  448,
+		TriageHeight: 448,
+		Frames:       16,
+	}
+}
+
+// AnalyzeFramesWithOptions 实现 scheduler.Analyzer；初筛未通过时返回 errdefs.ErrSkipped
+func (t *TwoPass) AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *ChatOptions) (*models.ChatResponse, error) {
+	res, err := t.AnalyzeFrames(context.Background(), prompt, frames, options)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Escalated {
+		return nil, errdefs.ErrSkipped
+	}
+	return res.Response, nil
+}
+
+// AnalyzeFrames 用 frames 的缩略子集初筛，通过后用全部 frames 详细分析
+func (t *TwoPass) AnalyzeFrames(ctx context.Context, prompt string, frames [][]byte, options *ChatOptions) (*TwoPassResult, error) {
+	res, err := t.triage(ctx, prompt, frames)
+	if err != nil || !res.Triage.Interesting {
+		return res, err
+	}
+	if res.Response, err = t.Client.sendFrames(ctx, "", prompt, frames, options, 0); err != nil {
+		return nil, err
+	}
+	res.Escalated = true
+	return res, nil
+}
+
+// Analyze 从视频源抽取 TriageFrames 帧初筛，通过后重新抽取 Frames 帧详细分析
+func (t *TwoPass) Analyze(ctx context.Context, src processor.Source, prompt string, options *ChatOptions) (*TwoPassResult, error) {
+	ctx = models.WithLabels(ctx, processor.SourceLabels(src))
+	sp := t.Client.StreamProcessor
+	frames, err := sp.ExtractSource(ctx, src, t.triageFrames())
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract triage frames: %w", err)
+	}
+	res, err := t.triage(ctx, prompt, frames)
+	if err != nil || !res.Triage.Interesting {
+		return res, err
+	}
+
+	n := t.Frames
+	if n <= 0 {
+		n = 16
+	}
+	start := time.Now()
+	if frames, err = sp.ExtractSource(ctx, src, n); err != nil {
+		return nil, fmt.Errorf("failed to extract frames: %w", err)
+	}
+	if res.Response, err = t.Client.sendFrames(ctx, "", prompt, frames, options, time.Since(start)); err != nil {
+		return nil, err
+	}
+	res.Escalated = true
+	return res, nil
+}
+
+// triage 运行第一阶段：预过滤、选帧、缩小并以低细节发送初筛提示词
+func (t *TwoPass) triage(ctx context.Context, prompt string, frames [][]byte) (*TwoPassResult, error) {
+	c := t.Client
+	if c.PreFilter != nil && len(frames) > 0 {
+		present, err := c.PreFilter.Present(ctx, frames)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run pre-filter: %w", err)
+		}
+		if !present {
+			return nil, errdefs.ErrSkipped
+		}
+	}
+
+	thumbs := pickFrames(frames, t.triageFrames())
+	width, height := t.TriageWidth, t.TriageHeight
+	if width <= 0 || height <= 0 {
+		width, height = 448, 448
+	}
+	thumbs, err := c.StreamProcessor.FilterFrames(thumbs, processor.Resize(width, height))
+	if err != nil {
+		return nil, fmt.Errorf("failed to downscale triage frames: %w", err)
+	}
+
+	criterion := t.Criterion
+	if criterion == "" {
+		criterion = DefaultTriageCriterion
+	}
+	res := &TwoPassResult{}
+	res.TriageResponse, err = c.AnalyzeFramesInto(ctx, fmt.Sprintf(triageInstruction, prompt, criterion), thumbs,
+		&res.Triage, &ChatOptions{Detail: models.DetailLow})
+	if err != nil && res.TriageResponse == nil {
+		return nil, fmt.Errorf("failed to triage frames: %w", err)
+	}
+	if err != nil {
+		// 无法解析初筛结果时按通过处理，宁可多花一次调用也不漏掉事件
+		res.Triage = TriageAnswer{Interesting: true, Reason: "无法解析初筛结果"}
+	}
+	if t.OnTriage != nil {
+		t.OnTriage(res.Triage, res.TriageResponse)
+	}
+	return res, nil
+}
+
+// triageFrames 返回初筛帧数
+func (t *TwoPass) triageFrames() int {
+	if t.TriageFrames <= 0 {
+		return 4
+	}
+	return t.TriageFrames
+}
+
+// pickFrames 从 frames 中均匀选取最多 n 帧
+func pickFrames(frames [][]byte, n int) [][]byte {
+	if len(frames) <= n {
+		return frames
+	}
+	out := make([][]byte, n)
+	for i := range out {
+		out[i] = frames[i*(len(frames)-1)/max(n-1, 1)]
+	}
+	return out
+}
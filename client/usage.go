@@ -0,0 +1,27 @@
+package client
+
+import (
+	"bytes"
+	"image"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// EstimateUsage 在客户端估算提示词与每帧图像的 token 数
+// 无法解析尺寸的帧按 0x0 处理（计为 1 个 token）
+func EstimateUsage(prompt string, frames [][]byte) *models.UsageEstimate {
+	est := &models.UsageEstimate{
+		TextTokens: EstimateTextTokens(prompt),
+		Images:     make([]models.ImageTokenEstimate, len(frames)),
+	}
+	for i, frame := range frames {
+		img := models.ImageTokenEstimate{Index: i}
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(frame)); err == nil {
+			img.Width, img.Height = cfg.Width, cfg.Height
+		}
+		img.Tokens = EstimateImageTokens(img.Width, img.Height)
+		est.ImageTokens += img.Tokens
+		est.Images[i] = img
+	}
+	return est
+}
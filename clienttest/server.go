@@ -0,0 +1,296 @@
+// Package clienttest provides an in-process mock of the Zhipu chat
+// completions API for offline integration testing.
+package clienttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// ChatPath is the path served by the mock server
+const ChatPath = "/api/paas/v4/chat/completions"
+
+// FaultKind is a type of injected failure
+type FaultKind int
+
+const (
+	FaultRateLimit     FaultKind = iota // 429 with a Zhipu-style error body
+	FaultServerError                    // 500 internal error
+	FaultUnauthorized                   // 401 invalid API key
+	FaultMalformedJSON                  // 200 with a truncated JSON body
+	FaultTimeout                        // Sleep for Delay before responding normally
+	FaultContentFilter                  // 400 content moderation error (code 1301)
+)
+
+// Fault is an injected failure applied to one upcoming request
+type Fault struct {
+	Kind  FaultKind
+	Delay time.Duration // Used by FaultTimeout
+}
+
+// Responder produces the assistant content for a request
+type Responder func(req *models.ChatRequest) string
+
+// Server is a mock chat completions server
+type Server struct {
+	*httptest.Server
+	APIKey string // Expected bearer token (empty accepts any)
+
+	mu        sync.Mutex
+	responder Responder
+	faults    []Fault
+	requests  []models.ChatRequest
+}
+
+// NewServer starts a mock server; the default responder describes the
+// number of images received
+func NewServer() *Server {
+	s := &Server{
+		responder: func(req *models.ChatRequest) string {
+			images := 0
+			for _, m := range req.Messages {
+				for _, c := range m.Content {
+					if c.ImageURL != nil {
+						images++
+					}
+				}
+			}
+			return fmt.Sprintf("mock response: received %d images", images)
+		},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// ChatURL returns the chat completions URL of the mock server
+func (s *Server) ChatURL() string {
+	return s.URL + ChatPath
+}
+
+// Client returns an SDK client pointed at the mock server
+func (s *Server) Client() *client.Client {
+	key := s.APIKey
+	if key == "" {
+		key = "test-key"
+	}
+	c := client.NewClient(key)
+	c.APIURL = s.ChatURL()
+	c.HTTPClient = s.Server.Client()
+	return c
+}
+
+// SetResponder replaces the content generator
+func (s *Server) SetResponder(fn Responder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responder = fn
+}
+
+// Respond makes the server always answer with content
+func (s *Server) Respond(content string) {
+	s.SetResponder(func(*models.ChatRequest) string { return content })
+}
+
+// InjectFault queues faults applied to the next requests, in order
+func (s *Server) InjectFault(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, faults...)
+}
+
+// Requests returns the decoded requests received so far
+func (s *Server) Requests() []models.ChatRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.ChatRequest(nil), s.requests...)
+}
+
+// Reset clears recorded requests and pending faults
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.faults = nil
+}
+
+// handle implements the chat completions endpoint
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != ChatPath {
+		writeError(w, http.StatusNotFound, "1211", "not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "1214", "method not allowed")
+		return
+	}
+
+	if s.APIKey != "" && r.Header.Get("Authorization") != "Bearer "+s.APIKey {
+		writeError(w, http.StatusUnauthorized, "1000", "身份验证失败")
+		return
+	}
+
+	var req models.ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "1214", "invalid request body: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	var fault *Fault
+	if len(s.faults) > 0 {
+		f := s.faults[0]
+		s.faults = s.faults[1:]
+		fault = &f
+	}
+	responder := s.responder
+	s.mu.Unlock()
+
+	if fault != nil {
+		switch fault.Kind {
+		case FaultRateLimit:
+			w.Header().Set("Retry-After", "1")
+			writeError(w, http.StatusTooManyRequests, "1302", "您当前使用该API的并发数过高，请降低并发")
+			return
+		case FaultServerError:
+			writeError(w, http.StatusInternalServerError, "500", "internal server error")
+			return
+		case FaultUnauthorized:
+			writeError(w, http.StatusUnauthorized, "1000", "身份验证失败")
+			return
+		case FaultMalformedJSON:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id":"mock","choices":[{"message":`))
+			return
+		case FaultContentFilter:
+			writeError(w, http.StatusBadRequest, "1301", "系统检测到输入或生成内容可能包含不安全或敏感内容")
+			return
+		case FaultTimeout:
+			select {
+			case <-time.After(fault.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+
+	content := responder(&req)
+	if req.Stream {
+		s.stream(w, &req, content)
+		return
+	}
+
+	resp := models.ChatResponse{
+		ID:      fmt.Sprintf("mock-%d", time.Now().UnixNano()),
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+		Choices: []models.Choice{{
+			Message:      models.ResponseMessage{Role: models.RoleAssistant, Content: content},
+			FinishReason: models.FinishReasonStop,
+		}},
+		Usage: usage(&req, content),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// stream writes the content as Server-Sent Events chunks
+func (s *Server) stream(w http.ResponseWriter, req *models.ChatRequest, content string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+
+	id := fmt.Sprintf("mock-%d", time.Now().UnixNano())
+	send := func(chunk models.ChatCompletionChunk) {
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	runes := []rune(content)
+	for i := 0; i < len(runes); i += 8 {
+		end := i + 8
+		if end > len(runes) {
+			end = len(runes)
+		}
+		delta := models.Delta{Content: string(runes[i:end])}
+		if i == 0 {
+			delta.Role = models.RoleAssistant
+		}
+		send(models.ChatCompletionChunk{
+			ID:      id,
+			Created: time.Now().Unix(),
+			Model:   req.Model,
+			Choices: []models.ChunkChoice{{Delta: delta}},
+		})
+	}
+
+	u := usage(req, content)
+	send(models.ChatCompletionChunk{
+		ID:      id,
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+		Choices: []models.ChunkChoice{{FinishReason: models.FinishReasonStop}},
+		Usage:   &u,
+	})
+	fmt.Fprintf(w, "data: %s\n\n", models.StreamDone)
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// usage fabricates plausible token usage
+func usage(req *models.ChatRequest, content string) models.Usage {
+	prompt := 0
+	for _, m := range req.Messages {
+		for _, c := range m.Content {
+			if c.ImageURL != nil {
+				prompt += 1600
+			} else {
+				prompt += len([]rune(c.Text))
+			}
+		}
+	}
+	completion := len([]rune(content))
+	return models.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}
+}
+
+// writeError writes a Zhipu-style error body
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	body, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]string{"code": code, "message": message},
+	})
+	w.Write(body)
+}
+
+// Contains reports whether any received prompt contains substr
+func (s *Server) Contains(substr string) bool {
+	for _, req := range s.Requests() {
+		for _, m := range req.Messages {
+			for _, c := range m.Content {
+				if strings.Contains(c.Text, substr) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/dedup"
+	"github.com/t8y2/zhipu-video-sdk/jobs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// videoExtensions 批量模式识别的视频扩展名
+var videoExtensions = map[string]bool{
+	".mp4": true, ".mov": true, ".mkv": true, ".avi": true, ".flv": true,
+	".ts": true, ".webm": true, ".m4v": true, ".h264": true, ".264": true,
+}
+
+// manifestRow 清单中的一行：一个视频在一个提示词下的分析结果
+type manifestRow struct {
+	File     string  `json:"file"`
+	Prompt   string  `json:"prompt"`
+	Duration float64 `json:"duration"`
+	Frames   int     `json:"frames"`
+	Answer   string  `json:"answer"`
+	Tokens   int     `json:"tokens"`
+	Cost     float64 `json:"cost"`
+	Error    string  `json:"error,omitempty"`
+
+	DuplicateOf string `json:"duplicate_of,omitempty"` // 内容相同、已分析过的视频，结果直接复用
+}
+
+// runBatch 并行分析目录中的所有视频，通过任务记录支持断点续跑，并输出 CSV/JSONL 清单
+func runBatch(args []string) error {
+	fset := flag.NewFlagSet("batch", flag.ExitOnError)
+	promptFile := fset.String("prompt-file", "", "提示词文件（YAML 或 JSON 映射：名称 -> 提示词）")
+	prompt := fset.String("prompt", "", "单个提示词（未指定 -prompt-file 时使用）")
+	out := fset.String("out", "manifest.jsonl", "清单输出路径（.csv 或 .jsonl）")
+	parallel := fset.Int("parallel", 4, "并行任务数")
+	frames := fset.Int("frames", 8, "每个视频采样帧数")
+	samplerName := fset.String("sampler", "uniform", "采样策略：uniform、keyframe、scene、motion、sharpest、random、pts（按时间戳，适合可变帧率）")
+	state := fset.String("state", "", "任务记录文件（默认 <dir>/.zhipu-batch.jobs），用于断点续跑")
+	trackSpec := fset.String("track", "", trackUsage)
+	osdSpec := fset.String("osd", "", osdUsage)
+	noDedup := fset.Bool("no-dedup", false, "不按内容指纹识别重复视频（默认复用重命名或重新上传的视频此前的结果）")
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		return fmt.Errorf("用法: zhipu-video batch [-prompt-file prompts.yaml | -prompt 提示词] [-out manifest.csv] <目录>")
+	}
+	dir := fset.Arg(0)
+	sampler, err := processor.ParseSampler(*samplerName)
+	if err != nil {
+		return err
+	}
+
+	var prompts []namedPrompt
+	switch {
+	case *promptFile != "":
+		var err error
+		if prompts, err = loadPrompts(*promptFile); err != nil {
+			return err
+		}
+	case *prompt != "":
+		prompts = []namedPrompt{{Name: "default", Prompt: *prompt}}
+	default:
+		return fmt.Errorf("请通过 -prompt-file 或 -prompt 指定提示词")
+	}
+
+	var files []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && videoExtensions[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan directory: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("%s 中没有视频文件", dir)
+	}
+
+	if *state == "" {
+		*state = filepath.Join(dir, ".zhipu-batch.jobs")
+	}
+	store, err := jobs.OpenFileStore(*state)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var fingerprints *dedup.VideoIndex
+	if !*noDedup {
+		if fingerprints, err = dedup.OpenVideoIndex(strings.TrimSuffix(*state, ".jobs") + ".fingerprints"); err != nil {
+			return err
+		}
+		defer fingerprints.Close()
+	}
+
+	c := client.NewClient("")
+	if _, err := c.ResolveAPIKey(context.Background()); err != nil {
+		return fmt.Errorf("请设置 ZHIPU_API_KEY 或 ZHIPU_API_KEY_FILE 环境变量")
+	}
+	defer c.CleanupStreamProcessor()
+	c.StreamProcessor.WithSampler(sampler)
+	if err := applyTrack(c.StreamProcessor, *trackSpec); err != nil {
+		return err
+	}
+	if err := applyOSD(c.StreamProcessor, *osdSpec); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	type task struct {
+		id, file string
+		prompt   namedPrompt
+	}
+	var tasks []task
+	for _, f := range files {
+		rel := relSlash(dir, f)
+		for _, p := range prompts {
+			id := rel + "#" + p.Name
+			pending, err := jobs.Pending(store, id)
+			if err != nil {
+				return err
+			}
+			if pending {
+				tasks = append(tasks, task{id: id, file: f, prompt: p})
+			}
+		}
+	}
+	total := len(files) * len(prompts)
+	fmt.Printf("共 %d 个视频、%d 个提示词，待处理 %d / %d 个任务\n", len(files), len(prompts), len(tasks), total)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	sem := make(chan struct{}, max(*parallel, 1))
+	for _, t := range tasks {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(t task) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			rel := relSlash(dir, t.file)
+			row := analyzeBatchFile(ctx, c, fingerprints, t.file, t.prompt, *frames)
+			row.File = rel
+			if ctx.Err() != nil {
+				return // 被中断的任务下次重新执行
+			}
+
+			job := &jobs.Job{ID: t.id, Status: jobs.StatusDone, Attempts: 1}
+			if prev, ok, _ := store.Get(t.id); ok {
+				job.Attempts = prev.Attempts + 1
+			}
+			if row.Error != "" {
+				job.Status = jobs.StatusFailed
+				job.Error = row.Error
+			}
+			job.Result, _ = json.Marshal(row)
+			if err := store.Put(job); err != nil {
+				fmt.Fprintf(os.Stderr, "保存任务 %s 失败: %v\n", t.id, err)
+			}
+
+			mu.Lock()
+			done++
+			status := "完成"
+			switch {
+			case row.Error != "":
+				status = "失败: " + row.Error
+			case row.DuplicateOf != "":
+				status = "与 " + row.DuplicateOf + " 内容相同，复用结果"
+			}
+			fmt.Printf("[%d/%d] %s (%s) %s\n", done, len(tasks), rel, t.prompt.Name, status)
+			mu.Unlock()
+		}(t)
+	}
+	wg.Wait()
+
+	if err := writeManifest(*out, store); err != nil {
+		return err
+	}
+	fmt.Printf("清单已写入 %s\n", *out)
+	if ctx.Err() != nil {
+		return fmt.Errorf("已中断，重新运行相同命令即可继续")
+	}
+	return nil
+}
+
+// relSlash 返回使用 / 分隔的相对路径，使任务 ID 与清单在不同平台间一致
+func relSlash(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		rel = path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// analyzeBatchFile 分析单个视频并生成清单行；fingerprints 不为 nil 时，
+// 内容与已分析视频相同（重命名、重新上传或转码）的视频直接复用其结果
+func analyzeBatchFile(ctx context.Context, c *client.Client, fingerprints *dedup.VideoIndex, path string, p namedPrompt, frames int) manifestRow {
+	row := manifestRow{Prompt: p.Name}
+
+	// 指纹计算失败时照常分析，只是不参与去重
+	var fp processor.Fingerprint
+	if fingerprints != nil {
+		var err error
+		if fp, err = c.StreamProcessor.Fingerprint(ctx, path); err != nil {
+			fingerprints = nil
+		} else if prev, ok := fingerprints.Lookup(p.Prompt, fp); ok && json.Unmarshal(prev.Result, &row) == nil {
+			row.DuplicateOf = prev.Source
+			row.Tokens, row.Cost = 0, 0
+			return row
+		}
+	}
+
+	duration, err := c.StreamProcessor.ProbeDuration(ctx, path)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Duration = duration.Seconds()
+
+	data, err := c.StreamProcessor.ExtractVideoSegment(ctx, path, 0, duration, frames)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Frames = len(data)
+
+	resp, err := c.AnalyzeFramesWithOptions(p.Prompt, data, nil)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Answer = resp.Text()
+	row.Tokens = resp.Usage.TotalTokens
+	row.Cost = models.ModelCost(c.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+	if fingerprints != nil {
+		result, _ := json.Marshal(row)
+		if err := fingerprints.Add(dedup.AnalyzedVideo{Key: p.Prompt, Fingerprint: fp, Source: path, Result: result}); err != nil {
+			fmt.Fprintf(os.Stderr, "保存视频指纹失败: %v\n", err)
+		}
+	}
+	return row
+}
+
+// writeManifest 根据任务记录写出完整清单（包括之前运行完成的任务）
+func writeManifest(path string, store jobs.Store) error {
+	all, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create manifest: %w", err)
+	}
+	defer f.Close()
+
+	var rows []manifestRow
+	for _, job := range all {
+		var row manifestRow
+		if json.Unmarshal(job.Result, &row) == nil {
+			rows = append(rows, row)
+		}
+	}
+
+	if strings.HasSuffix(strings.ToLower(path), ".csv") {
+		w := csv.NewWriter(f)
+		w.Write([]string{"file", "prompt", "duration", "frames", "answer", "tokens", "cost", "error", "duplicate_of"})
+		for _, r := range rows {
+			w.Write([]string{
+				r.File, r.Prompt,
+				strconv.FormatFloat(r.Duration, 'f', 2, 64),
+				strconv.Itoa(r.Frames), r.Answer, strconv.Itoa(r.Tokens),
+				strconv.FormatFloat(r.Cost, 'f', 6, 64), r.Error, r.DuplicateOf,
+			})
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+	return nil
+}
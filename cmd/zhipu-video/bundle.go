@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/t8y2/zhipu-video-sdk/archive"
+	"github.com/t8y2/zhipu-video-sdk/audit"
+)
+
+// errFound 找到目标记录后终止遍历
+var errFound = errors.New("found")
+
+// runBundle 将一条审计记录打包为 ZIP/TAR，便于附加到工单或交给审计人员
+func runBundle(args []string) error {
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	id := fs.String("id", "", "审计记录 ID（默认最后一条）")
+	out := fs.String("o", "bundle.zip", "输出文件（.zip、.tar 或 .tar.gz）")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: zhipu-video bundle [-id 记录ID] [-o bundle.zip] <audit.jsonl>")
+	}
+	store, err := audit.OpenFileStore(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var rec *audit.Record
+	err = store.Iterate(func(r *audit.Record) error {
+		if *id == "" || r.ID == *id {
+			rec = r
+		}
+		if *id != "" && rec != nil {
+			return errFound
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errFound) {
+		return fmt.Errorf("读取审计记录失败: %w", err)
+	}
+	if rec == nil {
+		return fmt.Errorf("未找到审计记录 %s", *id)
+	}
+
+	b, err := archive.BundleFromRecord(rec)
+	if err != nil {
+		return err
+	}
+	if err := b.WriteFile(*out); err != nil {
+		return err
+	}
+	fmt.Printf("记录 %s 已打包到 %s（%d 帧）\n", rec.ID, *out, len(b.Frames))
+	if len(b.Frames) == 0 {
+		fmt.Println("（审计记录未保存帧图像，包中只含帧校验值）")
+	}
+	return nil
+}
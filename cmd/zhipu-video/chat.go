@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/memory"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// runChat 对视频文件进行交互式问答：只抽帧一次，每个问题复用帧与对话历史
+func runChat(args []string) error {
+	fs := flag.NewFlagSet("chat", flag.ExitOnError)
+	frames := fs.Int("frames", 8, "采样帧数")
+	samplerName := fs.String("sampler", "uniform", "采样策略：uniform、keyframe、scene、motion、sharpest、random、pts（按时间戳，适合可变帧率）")
+	sessionKey := fs.String("session", "", "会话名称，与 -memory 一起使用时可在重启后继续对话（默认为视频路径）")
+	memoryDir := fs.String("memory", "", "保存对话历史的目录（可选）")
+	trackSpec := fs.String("track", "", trackUsage)
+	osdSpec := fs.String("osd", "", osdUsage)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: zhipu-video chat [-frames 8] [-memory dir] [-session name] <视频文件>")
+	}
+	path := fs.Arg(0)
+	sampler, err := processor.ParseSampler(*samplerName)
+	if err != nil {
+		return err
+	}
+
+	c := client.NewClient("")
+	if _, err := c.ResolveAPIKey(context.Background()); err != nil {
+		return fmt.Errorf("请设置 ZHIPU_API_KEY 或 ZHIPU_API_KEY_FILE 环境变量")
+	}
+	defer c.CleanupStreamProcessor()
+	c.StreamProcessor.WithSampler(sampler)
+	if err := applyTrack(c.StreamProcessor, *trackSpec); err != nil {
+		return err
+	}
+	if err := applyOSD(c.StreamProcessor, *osdSpec); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	duration, err := c.StreamProcessor.ProbeDuration(ctx, path)
+	if err != nil {
+		return err
+	}
+	data, err := c.StreamProcessor.ExtractVideoSegment(ctx, path, 0, duration, *frames)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已从 %s 提取 %d 帧（时长 %.1fs）。输入问题开始对话，/reset 清空历史，/quit 退出。\n",
+		path, len(data), duration.Seconds())
+
+	var mem memory.Memory
+	if *memoryDir != "" {
+		if mem, err = memory.NewFileStore(*memoryDir); err != nil {
+			return err
+		}
+	}
+	key := *sessionKey
+	if key == "" {
+		key = path
+	}
+	session, err := c.NewChatSession(ctx, key, data, mem)
+	if err != nil {
+		return err
+	}
+	if turns := len(session.History()) / 2; turns > 0 || session.Summary() != "" {
+		fmt.Printf("已恢复会话 %s（%d 轮对话）\n", key, turns)
+	}
+
+	input := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("\n> ")
+		if !input.Scan() {
+			fmt.Println()
+			return input.Err()
+		}
+		question := strings.TrimSpace(input.Text())
+		switch question {
+		case "":
+			continue
+		case "/quit", "/exit":
+			return nil
+		case "/reset":
+			if err := session.Reset(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "清空历史失败: %v\n", err)
+				continue
+			}
+			fmt.Println("对话历史已清空")
+			continue
+		}
+
+		turnCtx, cancel := context.WithCancel(ctx)
+		resp, err := session.AskStream(turnCtx, question, func(chunk *models.ChatCompletionChunk) error {
+			fmt.Print(chunk.Text())
+			return nil
+		})
+		cancel()
+		fmt.Println()
+		if err != nil && resp == nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "请求失败: %v\n", err)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "保存对话历史失败: %v\n", err)
+		}
+		fmt.Printf("[tokens: %d]\n", resp.Usage.TotalTokens)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+)
+
+// command 子命令定义
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+// commands 所有可用子命令
+var commands = []command{
+	{"serve", "serve [-addr :8080] [-token xxx]  启动 HTTP 服务（/healthz 与 OpenAI 兼容接口）", runServe},
+	{"chat", "chat [-frames 8] <file>           对视频进行交互式问答", runChat},
+	{"tail", "tail [-every 60s] <url|file>      持续跟踪实时流或正在写入的录像并滚动输出叙述", runTail},
+	{"batch", "batch -prompt-file p.yaml <dir>   批量分析目录中的视频并输出清单（可断点续跑）", runBatch},
+	{"snapshot", "snapshot [-at 10s] <file|url>     截取一帧（默认最新画面），可选地立即提问", runSnapshot},
+	{"tracks", "tracks <file|url>                 列出多节目 TS 等输入中的视频轨，供 -track 选择", runTracks},
+	{"replay", "replay [-model m] <audit.jsonl>   用其他模型或提示词重放审计记录并对比回答", runReplay},
+	{"bundle", "bundle [-id xxx] <audit.jsonl>    将一条审计记录打包为 ZIP/TAR（帧、提示词、回答与元数据）", runBundle},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	// 命令行工具沿用当前目录的 .env 配置
+	if err := client.LoadDotEnv(); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+	}
+
+	name := os.Args[1]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+	printUsage()
+	os.Exit(1)
+}
+
+// printUsage 打印用法
+func printUsage() {
+	fmt.Println("Usage: zhipu-video <command> [arguments]")
+	fmt.Println("\nCommands:")
+	for _, cmd := range commands {
+		fmt.Printf("  %s\n", cmd.usage)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import "github.com/t8y2/zhipu-video-sdk/processor"
+
+// osdUsage -osd 参数说明，各子命令共用
+const osdUsage = "遮挡或裁掉画面上叠加的时间戳、摄像头名称等 OSD 文字：区域名 top-left、top-right、bottom-left、bottom-right、top、bottom 或 x/y/w/h 比例，逗号分隔；加 crop: 前缀表示裁掉，例如 crop:top-left,bottom-right"
+
+// applyOSD 解析 -osd 参数并设置到处理器
+func applyOSD(sp *processor.StreamProcessor, spec string) error {
+	if spec == "" {
+		return nil
+	}
+	f, err := processor.ParseOSD(spec)
+	if err != nil {
+		return err
+	}
+	sp.WithOSD(f)
+	return nil
+}
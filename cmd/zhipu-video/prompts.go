@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// namedPrompt 带名称的提示词
+type namedPrompt struct {
+	Name   string
+	Prompt string
+}
+
+// loadPrompts 读取提示词文件：JSON 对象 {"名称": "提示词"}，或简单的 YAML 映射：
+//
+//	summary: 用一句话总结视频内容
+//	safety: |
+//	  是否有人未佩戴安全帽？
+//	  如有，请指出位置。
+func loadPrompts(path string) ([]namedPrompt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt file: %w", err)
+	}
+
+	values := map[string]string{}
+	var order []string
+	if strings.HasSuffix(path, ".json") {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("failed to decode prompt file: %w", err)
+		}
+		for name := range values {
+			order = append(order, name)
+		}
+		sort.Strings(order)
+	} else {
+		order, err = parseYAMLMap(string(data), values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	prompts := make([]namedPrompt, 0, len(order))
+	for _, name := range order {
+		if p := strings.TrimSpace(values[name]); p != "" {
+			prompts = append(prompts, namedPrompt{Name: name, Prompt: p})
+		}
+	}
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("%s: no prompts defined", path)
+	}
+	return prompts, nil
+}
+
+// parseYAMLMap 解析单层 YAML 映射，支持引号字符串与 | / > 块标量，返回键的出现顺序
+func parseYAMLMap(text string, out map[string]string) ([]string, error) {
+	var (
+		order []string
+		block string // 当前块标量的键
+		fold  bool
+		lines []string
+	)
+	flush := func() {
+		if block == "" {
+			return
+		}
+		sep := "\n"
+		if fold {
+			sep = " "
+		}
+		out[block] = strings.Join(lines, sep)
+		block, lines = "", nil
+	}
+
+	for i, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if block != "" && (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t") || strings.TrimSpace(raw) == "") {
+			lines = append(lines, strings.TrimSpace(raw))
+			continue
+		}
+		flush()
+
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"name: prompt\"", i+1)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		order = append(order, key)
+
+		switch {
+		case value == "|" || value == "|-" || value == ">" || value == ">-":
+			block, fold = key, strings.HasPrefix(value, ">")
+		case len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0]:
+			out[key] = value[1 : len(value)-1]
+		default:
+			out[key] = value
+		}
+	}
+	flush()
+	return order, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/audit"
+	"github.com/t8y2/zhipu-video-sdk/client"
+)
+
+// runReplay 用其他模型或提示词版本重放审计记录中的请求，并对比新旧回答
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	model := fs.String("model", "", "重放使用的模型（默认沿用记录中的模型）")
+	prompt := fs.String("prompt", "", "新的提示词模板，{prompt} 替换为原提示词（默认沿用原提示词）")
+	since := fs.Duration("since", 0, "只重放最近这段时间内的记录（0 表示全部）")
+	limit := fs.Int("limit", 20, "最多重放的记录数")
+	out := fs.String("o", "", "将对比结果以 JSONL 写入该文件（可选）")
+	changedOnly := fs.Bool("changed", false, "只输出回答发生变化的记录")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: zhipu-video replay [-model glm-4v-plus-0111] [-prompt 模板] [-since 24h] <audit.jsonl>")
+	}
+	if _, err := os.Stat(fs.Arg(0)); err != nil {
+		return fmt.Errorf("无法读取审计文件: %w", err)
+	}
+	store, err := audit.OpenFileStore(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	c := client.NewClient("")
+	if _, err := c.ResolveAPIKey(context.Background()); err != nil {
+		return fmt.Errorf("请设置 ZHIPU_API_KEY 或 ZHIPU_API_KEY_FILE 环境变量")
+	}
+
+	var enc *json.Encoder
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %w", err)
+		}
+		defer f.Close()
+		enc = json.NewEncoder(f)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	q := audit.Query{Limit: *limit}
+	if *since > 0 {
+		q.Since = time.Now().Add(-*since)
+	}
+	replayer := &audit.Replayer{Chatter: c, Model: *model, Prompt: *prompt}
+	var total, changed int
+	var similarity float64
+	err = replayer.ReplayAll(ctx, store, q, func(res *audit.ReplayResult) error {
+		total++
+		similarity += res.Similarity
+		if res.Changed() {
+			changed++
+		}
+		if enc != nil {
+			if err := enc.Encode(res); err != nil {
+				return fmt.Errorf("写入对比结果失败: %w", err)
+			}
+		}
+		if *changedOnly && !res.Changed() {
+			return nil
+		}
+
+		fmt.Printf("=== %s  %s → %s  相似度 %.2f\n", res.RecordID, res.OldModel, res.NewModel, res.Similarity)
+		if res.Degraded {
+			fmt.Println("（记录只保存了缩略图，重放结果仅供参考）")
+		}
+		if res.Error != "" {
+			fmt.Printf("重放失败: %s\n", res.Error)
+			return nil
+		}
+		fmt.Print(res.Diff())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		fmt.Println("没有可重放的记录（需要以 FramesFull 或 FramesThumbnail 模式保存帧）")
+		return nil
+	}
+	fmt.Printf("\n共重放 %d 条记录，%d 条回答有变化，平均相似度 %.2f\n", total, changed, similarity/float64(total))
+	return nil
+}
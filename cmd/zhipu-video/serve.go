@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/server"
+)
+
+// runServe 启动 HTTP 服务
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "监听地址")
+	tokens := fs.String("token", "", "允许的访问令牌，多个用逗号分隔（为空则不校验）")
+	frames := fs.Int("frames", 8, "每个视频附件采样的帧数")
+	videoHosts := fs.String("video-hosts", "", "video_url 允许下载的主机，多个用逗号分隔，.example.com 匹配子域名（为空则允许所有公网地址）")
+	fs.Parse(args)
+
+	c := client.NewClient("")
+	if _, err := c.ResolveAPIKey(context.Background()); err != nil {
+		return fmt.Errorf("请设置 ZHIPU_API_KEY 或 ZHIPU_API_KEY_FILE 环境变量")
+	}
+
+	srv := server.New(c)
+	srv.Addr = *addr
+	srv.FramesPerVideo = *frames
+	if *tokens != "" {
+		srv.Tokens = strings.Split(*tokens, ",")
+	}
+	if *videoHosts != "" {
+		srv.VideoHosts = strings.Split(*videoHosts, ",")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("服务已启动: %s\n", *addr)
+	fmt.Println("  GET  /healthz")
+	fmt.Println("  POST /v1/chat/completions")
+	err := srv.ListenAndServe()
+	c.CleanupStreamProcessor()
+	return err
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// runSnapshot 从视频文件或实时流截取一帧，可选地立即分析
+func runSnapshot(args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	at := fs.Duration("at", processor.Latest, "截取的时间偏移（默认最新画面；实时流忽略）")
+	out := fs.String("o", "snapshot.jpg", "输出 JPEG 文件")
+	prompt := fs.String("prompt", "", "对截图提问（可选）")
+	window := fs.Duration("window", 2*time.Second, "实时流的抓取时长")
+	trackSpec := fs.String("track", "", trackUsage)
+	osdSpec := fs.String("osd", "", osdUsage)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: zhipu-video snapshot [-at 10s] [-o snapshot.jpg] [-prompt 问题] <文件 | rtsp-url>")
+	}
+
+	c := client.NewClient("")
+	if *prompt != "" {
+		if _, err := c.ResolveAPIKey(context.Background()); err != nil {
+			return fmt.Errorf("请设置 ZHIPU_API_KEY 或 ZHIPU_API_KEY_FILE 环境变量")
+		}
+	}
+	defer c.CleanupStreamProcessor()
+	if err := applyTrack(c.StreamProcessor, *trackSpec); err != nil {
+		return err
+	}
+	if err := applyOSD(c.StreamProcessor, *osdSpec); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	frame, resp, err := c.Snapshot(ctx, processor.ParseSource(fs.Arg(0), *window), *at, *prompt, nil)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, frame, 0644); err != nil {
+		return fmt.Errorf("写入截图失败: %w", err)
+	}
+	fmt.Printf("截图已保存到 %s（%d 字节）\n", *out, len(frame))
+	if resp != nil {
+		fmt.Println(resp.Text())
+	}
+	return nil
+}
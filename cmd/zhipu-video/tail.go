@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/monitor"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// runTail 持续抓取实时流的滑动窗口，结合上一窗口的摘要滚动输出叙述
+func runTail(args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	every := fs.Duration("every", 60*time.Second, "每个分析窗口的时长")
+	frames := fs.Int("frames", 6, "每个窗口采样帧数")
+	focus := fs.String("prompt", "", "额外的关注点（可选）")
+	fromStart := fs.Bool("from-start", false, "跟踪录像文件时从头分析已写入的内容")
+	idle := fs.Duration("idle", 0, "跟踪录像文件时，文件超过该时长未增长即结束（0 表示一直等待）")
+	startAt := fs.String("start", "", "录像开始的实际时间（RFC3339 或当天的 15:04:05），用于以真实时间标注叙述")
+	simulate := fs.Float64("simulate", 0, "将已录制的文件按该倍速当作实时流回放（1 为实时），用于上线前验证")
+	trackSpec := fs.String("track", "", trackUsage)
+	osdSpec := fs.String("osd", "", osdUsage)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: zhipu-video tail [-every 60s] [-frames 6] [-prompt 关注点] [-simulate 10] <rtsp-url | 正在写入的录像文件>")
+	}
+	url := fs.Arg(0)
+	// 本地文件视为 NVR 正在写入的录像，只分析新追加的内容
+	_, statErr := os.Stat(url)
+	recording := statErr == nil
+	if *simulate > 0 && !recording {
+		return fmt.Errorf("-simulate 需要本地录像文件")
+	}
+	var clock *models.WallClock
+	if *startAt != "" {
+		start, err := parseStartTime(*startAt)
+		if err != nil {
+			return err
+		}
+		clock = models.NewWallClock(start)
+	}
+
+	c := client.NewClient("")
+	if _, err := c.ResolveAPIKey(context.Background()); err != nil {
+		return fmt.Errorf("请设置 ZHIPU_API_KEY 或 ZHIPU_API_KEY_FILE 环境变量")
+	}
+	defer c.CleanupStreamProcessor()
+	if err := applyTrack(c.StreamProcessor, *trackSpec); err != nil {
+		return err
+	}
+	if err := applyOSD(c.StreamProcessor, *osdSpec); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 抓取与分析并行：分析上一窗口的同时抓取下一窗口，保证覆盖连续
+	type window struct {
+		label  string
+		frames [][]byte
+	}
+	windows := make(chan window, 1)
+	var replay *processor.LiveReplay
+	if *simulate > 0 {
+		replay = processor.NewLiveReplay(c.StreamProcessor, url)
+		replay.Speed = *simulate
+		if clock != nil {
+			replay.Start = clock.Start
+		}
+	}
+	go func() {
+		defer close(windows)
+		if replay != nil {
+			// 按录像时间每 every 切一个窗口，从中均匀挑选 frames 帧
+			var (
+				buf       [][]byte
+				winStart  time.Time
+				winOffset time.Duration
+			)
+			send := func(end time.Time) error {
+				n := len(buf)
+				if *frames > 0 && *frames < n {
+					n = *frames
+				}
+				picked := make([][]byte, n)
+				for i := range picked {
+					picked[i] = buf[i*len(buf)/n]
+				}
+				buf = nil
+				select {
+				case windows <- window{label: winStart.Format("15:04:05") + " - " + end.Format("15:04:05"), frames: picked}:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			var last time.Time
+			err := replay.Run(ctx, func(f processor.ReplayFrame) error {
+				if len(buf) > 0 && f.Offset-winOffset >= *every {
+					if err := send(f.Time); err != nil {
+						return err
+					}
+				}
+				if len(buf) == 0 {
+					winStart, winOffset = f.Time, f.Offset
+				}
+				buf = append(buf, f.Data)
+				last = f.Time
+				return nil
+			})
+			if err == nil && len(buf) > 0 {
+				send(last)
+			}
+			if err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "回放录像失败: %v\n", err)
+			}
+			return
+		}
+		if recording {
+			err := c.StreamProcessor.TailFile(ctx, processor.TailFile{
+				Path:       url,
+				MinSegment: *every,
+				Idle:       *idle,
+				MaxFrames:  *frames,
+				FromStart:  *fromStart,
+			}, func(seg processor.TailSegment) error {
+				label := fmt.Sprintf("%s - %s", clock.Format(seg.Start, "15:04:05"), clock.Format(seg.End, "15:04:05"))
+				if seg.End == 0 {
+					label = fmt.Sprintf("字节 %d - %d", seg.Offset, seg.Offset+seg.Size)
+				}
+				select {
+				case windows <- window{label: label, frames: seg.Frames}:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+			if err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "跟踪录像失败: %v\n", err)
+			}
+			return
+		}
+		for ctx.Err() == nil {
+			start := time.Now()
+			data, err := c.StreamProcessor.CaptureStream(ctx, url, *every, *frames)
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "[%s] 抓取失败: %v\n", start.Format("15:04:05"), err)
+					time.Sleep(5 * time.Second)
+				}
+				continue
+			}
+			select {
+			case windows <- window{label: start.Format("15:04:05") + " - " + start.Add(*every).Format("15:04:05"), frames: data}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	switch {
+	case replay != nil:
+		fmt.Printf("正在以 %g 倍速回放录像 %s，每 %v 录像内容输出一次叙述（Ctrl+C 退出）\n", *simulate, url, *every)
+	case recording:
+		fmt.Printf("正在跟踪录像 %s，每新增 %v 内容输出一次叙述（Ctrl+C 退出）\n", url, *every)
+	default:
+		fmt.Printf("正在跟踪 %s，每 %v 输出一次叙述（Ctrl+C 退出）\n", url, *every)
+	}
+	cfg := monitor.NarratorConfig{Window: *every, Focus: *focus}
+	if replay != nil {
+		cfg.Now = replay.Now
+	}
+	narrator := monitor.NewNarrator(c, cfg)
+	for w := range windows {
+		nr, err := narrator.Next(w.frames)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[%s] 分析失败: %v\n", w.label, err)
+			continue
+		}
+		fmt.Printf("[%s] %s\n", w.label, nr.Text)
+	}
+	return nil
+}
+
+// parseStartTime 解析 -start 参数：完整的 RFC3339 时间，或当天的 15:04:05
+func parseStartTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("15:04:05", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无法解析开始时间 %q：应为 RFC3339 或 15:04:05", value)
+	}
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// trackUsage -track 参数说明，各子命令共用
+const trackUsage = "多节目/多视频轨输入（如 MPEG-TS）要解码的视频轨：index:2、pid:0x101、program:3、lang:eng、res:1920x1080 或 res:max，可用逗号组合（默认由 ffmpeg 选择，可用 tracks 命令查看）"
+
+// applyTrack 解析 -track 参数并设置到处理器
+func applyTrack(sp *processor.StreamProcessor, spec string) error {
+	if spec == "" {
+		return nil
+	}
+	sel, err := processor.ParseTrack(spec)
+	if err != nil {
+		return err
+	}
+	sp.WithTrack(sel)
+	return nil
+}
+
+// runTracks 列出视频文件或实时流中的视频轨（节目号、PID、语言、分辨率）
+func runTracks(args []string) error {
+	fs := flag.NewFlagSet("tracks", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: zhipu-video tracks <文件 | url>")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	sp := processor.NewStreamProcessor()
+	if err := sp.RequireTools(); err != nil {
+		return err
+	}
+	tracks, err := sp.ProbeTracks(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(tracks) == 0 {
+		return fmt.Errorf("%s 中没有视频轨", fs.Arg(0))
+	}
+	for _, t := range tracks {
+		fmt.Println(t)
+	}
+	return nil
+}
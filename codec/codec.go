@@ -0,0 +1,122 @@
+// Package codec abstracts the image encoder used for frames sent to the
+// model. The default is Go's image/jpeg; faster or smaller encoders backed
+// by native libraries are compiled in with build tags:
+//
+//	go build -tags libjpeg ./...  // libjpeg-turbo or mozjpeg via cgo, registered as "libjpeg"
+//	go build -tags libwebp ./...  // libwebp via cgo, registered as "webp"
+//
+// A build with the libjpeg tag makes it the Default encoder. Other encoders
+// are selected explicitly:
+//
+//	enc, ok := codec.Lookup("webp")
+//	sp.WithEncoder(enc)
+//
+// Importing codec also registers the WebP decoder with image.Decode, so
+// frames from any encoder decode throughout the SDK.
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"sort"
+	"sync"
+
+	_ "golang.org/x/image/webp" // Register the WebP decoder
+)
+
+// Encoder encodes frames for the model
+type Encoder interface {
+	// Encode writes img to w; quality is 1-100, as for image/jpeg
+	Encode(w io.Writer, img image.Image, quality int) error
+	// ContentType returns the MIME type of the encoded images
+	ContentType() string
+}
+
+// Std encodes JPEG frames with Go's image/jpeg
+var Std Encoder = stdJPEG{}
+
+type stdJPEG struct{}
+
+func (stdJPEG) Encode(w io.Writer, img image.Image, quality int) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+}
+
+func (stdJPEG) ContentType() string { return "image/jpeg" }
+
+var (
+	mu        sync.RWMutex
+	encoders  = map[string]Encoder{"jpeg": Std}
+	preferred Encoder
+)
+
+// Register makes an encoder available by name. Build-tagged encoders
+// register themselves from init.
+func Register(name string, e Encoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	encoders[name] = e
+}
+
+// Lookup returns the encoder registered under name
+func Lookup(name string) (Encoder, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := encoders[name]
+	return e, ok
+}
+
+// Names lists the registered encoders, sorted
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(encoders))
+	for name := range encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetDefault replaces the encoder returned by Default
+func SetDefault(e Encoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	preferred = e
+}
+
+// Default returns the encoder set with SetDefault, else a native JPEG
+// encoder when compiled in, else Std
+func Default() Encoder {
+	mu.RLock()
+	defer mu.RUnlock()
+	if preferred != nil {
+		return preferred
+	}
+	if e, ok := encoders["libjpeg"]; ok {
+		return e
+	}
+	return Std
+}
+
+// Parse returns the encoder registered under name; "" selects Default
+func Parse(name string) (Encoder, error) {
+	if name == "" {
+		return Default(), nil
+	}
+	if e, ok := Lookup(name); ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("unknown image encoder %q (available: %v)", name, Names())
+}
+
+// EncodeBytes encodes img with e and returns the encoded image
+func EncodeBytes(e Encoder, img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := e.Encode(&buf, img, quality); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
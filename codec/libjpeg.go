@@ -0,0 +1,111 @@
+//go:build libjpeg && cgo
+
+package codec
+
+/*
+#cgo pkg-config: libjpeg
+#include <stdio.h>
+#include <stdlib.h>
+#include <setjmp.h>
+#include <jpeglib.h>
+
+struct codec_error {
+	struct jpeg_error_mgr pub;
+	jmp_buf jmp;
+	char msg[JMSG_LENGTH_MAX];
+};
+
+static void codec_error_exit(j_common_ptr cinfo) {
+	struct codec_error *err = (struct codec_error *)cinfo->err;
+	(*cinfo->err->format_message)(cinfo, err->msg);
+	longjmp(err->jmp, 1);
+}
+
+// codec_jpeg_encode compresses packed RGB or grayscale rows. On success *out
+// holds a malloc'd JPEG the caller frees; on failure msg holds the reason.
+static int codec_jpeg_encode(unsigned char *pix, int width, int height, int components, int quality,
+		unsigned char **out, unsigned long *outlen, char *msg) {
+	struct jpeg_compress_struct cinfo;
+	struct codec_error err;
+
+	*out = NULL;
+	*outlen = 0;
+	cinfo.err = jpeg_std_error(&err.pub);
+	err.pub.error_exit = codec_error_exit;
+	if (setjmp(err.jmp)) {
+		jpeg_destroy_compress(&cinfo);
+		free(*out);
+		*out = NULL;
+		snprintf(msg, JMSG_LENGTH_MAX, "%s", err.msg);
+		return -1;
+	}
+
+	jpeg_create_compress(&cinfo);
+	jpeg_mem_dest(&cinfo, out, outlen);
+	cinfo.image_width = width;
+	cinfo.image_height = height;
+	cinfo.input_components = components;
+	cinfo.in_color_space = components == 1 ? JCS_GRAYSCALE : JCS_RGB;
+	jpeg_set_defaults(&cinfo);
+	jpeg_set_quality(&cinfo, quality, TRUE);
+	jpeg_start_compress(&cinfo, TRUE);
+	while (cinfo.next_scanline < cinfo.image_height) {
+		JSAMPROW row = pix + (size_t)cinfo.next_scanline * width * components;
+		jpeg_write_scanlines(&cinfo, &row, 1);
+	}
+	jpeg_finish_compress(&cinfo);
+	jpeg_destroy_compress(&cinfo);
+	return 0;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"unsafe"
+)
+
+func init() {
+	Register("libjpeg", libJPEG{})
+}
+
+// libJPEG encodes with the system libjpeg: libjpeg-turbo for speed, or
+// mozjpeg (trellis quantization, progressive scans) for smaller frames.
+// Point CGO_CFLAGS/CGO_LDFLAGS or PKG_CONFIG_PATH at the library to use.
+type libJPEG struct{}
+
+func (libJPEG) Encode(w io.Writer, img image.Image, quality int) error {
+	b := img.Bounds()
+	if b.Empty() {
+		return fmt.Errorf("failed to encode JPEG: empty image")
+	}
+	quality = min(max(quality, 1), 100)
+
+	var (
+		pix        []byte
+		components = 3
+	)
+	if g, ok := img.(*image.Gray); ok && g.Stride == b.Dx() && g.Rect.Min == (image.Point{}) {
+		pix, components = g.Pix, 1
+	} else {
+		pix = packRGB(img)
+	}
+
+	var (
+		out    *C.uchar
+		outLen C.ulong
+		msg    [C.JMSG_LENGTH_MAX]C.char
+	)
+	rc := C.codec_jpeg_encode((*C.uchar)(unsafe.Pointer(&pix[0])), C.int(b.Dx()), C.int(b.Dy()), C.int(components),
+		C.int(quality), &out, &outLen, &msg[0])
+	if rc != 0 {
+		return fmt.Errorf("failed to encode JPEG: %s", C.GoString(&msg[0]))
+	}
+	defer C.free(unsafe.Pointer(out))
+	_, err := w.Write(C.GoBytes(unsafe.Pointer(out), C.int(outLen)))
+	return err
+}
+
+func (libJPEG) ContentType() string { return "image/jpeg" }
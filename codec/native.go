@@ -0,0 +1,29 @@
+//go:build cgo && (libjpeg || libwebp)
+
+package codec
+
+import (
+	"image"
+	"image/draw"
+)
+
+// packRGB returns img as tightly packed 8-bit RGB rows
+func packRGB(img image.Image) []byte {
+	b := img.Bounds()
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Rect.Min != (image.Point{}) {
+		rgba = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	}
+
+	w, h := b.Dx(), b.Dy()
+	pix := make([]byte, w*h*3)
+	for y := 0; y < h; y++ {
+		src := rgba.Pix[y*rgba.Stride : y*rgba.Stride+w*4]
+		dst := pix[y*w*3 : (y+1)*w*3]
+		for x := 0; x < w; x++ {
+			copy(dst[x*3:x*3+3], src[x*4:x*4+3])
+		}
+	}
+	return pix
+}
@@ -0,0 +1,46 @@
+//go:build libwebp && cgo
+
+package codec
+
+/*
+#cgo pkg-config: libwebp
+#include <stdlib.h>
+#include <webp/encode.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"unsafe"
+)
+
+func init() {
+	Register("webp", libWebP{})
+}
+
+// libWebP encodes lossy WebP frames with libwebp, typically 25-35% smaller
+// than JPEG at the same visual quality
+type libWebP struct{}
+
+func (libWebP) Encode(w io.Writer, img image.Image, quality int) error {
+	b := img.Bounds()
+	if b.Empty() {
+		return fmt.Errorf("failed to encode WebP: empty image")
+	}
+	quality = min(max(quality, 1), 100)
+
+	pix := packRGB(img)
+	var out *C.uint8_t
+	size := C.WebPEncodeRGB((*C.uint8_t)(unsafe.Pointer(&pix[0])), C.int(b.Dx()), C.int(b.Dy()), C.int(b.Dx()*3),
+		C.float(quality), &out)
+	if size == 0 {
+		return fmt.Errorf("failed to encode WebP: %dx%d image", b.Dx(), b.Dy())
+	}
+	defer C.WebPFree(unsafe.Pointer(out))
+	_, err := w.Write(C.GoBytes(unsafe.Pointer(out), C.int(size)))
+	return err
+}
+
+func (libWebP) ContentType() string { return "image/webp" }
@@ -0,0 +1,289 @@
+// Package connect provides connectors between the SDK and external
+// streaming and messaging systems.
+//
+// Connectors depend on small interfaces instead of concrete client
+// libraries, so applications keep control of their Kafka/Redis client
+// versions and only write a thin adapter.
+package connect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Analyzer is the subset of client.Client used by connectors
+type Analyzer interface {
+	AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *client.ChatOptions) (*models.ChatResponse, error)
+}
+
+// Message is a Kafka record
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+}
+
+// KafkaConsumer fetches records from a consumer group. Scaling works by
+// running more workers in the same group; the broker balances partitions.
+//
+// An adapter for segmentio/kafka-go looks like:
+//
+//	type reader struct{ r *kafka.Reader }
+//	func (a reader) Fetch(ctx context.Context) (connect.Message, error) {
+//		m, err := a.r.FetchMessage(ctx)
+//		return connect.Message{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset, Key: m.Key, Value: m.Value}, err
+//	}
+//	func (a reader) Commit(ctx context.Context, m connect.Message) error {
+//		return a.r.CommitMessages(ctx, kafka.Message{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset})
+//	}
+type KafkaConsumer interface {
+	Fetch(ctx context.Context) (Message, error)
+	Commit(ctx context.Context, msg Message) error
+}
+
+// KafkaProducer writes records to a topic
+type KafkaProducer interface {
+	Produce(ctx context.Context, msg Message) error
+}
+
+// Job is an analysis job carried in a Kafka record value (JSON).
+// Frames are JPEG data, base64-encoded by encoding/json.
+type Job struct {
+	ID      string              `json:"id"`
+	Prompt  string              `json:"prompt"`
+	Frames  [][]byte            `json:"frames"`
+	Options *client.ChatOptions `json:"options,omitempty"`
+	Labels  models.Labels       `json:"labels,omitempty"`
+}
+
+// JobResult is produced for every consumed job
+type JobResult struct {
+	JobID       string        `json:"job_id"`
+	Content     string        `json:"content,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	TotalTokens int           `json:"total_tokens"`
+	Labels      models.Labels `json:"labels,omitempty"`
+	CompletedAt time.Time     `json:"completed_at"`
+	Latency     time.Duration `json:"latency"`
+}
+
+// KafkaWorker consumes jobs from one topic, analyzes them and produces
+// results to another topic
+type KafkaWorker struct {
+	Analyzer    Analyzer
+	Consumer    KafkaConsumer
+	Producer    KafkaProducer
+	ResultTopic string
+	Concurrency int             // Parallel jobs (default: 1)
+	OnError     func(err error) // Optional callback for consume/produce errors
+}
+
+// NewKafkaWorker creates a worker with concurrency 1
+func NewKafkaWorker(analyzer Analyzer, consumer KafkaConsumer, producer KafkaProducer, resultTopic string) *KafkaWorker {
+	return &KafkaWorker{
+		Analyzer:    analyzer,
+		Consumer:    consumer,
+		Producer:    producer,
+		ResultTopic: resultTopic,
+		Concurrency: 1,
+	}
+}
+
+// Run processes jobs until ctx is cancelled. Records are committed only
+// after their result was produced, giving at-least-once delivery: with
+// Concurrency > 1 jobs finish out of order, and since Kafka offsets are
+// cumulative a partition's offset advances only past records that have
+// completed along with everything fetched before them. A record whose
+// result cannot be produced holds back its partition's commits, so it and
+// the records after it are redelivered after a restart.
+func (w *KafkaWorker) Run(ctx context.Context) error {
+	n := w.Concurrency
+	if n <= 0 {
+		n = 1
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, n)
+	defer wg.Wait()
+
+	offsets := newOffsetTracker()
+	failures := 0
+	for {
+		msg, err := w.Consumer.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+				return ctx.Err()
+			}
+			w.reportError(fmt.Errorf("failed to fetch message: %w", err))
+
+			// Back off while the broker is unreachable
+			failures++
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(fetchBackoff(failures)):
+			}
+			continue
+		}
+		failures = 0
+		offsets.fetched(msg)
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(msg Message) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if w.handle(ctx, msg) {
+				offsets.complete(msg, func(commit Message) {
+					if err := w.Consumer.Commit(ctx, commit); err != nil {
+						w.reportError(fmt.Errorf("failed to commit offset %d: %w", commit.Offset, err))
+					}
+				})
+			}
+		}(msg)
+	}
+}
+
+// fetchBackoff returns the wait after consecutive fetch failures: 500ms
+// doubling up to 30s
+func fetchBackoff(failures int) time.Duration {
+	d := 500 * time.Millisecond
+	for i := 1; i < failures && d < 30*time.Second; i++ {
+		d *= 2
+	}
+	return min(d, 30*time.Second)
+}
+
+// partition identifies a topic partition
+type partition struct {
+	topic string
+	id    int
+}
+
+// offsetTracker orders commits per partition: a record is committed once
+// it and every record fetched before it from its partition completed
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[partition]*partitionOffsets
+}
+
+// partitionOffsets are the uncommitted records of a partition
+type partitionOffsets struct {
+	pending []int64           // Fetched offsets in fetch order
+	done    map[int64]Message // Completed records not yet committed
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: map[partition]*partitionOffsets{}}
+}
+
+// fetched registers a record before it is processed. An offset at or
+// below the last one fetched means the partition is being redelivered
+// (after a rebalance), so its state starts over.
+func (t *offsetTracker) fetched(msg Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := partition{msg.Topic, msg.Partition}
+	p := t.partitions[key]
+	if p == nil || (len(p.pending) > 0 && msg.Offset <= p.pending[len(p.pending)-1]) {
+		p = &partitionOffsets{done: map[int64]Message{}}
+		t.partitions[key] = p
+	}
+	p.pending = append(p.pending, msg.Offset)
+}
+
+// complete marks a record done and calls commit with the highest record of
+// its partition that can be committed, if any. Commits are serialized so a
+// lower offset never overwrites a higher one.
+func (t *offsetTracker) complete(msg Message, commit func(Message)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := t.partitions[partition{msg.Topic, msg.Partition}]
+	if p == nil {
+		return
+	}
+	p.done[msg.Offset] = msg
+	var last *Message
+	for len(p.pending) > 0 {
+		m, ok := p.done[p.pending[0]]
+		if !ok {
+			break
+		}
+		delete(p.done, p.pending[0])
+		p.pending = p.pending[1:]
+		last = &m
+	}
+	if last != nil {
+		commit(*last)
+	}
+}
+
+// handle processes a single record and reports whether its result was
+// produced, so it may be committed
+func (w *KafkaWorker) handle(ctx context.Context, msg Message) bool {
+	start := time.Now()
+
+	var job Job
+	result := JobResult{}
+	if err := json.Unmarshal(msg.Value, &job); err != nil {
+		result.Error = fmt.Sprintf("invalid job payload: %v", err)
+	} else {
+		result.JobID = job.ID
+		result.Labels = job.Labels
+
+		resp, err := w.Analyzer.AnalyzeFramesWithOptions(job.Prompt, job.Frames, job.Options)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.TotalTokens = resp.Usage.TotalTokens
+			result.Content = resp.Text()
+		}
+	}
+	result.CompletedAt = time.Now()
+	result.Latency = time.Since(start)
+
+	value, err := json.Marshal(result)
+	if err != nil {
+		w.reportError(fmt.Errorf("failed to marshal result: %w", err))
+		return false
+	}
+
+	out := Message{Topic: w.ResultTopic, Key: msg.Key, Value: value}
+	if err := w.Producer.Produce(ctx, out); err != nil {
+		w.reportError(fmt.Errorf("failed to produce result for job %s: %w", job.ID, err))
+		return false
+	}
+	return true
+}
+
+// reportError forwards errors to the callback
+func (w *KafkaWorker) reportError(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
+
+// PublishJob sends a frame batch as a job to a topic; the job ID is used as
+// the record key so retries land on the same partition
+func PublishJob(ctx context.Context, producer KafkaProducer, topic string, job Job) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	return producer.Produce(ctx, Message{Topic: topic, Key: []byte(job.ID), Value: value})
+}
@@ -0,0 +1,96 @@
+package connect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/memory"
+)
+
+// RedisKV is the minimal key-value API used by RedisMemory. It is
+// implemented by RESPClient; Get returns "" for missing keys.
+type RedisKV interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+// RedisMemory stores conversations as JSON strings in Redis, so several
+// workers (or a restarted one) share each stream's accumulated context
+type RedisMemory struct {
+	Client RedisKV
+	Prefix string        // Key prefix (default "zhipu:memory:")
+	TTL    time.Duration // Expire conversations idle for this long (0: never)
+}
+
+// key returns the Redis key of a conversation
+func (m *RedisMemory) key(key string) string {
+	prefix := m.Prefix
+	if prefix == "" {
+		prefix = "zhipu:memory:"
+	}
+	return prefix + key
+}
+
+// Load implements memory.Memory
+func (m *RedisMemory) Load(ctx context.Context, key string) (*memory.Conversation, error) {
+	data, err := m.Client.Get(ctx, m.key(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	if data == "" {
+		return nil, memory.ErrNotFound
+	}
+	var c memory.Conversation
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation %s: %w", key, err)
+	}
+	return &c, nil
+}
+
+// Save implements memory.Memory
+func (m *RedisMemory) Save(ctx context.Context, c *memory.Conversation) error {
+	if c.Key == "" {
+		return fmt.Errorf("conversation key is required")
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversation: %w", err)
+	}
+	if err := m.Client.Set(ctx, m.key(c.Key), string(data), m.TTL); err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return nil
+}
+
+// Delete implements memory.Memory
+func (m *RedisMemory) Delete(ctx context.Context, key string) error {
+	if err := m.Client.Del(ctx, m.key(key)); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return nil
+}
+
+// Get implements RedisKV
+func (c *RESPClient) Get(ctx context.Context, key string) (string, error) {
+	return c.Do(ctx, "GET", key)
+}
+
+// Set implements RedisKV; ttl <= 0 stores the value without expiry
+func (c *RESPClient) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	args := []string{"SET", key, value}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// Del implements RedisKV
+func (c *RESPClient) Del(ctx context.Context, key string) error {
+	_, err := c.Do(ctx, "DEL", key)
+	return err
+}
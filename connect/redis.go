@@ -0,0 +1,224 @@
+package connect
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/alert"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// RedisClient is the minimal Redis API used by RedisSink. It is implemented
+// by the built-in RESP client returned by DialRedis, and is easy to adapt
+// from go-redis:
+//
+//	func (a adapter) Publish(ctx context.Context, ch string, msg []byte) error {
+//		return a.c.Publish(ctx, ch, msg).Err()
+//	}
+//	func (a adapter) XAdd(ctx context.Context, stream string, maxLen int64, fields map[string]string) error {
+//		return a.c.XAdd(ctx, &redis.XAddArgs{Stream: stream, MaxLen: maxLen, Approx: true, Values: fields}).Err()
+//	}
+type RedisClient interface {
+	Publish(ctx context.Context, channel string, message []byte) error
+	XAdd(ctx context.Context, stream string, maxLen int64, fields map[string]string) error
+}
+
+// Event is the envelope published by RedisSink
+type Event struct {
+	Type   string        `json:"type"` // e.g. "analysis", "alert"
+	Time   time.Time     `json:"time"`
+	Source string        `json:"source,omitempty"`
+	Data   interface{}   `json:"data"`
+	Labels models.Labels `json:"labels,omitempty"`
+}
+
+// RedisSink publishes analysis results and alerts to Redis pub/sub
+// channels and/or streams for lightweight fan-out
+type RedisSink struct {
+	Client  RedisClient
+	Channel string // Pub/sub channel (empty disables pub/sub)
+	Stream  string // Stream key for XADD (empty disables streams)
+	MaxLen  int64  // Approximate stream length cap (0: unbounded)
+}
+
+// Send publishes an event to the configured channel and stream
+func (s *RedisSink) Send(ctx context.Context, e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Labels == nil {
+		e.Labels = models.LabelsFrom(ctx)
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var errs []error
+	if s.Channel != "" {
+		if err := s.Client.Publish(ctx, s.Channel, payload); err != nil {
+			errs = append(errs, fmt.Errorf("failed to publish to %s: %w", s.Channel, err))
+		}
+	}
+	if s.Stream != "" {
+		fields := map[string]string{
+			"type":    e.Type,
+			"source":  e.Source,
+			"payload": string(payload),
+		}
+		if len(e.Labels) > 0 {
+			fields["labels"] = e.Labels.String()
+		}
+		if err := s.Client.XAdd(ctx, s.Stream, s.MaxLen, fields); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add to stream %s: %w", s.Stream, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendResult publishes an analysis result, labeled with the labels of ctx
+func (s *RedisSink) SendResult(ctx context.Context, source string, result interface{}) error {
+	return s.Send(ctx, Event{Type: "analysis", Source: source, Data: result})
+}
+
+// AlertAction returns an alert.Action publishing fired alerts
+func (s *RedisSink) AlertAction() alert.Action {
+	return alert.ActionFunc(func(ctx context.Context, a alert.Alert) error {
+		return s.Send(ctx, Event{Type: "alert", Time: a.Time, Source: a.Source, Data: a, Labels: a.Labels})
+	})
+}
+
+// RESPClient is a minimal Redis client speaking RESP2 over a single
+// connection, sufficient for PUBLISH, XADD and the GET/SET/DEL used by
+// RedisMemory
+type RESPClient struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// DialRedis connects to a Redis server, authenticating and selecting db
+// when password/db are set
+func DialRedis(ctx context.Context, addr, password string, db int) (*RESPClient, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	c := &RESPClient{conn: conn, r: bufio.NewReader(conn)}
+	if password != "" {
+		if _, err := c.Do(ctx, "AUTH", password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis auth failed: %w", err)
+		}
+	}
+	if db != 0 {
+		if _, err := c.Do(ctx, "SELECT", strconv.Itoa(db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis select failed: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Publish implements RedisClient
+func (c *RESPClient) Publish(ctx context.Context, channel string, message []byte) error {
+	_, err := c.Do(ctx, "PUBLISH", channel, string(message))
+	return err
+}
+
+// XAdd implements RedisClient
+func (c *RESPClient) XAdd(ctx context.Context, stream string, maxLen int64, fields map[string]string) error {
+	args := []string{"XADD", stream}
+	if maxLen > 0 {
+		args = append(args, "MAXLEN", "~", strconv.FormatInt(maxLen, 10))
+	}
+	args = append(args, "*")
+	for k, v := range fields {
+		args = append(args, k, v)
+	}
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// Do sends a command and returns the first line of the reply
+func (c *RESPClient) Do(ctx context.Context, args ...string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	} else {
+		c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+
+	w := bufio.NewWriter(c.conn)
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if err := w.Flush(); err != nil {
+		return "", fmt.Errorf("failed to send command: %w", err)
+	}
+
+	return c.readReply()
+}
+
+// readReply reads one RESP reply, returning simple strings, integers and
+// bulk strings as text and errors as Go errors
+func (c *RESPClient) readReply() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read reply: %w", err)
+	}
+	if len(line) < 3 {
+		return "", fmt.Errorf("malformed reply %q", line)
+	}
+	body := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+', ':':
+		return body, nil
+	case '-':
+		return "", fmt.Errorf("redis: %s", body)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return "", nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, _ := strconv.Atoi(body)
+		var first string
+		for i := 0; i < n; i++ {
+			v, err := c.readReply()
+			if err != nil {
+				return "", err
+			}
+			if i == 0 {
+				first = v
+			}
+		}
+		return first, nil
+	}
+	return "", fmt.Errorf("unexpected reply %q", line)
+}
+
+// Close closes the connection
+func (c *RESPClient) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,243 @@
+// Package dedup suppresses repeated findings from periodic monitoring.
+// A Deduplicator compares each answer with the last one reported for the
+// same key (task, camera, rule) and flags it as a duplicate when the two are
+// similar enough, so "nothing new" reports do not flood sinks and alert
+// channels:
+//
+//	d := dedup.New(dedup.TextSimilarity(), 0)
+//	s := scheduler.NewScheduler(c, d.Recorder(recorder))
+//	rule.Actions = []alert.Action{d.Action(webhook)}
+//
+// VideoIndex does the same for whole videos: it recognizes content that
+// was analyzed before by its fingerprint, whatever the file is called.
+package dedup
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/t8y2/zhipu-video-sdk/alert"
+	"github.com/t8y2/zhipu-video-sdk/scheduler"
+	"github.com/t8y2/zhipu-video-sdk/search"
+)
+
+// DefaultThreshold is the similarity at or above which answers are
+// duplicates; it suits TextSimilarity, embeddings usually need about 0.9
+const DefaultThreshold = 0.7
+
+// Similarity scores how alike two answers are, from 0 (unrelated) to 1
+// (identical)
+type Similarity interface {
+	Similar(ctx context.Context, a, b string) (float64, error)
+}
+
+// SimilarityFunc adapts a function to Similarity
+type SimilarityFunc func(ctx context.Context, a, b string) (float64, error)
+
+// Similar implements Similarity
+func (f SimilarityFunc) Similar(ctx context.Context, a, b string) (float64, error) {
+	return f(ctx, a, b)
+}
+
+// TextSimilarity compares answers by the Dice coefficient of their
+// character bigrams, ignoring case, whitespace and punctuation. It needs no
+// API calls and works for Chinese and English alike, but treats answers that
+// differ only in a number ("3 人" vs "4 人") as near-identical; use a higher
+// threshold or EmbeddingSimilarity when such changes matter.
+func TextSimilarity() Similarity {
+	return SimilarityFunc(func(_ context.Context, a, b string) (float64, error) {
+		return dice(normalize(a), normalize(b)), nil
+	})
+}
+
+// EmbeddingSimilarity compares answers by the cosine similarity of their
+// embeddings (e.g. search.NewZhipuEmbedder), which tolerates rephrasing
+func EmbeddingSimilarity(e search.Embedder) Similarity {
+	return SimilarityFunc(func(ctx context.Context, a, b string) (float64, error) {
+		vectors, err := e.Embed(ctx, []string{a, b})
+		if err != nil {
+			return 0, fmt.Errorf("failed to embed answers: %w", err)
+		}
+		if len(vectors) != 2 {
+			return 0, fmt.Errorf("expected 2 embeddings, got %d", len(vectors))
+		}
+		return cosine(vectors[0], vectors[1]), nil
+	})
+}
+
+// Verdict is the outcome of comparing an answer with the last reported one
+type Verdict struct {
+	Duplicate  bool      // The answer repeats the last reported one
+	Similarity float64   // Similarity to the last reported answer (0 for the first)
+	Repeats    int       // Consecutive duplicates, including this one
+	Since      time.Time // When the repeated answer was last reported
+}
+
+// entry is the last reported answer of a key
+type entry struct {
+	text    string
+	at      time.Time
+	repeats int
+}
+
+// Deduplicator remembers the last reported answer per key
+type Deduplicator struct {
+	Similarity Similarity
+	Threshold  float64       // Similarity at which answers are duplicates (default DefaultThreshold)
+	Heartbeat  time.Duration // Report an unchanged answer again after this long (0: never)
+	Flag       bool          // Recorder keeps duplicates, marked Duplicate, instead of dropping them
+
+	mu   sync.Mutex
+	last map[string]*entry
+}
+
+// New creates a deduplicator; threshold <= 0 uses DefaultThreshold
+func New(sim Similarity, threshold float64) *Deduplicator {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	return &Deduplicator{Similarity: sim, Threshold: threshold, last: make(map[string]*entry)}
+}
+
+// Check compares text with the last answer reported for key. Answers that
+// are not duplicates become the new reference; duplicates do not, so slow
+// drift is still measured against what was last reported.
+func (d *Deduplicator) Check(ctx context.Context, key, text string) (Verdict, error) {
+	now := time.Now()
+
+	d.mu.Lock()
+	prev, ok := d.last[key]
+	var ref entry
+	if ok {
+		ref = *prev
+	}
+	d.mu.Unlock()
+
+	if !ok {
+		d.remember(key, text, now)
+		return Verdict{}, nil
+	}
+
+	score, err := d.Similarity.Similar(ctx, ref.text, text)
+	if err != nil {
+		return Verdict{}, err
+	}
+	v := Verdict{Similarity: score, Since: ref.at}
+	if score < d.Threshold || (d.Heartbeat > 0 && now.Sub(ref.at) >= d.Heartbeat) {
+		d.remember(key, text, now)
+		return v, nil
+	}
+
+	d.mu.Lock()
+	if cur, ok := d.last[key]; ok {
+		cur.repeats++
+		v.Repeats = cur.repeats
+	}
+	d.mu.Unlock()
+	v.Duplicate = true
+	return v, nil
+}
+
+// remember makes text the reference answer of key
+func (d *Deduplicator) remember(key, text string, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.last[key] = &entry{text: text, at: at}
+}
+
+// Reset forgets the last answer of key, so the next one is always reported
+func (d *Deduplicator) Reset(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.last, key)
+}
+
+// Recorder wraps a scheduler recorder, dropping results whose content
+// repeats the task's last recorded result (or marking them Duplicate when
+// Flag is set). Failed and skipped runs are always recorded.
+func (d *Deduplicator) Recorder(next scheduler.Recorder) scheduler.Recorder {
+	return scheduler.RecorderFunc(func(result scheduler.Result) error {
+		if result.Error != "" || result.Skipped || result.Content == "" {
+			return next.Record(result)
+		}
+		v, err := d.Check(context.Background(), result.Task, result.Content)
+		if err != nil {
+			return next.Record(result)
+		}
+		if v.Duplicate {
+			if !d.Flag {
+				return nil
+			}
+			result.Duplicate = true
+		}
+		return next.Record(result)
+	})
+}
+
+// Action wraps an alert action so that alerts repeating the last alert of
+// the same rule and source are not fired again
+func (d *Deduplicator) Action(next alert.Action) alert.Action {
+	return alert.ActionFunc(func(ctx context.Context, a alert.Alert) error {
+		v, err := d.Check(ctx, a.Rule+"|"+a.Source, a.Content)
+		if err == nil && v.Duplicate {
+			return nil
+		}
+		return next.Fire(ctx, a)
+	})
+}
+
+// normalize lowercases s and drops whitespace and punctuation
+func normalize(s string) []rune {
+	out := make([]rune, 0, len(s))
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// dice returns the Dice coefficient of the character bigrams of a and b
+func dice(a, b []rune) float64 {
+	if string(a) == string(b) {
+		return 1
+	}
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+	bigrams := make(map[[2]rune]int, len(a))
+	for i := 0; i+1 < len(a); i++ {
+		bigrams[[2]rune{a[i], a[i+1]}]++
+	}
+	shared := 0
+	for i := 0; i+1 < len(b); i++ {
+		k := [2]rune{b[i], b[i+1]}
+		if bigrams[k] > 0 {
+			bigrams[k]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b)-2)
+}
+
+// cosine returns the cosine similarity of two vectors
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
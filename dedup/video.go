@@ -0,0 +1,131 @@
+package dedup
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// AnalyzedVideo is a video whose result is kept by a VideoIndex
+type AnalyzedVideo struct {
+	Key         string                `json:"key"` // What was asked, e.g. the prompt name; results are only reused for the same key
+	Fingerprint processor.Fingerprint `json:"fingerprint"`
+	Source      string                `json:"source"` // File the result was computed from
+	Result      json.RawMessage       `json:"result"`
+	At          time.Time             `json:"at"`
+}
+
+// VideoIndex remembers analyzed videos by content fingerprint so batch
+// pipelines can recognize re-uploaded, renamed or re-encoded copies and
+// reuse the stored result instead of analyzing them again:
+//
+//	index, err := dedup.OpenVideoIndex(".fingerprints.jsonl")
+//	fp, err := sp.Fingerprint(ctx, path)
+//	if prev, ok := index.Lookup("summary", fp); ok {
+//		return prev.Result // analyzed before as prev.Source
+//	}
+//	...
+//	index.Add(dedup.AnalyzedVideo{Key: "summary", Fingerprint: fp, Source: path, Result: result})
+//
+// Lookups compare against every stored fingerprint, which is fast enough
+// for tens of thousands of videos.
+type VideoIndex struct {
+	MaxDistance float64 // Passed to Fingerprint.Matches (default 6)
+
+	mu     sync.RWMutex
+	videos []AnalyzedVideo
+	file   *os.File
+}
+
+// NewVideoIndex creates an in-memory index
+func NewVideoIndex() *VideoIndex {
+	return &VideoIndex{}
+}
+
+// OpenVideoIndex loads the index persisted at path (JSON lines), creating
+// it if needed; added videos are appended to it
+func OpenVideoIndex(path string) (*VideoIndex, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open video index: %w", err)
+	}
+	x := &VideoIndex{file: f}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var v AnalyzedVideo
+		if len(sc.Bytes()) == 0 || json.Unmarshal(sc.Bytes(), &v) != nil {
+			continue // A torn last line from a crash
+		}
+		x.videos = append(x.videos, v)
+	}
+	if err := sc.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read video index: %w", err)
+	}
+	return x, nil
+}
+
+// Lookup returns the stored video of key whose content matches fp; the
+// closest one wins when several do
+func (x *VideoIndex) Lookup(key string, fp processor.Fingerprint) (AnalyzedVideo, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	var (
+		best     AnalyzedVideo
+		bestDist = -1.0
+	)
+	for _, v := range x.videos {
+		if v.Key != key || !v.Fingerprint.Matches(fp, x.MaxDistance) {
+			continue
+		}
+		if d := v.Fingerprint.Distance(fp); bestDist < 0 || d < bestDist {
+			best, bestDist = v, d
+		}
+	}
+	return best, bestDist >= 0
+}
+
+// Add stores an analyzed video
+func (x *VideoIndex) Add(v AnalyzedVideo) error {
+	if v.At.IsZero() {
+		v.At = time.Now()
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.file != nil {
+		line, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode video index entry: %w", err)
+		}
+		if _, err := x.file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write video index: %w", err)
+		}
+	}
+	x.videos = append(x.videos, v)
+	return nil
+}
+
+// Len returns the number of stored videos
+func (x *VideoIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.videos)
+}
+
+// Close closes the backing file, if any
+func (x *VideoIndex) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.file == nil {
+		return nil
+	}
+	err := x.file.Close()
+	x.file = nil
+	return err
+}
@@ -0,0 +1,244 @@
+package detect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Track is an object followed across frames by IoU association
+type Track struct {
+	ID         int     `json:"id"`
+	Label      string  `json:"label"`
+	FirstFrame int     `json:"first_frame"`
+	LastFrame  int     `json:"last_frame"`
+	Hits       int     `json:"hits"`
+	LastBox    Box     `json:"last_box"`
+	Confidence float64 `json:"confidence"` // Mean detection confidence
+}
+
+// CountPoint is the per-frame object count
+type CountPoint struct {
+	FrameIndex int            `json:"frame_index"`
+	Timestamp  time.Duration  `json:"timestamp"`
+	Counts     map[string]int `json:"counts"`
+}
+
+// CountReport summarizes a counting run
+type CountReport struct {
+	Series []CountPoint   `json:"series"` // Counts over time
+	Unique map[string]int `json:"unique"` // Distinct objects per label (tracks)
+	Tracks []Track        `json:"tracks"`
+	Notes  []string       `json:"notes,omitempty"` // Confidence notes
+	Frames int            `json:"frames"`
+}
+
+// Counter counts objects over time, associating detections across frames
+// so that the same object is not counted twice
+type Counter struct {
+	Detector      *Detector
+	BatchSize     int     // Frames per detection request (default: 4)
+	IoUThreshold  float64 // Minimum IoU to continue a track (default: 0.3)
+	MaxAge        int     // Frames a track may be unseen before it is closed (default: 2)
+	MinConfidence float64 // Detections below this confidence are ignored (default: 0)
+}
+
+// NewCounter creates a counter for the given labels (e.g. "person", "vehicle")
+func NewCounter(analyzer Analyzer, labels ...string) *Counter {
+	return &Counter{
+		Detector:     NewDetector(analyzer, labels...),
+		BatchSize:    4,
+		IoUThreshold: 0.3,
+		MaxAge:       2,
+	}
+}
+
+// CountVideo samples a video file every interval and counts objects
+func (c *Counter) CountVideo(ctx context.Context, sp *processor.StreamProcessor, videoPath string, interval time.Duration) (*CountReport, error) {
+	duration, err := sp.ProbeDuration(ctx, videoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video duration: %w", err)
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	n := int(duration / interval)
+	if n < 1 {
+		n = 1
+	}
+
+	frames, err := sp.ExtractVideoSegment(ctx, videoPath, 0, duration, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract frames: %w", err)
+	}
+
+	timestamps := make([]time.Duration, len(frames))
+	for i := range timestamps {
+		timestamps[i] = time.Duration(i) * duration / time.Duration(len(frames))
+	}
+	return c.Count(frames, timestamps)
+}
+
+// Count detects objects in the ordered frames and associates them into
+// tracks. timestamps may be nil.
+func (c *Counter) Count(frames [][]byte, timestamps []time.Duration) (*CountReport, error) {
+	batch := c.BatchSize
+	if batch <= 0 {
+		batch = 4
+	}
+
+	perFrame := make([][]Detection, len(frames))
+	for start := 0; start < len(frames); start += batch {
+		end := start + batch
+		if end > len(frames) {
+			end = len(frames)
+		}
+
+		detections, err := c.Detector.Detect(frames[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("detection failed for frames %d-%d: %w", start, end-1, err)
+		}
+		for _, d := range detections {
+			if d.Confidence < c.MinConfidence {
+				continue
+			}
+			d.FrameIndex += start
+			perFrame[d.FrameIndex] = append(perFrame[d.FrameIndex], d)
+		}
+	}
+
+	report := c.track(perFrame)
+	for i := range report.Series {
+		if i < len(timestamps) {
+			report.Series[i].Timestamp = timestamps[i]
+		}
+	}
+	return report, nil
+}
+
+// track runs greedy IoU association over per-frame detections
+func (c *Counter) track(perFrame [][]Detection) *CountReport {
+	threshold := c.IoUThreshold
+	if threshold <= 0 {
+		threshold = 0.3
+	}
+	maxAge := c.MaxAge
+	if maxAge <= 0 {
+		maxAge = 2
+	}
+
+	report := &CountReport{
+		Unique: make(map[string]int),
+		Frames: len(perFrame),
+	}
+
+	var (
+		tracks  []*Track
+		confSum = make(map[int]float64)
+		lowConf int
+	)
+
+	for frame, detections := range perFrame {
+		point := CountPoint{FrameIndex: frame, Counts: make(map[string]int)}
+
+		// Candidate pairs sorted by IoU for greedy matching
+		type pair struct {
+			track, det int
+			iou        float64
+		}
+		var pairs []pair
+		for ti, t := range tracks {
+			if frame-t.LastFrame > maxAge {
+				continue
+			}
+			for di, d := range detections {
+				if d.Label != t.Label {
+					continue
+				}
+				if v := IoU(t.LastBox, d.Box); v >= threshold {
+					pairs = append(pairs, pair{ti, di, v})
+				}
+			}
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i].iou > pairs[j].iou })
+
+		usedTrack := make(map[int]bool)
+		usedDet := make(map[int]bool)
+		for _, p := range pairs {
+			if usedTrack[p.track] || usedDet[p.det] {
+				continue
+			}
+			usedTrack[p.track] = true
+			usedDet[p.det] = true
+
+			t := tracks[p.track]
+			d := detections[p.det]
+			t.LastFrame = frame
+			t.LastBox = d.Box
+			t.Hits++
+			confSum[t.ID] += d.Confidence
+		}
+
+		for di, d := range detections {
+			point.Counts[d.Label]++
+			if d.Confidence > 0 && d.Confidence < 0.5 {
+				lowConf++
+			}
+			if usedDet[di] {
+				continue
+			}
+			t := &Track{
+				ID:         len(tracks) + 1,
+				Label:      d.Label,
+				FirstFrame: frame,
+				LastFrame:  frame,
+				Hits:       1,
+				LastBox:    d.Box,
+			}
+			confSum[t.ID] = d.Confidence
+			tracks = append(tracks, t)
+		}
+
+		report.Series = append(report.Series, point)
+	}
+
+	var singles int
+	for _, t := range tracks {
+		t.Confidence = confSum[t.ID] / float64(t.Hits)
+		report.Unique[t.Label]++
+		report.Tracks = append(report.Tracks, *t)
+		if t.Hits == 1 && len(perFrame) > 1 {
+			singles++
+		}
+	}
+
+	if lowConf > 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("%d detections have confidence below 0.5", lowConf))
+	}
+	if singles > 0 {
+		report.Notes = append(report.Notes, fmt.Sprintf("%d objects were seen in a single frame only and may be false positives or fast movers", singles))
+	}
+	if len(perFrame) < 3 {
+		report.Notes = append(report.Notes, "too few frames for reliable tracking; unique counts may be overestimated")
+	}
+
+	return report
+}
+
+// IoU returns the intersection over union of two boxes
+func IoU(a, b Box) float64 {
+	inter := a.Rect().Intersect(b.Rect())
+	if inter.Empty() {
+		return 0
+	}
+	ia := inter.Dx() * inter.Dy()
+	union := a.Width()*a.Height() + b.Width()*b.Height() - ia
+	if union <= 0 {
+		return 0
+	}
+	return float64(ia) / float64(union)
+}
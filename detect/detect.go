@@ -0,0 +1,172 @@
+package detect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register JPEG for image.DecodeConfig
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Analyzer is the subset of client.Client used for detection requests
+type Analyzer interface {
+	AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *client.ChatOptions) (*models.ChatResponse, error)
+}
+
+// CoordinateScale is the normalized coordinate range used by GLM grounding
+// output: boxes are expressed in [0, 1000] relative to the image size
+const CoordinateScale = 1000
+
+// Box is an axis-aligned bounding box in pixel coordinates
+type Box struct {
+	X1 int `json:"x1"`
+	Y1 int `json:"y1"`
+	X2 int `json:"x2"`
+	Y2 int `json:"y2"`
+}
+
+// Width returns the box width
+func (b Box) Width() int { return b.X2 - b.X1 }
+
+// Height returns the box height
+func (b Box) Height() int { return b.Y2 - b.Y1 }
+
+// Rect converts the box to an image.Rectangle
+func (b Box) Rect() image.Rectangle { return image.Rect(b.X1, b.Y1, b.X2, b.Y2) }
+
+// Detection is a single detected object
+type Detection struct {
+	Label      string  `json:"label"`
+	Confidence float64 `json:"confidence"`
+	Box        Box     `json:"box"`
+	FrameIndex int     `json:"frame_index"`
+}
+
+// rawDetection is the JSON format requested from the model
+type rawDetection struct {
+	Frame      int       `json:"frame"`
+	Label      string    `json:"label"`
+	Confidence float64   `json:"confidence"`
+	BBox       []float64 `json:"bbox"`
+}
+
+// Detector prompts the model for grounded bounding boxes
+type Detector struct {
+	Analyzer Analyzer
+	Labels   []string            // Object classes to detect (empty: all salient objects)
+	Options  *client.ChatOptions // Optional chat options
+}
+
+// NewDetector creates a detector for the given labels
+func NewDetector(analyzer Analyzer, labels ...string) *Detector {
+	return &Detector{
+		Analyzer: analyzer,
+		Labels:   labels,
+	}
+}
+
+// Prompt builds the detection prompt for n frames
+func (d *Detector) Prompt(n int) string {
+	// Without given labels, ask for English class names, which the
+	// renderer's default ASCII font can draw
+	target := "所有显著的物体，label 使用英文类别名（如 person、car）"
+	if len(d.Labels) > 0 {
+		target = strings.Join(d.Labels, "、")
+	}
+
+	return fmt.Sprintf(`请在这 %d 张按顺序编号（从 0 开始）的图片中检测：%s。
+只返回 JSON 数组，每个元素格式为：
+{"frame": 图片编号, "label": "类别", "confidence": 0 到 1 的置信度, "bbox": [x1, y1, x2, y2]}
+其中 bbox 为左上角和右下角坐标，归一化到 0-%d 范围。没有检测到目标时返回 []。`, n, target, CoordinateScale)
+}
+
+// Detect runs detection on JPEG frames and returns boxes in the pixel
+// space of each frame
+func (d *Detector) Detect(frames [][]byte) ([]Detection, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames to analyze")
+	}
+
+	resp, err := d.Analyzer.AnalyzeFramesWithOptions(d.Prompt(len(frames)), frames, d.Options)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+
+	sizes := make([]image.Point, len(frames))
+	for i, frame := range frames {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(frame))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+		sizes[i] = image.Pt(cfg.Width, cfg.Height)
+	}
+
+	return ParseDetections(resp.Choices[0].Message.Content, sizes)
+}
+
+// ParseDetections parses the model output into detections, converting
+// normalized coordinates to pixels using the size of each frame.
+// Entries referring to unknown frames or with malformed boxes are skipped.
+func ParseDetections(content string, sizes []image.Point) ([]Detection, error) {
+	start := strings.Index(content, "[")
+	end := strings.LastIndex(content, "]")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("no JSON array in model output: %q", content)
+	}
+
+	var raw []rawDetection
+	if err := json.Unmarshal([]byte(content[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse detections: %w", err)
+	}
+
+	detections := make([]Detection, 0, len(raw))
+	for _, r := range raw {
+		if r.Frame < 0 || r.Frame >= len(sizes) || len(r.BBox) != 4 {
+			continue
+		}
+		detections = append(detections, Detection{
+			Label:      r.Label,
+			Confidence: r.Confidence,
+			Box:        ToPixels(r.BBox, sizes[r.Frame]),
+			FrameIndex: r.Frame,
+		})
+	}
+
+	return detections, nil
+}
+
+// ToPixels converts a normalized [x1, y1, x2, y2] box to pixel coordinates,
+// clamping to the image bounds and fixing swapped corners
+func ToPixels(bbox []float64, size image.Point) Box {
+	scale := func(v float64, max int) int {
+		p := int(v / CoordinateScale * float64(max))
+		if p < 0 {
+			return 0
+		}
+		if p > max {
+			return max
+		}
+		return p
+	}
+
+	b := Box{
+		X1: scale(bbox[0], size.X),
+		Y1: scale(bbox[1], size.Y),
+		X2: scale(bbox[2], size.X),
+		Y2: scale(bbox[3], size.Y),
+	}
+	if b.X1 > b.X2 {
+		b.X1, b.X2 = b.X2, b.X1
+	}
+	if b.Y1 > b.Y2 {
+		b.Y1, b.Y2 = b.Y2, b.Y1
+	}
+	return b
+}
@@ -0,0 +1,215 @@
+package detect
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// palette cycles box colors by label
+var palette = []color.RGBA{
+	{230, 25, 75, 255},
+	{60, 180, 75, 255},
+	{0, 130, 200, 255},
+	{245, 130, 48, 255},
+	{145, 30, 180, 255},
+	{70, 240, 240, 255},
+	{240, 50, 230, 255},
+	{255, 225, 25, 255},
+}
+
+// Renderer draws labeled bounding boxes onto frames. It is safe for
+// concurrent use.
+type Renderer struct {
+	Thickness int // Box line thickness in pixels (default: 3)
+	Quality   int // JPEG quality of annotated output (default: 90)
+
+	// Face draws the labels. The default basicfont face only has ASCII
+	// glyphs; load a CJK font with LoadFace for Chinese labels.
+	Face font.Face
+
+	mu     sync.Mutex // Guards colors and Face, which is not safe for concurrent use
+	colors map[string]color.RGBA
+}
+
+// LoadFace loads a TrueType/OpenType font file (e.g. NotoSansCJK or
+// wqy-microhei) as a label face of the given size in points
+func LoadFace(path string, size float64) (font.Face, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font: %w", err)
+	}
+	var f *opentype.Font
+	if coll, err := opentype.ParseCollection(data); err == nil && coll.NumFonts() > 1 {
+		f, err = coll.Font(0) // .ttc collections such as NotoSansCJK.ttc
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse font: %w", err)
+		}
+	} else if f, err = opentype.Parse(data); err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %w", err)
+	}
+	return face, nil
+}
+
+// NewRenderer creates a renderer with default settings
+func NewRenderer() *Renderer {
+	return &Renderer{
+		Thickness: 3,
+		Quality:   90,
+		colors:    make(map[string]color.RGBA),
+	}
+}
+
+// Annotate draws the detections belonging to frameIndex onto a JPEG frame
+// and returns the annotated JPEG
+func (r *Renderer) Annotate(frame []byte, frameIndex int, detections []Detection) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode frame: %w", err)
+	}
+
+	img := image.NewRGBA(src.Bounds())
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	for _, d := range detections {
+		if d.FrameIndex != frameIndex {
+			continue
+		}
+		c := r.colorFor(d.Label)
+		r.drawBox(img, d.Box.Rect().Add(img.Bounds().Min), c)
+		r.drawLabel(img, d, c)
+	}
+
+	quality := r.Quality
+	if quality <= 0 {
+		quality = 90
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode annotated frame: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// AnnotateAll annotates every frame with its detections
+func (r *Renderer) AnnotateAll(frames [][]byte, detections []Detection) ([][]byte, error) {
+	out := make([][]byte, len(frames))
+	for i, frame := range frames {
+		annotated, err := r.Annotate(frame, i, detections)
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i, err)
+		}
+		out[i] = annotated
+	}
+	return out, nil
+}
+
+// SaveAll annotates every frame and writes frame_0000.jpg ... into dir,
+// returning the written paths
+func (r *Renderer) SaveAll(dir string, frames [][]byte, detections []Detection) ([]string, error) {
+	annotated, err := r.AnnotateAll(frames, detections)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output dir: %w", err)
+	}
+
+	paths := make([]string, len(annotated))
+	for i, data := range annotated {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("frame_%04d.jpg", i))
+		if err := os.WriteFile(paths[i], data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", paths[i], err)
+		}
+	}
+	return paths, nil
+}
+
+// colorFor returns a stable color per label
+func (r *Renderer) colorFor(label string) color.RGBA {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.colors == nil {
+		r.colors = make(map[string]color.RGBA)
+	}
+	c, ok := r.colors[label]
+	if !ok {
+		c = palette[len(r.colors)%len(palette)]
+		r.colors[label] = c
+	}
+	return c
+}
+
+// drawBox draws a rectangle outline
+func (r *Renderer) drawBox(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	t := r.Thickness
+	if t <= 0 {
+		t = 3
+	}
+	rect = rect.Intersect(img.Bounds())
+	if rect.Empty() {
+		return
+	}
+
+	u := image.NewUniform(c)
+	edges := []image.Rectangle{
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+t),
+		image.Rect(rect.Min.X, rect.Max.Y-t, rect.Max.X, rect.Max.Y),
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+t, rect.Max.Y),
+		image.Rect(rect.Max.X-t, rect.Min.Y, rect.Max.X, rect.Max.Y),
+	}
+	for _, e := range edges {
+		draw.Draw(img, e.Intersect(rect), u, image.Point{}, draw.Src)
+	}
+}
+
+// drawLabel draws "label 0.92" on a filled tag above the box
+func (r *Renderer) drawLabel(img *image.RGBA, d Detection, c color.RGBA) {
+	text := d.Label
+	if d.Confidence > 0 {
+		text = fmt.Sprintf("%s %.2f", d.Label, d.Confidence)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var face font.Face = basicfont.Face7x13
+	if r.Face != nil {
+		face = r.Face
+	}
+	metrics := face.Metrics()
+	width := font.MeasureString(face, text).Ceil() + 6
+	height := metrics.Height.Ceil() + 4
+
+	origin := img.Bounds().Min.Add(image.Pt(d.Box.X1, d.Box.Y1))
+	tag := image.Rect(origin.X, origin.Y-height, origin.X+width, origin.Y)
+	if tag.Min.Y < img.Bounds().Min.Y {
+		// No room above the box: place the tag inside it
+		tag = tag.Add(image.Pt(0, height))
+	}
+	draw.Draw(img, tag.Intersect(img.Bounds()), image.NewUniform(c), image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.White,
+		Face: face,
+		Dot:  fixed.P(tag.Min.X+3, tag.Max.Y-2-metrics.Descent.Ceil()),
+	}
+	drawer.DrawString(text)
+}
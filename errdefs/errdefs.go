@@ -0,0 +1,142 @@
+// Package errdefs defines the error taxonomy shared by all SDK packages.
+//
+// Every package wraps failures so that callers can implement policy with
+// errors.Is and errors.As instead of matching error strings:
+//
+//	resp, err := c.AnalyzeFrames(prompt, frames)
+//	switch {
+//	case errors.Is(err, errdefs.ErrRateLimited):
+//		// back off and retry
+//	case errors.Is(err, errdefs.ErrContentFiltered):
+//		// skip this clip
+//	case errors.Is(err, errdefs.ErrAuth):
+//		// alert an operator
+//	}
+package errdefs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinel errors
+var (
+	ErrFFmpegNotFound  = errors.New("ffmpeg not found")      // ffmpeg/ffprobe is not installed or not in PATH
+	ErrNoFrames        = errors.New("no frames extracted")   // Decoding produced no frames
+	ErrNoAudio         = errors.New("no audio stream")       // Input has no audio track
+	ErrStreamCorrupt   = errors.New("stream is corrupt")     // Input could not be decoded
+	ErrPayloadTooLarge = errors.New("payload too large")     // Request or download exceeds a size limit
+	ErrRateLimited     = errors.New("rate limited")          // Too many requests or concurrency exceeded
+	ErrAuth            = errors.New("authentication failed") // Missing, invalid or expired API key
+	ErrContentFiltered = errors.New("content filtered")      // Input or output blocked by moderation
+	ErrInvalidRequest  = errors.New("invalid request")       // Request rejected as malformed
+	ErrServer          = errors.New("server error")          // Temporary API-side failure
+	ErrQuotaExceeded   = errors.New("quota exceeded")        // Account balance or resource package exhausted
+
+	ErrUnsupportedOnPlatform = errors.New("unsupported on this platform") // Feature unavailable in this build (e.g. no subprocesses on wasm)
+	ErrSkipped               = errors.New("skipped by pre-filter")        // A local pre-filter found nothing worth analyzing; no API call was made
+)
+
+// APIError is a non-200 response from the chat completions API
+type APIError struct {
+	StatusCode int
+	Code       string        // Zhipu business error code (e.g. "1302")
+	Message    string        // Error message from the API
+	RetryAfter time.Duration // From the Retry-After header, if present
+	Body       string        // Raw response body
+}
+
+// Error implements error
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("API error (status %d, code %s): %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// Unwrap maps the error to its sentinel, so errors.Is works
+func (e *APIError) Unwrap() error {
+	return e.Kind()
+}
+
+// Kind returns the sentinel error describing this failure, or nil
+func (e *APIError) Kind() error {
+	// Business codes are more precise than HTTP status codes
+	switch e.Code {
+	case "1000", "1001", "1002", "1003", "1004":
+		return ErrAuth
+	case "1113":
+		return ErrQuotaExceeded
+	case "1301":
+		return ErrContentFiltered
+	case "1302", "1303", "1305":
+		return ErrRateLimited
+	case "1210", "1211", "1213", "1214":
+		return ErrInvalidRequest
+	}
+
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrAuth
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusRequestEntityTooLarge:
+		return ErrPayloadTooLarge
+	case e.StatusCode >= 500:
+		return ErrServer
+	case e.StatusCode >= 400:
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+// Temporary reports whether retrying the request may succeed
+func (e *APIError) Temporary() bool {
+	kind := e.Kind()
+	return kind == ErrRateLimited || kind == ErrServer
+}
+
+// NewAPIError builds an APIError from an HTTP response status, headers
+// and body; Zhipu error bodies look like {"error":{"code":"1302","message":"..."}}
+func NewAPIError(statusCode int, header http.Header, body []byte) *APIError {
+	e := &APIError{StatusCode: statusCode, Body: string(body)}
+
+	var parsed struct {
+		Error struct {
+			Code    json.RawMessage `json:"code"`
+			Message string          `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		e.Message = parsed.Error.Message
+		var code string
+		if json.Unmarshal(parsed.Error.Code, &code) == nil {
+			e.Code = code
+		} else if len(parsed.Error.Code) > 0 {
+			e.Code = string(parsed.Error.Code)
+		}
+	}
+	if e.Message == "" {
+		e.Message = string(body)
+	}
+
+	if header != nil {
+		if s, err := strconv.Atoi(header.Get("Retry-After")); err == nil && s > 0 {
+			e.RetryAfter = time.Duration(s) * time.Second
+		}
+	}
+	return e
+}
+
+// IsTemporary reports whether err is worth retrying
+func IsTemporary(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServer)
+}
@@ -0,0 +1,200 @@
+package errdefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Locale selects the language of user-facing error messages
+type Locale string
+
+const (
+	LocaleZH Locale = "zh-CN"
+	LocaleEN Locale = "en"
+)
+
+// DefaultLocale is used by Localize when no locale is given
+var DefaultLocale = LocaleZH
+
+// ParseLocale maps a language tag such as "zh", "zh_CN.UTF-8" or "en-US" to
+// a supported locale, falling back to DefaultLocale
+func ParseLocale(tag string) Locale {
+	tag = strings.ToLower(tag)
+	switch {
+	case strings.HasPrefix(tag, "zh"):
+		return LocaleZH
+	case strings.HasPrefix(tag, "en"):
+		return LocaleEN
+	}
+	return DefaultLocale
+}
+
+// messages maps each known error to its user-facing text per locale
+var (
+	messagesMu sync.RWMutex
+	messages   = []messageEntry{
+		{ErrFFmpegNotFound, map[Locale]string{
+			LocaleZH: "未找到 ffmpeg，请先安装 ffmpeg 并确保其在 PATH 中",
+			LocaleEN: "ffmpeg was not found; install ffmpeg and make sure it is on the PATH",
+		}},
+		{ErrNoFrames, map[Locale]string{
+			LocaleZH: "未能从视频中提取到任何画面",
+			LocaleEN: "no frames could be extracted from the video",
+		}},
+		{ErrNoAudio, map[Locale]string{
+			LocaleZH: "视频中没有音轨",
+			LocaleEN: "the video has no audio track",
+		}},
+		{ErrStreamCorrupt, map[Locale]string{
+			LocaleZH: "视频数据已损坏或格式不受支持",
+			LocaleEN: "the video is corrupt or in an unsupported format",
+		}},
+		{ErrPayloadTooLarge, map[Locale]string{
+			LocaleZH: "视频或请求过大，请缩短视频或减少帧数",
+			LocaleEN: "the video or request is too large; use a shorter clip or fewer frames",
+		}},
+		{ErrRateLimited, map[Locale]string{
+			LocaleZH: "请求过于频繁，请稍后再试",
+			LocaleEN: "too many requests; please try again later",
+		}},
+		{ErrAuth, map[Locale]string{
+			LocaleZH: "API Key 无效或已过期，请检查配置",
+			LocaleEN: "the API key is invalid or has expired; check your configuration",
+		}},
+		{ErrContentFiltered, map[Locale]string{
+			LocaleZH: "内容未通过安全审核，无法分析",
+			LocaleEN: "the content was blocked by moderation and cannot be analyzed",
+		}},
+		{ErrInvalidRequest, map[Locale]string{
+			LocaleZH: "请求参数有误",
+			LocaleEN: "the request is invalid",
+		}},
+		{ErrServer, map[Locale]string{
+			LocaleZH: "模型服务暂时不可用，请稍后再试",
+			LocaleEN: "the model service is temporarily unavailable; please try again later",
+		}},
+		{ErrQuotaExceeded, map[Locale]string{
+			LocaleZH: "额度已用尽，请充值或调整预算",
+			LocaleEN: "the quota has been used up; top up or raise the budget",
+		}},
+		{ErrUnsupportedOnPlatform, map[Locale]string{
+			LocaleZH: "当前平台不支持视频处理，请在服务端抽帧后再提交图片",
+			LocaleEN: "video processing is not supported on this platform; extract frames elsewhere and submit images",
+		}},
+		{ErrSkipped, map[Locale]string{
+			LocaleZH: "画面中没有需要分析的内容，已跳过",
+			LocaleEN: "nothing worth analyzing was in view; the request was skipped",
+		}},
+		{context.DeadlineExceeded, map[Locale]string{
+			LocaleZH: "处理超时，请稍后再试",
+			LocaleEN: "the operation timed out; please try again later",
+		}},
+		{context.Canceled, map[Locale]string{
+			LocaleZH: "操作已取消",
+			LocaleEN: "the operation was canceled",
+		}},
+	}
+)
+
+type messageEntry struct {
+	target error
+	text   map[Locale]string
+}
+
+// RegisterMessage adds or replaces the localized messages for target, so
+// other packages and applications can extend the catalog. Entries
+// registered later take precedence when an error matches several targets.
+func RegisterMessage(target error, text map[Locale]string) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	for i, m := range messages {
+		if m.target == target {
+			messages = append(messages[:i], messages[i+1:]...)
+			break
+		}
+	}
+	messages = append(messages, messageEntry{target, text})
+}
+
+// Message returns a human-readable message for err in the given locale,
+// suitable for end users. Unknown errors get a generic message; the
+// original error should still be logged for diagnosis.
+func Message(err error, locale Locale) string {
+	if err == nil {
+		return ""
+	}
+	if locale == "" {
+		locale = DefaultLocale
+	}
+
+	text := lookup(err, locale)
+	if text == "" {
+		if locale == LocaleZH {
+			text = "处理失败，请稍后再试"
+		} else {
+			text = "something went wrong; please try again later"
+		}
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		wait := apiErr.RetryAfter.Round(time.Second)
+		if locale == LocaleZH {
+			text += fmt.Sprintf("（建议 %v 后重试）", wait)
+		} else {
+			text += fmt.Sprintf(" (retry after %v)", wait)
+		}
+	}
+	return text
+}
+
+// lookup finds the most recently registered message matching err
+func lookup(err error, locale Locale) string {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	for i := len(messages) - 1; i >= 0; i-- {
+		m := messages[i]
+		if !errors.Is(err, m.target) {
+			continue
+		}
+		if text, ok := m.text[locale]; ok {
+			return text
+		}
+		return m.text[LocaleEN]
+	}
+	return ""
+}
+
+// LocalizedError presents an error with a localized message while keeping
+// the original error available to errors.Is and errors.As
+type LocalizedError struct {
+	Err    error
+	Locale Locale
+}
+
+// Error returns the localized message
+func (e *LocalizedError) Error() string {
+	return Message(e.Err, e.Locale)
+}
+
+// Unwrap returns the original error
+func (e *LocalizedError) Unwrap() error {
+	return e.Err
+}
+
+// Detail returns the original (developer-facing) error text
+func (e *LocalizedError) Detail() string {
+	return e.Err.Error()
+}
+
+// Localize wraps err so that its message is shown in locale; nil stays nil
+func Localize(err error, locale Locale) error {
+	if err == nil {
+		return nil
+	}
+	return &LocalizedError{Err: err, Locale: locale}
+}
@@ -0,0 +1,54 @@
+package errdefs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Pipeline stages reported by PartialError
+const (
+	StageExtract = "extract" // Frame extraction
+	StageUpload  = "upload"  // Sending frames to the API
+	StageAnalyze = "analyze" // The API call itself
+	StageMap     = "map"     // Per-chunk analysis of a map-reduce run
+	StageReduce  = "reduce"  // Merging chunk results
+)
+
+// PartialError reports a pipeline that failed at Stage after earlier work
+// succeeded. It carries that work so callers can retry just the failed
+// stage instead of redoing everything:
+//
+//	resp, err := c.Analyze(ctx, src, prompt, nil)
+//	if pe, ok := errdefs.AsPartial(err); ok && pe.Stage == errdefs.StageAnalyze {
+//		resp, err = c.AnalyzeFramesWithOptions(prompt, pe.Frames, nil) // skip re-extraction
+//	}
+//
+// errors.Is and errors.As see through it to the underlying error.
+type PartialError struct {
+	Stage   string      // The stage that failed (Stage* constants)
+	Err     error       // Why it failed
+	Frames  [][]byte    // Frames extracted before the failure (all of them unless Stage is StageExtract)
+	Partial interface{} // Pipeline-specific progress, e.g. *client.MapReduceResult
+}
+
+// Error implements error
+func (e *PartialError) Error() string {
+	if len(e.Frames) == 0 {
+		return fmt.Sprintf("%s failed: %v", e.Stage, e.Err)
+	}
+	return fmt.Sprintf("%s failed with %d frames extracted: %v", e.Stage, len(e.Frames), e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// AsPartial returns the PartialError in err's chain, if any
+func AsPartial(err error) (*PartialError, bool) {
+	var pe *PartialError
+	if errors.As(err, &pe) {
+		return pe, true
+	}
+	return nil, false
+}
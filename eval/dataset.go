@@ -0,0 +1,74 @@
+// Package eval runs a dataset of clips with expected answers against one
+// or more prompt/model configurations and compares accuracy, latency and
+// cost, enabling data-driven prompt tuning.
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Case is one clip of the dataset
+type Case struct {
+	ID       string   `json:"id"`
+	Video    string   `json:"video,omitempty"`    // Video file path (relative paths resolve against the dataset file)
+	Frames   [][]byte `json:"frames,omitempty"`   // Pre-extracted JPEG frames, used instead of Video
+	Question string   `json:"question"`           // Inserted into the config prompt template
+	Expected string   `json:"expected,omitempty"` // Expected answer; matched case-insensitively as a substring
+	Keywords []string `json:"keywords,omitempty"` // All keywords must appear in the answer
+	Rubric   string   `json:"rubric,omitempty"`   // Grading instructions for the judge model
+}
+
+// Dataset is a named collection of cases
+type Dataset struct {
+	Name  string `json:"name"`
+	Cases []Case `json:"cases"`
+}
+
+// LoadDataset reads a dataset from a JSON file ({"name": ..., "cases": [...]})
+// or a JSONL file with one case per line
+func LoadDataset(path string) (*Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	ds := &Dataset{Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+	if strings.HasSuffix(path, ".jsonl") {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var c Case
+			if err := json.Unmarshal([]byte(text), &c); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			ds.Cases = append(ds.Cases, c)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read dataset: %w", err)
+		}
+	} else if err := json.NewDecoder(f).Decode(ds); err != nil {
+		return nil, fmt.Errorf("failed to decode dataset: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range ds.Cases {
+		c := &ds.Cases[i]
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("case-%d", i+1)
+		}
+		if c.Video != "" && !filepath.IsAbs(c.Video) && !strings.Contains(c.Video, "://") {
+			c.Video = filepath.Join(dir, c.Video)
+		}
+	}
+	return ds, nil
+}
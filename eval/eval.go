@@ -0,0 +1,273 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Config is one prompt/model configuration under evaluation
+type Config struct {
+	Name     string
+	Model    string              // Empty uses the runner client's model
+	Prompt   string              // Template; {question} is replaced by the case question
+	Frames   int                 // Frames sampled per video (default: 8)
+	Options  *client.ChatOptions // Optional chat options
+	PassMark float64             // Minimum score counted as passed (default: 0.5)
+}
+
+// render builds the prompt of a case
+func (cfg *Config) render(c Case) string {
+	if cfg.Prompt == "" {
+		return c.Question
+	}
+	if strings.Contains(cfg.Prompt, "{question}") {
+		return strings.ReplaceAll(cfg.Prompt, "{question}", c.Question)
+	}
+	return cfg.Prompt + "\n" + c.Question
+}
+
+// CaseResult is the outcome of one case under one config
+type CaseResult struct {
+	Config  string        `json:"config"`
+	CaseID  string        `json:"case_id"`
+	Answer  string        `json:"answer,omitempty"`
+	Score   float64       `json:"score"`
+	Passed  bool          `json:"passed"`
+	Latency time.Duration `json:"latency"`
+	Tokens  int           `json:"tokens"`
+	Cost    float64       `json:"cost"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Summary aggregates the results of one config
+type Summary struct {
+	Config      string
+	Model       string
+	Cases       int
+	Passed      int
+	Errors      int
+	Accuracy    float64 // Mean score
+	MeanLatency time.Duration
+	P95Latency  time.Duration
+	Tokens      int
+	Cost        float64
+}
+
+// Report is the outcome of an evaluation run
+type Report struct {
+	Dataset   string
+	Summaries []Summary
+	Results   []CaseResult
+}
+
+// Runner evaluates configurations against a dataset
+type Runner struct {
+	Client      *client.Client // Base client; each config uses a copy with its model
+	Scorer      Scorer         // Default: MatchScorer
+	Concurrency int            // Parallel API calls (default: 4)
+	OnResult    func(CaseResult)
+}
+
+// NewRunner creates a runner with keyword/expected-answer scoring
+func NewRunner(c *client.Client) *Runner {
+	return &Runner{Client: c, Scorer: MatchScorer, Concurrency: 4}
+}
+
+// WithScorer sets the scorer
+func (r *Runner) WithScorer(s Scorer) *Runner {
+	r.Scorer = s
+	return r
+}
+
+// Run evaluates all configs on the dataset. Frames of each case are
+// extracted once and shared by all configs.
+func (r *Runner) Run(ctx context.Context, ds *Dataset, configs ...Config) (*Report, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no configs to evaluate")
+	}
+
+	frames, err := r.loadFrames(ctx, ds, configs)
+	if err != nil {
+		return nil, err
+	}
+
+	n := r.Concurrency
+	if n <= 0 {
+		n = 4
+	}
+	sem := make(chan struct{}, n)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []CaseResult
+	)
+	for i := range configs {
+		cfg := configs[i]
+		if cfg.Model == "" {
+			cfg.Model = r.Client.Model
+		}
+		if cfg.PassMark == 0 {
+			cfg.PassMark = 0.5
+		}
+		cc := *r.Client
+		cc.Model = cfg.Model
+
+		for _, c := range ds.Cases {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				wg.Wait()
+				return nil, ctx.Err()
+			}
+			wg.Add(1)
+			go func(c Case) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				res := r.runCase(ctx, &cc, &cfg, c, frames[c.ID])
+				if r.OnResult != nil {
+					r.OnResult(res)
+				}
+				mu.Lock()
+				results = append(results, res)
+				mu.Unlock()
+			}(c)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Config != results[j].Config {
+			return results[i].Config < results[j].Config
+		}
+		return results[i].CaseID < results[j].CaseID
+	})
+
+	report := &Report{Dataset: ds.Name, Results: results}
+	for _, cfg := range configs {
+		model := cfg.Model
+		if model == "" {
+			model = r.Client.Model
+		}
+		report.Summaries = append(report.Summaries, summarize(cfg.Name, model, results))
+	}
+	return report, nil
+}
+
+// loadFrames extracts frames of every case once, using the largest
+// frame count requested by any config
+func (r *Runner) loadFrames(ctx context.Context, ds *Dataset, configs []Config) (map[string][][]byte, error) {
+	maxFrames := 0
+	for _, cfg := range configs {
+		if cfg.Frames > maxFrames {
+			maxFrames = cfg.Frames
+		}
+	}
+	if maxFrames == 0 {
+		maxFrames = 8
+	}
+
+	out := make(map[string][][]byte, len(ds.Cases))
+	for _, c := range ds.Cases {
+		if len(c.Frames) > 0 {
+			out[c.ID] = c.Frames
+			continue
+		}
+		if c.Video == "" {
+			return nil, fmt.Errorf("case %s has neither frames nor video", c.ID)
+		}
+
+		duration, err := r.Client.StreamProcessor.ProbeDuration(ctx, c.Video)
+		if err != nil {
+			return nil, fmt.Errorf("case %s: failed to probe video: %w", c.ID, err)
+		}
+		frames, err := r.Client.StreamProcessor.ExtractVideoSegment(ctx, c.Video, 0, duration, maxFrames)
+		if err != nil {
+			return nil, fmt.Errorf("case %s: failed to extract frames: %w", c.ID, err)
+		}
+		out[c.ID] = frames
+	}
+	return out, nil
+}
+
+// runCase analyzes and scores one case
+func (r *Runner) runCase(ctx context.Context, c *client.Client, cfg *Config, tc Case, frames [][]byte) CaseResult {
+	res := CaseResult{Config: cfg.Name, CaseID: tc.ID}
+	if cfg.Frames > 0 && len(frames) > cfg.Frames {
+		frames = subsample(frames, cfg.Frames)
+	}
+
+	start := time.Now()
+	resp, err := c.AnalyzeFramesWithOptions(cfg.render(tc), frames, cfg.Options)
+	res.Latency = time.Since(start)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Answer = resp.Text()
+	res.Tokens = resp.Usage.TotalTokens
+	res.Cost = models.ModelCost(c.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+	scorer := r.Scorer
+	if scorer == nil {
+		scorer = MatchScorer
+	}
+	score, err := scorer.Score(ctx, tc, res.Answer)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Score = score
+	res.Passed = score >= cfg.PassMark
+	return res
+}
+
+// subsample picks n evenly spaced frames
+func subsample(frames [][]byte, n int) [][]byte {
+	out := make([][]byte, n)
+	for i := range out {
+		out[i] = frames[i*len(frames)/n]
+	}
+	return out
+}
+
+// summarize aggregates the results of one config
+func summarize(config, model string, results []CaseResult) Summary {
+	s := Summary{Config: config, Model: model}
+	var latencies []time.Duration
+	var total time.Duration
+	for _, r := range results {
+		if r.Config != config {
+			continue
+		}
+		s.Cases++
+		s.Accuracy += r.Score
+		s.Tokens += r.Tokens
+		s.Cost += r.Cost
+		if r.Passed {
+			s.Passed++
+		}
+		if r.Error != "" {
+			s.Errors++
+		}
+		latencies = append(latencies, r.Latency)
+		total += r.Latency
+	}
+	if s.Cases == 0 {
+		return s
+	}
+
+	s.Accuracy /= float64(s.Cases)
+	s.MeanLatency = total / time.Duration(s.Cases)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	s.P95Latency = latencies[(len(latencies)*95+99)/100-1]
+	return s
+}
@@ -0,0 +1,101 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Scorer grades an answer between 0 (wrong) and 1 (correct)
+type Scorer interface {
+	Score(ctx context.Context, c Case, answer string) (float64, error)
+}
+
+// ScorerFunc adapts a function to the Scorer interface
+type ScorerFunc func(ctx context.Context, c Case, answer string) (float64, error)
+
+// Score implements Scorer
+func (f ScorerFunc) Score(ctx context.Context, c Case, answer string) (float64, error) {
+	return f(ctx, c, answer)
+}
+
+// MatchScorer scores Expected and Keywords: Expected is worth half (or all,
+// without keywords) and the keywords share the rest
+var MatchScorer = ScorerFunc(func(_ context.Context, c Case, answer string) (float64, error) {
+	answer = strings.ToLower(answer)
+	hasExpected := strings.TrimSpace(c.Expected) != ""
+	if !hasExpected && len(c.Keywords) == 0 {
+		return 0, fmt.Errorf("case %s has neither expected answer nor keywords", c.ID)
+	}
+
+	score, weight := 0.0, 1.0
+	if hasExpected && len(c.Keywords) > 0 {
+		weight = 0.5
+	}
+	if hasExpected && strings.Contains(answer, strings.ToLower(strings.TrimSpace(c.Expected))) {
+		score += weight
+	}
+	if len(c.Keywords) > 0 {
+		per := weight / float64(len(c.Keywords))
+		for _, kw := range c.Keywords {
+			if strings.Contains(answer, strings.ToLower(kw)) {
+				score += per
+			}
+		}
+	}
+	return score, nil
+})
+
+// Judge is the model used to grade answers against rubrics
+type Judge interface {
+	Chat(ctx context.Context, req *models.ChatRequest) (*models.ChatResponse, error)
+}
+
+const judgePrompt = `你是严格的评测员。请根据评分标准为回答打分。
+
+问题：%s
+评分标准：%s
+参考答案：%s
+待评回答：%s
+
+只返回 JSON：{"score": 0 到 1 之间的小数, "reason": "简短理由"}`
+
+// RubricScorer grades with a judge model for cases that have a rubric and
+// falls back to MatchScorer otherwise
+func RubricScorer(judge Judge) Scorer {
+	return ScorerFunc(func(ctx context.Context, c Case, answer string) (float64, error) {
+		if c.Rubric == "" {
+			return MatchScorer.Score(ctx, c, answer)
+		}
+
+		req := &models.ChatRequest{
+			Messages: []models.Message{models.UserMessage(models.Text(fmt.Sprintf(judgePrompt, c.Question, c.Rubric, c.Expected, answer)))},
+		}
+		resp, err := judge.Chat(ctx, req)
+		if err != nil {
+			return 0, fmt.Errorf("judge failed: %w", err)
+		}
+
+		var verdict struct {
+			Score float64 `json:"score"`
+		}
+		if err := client.DecodeJSON(resp.Text(), &verdict); err != nil {
+			return 0, fmt.Errorf("invalid judge verdict: %w", err)
+		}
+		return clamp(verdict.Score), nil
+	})
+}
+
+// clamp limits a score to [0, 1]
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
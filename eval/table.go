@@ -0,0 +1,52 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WriteTable writes the comparison table as Markdown
+func (r *Report) WriteTable(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "## %s\n\n", r.Dataset); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "| Config | Model | Accuracy | Passed | Errors | Mean latency | P95 latency | Tokens | Cost (CNY) |")
+	fmt.Fprintln(w, "|---|---|---|---|---|---|---|---|---|")
+	for _, s := range r.Summaries {
+		_, err := fmt.Fprintf(w, "| %s | %s | %.1f%% | %d/%d | %d | %v | %v | %d | %.4f |\n",
+			s.Config, s.Model, s.Accuracy*100, s.Passed, s.Cases, s.Errors,
+			s.MeanLatency.Round(time.Millisecond), s.P95Latency.Round(time.Millisecond), s.Tokens, s.Cost)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSONL writes every case result as a JSON line
+func (r *Report) WriteJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, res := range r.Results {
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Best returns the summary with the highest accuracy, preferring lower
+// cost on ties
+func (r *Report) Best() (Summary, bool) {
+	if len(r.Summaries) == 0 {
+		return Summary{}, false
+	}
+	best := r.Summaries[0]
+	for _, s := range r.Summaries[1:] {
+		if s.Accuracy > best.Accuracy || (s.Accuracy == best.Accuracy && s.Cost < best.Cost) {
+			best = s
+		}
+	}
+	return best, true
+}
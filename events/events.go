@@ -0,0 +1,239 @@
+// Package events is a typed notification bus for the whole pipeline.
+// Components publish what happens (frames extracted, analyses started and
+// completed, alerts, source errors, ffmpeg restarts) and applications
+// subscribe in one place instead of wiring per-component callbacks:
+//
+//	events.Default.Subscribe(func(e events.Event) {
+//		switch e := e.(type) {
+//		case events.AnalysisCompleted:
+//			metrics.Observe(e.Model, e.Latency)
+//		case events.SourceError:
+//			log.Printf("%s: %v", e.Source, e.Err)
+//		}
+//	})
+//	events.On(events.Default, func(a events.Alert) { page(a) })
+//
+// Publishers use the bus carried by the context (WithBus) or Default, so a
+// pipeline can be routed to its own bus without touching its components.
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Event is one pipeline notification; switch on the concrete type
+type Event interface {
+	// Metadata returns the fields common to every event
+	Metadata() Meta
+}
+
+// Meta holds the fields common to every event, filled in by Publish
+type Meta struct {
+	Time   time.Time     `json:"time"`
+	Labels models.Labels `json:"labels,omitempty"` // Labels of the publishing context
+}
+
+// Metadata implements Event
+func (m Meta) Metadata() Meta { return m }
+
+// FrameExtracted reports frames extracted from a source
+type FrameExtracted struct {
+	Meta
+	Source   string        `json:"source"`
+	Frames   int           `json:"frames"`
+	Duration time.Duration `json:"duration"`
+}
+
+// AnalysisStarted reports a request about to be sent to the model
+type AnalysisStarted struct {
+	Meta
+	Model  string `json:"model"`
+	Frames int    `json:"frames"`
+	Prompt string `json:"prompt"`
+}
+
+// AnalysisCompleted reports the outcome of a model request; Err is set if
+// it failed
+type AnalysisCompleted struct {
+	Meta
+	Model   string        `json:"model"`
+	Frames  int           `json:"frames"`
+	Usage   models.Usage  `json:"usage"`
+	Latency time.Duration `json:"latency"`
+	Err     error         `json:"-"`
+}
+
+// Alert reports an alert rule that fired
+type Alert struct {
+	Meta
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Source   string `json:"source"`
+	Reason   string `json:"reason"`
+	Content  string `json:"content"`
+}
+
+// SourceError reports a source that could not be opened or decoded
+type SourceError struct {
+	Meta
+	Source string `json:"source"`
+	Err    error  `json:"-"`
+}
+
+// FFmpegRestarted reports ffmpeg being restarted for a live source after
+// a failed capture
+type FFmpegRestarted struct {
+	Meta
+	Source  string        `json:"source"`
+	Attempt int           `json:"attempt"`
+	Delay   time.Duration `json:"delay"` // Wait before the restart
+	Err     error         `json:"-"`     // Why the previous run failed
+}
+
+// Bus delivers events to subscribers. Handlers run synchronously on the
+// publishing goroutine, so they must be quick; use Channel to decouple.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[int]func(Event)
+	nextID int
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{subs: make(map[int]func(Event))}
+}
+
+// Default is the bus used when the context carries none
+var Default = NewBus()
+
+// Subscribe registers fn for every event and returns a function removing it
+func (b *Bus) Subscribe(fn func(Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[int]func(Event))
+	}
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+// On subscribes fn to the events of type T only
+func On[T Event](b *Bus, fn func(T)) (unsubscribe func()) {
+	return b.Subscribe(func(e Event) {
+		if t, ok := e.(T); ok {
+			fn(t)
+		}
+	})
+}
+
+// Channel subscribes a buffered channel. Events are dropped rather than
+// blocking the pipeline when the channel is full; dropped reports how many.
+// The channel is closed by unsubscribe.
+func (b *Bus) Channel(buffer int) (ch <-chan Event, dropped func() int64, unsubscribe func()) {
+	c := make(chan Event, buffer)
+	var (
+		n      atomic.Int64
+		mu     sync.Mutex
+		closed bool
+	)
+	unsub := b.Subscribe(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case c <- e:
+		default:
+			n.Add(1)
+		}
+	})
+	return c, n.Load, func() {
+		unsub()
+		mu.Lock()
+		defer mu.Unlock()
+		if !closed {
+			closed = true
+			close(c)
+		}
+	}
+}
+
+// Publish delivers e to every subscriber
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	subs := make([]func(Event), 0, len(b.subs))
+	for _, fn := range b.subs {
+		subs = append(subs, fn)
+	}
+	b.mu.RUnlock()
+	for _, fn := range subs {
+		fn(e)
+	}
+}
+
+type busKey struct{}
+
+// WithBus routes events published with ctx to b instead of Default
+func WithBus(ctx context.Context, b *Bus) context.Context {
+	return context.WithValue(ctx, busKey{}, b)
+}
+
+// From returns the bus of ctx, or Default
+func From(ctx context.Context) *Bus {
+	if b, ok := ctx.Value(busKey{}).(*Bus); ok && b != nil {
+		return b
+	}
+	return Default
+}
+
+// Publish stamps e with the current time and the labels of ctx and
+// delivers it on the bus of ctx. Components call it with their event
+// struct; a zero Meta is filled in.
+func Publish[T Event](ctx context.Context, e T) {
+	b := From(ctx)
+	b.mu.RLock()
+	empty := len(b.subs) == 0
+	b.mu.RUnlock()
+	if empty {
+		return // Nobody listens; skip building the event
+	}
+	b.Publish(stamp(ctx, e))
+}
+
+// stamp fills the Meta of e
+func stamp[T Event](ctx context.Context, e T) Event {
+	m := Meta{Time: time.Now(), Labels: models.LabelsFrom(ctx)}
+	switch ev := any(e).(type) {
+	case FrameExtracted:
+		ev.Meta = m
+		return ev
+	case AnalysisStarted:
+		ev.Meta = m
+		return ev
+	case AnalysisCompleted:
+		ev.Meta = m
+		return ev
+	case Alert:
+		ev.Meta = m
+		return ev
+	case SourceError:
+		ev.Meta = m
+		return ev
+	case FFmpegRestarted:
+		ev.Meta = m
+		return ev
+	}
+	return e
+}
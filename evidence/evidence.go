@@ -0,0 +1,234 @@
+// Package evidence exports the footage around an alert as an MP4 clip with
+// the analysis attached, so reviewers see exactly what the model saw:
+//
+//	buf := processor.NewStreamBuffer(2 * time.Minute)
+//	go io.Copy(buf, camera) // or tee the stream that feeds the analysis
+//	exp := evidence.NewExporter(evidence.DirSink("/var/lib/zhipu/evidence"))
+//	exp.SetSource("cam1", evidence.BufferSource(sp, buf))
+//	rule.Actions = append(rule.Actions, exp)
+package evidence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/alert"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Source cuts footage between two wall-clock times into an MP4 file
+type Source interface {
+	Cut(ctx context.Context, from, to time.Time, out string) error
+}
+
+// SourceFunc adapts a function to Source
+type SourceFunc func(ctx context.Context, from, to time.Time, out string) error
+
+// Cut implements Source
+func (f SourceFunc) Cut(ctx context.Context, from, to time.Time, out string) error {
+	return f(ctx, from, to, out)
+}
+
+// FileSource cuts clips from a recording (a finished file or one an NVR is
+// still writing); clock maps wall times to offsets in the recording
+func FileSource(sp *processor.StreamProcessor, path string, clock *models.WallClock) Source {
+	return SourceFunc(func(ctx context.Context, from, to time.Time, out string) error {
+		start := clock.Offset(from)
+		return sp.ExportClip(ctx, path, start, to.Sub(from), out)
+	})
+}
+
+// BufferSource cuts clips from a live stream buffer. Footage after the
+// alert has not arrived yet when it fires, so Cut waits until to has passed.
+func BufferSource(sp *processor.StreamProcessor, buf *processor.StreamBuffer) Source {
+	return SourceFunc(func(ctx context.Context, from, to time.Time, out string) error {
+		if wait := time.Until(to); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		return sp.ExportBuffer(ctx, buf, from, to, out)
+	})
+}
+
+// Sink stores an exported clip and its metadata, returning where the clip
+// was saved (a path or URL)
+type Sink interface {
+	Save(ctx context.Context, clip *Clip, video io.Reader) (string, error)
+}
+
+// SinkFunc adapts a function to Sink
+type SinkFunc func(ctx context.Context, clip *Clip, video io.Reader) (string, error)
+
+// Save implements Sink
+func (f SinkFunc) Save(ctx context.Context, clip *Clip, video io.Reader) (string, error) {
+	return f(ctx, clip, video)
+}
+
+// DirSink saves clips to dir as <name>.mp4 with a <name>.json sidecar
+// holding the alert and analysis
+func DirSink(dir string) Sink {
+	return SinkFunc(func(_ context.Context, clip *Clip, video io.Reader) (string, error) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create evidence directory: %w", err)
+		}
+		path := filepath.Join(dir, clip.Name+".mp4")
+		f, err := os.Create(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to save clip: %w", err)
+		}
+		if _, err := io.Copy(f, video); err != nil {
+			f.Close()
+			return "", fmt.Errorf("failed to save clip: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return "", fmt.Errorf("failed to save clip: %w", err)
+		}
+
+		clip.Location = path
+		meta, err := json.MarshalIndent(clip, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal clip metadata: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, clip.Name+".json"), meta, 0644); err != nil {
+			return "", fmt.Errorf("failed to save clip metadata: %w", err)
+		}
+		return path, nil
+	})
+}
+
+// Clip describes an exported evidence clip
+type Clip struct {
+	Name     string      `json:"name"`     // File name without extension
+	Source   string      `json:"source"`   // Alert source (camera / stream)
+	From     time.Time   `json:"from"`     // Wall time of the first frame
+	To       time.Time   `json:"to"`       // Wall time of the end of the clip
+	Event    time.Time   `json:"event"`    // Wall time of the alerted footage
+	Alert    alert.Alert `json:"alert"`    // The alert, including the analysis in Content
+	Location string      `json:"location"` // Where the sink saved the clip
+}
+
+// Exporter is an alert action that exports Before/After seconds of footage
+// around each alert from the source registered for the alert's Source
+type Exporter struct {
+	Sources map[string]Source // Footage sources by alert source
+	Default Source            // Used for sources without an entry (optional)
+	Sink    Sink
+	Before  time.Duration // Footage before the event (default 10s)
+	After   time.Duration // Footage after the event (default 10s)
+	TempDir string        // Where clips are encoded before Save (default os.TempDir)
+
+	// OnExport is called with every saved clip (optional)
+	OnExport func(clip Clip)
+
+	mu sync.Mutex
+}
+
+// NewExporter creates an exporter saving to sink with 10s before and after
+func NewExporter(sink Sink) *Exporter {
+	return &Exporter{
+		Sources: make(map[string]Source),
+		Sink:    sink,
+		Before:  10 * time.Second,
+		After:   10 * time.Second,
+	}
+}
+
+// Fire implements alert.Action. It blocks until the clip is saved, which for
+// live buffers includes waiting for the After footage; wrap it in a
+// goroutine-spawning ActionFunc to keep the engine responsive.
+func (e *Exporter) Fire(ctx context.Context, a alert.Alert) error {
+	_, err := e.Export(ctx, a)
+	return err
+}
+
+// Export cuts and saves the clip for an alert
+func (e *Exporter) Export(ctx context.Context, a alert.Alert) (*Clip, error) {
+	e.mu.Lock()
+	src, ok := e.Sources[a.Source]
+	e.mu.Unlock()
+	if !ok {
+		src = e.Default
+	}
+	if src == nil {
+		return nil, fmt.Errorf("no footage source for %q", a.Source)
+	}
+
+	before, after := e.Before, e.After
+	if before <= 0 {
+		before = 10 * time.Second
+	}
+	if after <= 0 {
+		after = 10 * time.Second
+	}
+	event := a.At
+	if event.IsZero() {
+		event = a.Time
+	}
+	clip := &Clip{
+		Name:   clipName(a, event),
+		Source: a.Source,
+		From:   event.Add(-before),
+		To:     event.Add(after),
+		Event:  event,
+		Alert:  a,
+	}
+
+	tmp, err := os.MkdirTemp(e.TempDir, "evidence-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, clip.Name+".mp4")
+	if err := src.Cut(ctx, clip.From, clip.To, path); err != nil {
+		return nil, fmt.Errorf("failed to cut clip for %s: %w", a.Source, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open clip: %w", err)
+	}
+	defer f.Close()
+	location, err := e.Sink.Save(ctx, clip, f)
+	if err != nil {
+		return nil, err
+	}
+	clip.Location = location
+	if e.OnExport != nil {
+		e.OnExport(*clip)
+	}
+	return clip, nil
+}
+
+// SetSource registers the footage source of an alert source
+func (e *Exporter) SetSource(name string, src Source) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.Sources == nil {
+		e.Sources = make(map[string]Source)
+	}
+	e.Sources[name] = src
+}
+
+// clipName builds a file name such as "cam1-intrusion-20250601T080512"
+func clipName(a alert.Alert, event time.Time) string {
+	name := fmt.Sprintf("%s-%s-%s", a.Source, a.Rule, event.Format("20060102T150405"))
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
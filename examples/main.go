@@ -26,6 +26,7 @@ func main() {
 	}
 
 	// 创建客户端
+	_ = client.LoadDotEnv(".env", "../.env")
 	c := client.NewClient("")
 	if c.APIKey == "" {
 		log.Fatal("请设置 ZHIPU_API_KEY 环境变量")
@@ -97,6 +98,7 @@ func exampleRealtimeStreamProcessing() {
 	extractor.Start(streamReader)
 
 	// 创建客户端
+	_ = client.LoadDotEnv(".env", "../.env")
 	c := client.NewClient("")
 
 	// 在后台处理提取的帧
@@ -0,0 +1,200 @@
+// Package experiment splits production analysis traffic between named
+// prompt/model variants and aggregates comparative metrics, so prompts can
+// be iterated on safely.
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Variant is one arm of an experiment
+type Variant struct {
+	Name    string
+	Weight  float64             // Relative share of traffic
+	Model   string              // Empty uses the experiment client's model
+	Prompt  string              // Template; {prompt} is replaced by the job prompt. Empty passes the job prompt through.
+	Options *client.ChatOptions // Optional chat options
+}
+
+// render builds the variant prompt for a job prompt
+func (v *Variant) render(prompt string) string {
+	if v.Prompt == "" {
+		return prompt
+	}
+	return strings.ReplaceAll(v.Prompt, "{prompt}", prompt)
+}
+
+// Result is the outcome of one job, tagged with its variant
+type Result struct {
+	Experiment string
+	Variant    string
+	Response   *models.ChatResponse
+	Latency    time.Duration
+	Err        error
+}
+
+// Stats are the aggregated metrics of one variant
+type Stats struct {
+	Variant      string
+	Calls        int
+	Errors       int
+	Tokens       int
+	Cost         float64
+	MeanLatency  time.Duration
+	Scored       int     // Jobs with feedback
+	MeanScore    float64 // Mean feedback score
+	totalLatency time.Duration
+	totalScore   float64
+}
+
+// Experiment routes jobs to variants by weight
+type Experiment struct {
+	Name     string
+	Client   *client.Client
+	Variants []Variant
+
+	mu      sync.Mutex
+	clients map[string]*client.Client
+	stats   map[string]*Stats
+	rand    *rand.Rand
+}
+
+// New creates an experiment; weights do not need to sum to 1
+func New(name string, c *client.Client, variants ...Variant) (*Experiment, error) {
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("experiment %s has no variants", name)
+	}
+
+	e := &Experiment{
+		Name:     name,
+		Client:   c,
+		Variants: variants,
+		clients:  map[string]*client.Client{},
+		stats:    map[string]*Stats{},
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, v := range variants {
+		if v.Name == "" {
+			return nil, fmt.Errorf("experiment %s: variant name is required", name)
+		}
+		if v.Weight < 0 {
+			return nil, fmt.Errorf("experiment %s: variant %s has negative weight", name, v.Name)
+		}
+		if _, dup := e.stats[v.Name]; dup {
+			return nil, fmt.Errorf("experiment %s: duplicate variant %s", name, v.Name)
+		}
+		e.stats[v.Name] = &Stats{Variant: v.Name}
+
+		cc := *c
+		if v.Model != "" {
+			cc.Model = v.Model
+		}
+		e.clients[v.Name] = &cc
+	}
+	return e, nil
+}
+
+// Assign picks a variant. A non-empty key (camera ID, user ID...) always
+// maps to the same variant; an empty key picks randomly.
+func (e *Experiment) Assign(key string) *Variant {
+	total := 0.0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+
+	var point float64
+	if key != "" {
+		h := fnv.New64a()
+		h.Write([]byte(e.Name + "/" + key))
+		point = float64(h.Sum64()%1_000_000) / 1_000_000
+	} else {
+		e.mu.Lock()
+		point = e.rand.Float64()
+		e.mu.Unlock()
+	}
+
+	if total <= 0 {
+		return &e.Variants[int(point*float64(len(e.Variants)))]
+	}
+	point *= total
+	for i := range e.Variants {
+		point -= e.Variants[i].Weight
+		if point < 0 {
+			return &e.Variants[i]
+		}
+	}
+	return &e.Variants[len(e.Variants)-1]
+}
+
+// Analyze routes one job to a variant and records its metrics
+func (e *Experiment) Analyze(ctx context.Context, key, prompt string, frames [][]byte) *Result {
+	v := e.Assign(key)
+	c := e.clients[v.Name]
+
+	start := time.Now()
+	var resp *models.ChatResponse
+	var err error
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	} else {
+		resp, err = c.AnalyzeFramesWithOptions(v.render(prompt), frames, v.Options)
+	}
+	res := &Result{Experiment: e.Name, Variant: v.Name, Response: resp, Latency: time.Since(start), Err: err}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.stats[v.Name]
+	s.Calls++
+	s.totalLatency += res.Latency
+	if err != nil {
+		s.Errors++
+		return res
+	}
+	s.Tokens += resp.Usage.TotalTokens
+	s.Cost += models.ModelCost(c.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	return res
+}
+
+// Feedback records a quality score (e.g. 0-1 from human review or an
+// automatic check) for a result of a variant
+func (e *Experiment) Feedback(variant string, score float64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s, ok := e.stats[variant]
+	if !ok {
+		return fmt.Errorf("unknown variant %s", variant)
+	}
+	s.Scored++
+	s.totalScore += score
+	return nil
+}
+
+// Stats returns the metrics of all variants, sorted by name
+func (e *Experiment) Stats() []Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	out := make([]Stats, 0, len(e.stats))
+	for _, s := range e.stats {
+		cp := *s
+		if cp.Calls > 0 {
+			cp.MeanLatency = cp.totalLatency / time.Duration(cp.Calls)
+		}
+		if cp.Scored > 0 {
+			cp.MeanScore = cp.totalScore / float64(cp.Scored)
+		}
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Variant < out[j].Variant })
+	return out
+}
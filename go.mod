@@ -6,4 +6,6 @@ toolchain go1.24.3
 
 require github.com/joho/godotenv v1.5.1
 
-require golang.org/x/image v0.33.0 // indirect
+require golang.org/x/image v0.33.0
+
+require golang.org/x/text v0.31.0 // indirect
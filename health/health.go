@@ -0,0 +1,190 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+	"github.com/t8y2/zhipu-video-sdk/videotest"
+)
+
+// Status of a check
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report is the structured health report
+type Report struct {
+	Healthy bool          `json:"healthy"`
+	Time    time.Time     `json:"time"`
+	Checks  []CheckResult `json:"checks"`
+}
+
+// Options selects which checks run
+type Options struct {
+	SkipAPIKey bool // Skip the authentication probe
+	EndToEnd   bool // Send one tiny analysis request (consumes tokens)
+}
+
+// Check runs the health checks against a client:
+// ffmpeg/ffprobe availability, temp dir writability, API key validity and
+// optionally an end-to-end request
+func Check(ctx context.Context, c *client.Client, opts Options) *Report {
+	report := &Report{Time: time.Now(), Healthy: true}
+
+	add := func(name string, fn func() (Status, string)) {
+		start := time.Now()
+		status, msg := fn()
+		report.Checks = append(report.Checks, CheckResult{
+			Name:     name,
+			Status:   status,
+			Message:  msg,
+			Duration: time.Since(start),
+		})
+		if status == StatusFailed {
+			report.Healthy = false
+		}
+	}
+
+	add("ffmpeg", func() (Status, string) { return checkBinary(ctx, "ffmpeg") })
+	add("ffprobe", func() (Status, string) { return checkBinary(ctx, "ffprobe") })
+	add("temp_dir", checkTempDir)
+
+	add("api_key", func() (Status, string) {
+		if opts.SkipAPIKey {
+			return StatusSkipped, ""
+		}
+		return checkAPIKey(ctx, c)
+	})
+
+	add("end_to_end", func() (Status, string) {
+		if !opts.EndToEnd {
+			return StatusSkipped, ""
+		}
+		return checkEndToEnd(c)
+	})
+
+	return report
+}
+
+// checkBinary verifies that a binary exists and runs
+func checkBinary(ctx context.Context, name string) (Status, string) {
+	path, err := processor.LookTool(name)
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("%s not found in PATH", name)
+	}
+
+	out, err := exec.CommandContext(ctx, path, "-version").Output()
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("%s -version failed: %v", name, err)
+	}
+
+	version := strings.SplitN(string(out), "\n", 2)[0]
+	return StatusOK, version
+}
+
+// checkTempDir verifies the temp dir is writable
+func checkTempDir() (Status, string) {
+	dir, err := os.MkdirTemp("", "zhipu-health-*")
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("cannot create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "probe"), []byte("ok"), 0644); err != nil {
+		return StatusFailed, fmt.Sprintf("cannot write temp file: %v", err)
+	}
+	return StatusOK, os.TempDir()
+}
+
+// checkAPIKey sends an intentionally empty request: the API rejects it with
+// 400 when the key is valid and 401 when it is not, without consuming tokens
+func checkAPIKey(ctx context.Context, c *client.Client) (Status, string) {
+	key, err := c.ResolveAPIKey(ctx)
+	if err != nil {
+		return StatusFailed, err.Error()
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"model": c.Model, "messages": []interface{}{}})
+	req, err := http.NewRequestWithContext(ctx, "POST", c.APIURL, bytes.NewReader(body))
+	if err != nil {
+		return StatusFailed, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+key)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("API unreachable: %v", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return StatusFailed, fmt.Sprintf("API key rejected (status %d)", resp.StatusCode)
+	case http.StatusTooManyRequests:
+		return StatusOK, "API key accepted (rate limited)"
+	}
+	if resp.StatusCode >= 500 {
+		return StatusFailed, fmt.Sprintf("API server error (status %d)", resp.StatusCode)
+	}
+	return StatusOK, "API key accepted"
+}
+
+// checkEndToEnd sends a tiny synthetic frame for analysis
+func checkEndToEnd(c *client.Client) (Status, string) {
+	frames, err := videotest.Frames(videotest.Options{Width: 56, Height: 56, Frames: 1})
+	if err != nil {
+		return StatusFailed, err.Error()
+	}
+
+	maxTokens := 8
+	resp, err := c.AnalyzeFramesWithOptions("Reply with OK.", frames, &client.ChatOptions{MaxTokens: &maxTokens})
+	if err != nil {
+		return StatusFailed, err.Error()
+	}
+	if len(resp.Choices) == 0 {
+		return StatusFailed, "empty response"
+	}
+	return StatusOK, fmt.Sprintf("model %s responded (%d tokens)", resp.Model, resp.Usage.TotalTokens)
+}
+
+// Handler serves the report as JSON, with 200 when healthy and 503
+// otherwise; suitable for Kubernetes readiness probes (/healthz).
+// The query parameter ?e2e=1 enables the end-to-end check.
+func Handler(c *client.Client, opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o := opts
+		if r.URL.Query().Get("e2e") == "1" {
+			o.EndToEnd = true
+		}
+
+		report := Check(r.Context(), c, o)
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,182 @@
+// Package jobs tracks the state of analysis jobs so long-running batches
+// can be resumed after a crash or restart, and hands jobs out to workers
+// under leases so several instances can share one queue.
+package jobs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Status is the lifecycle state of a job
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// Job is one unit of work with its outcome
+type Job struct {
+	ID        string          `json:"id"`
+	Status    Status          `json:"status"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Labels    models.Labels   `json:"labels,omitempty"` // Origin tags, copied to the job's results
+	Attempts  int             `json:"attempts"`
+	UpdatedAt time.Time       `json:"updated_at"`
+
+	Owner      string    `json:"owner,omitempty"`      // Worker holding the lease of a running job
+	LeaseUntil time.Time `json:"lease_until,omitzero"` // When another worker may take over a running job
+}
+
+// Store persists jobs
+type Store interface {
+	Get(id string) (*Job, bool, error)
+	Put(job *Job) error
+	List() ([]*Job, error)
+}
+
+// FileStore keeps jobs in memory and appends every update to a JSONL log;
+// on open the log is replayed and the latest state of each job wins
+type FileStore struct {
+	mu   sync.Mutex
+	f    *os.File
+	jobs map[string]*Job
+}
+
+// OpenFileStore opens (or creates) a job log
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{jobs: map[string]*Job{}}
+
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var job Job
+			if json.Unmarshal(scanner.Bytes(), &job) == nil && job.ID != "" {
+				s.jobs[job.ID] = &job
+			}
+		}
+		err := scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read job log: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open job log: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job log: %w", err)
+	}
+	s.f = f
+	return s, nil
+}
+
+// Get implements Store
+func (s *FileStore) Get(id string) (*Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, false, nil
+	}
+	cp := *job
+	return &cp, true, nil
+}
+
+// Put implements Store
+func (s *FileStore) Put(job *Job) error {
+	if job.ID == "" {
+		return fmt.Errorf("job id is required")
+	}
+	cp := *job
+	if cp.UpdatedAt.IsZero() {
+		cp.UpdatedAt = time.Now()
+	}
+	line, err := json.Marshal(&cp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append job: %w", err)
+	}
+	s.jobs[cp.ID] = &cp
+	return nil
+}
+
+// List implements Store; jobs are sorted by ID
+func (s *FileStore) List() ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		cp := *job
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// Compact rewrites the log with only the latest state of every job
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.f.Name()
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted log: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, job := range s.jobs {
+		if err := enc.Encode(job); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write compacted log: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// Windows cannot replace a file that is still open
+	s.f.Close()
+	if err := os.Rename(tmp, path); err != nil {
+		s.f, _ = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		return fmt.Errorf("failed to replace job log: %w", err)
+	}
+	s.f, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	return err
+}
+
+// Close closes the log
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// Pending reports whether a job still needs to run: it is unknown, or it
+// did not finish successfully (interrupted runs leave jobs "running")
+func Pending(store Store, id string) (bool, error) {
+	job, ok, err := store.Get(id)
+	if err != nil {
+		return false, err
+	}
+	return !ok || job.Status != StatusDone, nil
+}
@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PostgresQueue is a Queue and Store in PostgreSQL, shared by workers on
+// any number of machines. Claims use SELECT ... FOR UPDATE SKIP LOCKED, so
+// concurrent workers never receive the same job, and lease expiry is
+// compared against the database clock, so clock skew between workers does
+// not matter. The application opens the database with the driver of its
+// choice:
+//
+//	db, err := sql.Open("pgx", os.Getenv("DATABASE_URL")) // github.com/jackc/pgx/v5/stdlib
+//	q, err := jobs.OpenPostgresQueue(ctx, db)
+//	q.Enqueue(ctx, &jobs.Job{ID: "lobby-0800.mp4#summary", Input: input})
+//	(&jobs.Worker{Queue: q, Handler: analyze}).Run(ctx)
+type PostgresQueue struct {
+	MaxAttempts int // Expired leases fail the job after this many claims (0: no limit)
+
+	db *sql.DB
+}
+
+// postgresSchema creates the job table; input, result and labels are JSON
+// text so every driver reads them back the same way
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS analysis_jobs (
+	id          TEXT        PRIMARY KEY,
+	status      TEXT        NOT NULL,
+	input       TEXT        NOT NULL DEFAULT '',
+	result      TEXT        NOT NULL DEFAULT '',
+	error       TEXT        NOT NULL DEFAULT '',
+	labels      TEXT        NOT NULL DEFAULT '',
+	attempts    INTEGER     NOT NULL DEFAULT 0,
+	owner       TEXT        NOT NULL DEFAULT '',
+	lease_until TIMESTAMPTZ,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS analysis_jobs_runnable ON analysis_jobs (status, created_at);
+`
+
+// jobColumns are read by scanJob
+const jobColumns = `id, status, input, result, error, labels, attempts, owner, lease_until, updated_at`
+
+// OpenPostgresQueue creates the job table in db if needed
+func OpenPostgresQueue(ctx context.Context, db *sql.DB) (*PostgresQueue, error) {
+	for _, stmt := range strings.Split(postgresSchema, ";") {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("failed to create job table: %w", err)
+		}
+	}
+	return &PostgresQueue{db: db}, nil
+}
+
+// Enqueue implements Queue
+func (q *PostgresQueue) Enqueue(ctx context.Context, job *Job) (bool, error) {
+	if job.ID == "" {
+		return false, fmt.Errorf("job id is required")
+	}
+	labels, err := encodeLabels(job)
+	if err != nil {
+		return false, err
+	}
+	res, err := q.db.ExecContext(ctx,
+		`INSERT INTO analysis_jobs (id, status, input, labels) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO NOTHING`,
+		job.ID, StatusPending, string(job.Input), labels)
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Claim implements Queue; jobs are taken in the order they were added
+func (q *PostgresQueue) Claim(ctx context.Context, worker string, lease time.Duration) (*Job, error) {
+	if q.MaxAttempts > 0 {
+		_, err := q.db.ExecContext(ctx,
+			`UPDATE analysis_jobs
+			SET status = $1, error = 'lease expired after ' || attempts || ' attempts', owner = '', lease_until = NULL, updated_at = now()
+			WHERE status = $2 AND lease_until < now() AND attempts >= $3`,
+			StatusFailed, StatusRunning, q.MaxAttempts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expire jobs: %w", err)
+		}
+	}
+	row := q.db.QueryRowContext(ctx,
+		`UPDATE analysis_jobs
+		SET status = $1, owner = $2, lease_until = now() + make_interval(secs => $3), attempts = attempts + 1, updated_at = now()
+		WHERE id = (
+			SELECT id FROM analysis_jobs
+			WHERE status = $4 OR (status = $1 AND (lease_until IS NULL OR lease_until < now()))
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns,
+		StatusRunning, worker, lease.Seconds(), StatusPending)
+	job, err := scanJob(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoJobs
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job, nil
+}
+
+// Extend implements Queue
+func (q *PostgresQueue) Extend(ctx context.Context, job *Job, lease time.Duration) error {
+	var until time.Time
+	err := q.db.QueryRowContext(ctx,
+		`UPDATE analysis_jobs SET lease_until = now() + make_interval(secs => $1), updated_at = now()
+		WHERE id = $2 AND status = $3 AND owner = $4 AND attempts = $5
+		RETURNING lease_until`,
+		lease.Seconds(), job.ID, StatusRunning, job.Owner, job.Attempts).Scan(&until)
+	if err == sql.ErrNoRows {
+		return ErrLeaseLost
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extend lease: %w", err)
+	}
+	job.LeaseUntil = until
+	return nil
+}
+
+// Complete implements Queue
+func (q *PostgresQueue) Complete(ctx context.Context, job *Job) error {
+	labels, err := encodeLabels(job)
+	if err != nil {
+		return err
+	}
+	res, err := q.db.ExecContext(ctx,
+		`UPDATE analysis_jobs SET status = $1, result = $2, error = $3, labels = $4, owner = '', lease_until = NULL, updated_at = now()
+		WHERE id = $5 AND status = $6 AND owner = $7 AND attempts = $8`,
+		job.Status, string(job.Result), job.Error, labels, job.ID, StatusRunning, job.Owner, job.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	} else if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Get implements Store
+func (q *PostgresQueue) Get(id string) (*Job, bool, error) {
+	job, err := scanJob(q.db.QueryRow(`SELECT `+jobColumns+` FROM analysis_jobs WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, true, nil
+}
+
+// Put implements Store; it overwrites the job regardless of leases
+func (q *PostgresQueue) Put(job *Job) error {
+	if job.ID == "" {
+		return fmt.Errorf("job id is required")
+	}
+	labels, err := encodeLabels(job)
+	if err != nil {
+		return err
+	}
+	var until sql.NullTime
+	if !job.LeaseUntil.IsZero() {
+		until = sql.NullTime{Time: job.LeaseUntil, Valid: true}
+	}
+	_, err = q.db.Exec(
+		`INSERT INTO analysis_jobs (id, status, input, result, error, labels, attempts, owner, lease_until, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())
+		ON CONFLICT (id) DO UPDATE SET status = $2, input = $3, result = $4, error = $5, labels = $6,
+			attempts = $7, owner = $8, lease_until = $9, updated_at = now()`,
+		job.ID, job.Status, string(job.Input), string(job.Result), job.Error, labels, job.Attempts, job.Owner, until)
+	if err != nil {
+		return fmt.Errorf("failed to put job: %w", err)
+	}
+	return nil
+}
+
+// List implements Store; jobs are sorted by ID
+func (q *PostgresQueue) List() ([]*Job, error) {
+	rows, err := q.db.Query(`SELECT ` + jobColumns + ` FROM analysis_jobs ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+	var out []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		out = append(out, job)
+	}
+	return out, rows.Err()
+}
+
+// encodeLabels returns the job's labels as JSON text
+func encodeLabels(job *Job) (string, error) {
+	if len(job.Labels) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(job.Labels)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode labels: %w", err)
+	}
+	return string(b), nil
+}
+
+// scanJob reads the jobColumns of a row
+func scanJob(row interface{ Scan(...interface{}) error }) (*Job, error) {
+	var (
+		job                   Job
+		status                string
+		input, result, labels string
+		until                 sql.NullTime
+	)
+	err := row.Scan(&job.ID, &status, &input, &result, &job.Error, &labels, &job.Attempts, &job.Owner, &until, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	job.Status = Status(status)
+	if input != "" {
+		job.Input = json.RawMessage(input)
+	}
+	if result != "" {
+		job.Result = json.RawMessage(result)
+	}
+	if labels != "" {
+		if err := json.Unmarshal([]byte(labels), &job.Labels); err != nil {
+			return nil, fmt.Errorf("failed to decode labels of job %s: %w", job.ID, err)
+		}
+	}
+	if until.Valid {
+		job.LeaseUntil = until.Time
+	}
+	return &job, nil
+}
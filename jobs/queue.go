@@ -0,0 +1,258 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoJobs    = errors.New("no jobs available") // Claim found nothing to run
+	ErrLeaseLost = errors.New("job lease lost")    // The lease expired and the job was taken over or finished
+)
+
+// Queue hands pending jobs to workers under leases. A claimed job is
+// invisible to other workers until its lease expires (the visibility
+// timeout); a worker that crashes or stalls stops renewing it, and the job
+// is claimed again elsewhere. A lease is identified by the job's Owner and
+// Attempts, so a worker whose lease expired cannot overwrite the outcome of
+// the worker that took over.
+type Queue interface {
+	// Enqueue adds a pending job; it reports false if the ID already exists
+	Enqueue(ctx context.Context, job *Job) (bool, error)
+	// Claim leases the oldest runnable job to worker, or returns ErrNoJobs
+	Claim(ctx context.Context, worker string, lease time.Duration) (*Job, error)
+	// Extend renews the lease of a claimed job
+	Extend(ctx context.Context, job *Job, lease time.Duration) error
+	// Complete stores the outcome of a claimed job and ends its lease;
+	// StatusPending puts the job back for another attempt
+	Complete(ctx context.Context, job *Job) error
+}
+
+// LocalQueue is a Queue over a Store for workers in one process, e.g. a
+// FileStore shared by goroutines. Use PostgresQueue across instances.
+type LocalQueue struct {
+	MaxAttempts int // Expired leases fail the job after this many claims (0: no limit)
+
+	mu    sync.Mutex
+	store Store
+}
+
+// NewLocalQueue creates a queue over store
+func NewLocalQueue(store Store) *LocalQueue {
+	return &LocalQueue{store: store}
+}
+
+// Enqueue implements Queue
+func (q *LocalQueue) Enqueue(ctx context.Context, job *Job) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok, err := q.store.Get(job.ID); err != nil || ok {
+		return false, err
+	}
+	cp := *job
+	cp.Status, cp.Owner, cp.LeaseUntil = StatusPending, "", time.Time{}
+	return true, q.store.Put(&cp)
+}
+
+// Claim implements Queue; jobs are taken in ID order
+func (q *LocalQueue) Claim(ctx context.Context, worker string, lease time.Duration) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	all, err := q.store.List()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, job := range all {
+		expired := job.Status == StatusRunning && job.LeaseUntil.Before(now)
+		if job.Status != StatusPending && !expired {
+			continue
+		}
+		if expired && q.MaxAttempts > 0 && job.Attempts >= q.MaxAttempts {
+			job.Status, job.Owner, job.LeaseUntil = StatusFailed, "", time.Time{}
+			job.Error = fmt.Sprintf("lease expired after %d attempts", job.Attempts)
+			job.UpdatedAt = now
+			if err := q.store.Put(job); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		job.Status, job.Owner, job.LeaseUntil = StatusRunning, worker, now.Add(lease)
+		job.Attempts++
+		job.UpdatedAt = now
+		if err := q.store.Put(job); err != nil {
+			return nil, err
+		}
+		return job, nil
+	}
+	return nil, ErrNoJobs
+}
+
+// leased returns the stored job if job still holds its lease
+func (q *LocalQueue) leased(job *Job) (*Job, error) {
+	cur, ok, err := q.store.Get(job.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || cur.Status != StatusRunning || cur.Owner != job.Owner || cur.Attempts != job.Attempts {
+		return nil, ErrLeaseLost
+	}
+	return cur, nil
+}
+
+// Extend implements Queue
+func (q *LocalQueue) Extend(ctx context.Context, job *Job, lease time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cur, err := q.leased(job)
+	if err != nil {
+		return err
+	}
+	cur.LeaseUntil = time.Now().Add(lease)
+	cur.UpdatedAt = time.Now()
+	if err := q.store.Put(cur); err != nil {
+		return err
+	}
+	job.LeaseUntil = cur.LeaseUntil
+	return nil
+}
+
+// Complete implements Queue
+func (q *LocalQueue) Complete(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, err := q.leased(job); err != nil {
+		return err
+	}
+	cp := *job
+	cp.Owner, cp.LeaseUntil = "", time.Time{}
+	cp.UpdatedAt = time.Now()
+	return q.store.Put(&cp)
+}
+
+// Worker claims jobs from a queue and runs them, renewing the lease while
+// the handler works:
+//
+//	w := &jobs.Worker{Queue: q, Retries: 2, Handler: func(ctx context.Context, job *jobs.Job) (json.RawMessage, error) {
+//		var in Input
+//		json.Unmarshal(job.Input, &in)
+//		return analyze(ctx, in)
+//	}}
+//	w.Run(ctx)
+//
+// Start any number of workers against the same queue; each job runs on
+// one of them at a time.
+type Worker struct {
+	Queue   Queue
+	Handler func(ctx context.Context, job *Job) (json.RawMessage, error)
+
+	ID      string        // Lease owner (default hostname:pid)
+	Lease   time.Duration // Visibility timeout, renewed every Lease/3 (default 5m)
+	Poll    time.Duration // Wait when the queue is empty (default 5s)
+	Retries int           // Failed runs are put back this many times
+
+	// OnError reports queue errors and lost leases; job is nil for Claim
+	// errors. Workers keep running after them.
+	OnError func(job *Job, err error)
+}
+
+// Run processes jobs until ctx is cancelled. A job interrupted by the
+// cancellation is put back in the queue for another worker.
+func (w *Worker) Run(ctx context.Context) error {
+	if w.Queue == nil || w.Handler == nil {
+		return fmt.Errorf("worker requires a queue and a handler")
+	}
+	id := w.ID
+	if id == "" {
+		host, _ := os.Hostname()
+		id = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	lease, poll := w.Lease, w.Poll
+	if lease <= 0 {
+		lease = 5 * time.Minute
+	}
+	if poll <= 0 {
+		poll = 5 * time.Second
+	}
+
+	for ctx.Err() == nil {
+		job, err := w.Queue.Claim(ctx, id, lease)
+		if err != nil {
+			if !errors.Is(err, ErrNoJobs) && ctx.Err() == nil {
+				w.report(nil, fmt.Errorf("failed to claim job: %w", err))
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(poll):
+			}
+			continue
+		}
+		w.process(ctx, job, lease)
+	}
+	return nil
+}
+
+// process runs one claimed job and stores its outcome
+func (w *Worker) process(ctx context.Context, job *Job, lease time.Duration) {
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			hb := *job
+			if err := w.Queue.Extend(jobCtx, &hb, lease); errors.Is(err, ErrLeaseLost) {
+				cancel(ErrLeaseLost)
+				return
+			} else if err != nil && jobCtx.Err() == nil {
+				w.report(job, fmt.Errorf("failed to extend lease: %w", err))
+			}
+		}
+	}()
+	result, err := w.Handler(jobCtx, job)
+	lost := errors.Is(context.Cause(jobCtx), ErrLeaseLost)
+	cancel(nil)
+	wg.Wait()
+	if lost {
+		w.report(job, ErrLeaseLost)
+		return
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		job.Status = StatusPending // Shutting down; let another worker run it
+	case err != nil:
+		job.Status, job.Error = StatusFailed, err.Error()
+		if job.Attempts <= w.Retries {
+			job.Status = StatusPending
+		}
+	default:
+		job.Status, job.Result, job.Error = StatusDone, result, ""
+	}
+	// The outcome is stored even when ctx was cancelled
+	saveCtx, done := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer done()
+	if err := w.Queue.Complete(saveCtx, job); err != nil {
+		w.report(job, fmt.Errorf("failed to complete job: %w", err))
+	}
+}
+
+func (w *Worker) report(job *Job, err error) {
+	if w.OnError != nil {
+		w.OnError(job, err)
+	}
+}
@@ -0,0 +1,208 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// ErrShuttingDown is returned by Track once shutdown has started
+var ErrShuttingDown = errors.New("shutting down")
+
+// hook is a named shutdown step
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager coordinates graceful shutdown of pipeline components.
+// On shutdown it runs, in order:
+//  1. stop sources (no new data enters the pipeline)
+//  2. drain extractor channels
+//  3. wait for in-flight API calls (bounded by the shutdown deadline)
+//  4. flush sinks
+//  5. clean up temp directories and other resources
+type Manager struct {
+	mu       sync.Mutex
+	sources  []hook
+	drainers []hook
+	sinks    []hook
+	cleanups []hook
+
+	inflight sync.WaitGroup
+	closing  bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	once     sync.Once
+	err      error
+}
+
+// NewManager creates a lifecycle manager
+func NewManager() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{ctx: ctx, cancel: cancel}
+}
+
+// Context is cancelled as soon as shutdown begins; long-running components
+// should derive their contexts from it
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// OnStopSource registers a function stopping a source
+func (m *Manager) OnStopSource(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sources = append(m.sources, hook{name, fn})
+}
+
+// OnDrain registers a function draining buffered data
+func (m *Manager) OnDrain(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drainers = append(m.drainers, hook{name, fn})
+}
+
+// OnFlushSink registers a function flushing a sink
+func (m *Manager) OnFlushSink(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sinks = append(m.sinks, hook{name, fn})
+}
+
+// OnCleanup registers a final cleanup function
+func (m *Manager) OnCleanup(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cleanups = append(m.cleanups, hook{name, fn})
+}
+
+// Track marks the start of an in-flight API call; call the returned
+// function when it completes. ErrShuttingDown is returned after shutdown
+// has started so no new calls are issued.
+func (m *Manager) Track() (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closing {
+		return nil, ErrShuttingDown
+	}
+	m.inflight.Add(1)
+	var once sync.Once
+	return func() { once.Do(m.inflight.Done) }, nil
+}
+
+// RegisterExtractor stops the extractor as a source and drains its frame
+// and error channels so its goroutine can exit. onFrame may be nil to
+// discard remaining frames.
+func (m *Manager) RegisterExtractor(name string, sfe *processor.StreamFrameExtractor, onFrame func([]byte)) {
+	drained := make(chan struct{})
+
+	m.OnStopSource(name, func(ctx context.Context) error {
+		go func() {
+			defer close(drained)
+			frames, errs := sfe.GetFrameChannel(), sfe.GetErrorChannel()
+			for frames != nil || errs != nil {
+				select {
+				case f, ok := <-frames:
+					if !ok {
+						frames = nil
+					} else if onFrame != nil {
+						onFrame(f)
+					}
+				case _, ok := <-errs:
+					if !ok {
+						errs = nil
+					}
+				}
+			}
+		}()
+		sfe.Stop()
+		return nil
+	})
+
+	m.OnDrain(name, func(ctx context.Context) error {
+		select {
+		case <-drained:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("drain %s: %w", name, ctx.Err())
+		}
+	})
+}
+
+// RegisterProcessor removes the processor's temp files during cleanup
+func (m *Manager) RegisterProcessor(name string, sp *processor.StreamProcessor) {
+	m.OnCleanup(name, func(ctx context.Context) error {
+		return sp.Cleanup()
+	})
+}
+
+// Shutdown runs all registered steps in order. It is safe to call more
+// than once; later calls return the first result.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() {
+		m.mu.Lock()
+		m.closing = true
+		sources := append([]hook(nil), m.sources...)
+		drainers := append([]hook(nil), m.drainers...)
+		sinks := append([]hook(nil), m.sinks...)
+		cleanups := append([]hook(nil), m.cleanups...)
+		m.mu.Unlock()
+
+		m.cancel()
+
+		var errs []error
+		run := func(phase string, hooks []hook) {
+			for _, h := range hooks {
+				if err := h.fn(ctx); err != nil {
+					errs = append(errs, fmt.Errorf("%s %s: %w", phase, h.name, err))
+				}
+			}
+		}
+
+		run("stop source", sources)
+		run("drain", drainers)
+
+		done := make(chan struct{})
+		go func() {
+			m.inflight.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("waiting for in-flight calls: %w", ctx.Err()))
+		}
+
+		run("flush sink", sinks)
+
+		// Cleanup always gets a chance to run, even past the deadline
+		run("cleanup", cleanups)
+
+		m.err = errors.Join(errs...)
+	})
+	return m.err
+}
+
+// Run blocks until SIGINT/SIGTERM is received or ctx is done, then shuts
+// down with the given timeout
+func (m *Manager) Run(ctx context.Context, timeout time.Duration) error {
+	sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case <-sigCtx.Done():
+	case <-m.ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return m.Shutdown(shutdownCtx)
+}
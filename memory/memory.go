@@ -0,0 +1,182 @@
+// Package memory persists conversation history and rolling summaries per
+// stream or session, so chat sessions and monitors keep the context they
+// have accumulated across restarts:
+//
+//	mem, _ := memory.NewFileStore("/var/lib/zhipu/memory")
+//	s, _ := c.NewChatSession(ctx, "clip-42", frames, mem)
+//	n := monitor.NewNarrator(c, monitor.NarratorConfig{Source: "cam1", Memory: mem})
+//
+// A Redis-backed Memory is available as connect.RedisMemory.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// ErrNotFound is returned by Load when nothing is stored under a key
+var ErrNotFound = errors.New("conversation not found")
+
+// Conversation is the context accumulated for one session or stream
+type Conversation struct {
+	Key      string           `json:"key"`
+	Messages []models.Message `json:"messages,omitempty"` // Recent chat turns, oldest first, without frames
+	Summary  string           `json:"summary,omitempty"`  // Rolling summary of turns dropped from Messages
+	Notes    []string         `json:"notes,omitempty"`    // Carried-over answers, e.g. a narrator's history
+	Updated  time.Time        `json:"updated"`
+}
+
+// Memory stores conversations by key (session ID, stream or camera name)
+type Memory interface {
+	// Load returns the conversation stored under key, or ErrNotFound
+	Load(ctx context.Context, key string) (*Conversation, error)
+	// Save replaces the conversation stored under c.Key
+	Save(ctx context.Context, c *Conversation) error
+	// Delete forgets key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Clone returns a deep copy of c, so stores never share slices with callers
+func (c *Conversation) Clone() *Conversation {
+	out := *c
+	out.Messages = append([]models.Message(nil), c.Messages...)
+	for i, m := range out.Messages {
+		out.Messages[i].Content = append([]models.Content(nil), m.Content...)
+	}
+	out.Notes = append([]string(nil), c.Notes...)
+	return &out
+}
+
+// MemoryStore keeps conversations in process memory; contents are lost on
+// restart, which makes it suitable for tests and short-lived sessions
+type MemoryStore struct {
+	mu            sync.Mutex
+	conversations map[string]*Conversation
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{conversations: make(map[string]*Conversation)}
+}
+
+// Load implements Memory
+func (s *MemoryStore) Load(_ context.Context, key string) (*Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return c.Clone(), nil
+}
+
+// Save implements Memory
+func (s *MemoryStore) Save(_ context.Context, c *Conversation) error {
+	if c.Key == "" {
+		return fmt.Errorf("conversation key is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conversations[c.Key] = c.Clone()
+	return nil
+}
+
+// Delete implements Memory
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conversations, key)
+	return nil
+}
+
+// FileStore keeps one JSON file per conversation in a directory. Files are
+// replaced atomically, so a crash never leaves a half-written conversation.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates dir if needed and stores conversations in it
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create memory directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path maps a key to its file; keys are escaped so "site/cam1" stays in dir
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
+
+// Load implements Memory
+func (s *FileStore) Load(_ context.Context, key string) (*Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation: %w", err)
+	}
+	var c Conversation
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation %s: %w", key, err)
+	}
+	return &c, nil
+}
+
+// Save implements Memory
+func (s *FileStore) Save(_ context.Context, c *Conversation) error {
+	if c.Key == "" {
+		return fmt.Errorf("conversation key is required")
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversation: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(c.Key)
+	tmp, err := os.CreateTemp(s.dir, ".conversation-*")
+	if err != nil {
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	return nil
+}
+
+// Delete implements Memory
+func (s *FileStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return nil
+}
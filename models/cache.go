@@ -0,0 +1,104 @@
+package models
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// EncodingCache memoizes the base64 data URIs of frames by content hash,
+// so a frame sent again (later turns of a conversation, several prompts
+// about the same window) isn't re-encoded. The URI strings are shared, not
+// copied, between requests. A nil cache encodes every time.
+type EncodingCache struct {
+	MaxEntries int // Frames kept, least recently used evicted first (default 256)
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	lru     list.List
+	hits    int
+	misses  int
+}
+
+// cachedURI is one entry of an EncodingCache
+type cachedURI struct {
+	key [sha256.Size]byte
+	uri string
+}
+
+// NewEncodingCache creates a cache holding up to maxEntries frames
+// (0 uses the default)
+func NewEncodingCache(maxEntries int) *EncodingCache {
+	return &EncodingCache{MaxEntries: maxEntries}
+}
+
+// Image is ImageBase64 with the data URI taken from the cache when the
+// same bytes were encoded before
+func (c *EncodingCache) Image(data []byte, detail Detail) Content {
+	if c == nil {
+		return ImageBase64(data, detail)
+	}
+	key := sha256.Sum256(data)
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		c.hits++
+		uri := el.Value.(*cachedURI).uri
+		c.mu.Unlock()
+		return Content{Type: ContentTypeImageURL, ImageURL: &ImageURL{URL: uri, Detail: detail}}
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	// Encode outside the lock; a concurrent miss on the same frame only
+	// costs one redundant encoding
+	part := ImageBase64(data, detail)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[[sha256.Size]byte]*list.Element)
+	}
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.lru.PushFront(&cachedURI{key: key, uri: part.ImageURL.URL})
+		c.evict()
+	}
+	return part
+}
+
+// Frames is the cached counterpart of the package-level Frames
+func (c *EncodingCache) Frames(frames [][]byte) []Content {
+	if c == nil {
+		return Frames(frames)
+	}
+	parts := make([]Content, len(frames))
+	for i, f := range frames {
+		parts[i] = c.Image(f, DetailHigh)
+	}
+	return parts
+}
+
+// Stats returns the number of cache hits and misses so far
+func (c *EncodingCache) Stats() (hits, misses int) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// evict drops the least recently used entries beyond MaxEntries; callers
+// hold c.mu
+func (c *EncodingCache) evict() {
+	maxEntries := c.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 256
+	}
+	for c.lru.Len() > maxEntries {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*cachedURI).key)
+	}
+}
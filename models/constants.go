@@ -0,0 +1,98 @@
+package models
+
+import "fmt"
+
+// Role is the author of a chat message
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	switch r {
+	case RoleSystem, RoleUser, RoleAssistant:
+		return true
+	}
+	return false
+}
+
+// ContentType is the kind of a message content part
+type ContentType string
+
+const (
+	ContentTypeText     ContentType = "text"
+	ContentTypeImageURL ContentType = "image_url"
+	ContentTypeVideoURL ContentType = "video_url"
+)
+
+// Valid reports whether t is a known content type
+func (t ContentType) Valid() bool {
+	switch t {
+	case ContentTypeText, ContentTypeImageURL, ContentTypeVideoURL:
+		return true
+	}
+	return false
+}
+
+// Detail is the image detail level requested from the model
+type Detail string
+
+const (
+	DetailAuto Detail = "auto"
+	DetailLow  Detail = "low"
+	DetailHigh Detail = "high"
+)
+
+// Valid reports whether d is a known detail level; empty means the API default
+func (d Detail) Valid() bool {
+	switch d {
+	case "", DetailAuto, DetailLow, DetailHigh:
+		return true
+	}
+	return false
+}
+
+// FinishReason explains why the model stopped generating
+type FinishReason string
+
+const (
+	FinishReasonStop         FinishReason = "stop"          // Natural end or stop sequence
+	FinishReasonLength       FinishReason = "length"        // max_tokens reached
+	FinishReasonToolCalls    FinishReason = "tool_calls"    // Model requested a tool call
+	FinishReasonSensitive    FinishReason = "sensitive"     // Output blocked by content moderation
+	FinishReasonNetworkError FinishReason = "network_error" // Inference backend failure
+)
+
+// Valid reports whether f is a known finish reason
+func (f FinishReason) Valid() bool {
+	switch f {
+	case FinishReasonStop, FinishReasonLength, FinishReasonToolCalls, FinishReasonSensitive, FinishReasonNetworkError:
+		return true
+	}
+	return false
+}
+
+// Truncated reports whether the answer was cut off before completion
+func (f FinishReason) Truncated() bool {
+	return f == FinishReasonLength || f == FinishReasonSensitive || f == FinishReasonNetworkError
+}
+
+// ParseRole converts a string to a Role, rejecting unknown values
+func ParseRole(s string) (Role, error) {
+	if r := Role(s); r.Valid() {
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown role %q", s)
+}
+
+// ParseDetail converts a string to a Detail, rejecting unknown values
+func ParseDetail(s string) (Detail, error) {
+	if d := Detail(s); d.Valid() {
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown detail level %q", s)
+}
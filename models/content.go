@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// Text creates a text content part
+func Text(s string) Content {
+	return Content{Type: ContentTypeText, Text: s}
+}
+
+// ImageBase64 creates an image part from raw image data, embedded as a
+// base64 data URI. The MIME type is sniffed from the data (JPEG if unknown).
+func ImageBase64(data []byte, detail Detail) Content {
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = "image/jpeg"
+	}
+	return imageData(mimeType, data, detail)
+}
+
+// JPEG creates a high-detail image part from a JPEG frame
+func JPEG(frame []byte) Content {
+	return imageData("image/jpeg", frame, DetailHigh)
+}
+
+// imageData creates an image part with an explicit MIME type
+func imageData(mimeType string, data []byte, detail Detail) Content {
+	uri := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	return Content{Type: ContentTypeImageURL, ImageURL: &ImageURL{URL: uri, Detail: detail}}
+}
+
+// ImageURLRef creates an image part referencing a remote image URL
+func ImageURLRef(url string) Content {
+	return Content{Type: ContentTypeImageURL, ImageURL: &ImageURL{URL: url}}
+}
+
+// Video creates a video part referencing a remote video URL
+func Video(url string) Content {
+	return Content{Type: ContentTypeVideoURL, VideoURL: &VideoURL{URL: url}}
+}
+
+// Frames creates high-detail image parts from encoded frames (JPEG, or the
+// format of a codec.Encoder; the MIME type is sniffed)
+func Frames(frames [][]byte) []Content {
+	parts := make([]Content, len(frames))
+	for i, f := range frames {
+		parts[i] = ImageBase64(f, DetailHigh)
+	}
+	return parts
+}
+
+// NewMessage creates a message from content parts
+func NewMessage(role Role, parts ...Content) Message {
+	return Message{Role: role, Content: parts}
+}
+
+// UserMessage creates a user message from content parts
+func UserMessage(parts ...Content) Message {
+	return NewMessage(RoleUser, parts...)
+}
+
+// SystemMessage creates a system message with a text instruction
+func SystemMessage(text string) Message {
+	return NewMessage(RoleSystem, Text(text))
+}
+
+// AssistantMessage creates an assistant message, used to replay earlier
+// answers in multi-turn conversations
+func AssistantMessage(text string) Message {
+	return NewMessage(RoleAssistant, Text(text))
+}
+
+// Append adds parts to the message and returns it
+func (m Message) Append(parts ...Content) Message {
+	m.Content = append(m.Content, parts...)
+	return m
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register the JPEG decoder
+	"strings"
+)
+
+// FrameLimits are the per-model constraints checked before an API call
+type FrameLimits struct {
+	MaxImages       int // Maximum images per request
+	MaxImageBytes   int // Maximum size of one encoded image
+	MaxImageSide    int // Maximum width or height in pixels
+	MaxPayloadBytes int // Maximum total size of all images, base64-encoded
+}
+
+// defaultFrameLimits applies to models without specific limits
+var defaultFrameLimits = FrameLimits{
+	MaxImages:       DefaultMaxImages,
+	MaxImageBytes:   5 << 20,
+	MaxImageSide:    6000,
+	MaxPayloadBytes: 50 << 20,
+}
+
+// FrameIssue is a problem found in one frame; Index is -1 for problems
+// affecting the whole batch
+type FrameIssue struct {
+	Index   int    `json:"index"`
+	Problem string `json:"problem"`
+	Fatal   bool   `json:"fatal"` // The API would reject the request
+}
+
+// String formats the issue
+func (i FrameIssue) String() string {
+	if i.Index < 0 {
+		return i.Problem
+	}
+	return fmt.Sprintf("frame %d: %s", i.Index, i.Problem)
+}
+
+// FrameValidation is the result of ValidateFramesForModel
+type FrameValidation struct {
+	Metadata FrameMetadata `json:"metadata"`
+	Issues   []FrameIssue  `json:"issues,omitempty"`
+}
+
+// OK reports whether no fatal issue was found
+func (v *FrameValidation) OK() bool {
+	return v.Err() == nil
+}
+
+// Err returns an error listing the fatal issues, or nil
+func (v *FrameValidation) Err() error {
+	var msgs []string
+	for _, issue := range v.Issues {
+		if issue.Fatal {
+			msgs = append(msgs, issue.String())
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New("invalid frames: " + strings.Join(msgs, "; "))
+}
+
+// ValidateFramesForModel decodes every JPEG frame and checks it against the
+// model's limits: decodability, 28-divisible dimensions, consistent size,
+// per-image and total payload size and the image count. Non-divisible
+// dimensions are reported as non-fatal because the API rescales them, at the
+// cost of accuracy.
+func ValidateFramesForModel(frames [][]byte, model string) *FrameValidation {
+	limits := frameLimitsFor(model)
+	v := &FrameValidation{Metadata: FrameMetadata{TotalFrames: len(frames), ValidDimension: true}}
+	issue := func(index int, fatal bool, format string, args ...interface{}) {
+		v.Issues = append(v.Issues, FrameIssue{Index: index, Problem: fmt.Sprintf(format, args...), Fatal: fatal})
+	}
+
+	if len(frames) == 0 {
+		issue(-1, true, "no frames")
+		v.Metadata.ValidDimension = false
+		return v
+	}
+	if limits.MaxImages > 0 && len(frames) > limits.MaxImages {
+		issue(-1, true, "%d frames exceed the %s limit of %d images", len(frames), model, limits.MaxImages)
+	}
+
+	payload := 0
+	for i, frame := range frames {
+		payload += (len(frame) + 2) / 3 * 4
+		if limits.MaxImageBytes > 0 && len(frame) > limits.MaxImageBytes {
+			issue(i, true, "%d bytes exceed the per-image limit of %d", len(frame), limits.MaxImageBytes)
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(frame))
+		if err != nil {
+			issue(i, true, "corrupt JPEG: %v", err)
+			v.Metadata.ValidDimension = false
+			continue
+		}
+
+		b := img.Bounds()
+		w, h := b.Dx(), b.Dy()
+		if v.Metadata.Width == 0 {
+			v.Metadata.Width, v.Metadata.Height = w, h
+		} else if w != v.Metadata.Width || h != v.Metadata.Height {
+			issue(i, false, "size %dx%d differs from the first frame (%dx%d)", w, h, v.Metadata.Width, v.Metadata.Height)
+		}
+
+		if w%ResolutionMultiple != 0 || h%ResolutionMultiple != 0 {
+			issue(i, false, "size %dx%d is not divisible by %d", w, h, ResolutionMultiple)
+			v.Metadata.ValidDimension = false
+		}
+		if limits.MaxImageSide > 0 && (w > limits.MaxImageSide || h > limits.MaxImageSide) {
+			issue(i, true, "size %dx%d exceeds the maximum side of %d", w, h, limits.MaxImageSide)
+			v.Metadata.ValidDimension = false
+		}
+	}
+
+	if limits.MaxPayloadBytes > 0 && payload > limits.MaxPayloadBytes {
+		issue(-1, true, "encoded payload of %d bytes exceeds the limit of %d", payload, limits.MaxPayloadBytes)
+	}
+	return v
+}
+
+// frameLimitsFor returns the frame limits of a model from the registry
+func frameLimitsFor(model string) FrameLimits {
+	return ModelInfoFor(model).Limits
+}
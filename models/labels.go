@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Well-known label keys; any other key is allowed
+const (
+	LabelCamera = "camera_id"
+	LabelSite   = "site"
+	LabelTenant = "tenant"
+)
+
+// Labels are opaque key/value tags describing where data came from
+// (camera, site, tenant...). They are attached to a context with
+// WithLabels and copied into every output derived from it: analysis
+// results, usage and audit records, scheduler results, alerts and sink
+// events.
+type Labels map[string]string
+
+// Merge returns a new set holding l overridden by each of others in order
+func (l Labels) Merge(others ...Labels) Labels {
+	size := len(l)
+	for _, o := range others {
+		size += len(o)
+	}
+	if size == 0 {
+		return nil
+	}
+	merged := make(Labels, size)
+	for k, v := range l {
+		merged[k] = v
+	}
+	for _, o := range others {
+		for k, v := range o {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// Clone returns a copy of l, or nil for an empty set
+func (l Labels) Clone() Labels {
+	return l.Merge()
+}
+
+// Matches reports whether l contains every key/value pair of selector
+func (l Labels) Matches(selector Labels) bool {
+	for k, v := range selector {
+		if got, ok := l[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the labels as "k=v,k=v" sorted by key
+func (l Labels) String() string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + l[k]
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseLabels parses the String form ("camera_id=cam1,site=hq")
+func ParseLabels(s string) (Labels, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	l := Labels{}
+	for _, part := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid label %q: want key=value", part)
+		}
+		l[k] = strings.TrimSpace(v)
+	}
+	return l, nil
+}
+
+type labelsKey struct{}
+
+// WithLabels returns a context carrying the labels of ctx merged with l
+// (l wins on conflicts)
+func WithLabels(ctx context.Context, l Labels) context.Context {
+	if len(l) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, labelsKey{}, LabelsFrom(ctx).Merge(l))
+}
+
+// LabelsFrom returns the labels set on ctx by WithLabels, or nil. The
+// result must not be modified; use Clone or Merge.
+func LabelsFrom(ctx context.Context) Labels {
+	if ctx == nil {
+		return nil
+	}
+	l, _ := ctx.Value(labelsKey{}).(Labels)
+	return l
+}
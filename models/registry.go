@@ -0,0 +1,83 @@
+package models
+
+import (
+	"sort"
+	"sync"
+)
+
+// ModelInfo describes the capabilities, limits and pricing of a model.
+// Prices are in CNY per million tokens; check the official price list
+// before relying on them for billing.
+type ModelInfo struct {
+	Name             string
+	Limits           FrameLimits
+	SupportsVideoURL bool // Accepts video_url content parts
+	SupportsThinking bool // Produces reasoning content
+	InputPrice       float64
+	OutputPrice      float64
+}
+
+// Cost computes the cost of a call with the given token usage
+func (m ModelInfo) Cost(promptTokens, completionTokens int) float64 {
+	return float64(promptTokens)/1e6*m.InputPrice + float64(completionTokens)/1e6*m.OutputPrice
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]ModelInfo{}
+)
+
+func init() {
+	for _, m := range []ModelInfo{
+		{Name: "glm-4.5v", Limits: defaultFrameLimits, SupportsVideoURL: true, SupportsThinking: true, InputPrice: 2, OutputPrice: 6},
+		{Name: "glm-4.1v-thinking-flashx", Limits: defaultFrameLimits, SupportsVideoURL: true, SupportsThinking: true, InputPrice: 2, OutputPrice: 2},
+		{Name: "glm-4.1v-thinking-flash", Limits: defaultFrameLimits, SupportsVideoURL: true, SupportsThinking: true},
+		{Name: "glm-4v-plus-0111", Limits: defaultFrameLimits, SupportsVideoURL: true, InputPrice: 4, OutputPrice: 4},
+		{Name: "glm-4v-flash", Limits: FrameLimits{MaxImages: 1, MaxImageBytes: 5 << 20, MaxImageSide: 6000, MaxPayloadBytes: 10 << 20}},
+	} {
+		registry[m.Name] = m
+	}
+}
+
+// RegisterModel adds or replaces a model entry, e.g. for private
+// deployments or models released after this SDK version
+func RegisterModel(info ModelInfo) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[info.Name] = info
+}
+
+// LookupModel returns the registered entry of a model
+func LookupModel(name string) (ModelInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	info, ok := registry[name]
+	return info, ok
+}
+
+// ModelInfoFor returns the registered entry of a model, falling back to
+// default limits and no pricing for unknown models
+func ModelInfoFor(name string) ModelInfo {
+	if info, ok := LookupModel(name); ok {
+		return info
+	}
+	return ModelInfo{Name: name, Limits: defaultFrameLimits}
+}
+
+// RegisteredModels returns all registered entries sorted by name
+func RegisteredModels() []ModelInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]ModelInfo, 0, len(registry))
+	for _, m := range registry {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ModelCost computes the cost of a call from the registered pricing; it
+// matches audit.CostFunc
+func ModelCost(model string, promptTokens, completionTokens int) float64 {
+	return ModelInfoFor(model).Cost(promptTokens, completionTokens)
+}
@@ -0,0 +1,279 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register JPEG for resolution checks
+	_ "image/png"  // Register PNG for resolution checks
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+const (
+	// DefaultMaxImages is the default maximum number of images per request
+	DefaultMaxImages = 50
+	// ResolutionMultiple is the patch size image sides should be divisible by
+	ResolutionMultiple = 28
+)
+
+// allowedImageMIMETypes lists the MIME types accepted in image data URIs
+var allowedImageMIMETypes = map[string]bool{
+	"image/jpeg": true,
+	"image/jpg":  true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// ValidationError describes one request constraint violation
+type ValidationError struct {
+	Field  string // Offending field (e.g. "temperature", "images[3]")
+	Reason string
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+// Is reports validation failures as errdefs.ErrInvalidRequest
+func (e *ValidationError) Is(target error) bool {
+	return target == errdefs.ErrInvalidRequest
+}
+
+// ValidationErrors collects all violations found in a request
+type ValidationErrors []*ValidationError
+
+// Error implements error
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Error()
+	}
+	return "request validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap exposes the individual violations to errors.As
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, v := range e {
+		errs[i] = v
+	}
+	return errs
+}
+
+// RequestBuilder assembles a single-turn ChatRequest and validates it
+// before it is sent, so mistakes surface as ValidationErrors instead of
+// opaque API 400 responses
+type RequestBuilder struct {
+	model       string
+	prompt      string
+	images      []ImageURL
+	temperature *float64
+	topP        *float64
+	maxTokens   *int
+	stream      bool
+
+	maxImages       int
+	checkResolution bool
+	imageDetail     Detail
+}
+
+// NewRequestBuilder creates a builder for the given model
+func NewRequestBuilder(model string) *RequestBuilder {
+	return &RequestBuilder{
+		model:           model,
+		maxImages:       ModelInfoFor(model).Limits.MaxImages,
+		checkResolution: true,
+		imageDetail:     DetailHigh,
+	}
+}
+
+// WithPrompt sets the text prompt
+func (b *RequestBuilder) WithPrompt(prompt string) *RequestBuilder {
+	b.prompt = prompt
+	return b
+}
+
+// AddFrame adds a JPEG frame, encoded as a base64 data URI
+func (b *RequestBuilder) AddFrame(jpegData []byte) *RequestBuilder {
+	return b.AddImage("image/jpeg", jpegData)
+}
+
+// AddFrames adds several JPEG frames
+func (b *RequestBuilder) AddFrames(frames [][]byte) *RequestBuilder {
+	for _, f := range frames {
+		b.AddFrame(f)
+	}
+	return b
+}
+
+// AddImage adds an image of the given MIME type as a base64 data URI
+func (b *RequestBuilder) AddImage(mimeType string, data []byte) *RequestBuilder {
+	uri := fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data))
+	return b.AddImageURL(uri)
+}
+
+// AddImageURL adds an image by URL or data URI
+func (b *RequestBuilder) AddImageURL(url string) *RequestBuilder {
+	b.images = append(b.images, ImageURL{URL: url, Detail: b.imageDetail})
+	return b
+}
+
+// WithDetail sets the detail level of images added afterwards
+func (b *RequestBuilder) WithDetail(detail Detail) *RequestBuilder {
+	b.imageDetail = detail
+	return b
+}
+
+// WithTemperature sets the sampling temperature (0.0-1.0)
+func (b *RequestBuilder) WithTemperature(t float64) *RequestBuilder {
+	b.temperature = &t
+	return b
+}
+
+// WithTopP sets the nucleus sampling parameter (0.0-1.0)
+func (b *RequestBuilder) WithTopP(p float64) *RequestBuilder {
+	b.topP = &p
+	return b
+}
+
+// WithMaxTokens sets the maximum number of generated tokens
+func (b *RequestBuilder) WithMaxTokens(n int) *RequestBuilder {
+	b.maxTokens = &n
+	return b
+}
+
+// WithStream enables streaming responses
+func (b *RequestBuilder) WithStream(stream bool) *RequestBuilder {
+	b.stream = stream
+	return b
+}
+
+// WithMaxImages sets the image count limit enforced by Build (0 disables it)
+func (b *RequestBuilder) WithMaxImages(n int) *RequestBuilder {
+	b.maxImages = n
+	return b
+}
+
+// WithResolutionCheck enables or disables the check that data URI images
+// have sides divisible by ResolutionMultiple
+func (b *RequestBuilder) WithResolutionCheck(enabled bool) *RequestBuilder {
+	b.checkResolution = enabled
+	return b
+}
+
+// Validate checks all constraints and returns ValidationErrors, or nil
+func (b *RequestBuilder) Validate() error {
+	var errs ValidationErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Field: field, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if b.model == "" {
+		add("model", "must not be empty")
+	}
+	if strings.TrimSpace(b.prompt) == "" {
+		add("prompt", "must not be empty")
+	}
+	if b.maxImages > 0 && len(b.images) > b.maxImages {
+		add("images", "%d images exceed the limit of %d", len(b.images), b.maxImages)
+	}
+	if b.temperature != nil && (*b.temperature < 0 || *b.temperature > 1) {
+		add("temperature", "%.2f is outside [0, 1]", *b.temperature)
+	}
+	if b.topP != nil && (*b.topP <= 0 || *b.topP > 1) {
+		add("top_p", "%.2f is outside (0, 1]", *b.topP)
+	}
+	if b.maxTokens != nil && *b.maxTokens <= 0 {
+		add("max_tokens", "must be positive")
+	}
+
+	for i, img := range b.images {
+		field := fmt.Sprintf("images[%d]", i)
+		if !img.Detail.Valid() {
+			add(field, "unknown detail %q", img.Detail)
+		}
+
+		if !strings.HasPrefix(img.URL, "data:") {
+			if !strings.HasPrefix(img.URL, "http://") && !strings.HasPrefix(img.URL, "https://") {
+				add(field, "must be an http(s) URL or a data URI")
+			}
+			continue
+		}
+
+		mimeType, data, err := ParseDataURI(img.URL)
+		if err != nil {
+			add(field, "%v", err)
+			continue
+		}
+		if !allowedImageMIMETypes[mimeType] {
+			add(field, "unsupported MIME type %q", mimeType)
+			continue
+		}
+
+		if !b.checkResolution {
+			continue
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			if mimeType != "image/webp" {
+				add(field, "cannot decode image: %v", err)
+			}
+			continue
+		}
+		if cfg.Width%ResolutionMultiple != 0 || cfg.Height%ResolutionMultiple != 0 {
+			add(field, "resolution %dx%d is not divisible by %d", cfg.Width, cfg.Height, ResolutionMultiple)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Build validates the request and returns it
+func (b *RequestBuilder) Build() (*ChatRequest, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	contents := []Content{{Type: ContentTypeText, Text: b.prompt}}
+	for i := range b.images {
+		img := b.images[i]
+		contents = append(contents, Content{Type: ContentTypeImageURL, ImageURL: &img})
+	}
+
+	return &ChatRequest{
+		Model:       b.model,
+		Messages:    []Message{{Role: RoleUser, Content: contents}},
+		Temperature: b.temperature,
+		TopP:        b.topP,
+		MaxTokens:   b.maxTokens,
+		Stream:      b.stream,
+	}, nil
+}
+
+// ParseDataURI splits a base64 data URI into its MIME type and payload
+func ParseDataURI(uri string) (string, []byte, error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return "", nil, fmt.Errorf("not a data URI")
+	}
+	meta, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", nil, fmt.Errorf("malformed data URI")
+	}
+	mimeType, ok := strings.CutSuffix(meta, ";base64")
+	if !ok {
+		return "", nil, fmt.Errorf("data URI must be base64 encoded")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid base64 payload: %w", err)
+	}
+	return strings.ToLower(mimeType), data, nil
+}
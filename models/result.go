@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// AnalysisResult bundles a chat response with the details of how its
+// input frames were produced, so callers keep the extraction context
+type AnalysisResult struct {
+	Response *ChatResponse `json:"response"`
+	Source   string        `json:"source,omitempty"` // File path, URL or stream name
+	Labels   Labels        `json:"labels,omitempty"` // Origin tags (camera, site, tenant) from the context
+
+	Frames          int         `json:"frames"`                     // Frames sent to the model
+	FrameTimestamps []float64   `json:"frame_timestamps,omitempty"` // Source offset of each frame, in seconds
+	FrameTimes      []time.Time `json:"frame_times,omitempty"`      // Wall-clock time of each frame, set by ApplyClock
+	Gaps            []Gap       `json:"gaps,omitempty"`             // Intervals of the source with no frames (dropouts, recording pauses)
+	VariableRate    bool        `json:"variable_rate,omitempty"`    // The source has a variable frame rate
+	DroppedFrames   int         `json:"dropped_frames"`             // Extracted frames that were not sent
+	FrameBytes      int         `json:"frame_bytes"`                // Total JPEG size of the sent frames
+	PayloadBytes    int         `json:"payload_bytes"`              // Request body size
+
+	ExtractionLatency time.Duration `json:"extraction_latency"` // Time spent decoding and extracting frames
+	RequestLatency    time.Duration `json:"request_latency"`    // Time spent in the API call
+	TotalLatency      time.Duration `json:"total_latency"`
+	Timings           *Timings      `json:"timings,omitempty"` // Per-stage breakdown
+}
+
+// ApplyClock fills FrameTimes by mapping FrameTimestamps through clock
+func (r *AnalysisResult) ApplyClock(clock *WallClock) {
+	r.FrameTimes = make([]time.Time, len(r.FrameTimestamps))
+	for i, ts := range r.FrameTimestamps {
+		r.FrameTimes[i] = clock.At(time.Duration(ts * float64(time.Second)))
+	}
+}
+
+// Text returns the content of the first choice of the response
+func (r *AnalysisResult) Text() string {
+	if r == nil {
+		return ""
+	}
+	return r.Response.Text()
+}
+
+// Gap is an interval of a source in which no frames were recorded, in
+// seconds from the start like FrameTimestamps
+type Gap struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Seconds returns the length of the gap
+func (g Gap) Seconds() float64 { return g.End - g.Start }
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Response format types
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat asks the model for a specific output format
+type ResponseFormat struct {
+	Type       string       `json:"type"`
+	JSONSchema *NamedSchema `json:"json_schema,omitempty"`
+}
+
+// NamedSchema is the json_schema payload of a ResponseFormat
+type NamedSchema struct {
+	Name   string      `json:"name"`
+	Schema *JSONSchema `json:"schema"`
+	Strict bool        `json:"strict,omitempty"`
+}
+
+// JSONSchema is the subset of JSON Schema used for structured output
+type JSONSchema struct {
+	Type                 string                 `json:"type,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+}
+
+// String returns the schema as indented JSON, suitable for prompts
+func (s *JSONSchema) String() string {
+	data, _ := json.MarshalIndent(s, "", "  ")
+	return string(data)
+}
+
+// JSONObjectFormat requests any valid JSON object
+func JSONObjectFormat() *ResponseFormat {
+	return &ResponseFormat{Type: ResponseFormatJSONObject}
+}
+
+// JSONSchemaFormat requests output matching the schema derived from v
+func JSONSchemaFormat(name string, v interface{}) (*ResponseFormat, error) {
+	schema, err := SchemaFor(v)
+	if err != nil {
+		return nil, err
+	}
+	return &ResponseFormat{
+		Type:       ResponseFormatJSONSchema,
+		JSONSchema: &NamedSchema{Name: name, Schema: schema, Strict: true},
+	}, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFor derives a JSON schema from a Go value (usually a pointer to a
+// struct). Field names follow `json` tags; fields without omitempty are
+// required. A `description` tag documents a field and an `enum` tag
+// (comma separated) restricts string values:
+//
+//	type Person struct {
+//		Action string `json:"action" description:"what the person does" enum:"walking,standing"`
+//	}
+func SchemaFor(v interface{}) (*JSONSchema, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("cannot derive schema from nil")
+	}
+	return schemaForType(t, map[reflect.Type]bool{})
+}
+
+// schemaForType builds the schema of t; seen guards against recursive types
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) (*JSONSchema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &JSONSchema{Type: "string", Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}, nil
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := schemaForType(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := schemaForType(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Interface:
+		return &JSONSchema{}, nil
+	case reflect.Struct:
+		return schemaForStruct(t, seen)
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// schemaForStruct builds an object schema from exported struct fields
+func schemaForStruct(t reflect.Type, seen map[reflect.Type]bool) (*JSONSchema, error) {
+	if seen[t] {
+		return nil, fmt.Errorf("recursive type %s is not supported", t)
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	schema := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		omitempty := false
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					omitempty = true
+				}
+			}
+		}
+
+		prop, err := schemaForType(f.Type, seen)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		prop.Description = f.Tag.Get("description")
+		if enum := f.Tag.Get("enum"); enum != "" {
+			prop.Enum = strings.Split(enum, ",")
+		}
+
+		schema.Properties[name] = prop
+		if !omitempty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema, nil
+}
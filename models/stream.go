@@ -0,0 +1,124 @@
+package models
+
+import "strings"
+
+// ChatCompletionChunk is one Server-Sent Events payload of a streaming
+// chat completion. The final chunk carries the finish reason and, when
+// provided by the API, the usage of the whole request.
+type ChatCompletionChunk struct {
+	ID      string        `json:"id"`
+	Object  string        `json:"object,omitempty"` // "chat.completion.chunk" in OpenAI-compatible output
+	Created int64         `json:"created"`
+	Model   string        `json:"model"`
+	Choices []ChunkChoice `json:"choices"`
+	Usage   *Usage        `json:"usage,omitempty"`
+}
+
+// ChunkChoice is the per-choice part of a streaming chunk
+type ChunkChoice struct {
+	Index        int          `json:"index"`
+	Delta        Delta        `json:"delta"`
+	FinishReason FinishReason `json:"finish_reason,omitempty"`
+}
+
+// Delta is the incremental message content of a chunk
+type Delta struct {
+	Role             Role   `json:"role,omitempty"`
+	Content          string `json:"content,omitempty"`
+	ReasoningContent string `json:"reasoning_content,omitempty"` // Thinking output of reasoning models
+}
+
+// Text returns the content delta of the first choice
+func (c *ChatCompletionChunk) Text() string {
+	if c == nil || len(c.Choices) == 0 {
+		return ""
+	}
+	return c.Choices[0].Delta.Content
+}
+
+// StreamEvent is delivered to stream consumers: either a chunk, an error,
+// or the terminal Done event carrying the aggregated response
+type StreamEvent struct {
+	Chunk    *ChatCompletionChunk
+	Err      error
+	Done     bool
+	Response *ChatResponse // Set on the Done event
+}
+
+// StreamDone is the SSE data payload terminating a stream
+const StreamDone = "[DONE]"
+
+// maxStreamChoices bounds the choice index accepted from a stream, so a
+// malformed chunk cannot make the accumulator allocate without limit
+const maxStreamChoices = 64
+
+// StreamAccumulator aggregates chunks into a complete ChatResponse
+type StreamAccumulator struct {
+	resp     ChatResponse
+	contents map[int]*choiceText
+}
+
+// choiceText collects the content and reasoning deltas of one choice
+type choiceText struct {
+	content   strings.Builder
+	reasoning strings.Builder
+}
+
+// Add merges a chunk into the accumulated response
+func (a *StreamAccumulator) Add(chunk *ChatCompletionChunk) {
+	if a.contents == nil {
+		a.contents = make(map[int]*choiceText)
+	}
+	if chunk.ID != "" {
+		a.resp.ID = chunk.ID
+	}
+	if chunk.Created != 0 {
+		a.resp.Created = chunk.Created
+	}
+	if chunk.Model != "" {
+		a.resp.Model = chunk.Model
+	}
+	if chunk.Usage != nil {
+		a.resp.Usage = *chunk.Usage
+	}
+
+	for _, c := range chunk.Choices {
+		if c.Index < 0 || c.Index >= maxStreamChoices {
+			continue // Out-of-range index from a misbehaving server
+		}
+		for len(a.resp.Choices) <= c.Index {
+			a.resp.Choices = append(a.resp.Choices, Choice{Index: len(a.resp.Choices)})
+		}
+		choice := &a.resp.Choices[c.Index]
+		if c.Delta.Role != "" {
+			choice.Message.Role = c.Delta.Role
+		}
+		if c.FinishReason != "" {
+			choice.FinishReason = c.FinishReason
+		}
+
+		t, ok := a.contents[c.Index]
+		if !ok {
+			t = &choiceText{}
+			a.contents[c.Index] = t
+		}
+		t.content.WriteString(c.Delta.Content)
+		t.reasoning.WriteString(c.Delta.ReasoningContent)
+	}
+}
+
+// Response returns the aggregated response so far
+func (a *StreamAccumulator) Response() *ChatResponse {
+	resp := a.resp
+	resp.Choices = append([]Choice(nil), a.resp.Choices...)
+	for i := range resp.Choices {
+		if t, ok := a.contents[i]; ok {
+			resp.Choices[i].Message.Content = t.content.String()
+			resp.Choices[i].Message.ReasoningContent = t.reasoning.String()
+		}
+		if resp.Choices[i].Message.Role == "" {
+			resp.Choices[i].Message.Role = RoleAssistant
+		}
+	}
+	return &resp
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Timings is the latency breakdown of one analysis, filled in by the
+// client. Stages that did not run are zero.
+type Timings struct {
+	Probe      time.Duration `json:"probe,omitempty"`      // ffprobe of the source
+	Extraction time.Duration `json:"extraction,omitempty"` // ffmpeg decoding and frame extraction
+	Preprocess time.Duration `json:"preprocess,omitempty"` // Frame filtering, resizing and validation
+	Encode     time.Duration `json:"encode,omitempty"`     // base64 and JSON encoding of the request
+	Upload     time.Duration `json:"upload,omitempty"`     // Connecting and writing the request body
+	Server     time.Duration `json:"server,omitempty"`     // Request written to first response byte
+	Download   time.Duration `json:"download,omitempty"`   // Reading the response body
+	Parse      time.Duration `json:"parse,omitempty"`      // Decoding the response JSON
+	Total      time.Duration `json:"total"`
+}
+
+// Stage is a named latency stage
+type Stage struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Stages returns all stages in pipeline order
+func (t *Timings) Stages() []Stage {
+	return []Stage{
+		{"probe", t.Probe},
+		{"extraction", t.Extraction},
+		{"preprocess", t.Preprocess},
+		{"encode", t.Encode},
+		{"upload", t.Upload},
+		{"server", t.Server},
+		{"download", t.Download},
+		{"parse", t.Parse},
+	}
+}
+
+// Slowest returns the stage that took the longest
+func (t *Timings) Slowest() Stage {
+	var slowest Stage
+	for _, s := range t.Stages() {
+		if s.Duration > slowest.Duration {
+			slowest = s
+		}
+	}
+	return slowest
+}
+
+// Add accumulates another breakdown into t
+func (t *Timings) Add(other *Timings) {
+	if other == nil {
+		return
+	}
+	t.Probe += other.Probe
+	t.Extraction += other.Extraction
+	t.Preprocess += other.Preprocess
+	t.Encode += other.Encode
+	t.Upload += other.Upload
+	t.Server += other.Server
+	t.Download += other.Download
+	t.Parse += other.Parse
+	t.Total += other.Total
+}
+
+// String formats the non-zero stages, e.g. "extraction=1.2s server=3.4s total=4.7s"
+func (t *Timings) String() string {
+	var parts []string
+	for _, s := range t.Stages() {
+		if s.Duration > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%v", s.Name, s.Duration.Round(time.Millisecond)))
+		}
+	}
+	parts = append(parts, fmt.Sprintf("total=%v", t.Total.Round(time.Millisecond)))
+	return strings.Join(parts, " ")
+}
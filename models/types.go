@@ -2,15 +2,16 @@ package models
 
 // Message represents a chat message
 type Message struct {
-	Role    string    `json:"role"`
+	Role    Role      `json:"role"`
 	Content []Content `json:"content"`
 }
 
-// Content represents message content (text or image)
+// Content represents message content (text, image or video)
 type Content struct {
-	Type     string    `json:"type"`
-	Text     string    `json:"text,omitempty"`
-	ImageURL *ImageURL `json:"image_url,omitempty"`
+	Type     ContentType `json:"type"`
+	Text     string      `json:"text,omitempty"`
+	ImageURL *ImageURL   `json:"image_url,omitempty"`
+	VideoURL *VideoURL   `json:"video_url,omitempty"`
 }
 
 // ImageURL represents an image URL
@@ -21,7 +22,12 @@ type Content struct {
 // - Recommended quality: 85-95
 type ImageURL struct {
 	URL    string `json:"url"`              // URL or data URI (data:image/jpeg;base64,...)
-	Detail string `json:"detail,omitempty"` // Optional: "auto", "low", "high"
+	Detail Detail `json:"detail,omitempty"` // Optional: DetailAuto, DetailLow, DetailHigh
+}
+
+// VideoURL references a video the API fetches and samples itself
+type VideoURL struct {
+	URL string `json:"url"`
 }
 
 // ChatRequest represents the API request
@@ -32,26 +38,153 @@ type ChatRequest struct {
 	TopP        *float64  `json:"top_p,omitempty"`       // Optional: 0.0-1.0
 	MaxTokens   *int      `json:"max_tokens,omitempty"`  // Optional: max tokens to generate
 	Stream      bool      `json:"stream,omitempty"`      // Optional: enable streaming
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"` // Optional: structured output
 }
 
 // ChatResponse represents the API response
 type ChatResponse struct {
-	ID      string `json:"id"`
-	Created int64  `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Index   int `json:"index"`
-		Message struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"message"`
-		FinishReason string `json:"finish_reason"`
-	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	ID      string   `json:"id"`
+	Created int64    `json:"created"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+
+	// Estimate, Timings, Labels and Fallback are filled in by the client, not the API
+	Estimate *UsageEstimate `json:"estimate,omitempty"`
+	Timings  *Timings       `json:"timings,omitempty"`
+	Labels   Labels         `json:"labels,omitempty"`   // Labels of the request context (see WithLabels)
+	Fallback *Fallback      `json:"fallback,omitempty"` // Set when the answer was not derived from the video frames
+}
+
+// FallbackAudio marks answers derived from the speech transcript alone
+const FallbackAudio = "audio"
+
+// Fallback annotates a degraded answer: the video could not be decoded, so
+// the answer was produced from another modality
+type Fallback struct {
+	Mode       string `json:"mode"`                 // FallbackAudio
+	Reason     string `json:"reason"`               // Why the video could not be used
+	Transcript string `json:"transcript,omitempty"` // The input the answer was based on
+}
+
+// Text returns the content of the first choice, or "" if there is none
+func (r *ChatResponse) Text() string {
+	if r == nil || len(r.Choices) == 0 {
+		return ""
+	}
+	return r.Choices[0].Message.Content
+}
+
+// FinishReason returns the finish reason of the first choice
+func (r *ChatResponse) FinishReason() FinishReason {
+	if r == nil || len(r.Choices) == 0 {
+		return ""
+	}
+	return r.Choices[0].FinishReason
+}
+
+// Choice is one generated answer
+type Choice struct {
+	Index        int             `json:"index"`
+	Message      ResponseMessage `json:"message"`
+	FinishReason FinishReason    `json:"finish_reason"`
+}
+
+// ResponseMessage is the assistant message of a choice
+type ResponseMessage struct {
+	Role             Role   `json:"role"`
+	Content          string `json:"content"`
+	ReasoningContent string `json:"reasoning_content,omitempty"` // Thinking output of reasoning models
+}
+
+// Usage reports token consumption of a request
+type Usage struct {
+	PromptTokens            int                      `json:"prompt_tokens"`
+	CompletionTokens        int                      `json:"completion_tokens"`
+	TotalTokens             int                      `json:"total_tokens"`
+	PromptTokensDetails     *PromptTokensDetails     `json:"prompt_tokens_details,omitempty"`     // Present when the API reports cache hits
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"` // Present for thinking models
+}
+
+// PromptTokensDetails breaks down prompt tokens
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"` // Prompt tokens served from the context cache
+}
+
+// CompletionTokensDetails breaks down completion tokens
+type CompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"` // Tokens spent on reasoning content
+}
+
+// CachedTokens returns the number of cached prompt tokens, or 0
+func (u Usage) CachedTokens() int {
+	if u.PromptTokensDetails == nil {
+		return 0
+	}
+	return u.PromptTokensDetails.CachedTokens
+}
+
+// ReasoningTokens returns the number of reasoning tokens, or 0
+func (u Usage) ReasoningTokens() int {
+	if u.CompletionTokensDetails == nil {
+		return 0
+	}
+	return u.CompletionTokensDetails.ReasoningTokens
+}
+
+// Add accumulates another usage into u
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	if other.PromptTokensDetails != nil {
+		if u.PromptTokensDetails == nil {
+			u.PromptTokensDetails = &PromptTokensDetails{}
+		}
+		u.PromptTokensDetails.CachedTokens += other.PromptTokensDetails.CachedTokens
+	}
+	if other.CompletionTokensDetails != nil {
+		if u.CompletionTokensDetails == nil {
+			u.CompletionTokensDetails = &CompletionTokensDetails{}
+		}
+		u.CompletionTokensDetails.ReasoningTokens += other.CompletionTokensDetails.ReasoningTokens
+	}
+}
+
+// UsageEstimate is a client-side breakdown of prompt tokens, attached to
+// responses so cost can be attributed per frame, camera or job
+type UsageEstimate struct {
+	TextTokens  int                  `json:"text_tokens"`
+	ImageTokens int                  `json:"image_tokens"`
+	Images      []ImageTokenEstimate `json:"images,omitempty"`
+}
+
+// ImageTokenEstimate is the estimated token cost of one image
+type ImageTokenEstimate struct {
+	Index  int `json:"index"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Tokens int `json:"tokens"`
+}
+
+// Total returns the estimated prompt tokens
+func (e *UsageEstimate) Total() int {
+	return e.TextTokens + e.ImageTokens
+}
+
+// Attribute scales the per-image estimates so that, together with the text
+// estimate, they sum to the actual prompt token count reported by the API
+func (e *UsageEstimate) Attribute(promptTokens int) []int {
+	out := make([]int, len(e.Images))
+	total := e.Total()
+	if total == 0 {
+		return out
+	}
+	for i, img := range e.Images {
+		out[i] = img.Tokens * promptTokens / total
+	}
+	return out
 }
 
 // FrameMetadata contains metadata about extracted video frames
@@ -63,3 +196,37 @@ type FrameMetadata struct {
 	Height         int     `json:"height"`
 	ValidDimension bool    `json:"valid_dimension"` // Whether dimensions meet requirements
 }
+
+// Summary is a hierarchical summary of a long video
+type Summary struct {
+	Source      string    `json:"source"`
+	Duration    float64   `json:"duration"` // Video duration in seconds
+	Overview    string    `json:"overview"` // Whole-video summary
+	Chapters    []Chapter `json:"chapters"`
+	TotalTokens int       `json:"total_tokens"` // Tokens used across all requests
+}
+
+// Chapter groups consecutive segments under a chapter-level summary
+type Chapter struct {
+	Start    float64   `json:"start"` // Start offset in seconds
+	End      float64   `json:"end"`   // End offset in seconds
+	Summary  string    `json:"summary"`
+	Segments []Segment `json:"segments"`
+}
+
+// Segment is the smallest analyzed unit of a summarized video
+type Segment struct {
+	Start   float64 `json:"start"` // Start offset in seconds
+	End     float64 `json:"end"`   // End offset in seconds
+	Frames  int     `json:"frames"`
+	Summary string  `json:"summary"`
+}
+
+// ChangeReport is a structured diff between two time windows of a source
+type ChangeReport struct {
+	Appeared []string `json:"appeared"` // Items present only in the second window
+	Missing  []string `json:"missing"`  // Items present only in the first window
+	Changed  []string `json:"changed"`  // Layout, position or state changes
+	Summary  string   `json:"summary"`  // Short natural language summary
+	Raw      string   `json:"raw,omitempty"`
+}
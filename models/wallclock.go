@@ -0,0 +1,165 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultClockTolerance is how far a sync point may disagree with the
+// others before it is treated as a clock glitch and ignored
+const DefaultClockTolerance = 2 * time.Second
+
+// WallClock maps media timestamps (offsets from the start of a stream or
+// recording) to wall-clock time, so results can cite "14:32:05" instead of
+// "00:12:41". It starts from a known stream start time and can be refined
+// with sync points from SEI timecodes or RTCP sender reports; camera clock
+// drift is followed by interpolating between sync points, and points that
+// jump away from the rest (NTP resyncs, bad SEI) are rejected.
+type WallClock struct {
+	Start     time.Time     // Wall time of media offset 0
+	Skew      time.Duration // Known source clock error (source minus true time), subtracted from every result
+	Tolerance time.Duration // Outlier threshold for sync points (default: DefaultClockTolerance)
+
+	mu      sync.Mutex
+	anchors []clockAnchor // Sorted by media offset
+}
+
+// clockAnchor pairs a media offset with the wall time it was observed at
+type clockAnchor struct {
+	media time.Duration
+	wall  time.Time
+}
+
+// NewWallClock creates a clock for a stream that started at start
+func NewWallClock(start time.Time) *WallClock {
+	return &WallClock{Start: start}
+}
+
+// WithSkew sets the known clock error of the source
+func (w *WallClock) WithSkew(skew time.Duration) *WallClock {
+	w.Skew = skew
+	return w
+}
+
+// Anchor records that media offset media was captured at wall time wall,
+// e.g. from an SEI timecode or an RTCP sender report (see NTPTime)
+func (w *WallClock) Anchor(media time.Duration, wall time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := sort.Search(len(w.anchors), func(i int) bool { return w.anchors[i].media >= media })
+	if i < len(w.anchors) && w.anchors[i].media == media {
+		w.anchors[i].wall = wall
+		return
+	}
+	w.anchors = append(w.anchors, clockAnchor{})
+	copy(w.anchors[i+1:], w.anchors[i:])
+	w.anchors[i] = clockAnchor{media, wall}
+}
+
+// At returns the wall time of media offset media; a nil clock returns the
+// zero time
+func (w *WallClock) At(media time.Duration) time.Time {
+	if w == nil {
+		return time.Time{}
+	}
+	w.mu.Lock()
+	anchors := w.trusted()
+	w.mu.Unlock()
+
+	var t time.Time
+	switch {
+	case len(anchors) == 0:
+		if w.Start.IsZero() {
+			return time.Time{}
+		}
+		t = w.Start.Add(media)
+	case media <= anchors[0].media:
+		t = anchors[0].wall.Add(media - anchors[0].media)
+	case media >= anchors[len(anchors)-1].media:
+		last := anchors[len(anchors)-1]
+		t = last.wall.Add(media - last.media)
+	default:
+		// Interpolate between the surrounding sync points, following drift
+		i := sort.Search(len(anchors), func(i int) bool { return anchors[i].media > media })
+		a, b := anchors[i-1], anchors[i]
+		frac := float64(media-a.media) / float64(b.media-a.media)
+		t = a.wall.Add(time.Duration(frac * float64(b.wall.Sub(a.wall))))
+	}
+	return t.Add(-w.Skew)
+}
+
+// Offset returns the media offset recorded at wall time t, the inverse of
+// At; a nil clock or one without a reference returns 0
+func (w *WallClock) Offset(t time.Time) time.Duration {
+	origin := w.At(0)
+	if origin.IsZero() {
+		return 0
+	}
+	// At is piecewise linear with a slope close to 1, so a few Newton
+	// steps land on the exact offset
+	media := t.Sub(origin)
+	for i := 0; i < 4; i++ {
+		d := t.Sub(w.At(media))
+		if d == 0 {
+			break
+		}
+		media += d
+	}
+	return media
+}
+
+// Format formats the wall time of media offset media with layout, falling
+// back to an HH:MM:SS offset when the clock is nil or has no reference
+func (w *WallClock) Format(media time.Duration, layout string) string {
+	if t := w.At(media); !t.IsZero() {
+		return t.Format(layout)
+	}
+	return formatHMS(int(media.Seconds()))
+}
+
+// trusted returns the anchors whose offset to the media timeline agrees
+// with the median within Tolerance; callers hold w.mu
+func (w *WallClock) trusted() []clockAnchor {
+	if len(w.anchors) < 3 {
+		return w.anchors
+	}
+	tolerance := w.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultClockTolerance
+	}
+
+	offsets := make([]time.Duration, len(w.anchors))
+	for i, a := range w.anchors {
+		offsets[i] = a.wall.Sub(w.anchors[0].wall) - (a.media - w.anchors[0].media)
+	}
+	sorted := append([]time.Duration(nil), offsets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[len(sorted)/2]
+
+	var out []clockAnchor
+	for i, a := range w.anchors {
+		if d := offsets[i] - median; d <= tolerance && d >= -tolerance {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// ntpEpochOffset is the number of seconds between 1900-01-01 (NTP epoch)
+// and 1970-01-01 (Unix epoch)
+const ntpEpochOffset = 2208988800
+
+// NTPTime converts a 64-bit NTP timestamp (as carried in RTCP sender
+// reports) to a time.Time
+func NTPTime(ntp uint64) time.Time {
+	secs := int64(ntp>>32) - ntpEpochOffset
+	frac := int64((ntp & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(secs, frac)
+}
+
+// formatHMS formats seconds as HH:MM:SS
+func formatHMS(total int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
@@ -0,0 +1,309 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+)
+
+// Baseline is the learned "normal state" of a camera
+type Baseline struct {
+	Source      string    `json:"source"`
+	Description string    `json:"description"` // Normal scene description
+	Elements    []string  `json:"elements"`    // Objects normally present
+	Activity    string    `json:"activity"`    // Typical activity level and patterns
+	Windows     int       `json:"windows"`     // Calibration windows used
+	CreatedAt   time.Time `json:"created_at"`
+	Notes       string    `json:"notes,omitempty"` // Optional operator notes
+}
+
+// SaveBaseline writes a baseline to a JSON file
+func SaveBaseline(path string, b *Baseline) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// LoadBaseline reads a baseline written by SaveBaseline
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to decode baseline: %w", err)
+	}
+	return &b, nil
+}
+
+// Finding is one deviation from the baseline
+type Finding struct {
+	Description string  `json:"description"`
+	Severity    float64 `json:"severity"` // 0 (negligible) to 1 (critical)
+}
+
+// Anomaly is the result of comparing a window against the baseline
+type Anomaly struct {
+	Source    string    `json:"source"`
+	Time      time.Time `json:"time"`
+	Anomalous bool      `json:"anomalous"`
+	Severity  float64   `json:"severity"` // Highest finding severity
+	Summary   string    `json:"summary"`
+	Findings  []Finding `json:"findings,omitempty"`
+	Frames    int       `json:"frames"`
+}
+
+// AnomalyConfig configures an AnomalyDetector
+type AnomalyConfig struct {
+	Source      string              // Camera name copied into results
+	Interval    time.Duration       // Analysis period (default: 30s)
+	WindowSize  int                 // Frames per analysis (default: 4)
+	Calibration time.Duration       // Calibration period when no baseline is given (default: 10m)
+	MinSeverity float64             // Minimum severity reported by Run (default: 0.3)
+	Options     *client.ChatOptions // Optional chat options
+	OnError     func(err error)     // Optional error callback
+}
+
+// maxCalibrationWindows caps the windows described in one calibration;
+// each costs an API call
+const maxCalibrationWindows = 32
+
+// AnomalyDetector learns a baseline of a camera and flags deviations
+type AnomalyDetector struct {
+	analyzer Analyzer
+	cfg      AnomalyConfig
+
+	mu       sync.Mutex
+	baseline *Baseline
+}
+
+// NewAnomalyDetector creates a detector, applying defaults to zero config values
+func NewAnomalyDetector(analyzer Analyzer, cfg AnomalyConfig) *AnomalyDetector {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = 4
+	}
+	if cfg.Calibration <= 0 {
+		cfg.Calibration = 10 * time.Minute
+	}
+	if cfg.MinSeverity == 0 {
+		cfg.MinSeverity = 0.3
+	}
+	return &AnomalyDetector{analyzer: analyzer, cfg: cfg}
+}
+
+// Baseline returns the current baseline, or nil before calibration
+func (d *AnomalyDetector) Baseline() *Baseline {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.baseline
+}
+
+// SetBaseline replaces the baseline, e.g. one loaded with LoadBaseline to
+// skip calibration
+func (d *AnomalyDetector) SetBaseline(b *Baseline) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.baseline = b
+}
+
+const describePrompt = `以下图片来自同一摄像头，处于正常状态。请描述这一常态，只返回 JSON：
+{"description": "场景描述", "elements": ["通常存在的物体或设施"], "activity": "典型的人员/车辆活动情况"}`
+
+const mergePrompt = `以下是同一摄像头在校准期间各时段的常态描述（JSON，每行一条）：
+%s
+请合并为一个常态基线，只返回 JSON：
+{"description": "场景描述", "elements": ["通常存在的物体或设施"], "activity": "典型活动规律，包括变化范围"}`
+
+const comparePrompt = `这是该摄像头的常态基线：
+场景：%s
+常见元素：%s
+典型活动：%s
+%s
+请将以下按时间顺序排列的当前画面与基线对比，找出异常（新出现或消失的物体、异常行为、设备状态变化、画面遮挡等）。正常范围内的变化不算异常。
+只返回 JSON：{"anomalous": true 或 false, "summary": "一句话总结", "findings": [{"description": "异常描述", "severity": 0 到 1}]}`
+
+// Calibrate builds a baseline from windows of frames recorded while the
+// scene was in its normal state
+func (d *AnomalyDetector) Calibrate(windows [][][]byte) (*Baseline, error) {
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("no calibration windows")
+	}
+
+	var descriptions []string
+	for i, w := range windows {
+		resp, err := d.analyzer.AnalyzeFramesWithOptions(describePrompt, w, d.cfg.Options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe calibration window %d: %w", i, err)
+		}
+		descriptions = append(descriptions, strings.Join(strings.Fields(resp.Text()), " "))
+	}
+
+	content := descriptions[0]
+	if len(descriptions) > 1 {
+		resp, err := d.analyzer.AnalyzeFramesWithOptions(fmt.Sprintf(mergePrompt, strings.Join(descriptions, "\n")), nil, d.cfg.Options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge baseline: %w", err)
+		}
+		content = resp.Text()
+	}
+
+	b := &Baseline{Source: d.cfg.Source, Windows: len(windows), CreatedAt: time.Now()}
+	if err := decodeObject(content, b); err != nil {
+		return nil, err
+	}
+	b.Source, b.Windows = d.cfg.Source, len(windows)
+	d.SetBaseline(b)
+	return b, nil
+}
+
+// Check compares a window of frames against the baseline
+func (d *AnomalyDetector) Check(frames [][]byte) (*Anomaly, error) {
+	b := d.Baseline()
+	if b == nil {
+		return nil, fmt.Errorf("no baseline: calibrate first")
+	}
+
+	notes := ""
+	if b.Notes != "" {
+		notes = "补充说明：" + b.Notes
+	}
+	prompt := fmt.Sprintf(comparePrompt, b.Description, strings.Join(b.Elements, "、"), b.Activity, notes)
+	resp, err := d.analyzer.AnalyzeFramesWithOptions(prompt, frames, d.cfg.Options)
+	if err != nil {
+		return nil, fmt.Errorf("anomaly check failed: %w", err)
+	}
+
+	a := &Anomaly{Source: d.cfg.Source, Time: time.Now(), Frames: len(frames)}
+	if err := decodeObject(resp.Text(), a); err != nil {
+		return nil, err
+	}
+	a.Source, a.Frames = d.cfg.Source, len(frames)
+	for _, f := range a.Findings {
+		if f.Severity > a.Severity {
+			a.Severity = f.Severity
+		}
+	}
+	if a.Anomalous && len(a.Findings) == 0 {
+		a.Severity = 1
+	}
+	return a, nil
+}
+
+// Run consumes frames; without a baseline it first calibrates over the
+// calibration period, then checks one window per interval and emits
+// anomalies at or above MinSeverity. A failed calibration starts over with
+// a doubled period, up to 8 times Calibration. The channel closes with ctx
+// or frames.
+func (d *AnomalyDetector) Run(ctx context.Context, frames <-chan []byte) <-chan Anomaly {
+	out := make(chan Anomaly, 8)
+
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(d.cfg.Interval)
+		defer ticker.Stop()
+
+		var (
+			window      [][]byte
+			calibration [][][]byte
+			period      = d.cfg.Calibration
+			calibrateBy = time.Now().Add(period)
+		)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case frame, ok := <-frames:
+				if !ok {
+					return
+				}
+				window = append(window, frame)
+				if len(window) > d.cfg.WindowSize {
+					window = window[len(window)-d.cfg.WindowSize:]
+				}
+
+			case now := <-ticker.C:
+				if len(window) == 0 {
+					continue
+				}
+				snapshot := append([][]byte(nil), window...)
+
+				if d.Baseline() == nil {
+					if len(calibration) == maxCalibrationWindows {
+						// Keep every other window so the set still spans
+						// the whole period
+						for i := range maxCalibrationWindows / 2 {
+							calibration[i] = calibration[2*i+1]
+						}
+						calibration = calibration[:maxCalibrationWindows/2]
+					}
+					calibration = append(calibration, snapshot)
+					if now.Before(calibrateBy) {
+						continue
+					}
+					_, err := d.Calibrate(calibration)
+					calibration = nil
+					if err != nil {
+						d.reportError(err)
+						period = min(2*period, 8*d.cfg.Calibration)
+						calibrateBy = now.Add(period)
+					}
+					continue
+				}
+
+				a, err := d.Check(snapshot)
+				if err != nil {
+					d.reportError(err)
+					continue
+				}
+				if !a.Anomalous || a.Severity < d.cfg.MinSeverity {
+					continue
+				}
+				select {
+				case out <- *a:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// reportError forwards errors to the configured callback
+func (d *AnomalyDetector) reportError(err error) {
+	if d.cfg.OnError != nil {
+		d.cfg.OnError(err)
+	}
+}
+
+// decodeObject extracts the JSON object from model output into v
+func decodeObject(content string, v interface{}) error {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end <= start {
+		return fmt.Errorf("no JSON object in model output: %q", content)
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), v); err != nil {
+		return fmt.Errorf("failed to parse model output: %w", err)
+	}
+	return nil
+}
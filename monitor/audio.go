@@ -0,0 +1,287 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// AudioTrigger finds sudden loud sounds in an audio track, optionally
+// restricted to a frequency band (see GlassBreak)
+type AudioTrigger struct {
+	Label      string        // How the sound is described to the model (default: "突发巨响")
+	Window     time.Duration // Analysis frame length (default: 50ms)
+	Background time.Duration // Trailing span whose median loudness is the baseline (default: 5s)
+	Ratio      float64       // Frame RMS must exceed the baseline by this factor (default: 4, about +12 dB)
+	MinLevel   float64       // Absolute floor in dBFS below which nothing triggers (default: -40)
+	BandLow    float64       // Optional band in Hz; when set, triggers also need
+	BandHigh   float64       //   BandRatio of their energy inside [BandLow, BandHigh]
+	BandRatio  float64       // Default 0.4 when a band is set
+	MergeGap   time.Duration // Triggers closer than this form one event (default: 2s)
+}
+
+// GlassBreak returns a trigger for loud, high-frequency impacts such as
+// breaking glass
+func GlassBreak() AudioTrigger {
+	return AudioTrigger{Label: "疑似玻璃破碎的高频冲击声", BandLow: 3000, BandHigh: 8000, BandRatio: 0.4}
+}
+
+// AudioEvent is a span of triggered audio
+type AudioEvent struct {
+	Start  time.Duration `json:"start"`
+	End    time.Duration `json:"end"`
+	PeakDB float64       `json:"peak_db"` // Loudest frame in dBFS
+	Label  string        `json:"label"`
+}
+
+// Detect returns the events in pcm, in order
+func (t AudioTrigger) Detect(pcm *processor.AudioPCM) []AudioEvent {
+	t = t.withDefaults()
+	if pcm == nil || pcm.Rate <= 0 {
+		return nil
+	}
+	size := max(int(t.Window.Seconds()*float64(pcm.Rate)), 16)
+	frameDur := time.Duration(size) * time.Second / time.Duration(pcm.Rate)
+	history := max(int(t.Background/frameDur), 1)
+	band := t.BandHigh > t.BandLow && t.BandLow >= 0
+
+	var (
+		events []AudioEvent
+		levels []float64 // RMS of every frame so far
+	)
+	for i := 0; i+size <= len(pcm.Samples); i += size {
+		frame := pcm.Samples[i : i+size]
+		rms := rmsOf(frame)
+		baseline := median(levels[max(len(levels)-history, 0):])
+		levels = append(levels, rms)
+
+		db := dbfs(rms)
+		if db < t.MinLevel || len(levels) == 1 || rms < t.Ratio*math.Max(baseline, 1) {
+			continue
+		}
+		if band && bandRatio(frame, pcm.Rate, t.BandLow, t.BandHigh) < t.BandRatio {
+			continue
+		}
+
+		start := time.Duration(i) * time.Second / time.Duration(pcm.Rate)
+		end := start + frameDur
+		if n := len(events); n > 0 && start-events[n-1].End <= t.MergeGap {
+			events[n-1].End = end
+			events[n-1].PeakDB = math.Max(events[n-1].PeakDB, db)
+			continue
+		}
+		events = append(events, AudioEvent{Start: start, End: end, PeakDB: db, Label: t.Label})
+	}
+	return events
+}
+
+// withDefaults fills zero fields
+func (t AudioTrigger) withDefaults() AudioTrigger {
+	if t.Label == "" {
+		t.Label = "突发巨响"
+	}
+	if t.Window <= 0 {
+		t.Window = 50 * time.Millisecond
+	}
+	if t.Background <= 0 {
+		t.Background = 5 * time.Second
+	}
+	if t.Ratio <= 0 {
+		t.Ratio = 4
+	}
+	if t.MinLevel == 0 {
+		t.MinLevel = -40
+	}
+	if t.BandRatio <= 0 {
+		t.BandRatio = 0.4
+	}
+	if t.MergeGap <= 0 {
+		t.MergeGap = 2 * time.Second
+	}
+	return t
+}
+
+// AudioConfig configures AnalyzeAudioEvents
+type AudioConfig struct {
+	Trigger   AudioTrigger
+	Prompt    string              // Question asked about the footage around each event
+	Before    time.Duration       // Footage before an event (default: 3s)
+	After     time.Duration       // Footage after an event (default: 5s)
+	MaxFrames int                 // Frames per event (default: 6)
+	Options   *client.ChatOptions // Optional chat options
+}
+
+// AudioResult is the analysis of the footage around one audio event
+type AudioResult struct {
+	Event    AudioEvent           `json:"event"`
+	Frames   int                  `json:"frames"`
+	Content  string               `json:"content,omitempty"`
+	Error    string               `json:"error,omitempty"`
+	Response *models.ChatResponse `json:"response,omitempty"`
+}
+
+// AnalyzeAudioEvents scans the audio of a recorded source and analyzes
+// frames only around the loud events it finds, so a long, quiet recording
+// (a parking lot at night) costs a handful of calls. Live sources are not
+// supported; run it on recorded segments instead.
+func AnalyzeAudioEvents(ctx context.Context, a Analyzer, sp *processor.StreamProcessor, src processor.Source, cfg AudioConfig) ([]AudioResult, error) {
+	if cfg.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+	if cfg.Before <= 0 {
+		cfg.Before = 3 * time.Second
+	}
+	if cfg.After <= 0 {
+		cfg.After = 5 * time.Second
+	}
+	if cfg.MaxFrames <= 0 {
+		cfg.MaxFrames = 6
+	}
+
+	ctx = models.WithLabels(ctx, processor.SourceLabels(src))
+
+	// Open once so downloads and spooled readers are shared by both passes
+	s, err := src.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	if s.Live {
+		return nil, fmt.Errorf("audio-triggered analysis needs a recorded source, got live %s", s.Name)
+	}
+
+	pcm, err := sp.ExtractAudio(ctx, processor.FileSource(s.Path), 0)
+	if err != nil {
+		return nil, err
+	}
+	total := pcm.Duration()
+
+	var results []AudioResult
+	for _, ev := range cfg.Trigger.Detect(pcm) {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		start := max(ev.Start-cfg.Before, 0)
+		end := min(ev.End+cfg.After, total)
+		result := AudioResult{Event: ev}
+
+		frames, err := sp.ExtractVideoSegment(ctx, s.Path, start, end-start, cfg.MaxFrames)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Frames = len(frames)
+
+		prompt := fmt.Sprintf("音频在 %s 处检测到%s（峰值 %.0f dBFS），以下画面取自 %s 至 %s。\n%s",
+			formatClock(ev.Start), ev.Label, ev.PeakDB, formatClock(start), formatClock(end), cfg.Prompt)
+		resp, err := a.AnalyzeFramesWithOptions(prompt, frames, cfg.Options)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			if resp.Labels == nil {
+				resp.Labels = models.LabelsFrom(ctx).Clone()
+			}
+			result.Response = resp
+			result.Content = resp.Text()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// formatClock formats an offset as MM:SS
+func formatClock(d time.Duration) string {
+	s := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d", s/60, s%60)
+}
+
+// rmsOf returns the root mean square of the samples
+func rmsOf(samples []int16) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+// dbfs converts an RMS value to decibels relative to full scale
+func dbfs(rms float64) float64 {
+	if rms <= 0 {
+		return -120
+	}
+	return 20 * math.Log10(rms/32768)
+}
+
+// median returns the median of values (0 when empty)
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// bandRatio returns the fraction of the frame's spectral energy between
+// low and high Hz
+func bandRatio(samples []int16, rate int, low, high float64) float64 {
+	n := 1
+	for n < len(samples) {
+		n <<= 1
+	}
+	x := make([]complex128, n)
+	for i, s := range samples {
+		// Hann window against spectral leakage
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(len(samples)-1))
+		x[i] = complex(float64(s)*w, 0)
+	}
+	fft(x)
+
+	var in, total float64
+	for k := 1; k < n/2; k++ {
+		e := cmplx.Abs(x[k])
+		e *= e
+		total += e
+		if f := float64(k) * float64(rate) / float64(n); f >= low && f <= high {
+			in += e
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return in / total
+}
+
+// fft is an in-place iterative radix-2 FFT; len(x) must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Analyzer is the subset of client.Client used by the monitor
+type Analyzer interface {
+	AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *client.ChatOptions) (*models.ChatResponse, error)
+}
+
+// Question is a yes/no question asked about every analyzed window
+type Question struct {
+	ID     string // Stable identifier, used as event key (e.g. "fence_climb")
+	Prompt string // Natural language question (e.g. "是否有人在翻越围栏？")
+}
+
+// EventType is the lifecycle stage of a detected event
+type EventType string
+
+const (
+	EventStart   EventType = "start"   // Condition became true
+	EventOngoing EventType = "ongoing" // Condition is still true
+	EventEnd     EventType = "end"     // Condition is no longer true
+)
+
+// Event is emitted whenever the state of a question changes or persists
+type Event struct {
+	Type       EventType `json:"type"`
+	Source     string    `json:"source"`
+	QuestionID string    `json:"question_id"`
+	Question   string    `json:"question"`
+	Detail     string    `json:"detail,omitempty"`
+	Time       time.Time `json:"time"`
+	StartedAt  time.Time `json:"started_at"`
+	Frames     int       `json:"frames"`
+}
+
+// Config configures a Monitor
+type Config struct {
+	Source          string              // Stream / camera name copied into events
+	Questions       []Question          // Questions evaluated on every window
+	Interval        time.Duration       // Analysis period (default: 10s)
+	WindowSize      int                 // Frames sent per analysis (default: 4)
+	MotionThreshold float64             // Min motion score [0,1] to analyze; negative disables gating (default: 0.02)
+	EndAfter        int                 // Consecutive negative answers before an event ends (default: 2)
+	Options         *client.ChatOptions // Optional chat options
+	OnError         func(err error)     // Optional error callback
+	Now             func() time.Time    // Clock for event times (default: time.Now; e.g. processor.LiveReplay.Now)
+}
+
+// activeEvent tracks a question whose condition is currently true
+type activeEvent struct {
+	startedAt time.Time
+	negatives int
+}
+
+// Monitor runs motion-gated periodic analysis over a frame stream and
+// turns the answers into start/ongoing/end events
+type Monitor struct {
+	analyzer Analyzer
+	cfg      Config
+	active   map[string]*activeEvent
+
+	mu      sync.Mutex
+	next    *Config       // Configuration staged by Update
+	updated chan struct{} // Signals the Run loop that next is set
+}
+
+// Update changes the configuration of a running monitor without
+// restarting it; zero fields keep the current value
+type Update struct {
+	Questions       []Question          // Replaces the question set; events of removed questions end
+	Interval        time.Duration       // New analysis period
+	WindowSize      int                 // New frames per analysis
+	MotionThreshold float64             // New motion gate; negative disables gating
+	EndAfter        int                 // New consecutive negatives before an event ends
+	Options         *client.ChatOptions // New chat options
+}
+
+// New creates a monitor, applying defaults to zero config values
+func New(analyzer Analyzer, cfg Config) (*Monitor, error) {
+	if err := validate(cfg.Questions); err != nil {
+		return nil, err
+	}
+	cfg.defaults()
+
+	return &Monitor{
+		analyzer: analyzer,
+		cfg:      cfg,
+		active:   make(map[string]*activeEvent),
+		updated:  make(chan struct{}, 1),
+	}, nil
+}
+
+// validate checks that questions are present with unique IDs
+func validate(questions []Question) error {
+	if len(questions) == 0 {
+		return fmt.Errorf("at least one question is required")
+	}
+	seen := make(map[string]bool)
+	for _, q := range questions {
+		if q.ID == "" || q.Prompt == "" {
+			return fmt.Errorf("question id and prompt are required")
+		}
+		if seen[q.ID] {
+			return fmt.Errorf("duplicate question id %q", q.ID)
+		}
+		seen[q.ID] = true
+	}
+	return nil
+}
+
+// defaults fills zero config values
+func (cfg *Config) defaults() {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = 4
+	}
+	if cfg.MotionThreshold == 0 {
+		cfg.MotionThreshold = 0.02
+	}
+	if cfg.EndAfter <= 0 {
+		cfg.EndAfter = 2
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+}
+
+// Update reconfigures the monitor. It is safe to call while Run is
+// active; the change applies before the next frame or analysis, and a new
+// Interval restarts the analysis period. Open events keep their state
+// unless their question was removed.
+func (m *Monitor) Update(u Update) error {
+	if u.Questions != nil {
+		if err := validate(u.Questions); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	cfg := m.cfg
+	if m.next != nil {
+		cfg = *m.next
+	}
+	if u.Questions != nil {
+		cfg.Questions = append([]Question(nil), u.Questions...)
+	}
+	if u.Interval > 0 {
+		cfg.Interval = u.Interval
+	}
+	if u.WindowSize > 0 {
+		cfg.WindowSize = u.WindowSize
+	}
+	if u.MotionThreshold != 0 {
+		cfg.MotionThreshold = u.MotionThreshold
+	}
+	if u.EndAfter > 0 {
+		cfg.EndAfter = u.EndAfter
+	}
+	if u.Options != nil {
+		cfg.Options = u.Options
+	}
+	m.next = &cfg
+	m.mu.Unlock()
+
+	select {
+	case m.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Config returns the configuration in effect, including staged updates
+func (m *Monitor) Config() Config {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.next != nil {
+		return *m.next
+	}
+	return m.cfg
+}
+
+// reload applies a staged update; only the Run goroutine replaces m.cfg,
+// under m.mu since Update and Config read it from other goroutines. It
+// reports whether the interval changed.
+func (m *Monitor) reload(ctx context.Context, events chan<- Event) bool {
+	m.mu.Lock()
+	next := m.next
+	m.next = nil
+	if next == nil {
+		m.mu.Unlock()
+		return false
+	}
+	kept := make(map[string]bool, len(next.Questions))
+	for _, q := range next.Questions {
+		kept[q.ID] = true
+	}
+	var removed []Question
+	for _, q := range m.cfg.Questions {
+		if !kept[q.ID] {
+			removed = append(removed, q)
+		}
+	}
+	changed := next.Interval != m.cfg.Interval
+	m.cfg = *next
+	m.mu.Unlock()
+
+	m.endQuestions(ctx, events, removed, "question removed")
+	return changed
+}
+
+// Run consumes JPEG frames (e.g. from processor.StreamFrameExtractor) and
+// returns a channel of events. The channel is closed when ctx is done or
+// the frame channel is closed; open events are ended at that point.
+func (m *Monitor) Run(ctx context.Context, frames <-chan []byte) <-chan Event {
+	events := make(chan Event, 16)
+
+	go func() {
+		defer close(events)
+
+		m.reload(ctx, events)
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+
+		var (
+			window    [][]byte
+			lastThumb []uint8
+			motion    float64
+		)
+
+		for {
+			select {
+			case <-ctx.Done():
+				// Give the consumer one interval to receive the end events
+				flush, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.cfg.Interval)
+				m.endAll(flush, events, "monitor stopped")
+				cancel()
+				return
+
+			case <-m.updated:
+				if m.reload(ctx, events) {
+					ticker.Reset(m.cfg.Interval)
+				}
+				if len(window) > m.cfg.WindowSize {
+					window = window[len(window)-m.cfg.WindowSize:]
+				}
+
+			case frame, ok := <-frames:
+				if !ok {
+					m.endAll(ctx, events, "stream closed")
+					return
+				}
+				window = append(window, frame)
+				if len(window) > m.cfg.WindowSize {
+					window = window[len(window)-m.cfg.WindowSize:]
+				}
+
+				if m.cfg.MotionThreshold > 0 {
+					thumb, err := thumbnail(frame)
+					if err != nil {
+						m.reportError(fmt.Errorf("failed to decode frame: %w", err))
+						continue
+					}
+					if lastThumb != nil {
+						if s := motionScore(lastThumb, thumb); s > motion {
+							motion = s
+						}
+					}
+					lastThumb = thumb
+				}
+
+			case <-ticker.C:
+				if len(window) == 0 {
+					continue
+				}
+				gated := m.cfg.MotionThreshold > 0 && motion < m.cfg.MotionThreshold
+				if gated && len(m.active) == 0 {
+					continue
+				}
+				motion = 0
+
+				for _, e := range m.analyze(window) {
+					select {
+					case events <- e:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return events
+}
+
+// answer is the per-question structured output requested from the model
+type answer struct {
+	Answer bool   `json:"answer"`
+	Detail string `json:"detail"`
+}
+
+// analyze asks all questions about the window and updates event state
+func (m *Monitor) analyze(window [][]byte) []Event {
+	frames := append([][]byte(nil), window...)
+
+	resp, err := m.analyzer.AnalyzeFramesWithOptions(m.prompt(), frames, m.cfg.Options)
+	var answers map[string]answer
+	switch {
+	case errors.Is(err, errdefs.ErrSkipped):
+		// The client's pre-filter saw nothing in view: every answer is "no"
+		answers = map[string]answer{}
+	case err != nil:
+		m.reportError(fmt.Errorf("analysis failed: %w", err))
+		return nil
+	case len(resp.Choices) == 0:
+		m.reportError(fmt.Errorf("analysis returned no choices"))
+		return nil
+	default:
+		if answers, err = parseAnswers(resp.Choices[0].Message.Content); err != nil {
+			m.reportError(err)
+			return nil
+		}
+	}
+
+	now := m.cfg.Now()
+	var events []Event
+	for _, q := range m.cfg.Questions {
+		a := answers[q.ID]
+		state, isActive := m.active[q.ID]
+
+		base := Event{
+			Source:     m.cfg.Source,
+			QuestionID: q.ID,
+			Question:   q.Prompt,
+			Detail:     a.Detail,
+			Time:       now,
+			Frames:     len(frames),
+		}
+
+		switch {
+		case a.Answer && !isActive:
+			m.active[q.ID] = &activeEvent{startedAt: now}
+			base.Type = EventStart
+			base.StartedAt = now
+			events = append(events, base)
+
+		case a.Answer && isActive:
+			state.negatives = 0
+			base.Type = EventOngoing
+			base.StartedAt = state.startedAt
+			events = append(events, base)
+
+		case !a.Answer && isActive:
+			state.negatives++
+			if state.negatives >= m.cfg.EndAfter {
+				delete(m.active, q.ID)
+				base.Type = EventEnd
+				base.StartedAt = state.startedAt
+				events = append(events, base)
+			}
+		}
+	}
+
+	return events
+}
+
+// prompt builds the combined question prompt requesting JSON output
+func (m *Monitor) prompt() string {
+	var b strings.Builder
+	b.WriteString("请观察以下按时间顺序排列的视频帧，并逐一回答下列问题。\n")
+	b.WriteString("只返回一个 JSON 对象，键为问题 ID，值为 {\"answer\": true 或 false, \"detail\": \"简要说明\"}。\n")
+	b.WriteString("问题：\n")
+	for _, q := range m.cfg.Questions {
+		fmt.Fprintf(&b, "- %s: %s\n", q.ID, q.Prompt)
+	}
+	return b.String()
+}
+
+// parseAnswers extracts the JSON answer object from the model output
+func parseAnswers(content string) (map[string]answer, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("no JSON object in model output: %q", content)
+	}
+
+	var answers map[string]answer
+	if err := json.Unmarshal([]byte(content[start:end+1]), &answers); err != nil {
+		return nil, fmt.Errorf("failed to parse model output: %w", err)
+	}
+	return answers, nil
+}
+
+// endAll emits end events for every active question
+func (m *Monitor) endAll(ctx context.Context, events chan<- Event, detail string) {
+	m.endQuestions(ctx, events, m.cfg.Questions, detail)
+}
+
+// endQuestions emits end events for the active questions among questions,
+// waiting for the consumer until ctx is done
+func (m *Monitor) endQuestions(ctx context.Context, events chan<- Event, questions []Question, detail string) {
+	now := m.cfg.Now()
+	for _, q := range questions {
+		state, ok := m.active[q.ID]
+		if !ok {
+			continue
+		}
+		delete(m.active, q.ID)
+		select {
+		case events <- Event{
+			Type:       EventEnd,
+			Source:     m.cfg.Source,
+			QuestionID: q.ID,
+			Question:   q.Prompt,
+			Detail:     detail,
+			Time:       now,
+			StartedAt:  state.startedAt,
+		}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reportError forwards errors to the configured callback
+func (m *Monitor) reportError(err error) {
+	if m.cfg.OnError != nil {
+		m.cfg.OnError(err)
+	}
+}
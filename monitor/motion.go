@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg" // Register the JPEG decoder
+)
+
+// thumbSize is the edge length of the grayscale thumbnail used for motion scoring
+const thumbSize = 32
+
+// thumbnail decodes a JPEG frame into a small grayscale grid
+func thumbnail(frame []byte) ([]uint8, error) {
+	img, _, err := image.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+	return grayThumb(img), nil
+}
+
+// grayThumb box-samples an image into a thumbSize x thumbSize luma grid
+func grayThumb(img image.Image) []uint8 {
+	b := img.Bounds()
+	out := make([]uint8, thumbSize*thumbSize)
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return out
+	}
+
+	for ty := 0; ty < thumbSize; ty++ {
+		for tx := 0; tx < thumbSize; tx++ {
+			x := b.Min.X + tx*w/thumbSize + w/(2*thumbSize)
+			y := b.Min.Y + ty*h/thumbSize + h/(2*thumbSize)
+			r, g, bl, _ := img.At(x, y).RGBA()
+			// ITU-R BT.601 luma, inputs are 16-bit
+			luma := (299*r + 587*g + 114*bl) / 1000
+			out[ty*thumbSize+tx] = uint8(luma >> 8)
+		}
+	}
+	return out
+}
+
+// motionScore returns the mean absolute luma difference between two
+// thumbnails, normalized to [0, 1]
+func motionScore(a, b []uint8) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 1
+	}
+
+	var sum int
+	for i := range a {
+		d := int(a[i]) - int(b[i])
+		if d < 0 {
+			d = -d
+		}
+		sum += d
+	}
+	return float64(sum) / float64(len(a)*255)
+}
@@ -0,0 +1,239 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/memory"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// DefaultUnchanged is the answer the narrator asks for when nothing changed
+const DefaultUnchanged = "无明显变化"
+
+// NarratorConfig configures a Narrator
+type NarratorConfig struct {
+	Source    string              // Stream / camera name copied into narrations
+	Focus     string              // Optional extra instructions (what to pay attention to)
+	Window    time.Duration       // Span covered by each window, mentioned in the prompt
+	History   int                 // Previous answers carried into the next prompt (default: 1)
+	Unchanged string              // Answer meaning "nothing changed" (default: DefaultUnchanged)
+	Options   *client.ChatOptions // Optional chat options
+	OnError   func(err error)     // Optional error callback used by Run and for Memory failures
+	Now       func() time.Time    // Clock for narration times (default: time.Now)
+
+	// Memory persists the carried history under Key (default: Source), so
+	// a restarted narrator continues where it left off
+	Memory memory.Memory
+	Key    string
+}
+
+// Narration is the narrator's answer for one window
+type Narration struct {
+	Source   string               `json:"source,omitempty"`
+	Index    int                  `json:"index"`
+	Text     string               `json:"text"`
+	Changed  bool                 `json:"changed"` // False when the model reported no change
+	Time     time.Time            `json:"time"`
+	Frames   int                  `json:"frames"`
+	Response *models.ChatResponse `json:"response,omitempty"`
+}
+
+// Narrator analyzes consecutive windows of one stream and feeds the
+// previous answer back into the next prompt ("previously: X; what changed
+// since?"), so a live feed yields a continuous, non-repetitive narration
+// instead of independent descriptions of every window
+type Narrator struct {
+	analyzer Analyzer
+	cfg      NarratorConfig
+
+	mu       sync.Mutex
+	history  []string
+	index    int
+	restored bool
+}
+
+// NewNarrator creates a narrator, applying defaults to zero config values
+func NewNarrator(analyzer Analyzer, cfg NarratorConfig) *Narrator {
+	if cfg.History <= 0 {
+		cfg.History = 1
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Unchanged == "" {
+		cfg.Unchanged = DefaultUnchanged
+	}
+	if cfg.Key == "" {
+		cfg.Key = cfg.Source
+	}
+	return &Narrator{analyzer: analyzer, cfg: cfg}
+}
+
+// Prompt returns the prompt the next window will be sent with
+func (n *Narrator) Prompt() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.restore()
+	return n.promptLocked()
+}
+
+// promptLocked builds the prompt from the carried history; callers hold n.mu
+func (n *Narrator) promptLocked() string {
+	span := "最近一段时间"
+	if n.cfg.Window > 0 {
+		span = "最近 " + n.cfg.Window.String()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "你正在持续观察一路实时视频，以下图片是%s内均匀采样的画面。\n", span)
+	if len(n.history) == 0 {
+		b.WriteString("请用一到三句话叙述画面中的场景与正在发生的事情。")
+	} else {
+		b.WriteString("此前的叙述：\n")
+		for _, h := range n.history {
+			b.WriteString("- " + h + "\n")
+		}
+		fmt.Fprintf(&b, "请用一到三句话只叙述自上一段以来的新变化，不要重复已经叙述过的内容；没有明显变化时只回答\"%s\"。", n.cfg.Unchanged)
+	}
+	if n.cfg.Focus != "" {
+		b.WriteString("\n关注点：" + n.cfg.Focus)
+	}
+	return b.String()
+}
+
+// Next analyzes the next window. Answers reporting no change are returned
+// with Changed false and do not replace the carried context.
+func (n *Narrator) Next(frames [][]byte) (*Narration, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames in window")
+	}
+
+	// Windows are narrated in order; holding the lock keeps the history consistent
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.restore()
+
+	resp, err := n.analyzer.AnalyzeFramesWithOptions(n.promptLocked(), frames, n.cfg.Options)
+	if errors.Is(err, errdefs.ErrSkipped) {
+		// The client's pre-filter found nothing worth analyzing
+		resp = &models.ChatResponse{Choices: []models.Choice{{Message: models.ResponseMessage{
+			Role: models.RoleAssistant, Content: n.cfg.Unchanged,
+		}}}}
+	} else if err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimSpace(resp.Text())
+	nr := &Narration{
+		Source:   n.cfg.Source,
+		Index:    n.index,
+		Text:     text,
+		Changed:  !strings.HasPrefix(text, n.cfg.Unchanged),
+		Time:     n.cfg.Now(),
+		Frames:   len(frames),
+		Response: resp,
+	}
+	n.index++
+
+	if nr.Changed && text != "" {
+		n.history = append(n.history, text)
+		if len(n.history) > n.cfg.History {
+			n.history = n.history[len(n.history)-n.cfg.History:]
+		}
+		n.persist()
+	}
+	return nr, nil
+}
+
+// restore loads the carried history from Memory once; callers hold n.mu
+func (n *Narrator) restore() {
+	if n.restored || n.cfg.Memory == nil {
+		return
+	}
+	n.restored = true
+	conv, err := n.cfg.Memory.Load(context.Background(), n.cfg.Key)
+	switch {
+	case errors.Is(err, memory.ErrNotFound):
+	case err != nil:
+		n.reportError(fmt.Errorf("failed to restore narration history: %w", err))
+	default:
+		n.history = conv.Notes
+		if len(n.history) > n.cfg.History {
+			n.history = n.history[len(n.history)-n.cfg.History:]
+		}
+	}
+}
+
+// persist saves the carried history to Memory; callers hold n.mu
+func (n *Narrator) persist() {
+	if n.cfg.Memory == nil {
+		return
+	}
+	conv := &memory.Conversation{Key: n.cfg.Key, Notes: n.history, Updated: n.cfg.Now()}
+	if err := n.cfg.Memory.Save(context.Background(), conv); err != nil {
+		n.reportError(fmt.Errorf("failed to save narration history: %w", err))
+	}
+}
+
+// reportError passes err to OnError, if set
+func (n *Narrator) reportError(err error) {
+	if n.cfg.OnError != nil {
+		n.cfg.OnError(err)
+	}
+}
+
+// Reset forgets the carried context, e.g. after the stream was interrupted
+// or the camera moved
+func (n *Narrator) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.history = nil
+	n.restored = true
+	if n.cfg.Memory != nil {
+		if err := n.cfg.Memory.Delete(context.Background(), n.cfg.Key); err != nil {
+			n.reportError(fmt.Errorf("failed to reset narration history: %w", err))
+		}
+	}
+}
+
+// Run narrates every frame window received until ctx is done or windows is
+// closed. Failed windows are reported to OnError and skipped.
+func (n *Narrator) Run(ctx context.Context, windows <-chan [][]byte) <-chan Narration {
+	out := make(chan Narration, 4)
+
+	go func() {
+		defer close(out)
+		for {
+			var frames [][]byte
+			select {
+			case <-ctx.Done():
+				return
+			case w, ok := <-windows:
+				if !ok {
+					return
+				}
+				frames = w
+			}
+
+			nr, err := n.Next(frames)
+			if err != nil {
+				n.reportError(fmt.Errorf("failed to narrate window: %w", err))
+				continue
+			}
+			select {
+			case out <- *nr:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,90 @@
+package objstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrNoCredentials is returned when no provider in a chain has credentials
+var ErrNoCredentials = errors.New("no object storage credentials found")
+
+// Credentials are access keys used for request signing
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Optional STS token
+}
+
+// CredentialProvider supplies credentials; it is called for every request
+// so rotating providers (STS, secret managers) work without restarts
+type CredentialProvider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// ProviderFunc adapts a function to CredentialProvider
+type ProviderFunc func(ctx context.Context) (Credentials, error)
+
+// Retrieve implements CredentialProvider
+func (f ProviderFunc) Retrieve(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// Static returns fixed credentials
+func Static(accessKeyID, secretAccessKey, sessionToken string) CredentialProvider {
+	return ProviderFunc(func(context.Context) (Credentials, error) {
+		if accessKeyID == "" || secretAccessKey == "" {
+			return Credentials{}, ErrNoCredentials
+		}
+		return Credentials{accessKeyID, secretAccessKey, sessionToken}, nil
+	})
+}
+
+// Env reads credentials from the first set of environment variables present
+// in the list of (id, secret, token) name triples
+func Env(names ...[3]string) CredentialProvider {
+	return ProviderFunc(func(context.Context) (Credentials, error) {
+		for _, n := range names {
+			id, secret := os.Getenv(n[0]), os.Getenv(n[1])
+			if id != "" && secret != "" {
+				token := ""
+				if n[2] != "" {
+					token = os.Getenv(n[2])
+				}
+				return Credentials{id, secret, token}, nil
+			}
+		}
+		return Credentials{}, ErrNoCredentials
+	})
+}
+
+// Environment variable names used by each provider's own SDKs
+var (
+	EnvS3  = [3]string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"}
+	EnvOSS = [3]string{"ALIBABA_CLOUD_ACCESS_KEY_ID", "ALIBABA_CLOUD_ACCESS_KEY_SECRET", "ALIBABA_CLOUD_SECURITY_TOKEN"}
+	EnvCOS = [3]string{"TENCENTCLOUD_SECRET_ID", "TENCENTCLOUD_SECRET_KEY", "TENCENTCLOUD_SESSION_TOKEN"}
+
+	envOSSLegacy = [3]string{"OSS_ACCESS_KEY_ID", "OSS_ACCESS_KEY_SECRET", "OSS_SESSION_TOKEN"}
+	envCOSLegacy = [3]string{"COS_SECRET_ID", "COS_SECRET_KEY", ""}
+)
+
+// Chain tries providers in order and returns the first credentials found
+func Chain(providers ...CredentialProvider) CredentialProvider {
+	return ProviderFunc(func(ctx context.Context) (Credentials, error) {
+		var errs []error
+		for _, p := range providers {
+			creds, err := p.Retrieve(ctx)
+			if err == nil {
+				return creds, nil
+			}
+			if !errors.Is(err, ErrNoCredentials) {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			return Credentials{}, fmt.Errorf("%w: %v", ErrNoCredentials, errors.Join(errs...))
+		}
+		return Credentials{}, ErrNoCredentials
+	})
+}
@@ -0,0 +1,358 @@
+// Package objstore fetches videos directly from S3, Aliyun OSS and Tencent
+// COS so batch jobs can reference object URIs (s3://, oss://, cos://)
+// instead of pre-downloading files, and uploads results such as archived
+// frames.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Location is a parsed object URI
+type Location struct {
+	Scheme string // s3, oss or cos
+	Bucket string
+	Key    string
+}
+
+// String returns the URI form
+func (l Location) String() string {
+	return fmt.Sprintf("%s://%s/%s", l.Scheme, l.Bucket, l.Key)
+}
+
+// ParseURI parses s3://bucket/key, oss://bucket/key or cos://bucket/key
+func ParseURI(uri string) (Location, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid object URI %q: %w", uri, err)
+	}
+
+	switch u.Scheme {
+	case "s3", "oss", "cos":
+	default:
+		return Location{}, fmt.Errorf("unsupported object URI scheme %q", u.Scheme)
+	}
+
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return Location{}, fmt.Errorf("object URI %q must include bucket and key", uri)
+	}
+	return Location{Scheme: u.Scheme, Bucket: u.Host, Key: key}, nil
+}
+
+// Store reads and writes objects of one provider/region
+type Store struct {
+	Region      string
+	Endpoint    func(bucket string) string // Base URL for a bucket (virtual-host style)
+	Credentials CredentialProvider
+	HTTPClient  *http.Client
+	ChunkSize   int64 // Ranged read size for downloads (default: 8MB)
+}
+
+// NewS3 creates a store for AWS S3 in the given region
+func NewS3(region string, creds CredentialProvider) *Store {
+	if creds == nil {
+		creds = Env(EnvS3)
+	}
+	return newStore(region, creds, func(bucket string) string {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+	})
+}
+
+// NewOSS creates a store for Aliyun OSS (region such as "cn-hangzhou")
+// using its S3-compatible endpoint
+func NewOSS(region string, creds CredentialProvider) *Store {
+	if creds == nil {
+		creds = Env(EnvOSS, envOSSLegacy)
+	}
+	return newStore(region, creds, func(bucket string) string {
+		return fmt.Sprintf("https://%s.s3.oss-%s.aliyuncs.com", bucket, region)
+	})
+}
+
+// NewCOS creates a store for Tencent COS (region such as "ap-guangzhou")
+// using its S3-compatible endpoint
+func NewCOS(region string, creds CredentialProvider) *Store {
+	if creds == nil {
+		creds = Env(EnvCOS, envCOSLegacy)
+	}
+	return newStore(region, creds, func(bucket string) string {
+		return fmt.Sprintf("https://%s.cos.%s.myqcloud.com", bucket, region)
+	})
+}
+
+// newStore applies shared defaults
+func newStore(region string, creds CredentialProvider, endpoint func(string) string) *Store {
+	return &Store{
+		Region:      region,
+		Endpoint:    endpoint,
+		Credentials: creds,
+		HTTPClient:  &http.Client{Timeout: 5 * time.Minute},
+		ChunkSize:   8 << 20,
+	}
+}
+
+var (
+	ErrNotFound = errors.New("object not found")                       // The object does not exist
+	ErrChanged  = errors.New("object changed while it was downloaded") // A ranged read's If-Match failed
+)
+
+// do sends a signed request for an object
+func (s *Store) do(ctx context.Context, method, bucket, key string, header http.Header, body []byte) (*http.Response, error) {
+	creds, err := s.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u := s.Endpoint(bucket) + "/" + escapePath(key)
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	signV4(req, creds, s.Region, time.Now())
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request object: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s/%s", ErrNotFound, bucket, key)
+	}
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s/%s", ErrChanged, bucket, key)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("object storage error (status %d): %s", resp.StatusCode, string(msg))
+	}
+	return resp, nil
+}
+
+// Size returns the object size in bytes
+func (s *Store) Size(ctx context.Context, bucket, key string) (int64, error) {
+	size, _, err := s.stat(ctx, bucket, key)
+	return size, err
+}
+
+// stat returns the object size and ETag
+func (s *Store) stat(ctx context.Context, bucket, key string) (int64, string, error) {
+	resp, err := s.do(ctx, http.MethodHead, bucket, key, nil, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	resp.Body.Close()
+	return resp.ContentLength, resp.Header.Get("ETag"), nil
+}
+
+// OpenRange returns a reader over [offset, offset+length) of the object;
+// length <= 0 reads to the end
+func (s *Store) OpenRange(ctx context.Context, bucket, key string, offset, length int64) (io.ReadCloser, error) {
+	return s.openRange(ctx, bucket, key, offset, length, "")
+}
+
+// openRange is OpenRange that fails with ErrChanged unless the object
+// still has etag (empty: any version)
+func (s *Store) openRange(ctx context.Context, bucket, key string, offset, length int64, etag string) (io.ReadCloser, error) {
+	rng := fmt.Sprintf("bytes=%d-", offset)
+	if length > 0 {
+		rng = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	header := http.Header{"Range": {rng}}
+	if etag != "" {
+		header.Set("If-Match", etag)
+	}
+	resp, err := s.do(ctx, http.MethodGet, bucket, key, header, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Put uploads data as the object at key, replacing any existing object
+func (s *Store) Put(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPut, bucket, key, header, data)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s/%s: %w", bucket, key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Download copies the object to path using ranged reads. The object's
+// ETag is kept in path+".etag"; an existing partial (or complete) file is
+// resumed only if it was written from the same object version, and every
+// ranged read requires that version with If-Match, so a replaced object
+// is never spliced into or mistaken for the local copy.
+func (s *Store) Download(ctx context.Context, bucket, key, path string) error {
+	size, etag, err := s.stat(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := info.Size()
+	etagPath := path + ".etag"
+	if stored, _ := os.ReadFile(etagPath); etag == "" || string(stored) != etag || offset > size {
+		// Unknown or different object version: start over
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		offset = 0
+		if etag != "" {
+			if err := os.WriteFile(etagPath, []byte(etag), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", etagPath, err)
+			}
+		}
+	}
+
+	chunk := s.ChunkSize
+	if chunk <= 0 {
+		chunk = 8 << 20
+	}
+
+	for offset < size {
+		n := chunk
+		if offset+n > size {
+			n = size - offset
+		}
+
+		body, err := s.openRange(ctx, bucket, key, offset, n, etag)
+		if err != nil {
+			return fmt.Errorf("failed to read range at %d: %w", offset, err)
+		}
+		written, err := copyAt(f, body, offset)
+		body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write range at %d: %w", offset, err)
+		}
+		offset += written
+	}
+	return nil
+}
+
+// copyAt writes r into f starting at offset
+func copyAt(f *os.File, r io.Reader, offset int64) (int64, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(f, r)
+}
+
+// Resolver maps URI schemes to stores
+type Resolver struct {
+	Stores map[string]*Store // Keyed by scheme: s3, oss, cos
+}
+
+// NewResolver creates a resolver with the given stores
+func NewResolver(s3, oss, cos *Store) *Resolver {
+	r := &Resolver{Stores: make(map[string]*Store)}
+	if s3 != nil {
+		r.Stores["s3"] = s3
+	}
+	if oss != nil {
+		r.Stores["oss"] = oss
+	}
+	if cos != nil {
+		r.Stores["cos"] = cos
+	}
+	return r
+}
+
+// Fetch downloads the object referenced by uri into dir and returns the
+// local path, suitable for processor functions expecting a file
+func (r *Resolver) Fetch(ctx context.Context, uri, dir string) (string, error) {
+	loc, err := ParseURI(uri)
+	if err != nil {
+		return "", err
+	}
+
+	store, ok := r.Stores[loc.Scheme]
+	if !ok {
+		return "", fmt.Errorf("no store configured for scheme %q", loc.Scheme)
+	}
+
+	// One directory per bucket, and escaped keys, so distinct objects
+	// (a/b and a_b) never share a cache file; the extension is preserved
+	bucketDir := filepath.Join(dir, url.PathEscape(loc.Bucket))
+	if err := os.MkdirAll(bucketDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create download dir: %w", err)
+	}
+	name := url.PathEscape(loc.Key)
+	if strings.HasPrefix(name, ".") {
+		name = "%2E" + name[1:] // Keys "." and ".." must not leave bucketDir
+	}
+	path := filepath.Join(bucketDir, name)
+
+	if err := store.Download(ctx, loc.Bucket, loc.Key, path); err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", uri, err)
+	}
+	return path, nil
+}
+
+// Put uploads data to the object referenced by uri
+func (r *Resolver) Put(ctx context.Context, uri string, data []byte, contentType string) error {
+	loc, err := ParseURI(uri)
+	if err != nil {
+		return err
+	}
+
+	store, ok := r.Stores[loc.Scheme]
+	if !ok {
+		return fmt.Errorf("no store configured for scheme %q", loc.Scheme)
+	}
+	return store.Put(ctx, loc.Bucket, loc.Key, data, contentType)
+}
+
+// Open returns a streaming reader for the whole object
+func (r *Resolver) Open(ctx context.Context, uri string) (io.ReadCloser, int64, error) {
+	loc, err := ParseURI(uri)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	store, ok := r.Stores[loc.Scheme]
+	if !ok {
+		return nil, 0, fmt.Errorf("no store configured for scheme %q", loc.Scheme)
+	}
+
+	resp, err := store.do(ctx, http.MethodGet, loc.Bucket, loc.Key, nil, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	return resp.Body, size, nil
+}
@@ -0,0 +1,107 @@
+package ocr
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+)
+
+// edgeThreshold is the minimum luma gradient counted as a text edge
+const edgeThreshold = 40
+
+// cropToText crops a JPEG frame to the region with the highest density of
+// sharp luma edges, which is where text usually is. The crop is expanded to
+// multiples of 28 pixels so it still satisfies GLM-4V requirements. The
+// original frame is returned if no text-like region is found.
+func cropToText(frame []byte, quality int) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w < 56 || h < 56 {
+		return frame, nil
+	}
+
+	gray := image.NewGray(b)
+	draw.Draw(gray, b, img, b.Min, draw.Src)
+
+	rowEdges := make([]int, h)
+	colEdges := make([]int, w)
+	for y := 0; y < h; y++ {
+		row := gray.Pix[y*gray.Stride : y*gray.Stride+w]
+		for x := 1; x < w; x++ {
+			d := int(row[x]) - int(row[x-1])
+			if d < 0 {
+				d = -d
+			}
+			if d > edgeThreshold {
+				rowEdges[y]++
+				colEdges[x]++
+			}
+		}
+	}
+
+	top, bottom := activeSpan(rowEdges, w/100+1)
+	left, right := activeSpan(colEdges, h/100+1)
+	if top < 0 || left < 0 {
+		return frame, nil
+	}
+
+	// Add a small margin and align to multiples of 28
+	rect := alignRect(image.Rect(left-8, top-8, right+8, bottom+8), w, h)
+	if rect.Dx()*rect.Dy() > w*h*9/10 {
+		return frame, nil
+	}
+
+	cropped := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, b.Min.Add(rect.Min), draw.Src)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, cropped, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// activeSpan returns the first and last index whose count reaches min
+func activeSpan(counts []int, min int) (int, int) {
+	first, last := -1, -1
+	for i, c := range counts {
+		if c >= min {
+			if first == -1 {
+				first = i
+			}
+			last = i
+		}
+	}
+	return first, last + 1
+}
+
+// alignRect clamps r to [0,w)x[0,h) and grows it to multiples of 28
+func alignRect(r image.Rectangle, w, h int) image.Rectangle {
+	r = r.Intersect(image.Rect(0, 0, w, h))
+
+	grow := func(lo, hi, max int) (int, int) {
+		size := ((hi - lo + 27) / 28) * 28
+		if size > max {
+			size = (max / 28) * 28
+		}
+		hi = lo + size
+		if hi > max {
+			lo -= hi - max
+			hi = max
+		}
+		if lo < 0 {
+			lo = 0
+		}
+		return lo, hi
+	}
+
+	r.Min.X, r.Max.X = grow(r.Min.X, r.Max.X, w)
+	r.Min.Y, r.Max.Y = grow(r.Min.Y, r.Max.Y, h)
+	return r
+}
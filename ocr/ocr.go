@@ -0,0 +1,163 @@
+package ocr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Analyzer is the subset of client.Client used for OCR requests
+type Analyzer interface {
+	AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *client.ChatOptions) (*models.ChatResponse, error)
+}
+
+// Prompt is the OCR prompt template asking for structured text blocks
+const Prompt = `请识别这张图片中所有可见的文字，按从上到下、从左到右的阅读顺序输出。
+只返回 JSON，格式如下：
+{"blocks": [{"text": "文字内容", "position": "top|middle|bottom"}]}
+保持原文，不要翻译、总结或补充。没有文字时返回 {"blocks": []}。`
+
+// TextBlock is a block of recognized text
+type TextBlock struct {
+	Text     string `json:"text"`
+	Position string `json:"position,omitempty"`
+}
+
+// FrameText is the recognized text of one sampled frame
+type FrameText struct {
+	Index     int           `json:"index"`
+	Timestamp time.Duration `json:"timestamp"`
+	Blocks    []TextBlock   `json:"blocks"`
+}
+
+// Extractor runs OCR-tuned analysis on screen recordings and document scans
+type Extractor struct {
+	Analyzer   Analyzer
+	Processor  *processor.StreamProcessor // Frame extraction (default: processor.NewStreamProcessor())
+	Interval   time.Duration              // One frame per interval (default: 2s)
+	CropToText bool                       // Crop frames to text-dense regions before sending
+	Prompt     string                     // OCR prompt (default: Prompt)
+	Options    *client.ChatOptions        // Optional chat options (default: temperature 0)
+}
+
+// NewExtractor creates an OCR extractor with a dedicated high resolution,
+// high quality processor (1680x1680, quality 95)
+func NewExtractor(analyzer Analyzer) *Extractor {
+	temperature := 0.0
+	return &Extractor{
+		Analyzer:   analyzer,
+		Processor:  processor.NewStreamProcessor().WithResolution(1680, 1680).WithQuality(95),
+		Interval:   2 * time.Second,
+		CropToText: true,
+		Prompt:     Prompt,
+		Options:    &client.ChatOptions{Temperature: &temperature},
+	}
+}
+
+// ExtractVideo samples a video file and returns per-frame text blocks
+func (e *Extractor) ExtractVideo(ctx context.Context, videoPath string) ([]FrameText, error) {
+	interval := e.Interval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	sp := e.processor()
+	duration, err := sp.ProbeDuration(ctx, videoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video duration: %w", err)
+	}
+
+	var results []FrameText
+	for start := time.Duration(0); start < duration; start += interval {
+		length := interval
+		if start+length > duration {
+			length = duration - start
+		}
+
+		frames, err := sp.ExtractVideoSegment(ctx, videoPath, start, length, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract frame at %v: %w", start, err)
+		}
+		if len(frames) == 0 {
+			// e.g. the tail after the last decodable frame
+			continue
+		}
+
+		blocks, err := e.ExtractFrame(frames[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to recognize frame at %v: %w", start, err)
+		}
+
+		results = append(results, FrameText{
+			Index:     len(results),
+			Timestamp: start,
+			Blocks:    blocks,
+		})
+	}
+
+	return results, nil
+}
+
+// ExtractFrame recognizes the text of a single JPEG frame
+func (e *Extractor) ExtractFrame(frame []byte) ([]TextBlock, error) {
+	if e.CropToText {
+		cropped, err := cropToText(frame, e.processor().Quality)
+		if err != nil {
+			return nil, fmt.Errorf("failed to crop frame: %w", err)
+		}
+		frame = cropped
+	}
+
+	prompt := e.Prompt
+	if prompt == "" {
+		prompt = Prompt
+	}
+
+	resp, err := e.Analyzer.AnalyzeFramesWithOptions(prompt, [][]byte{frame}, e.Options)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, nil
+	}
+
+	return ParseBlocks(resp.Choices[0].Message.Content), nil
+}
+
+// processor returns the configured processor or a default one
+func (e *Extractor) processor() *processor.StreamProcessor {
+	if e.Processor != nil {
+		return e.Processor
+	}
+	return processor.NewStreamProcessor()
+}
+
+// ParseBlocks parses model OCR output into text blocks. JSON output in the
+// Prompt format is preferred; otherwise each non-empty line becomes a block.
+func ParseBlocks(content string) []TextBlock {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start != -1 && end > start {
+		var parsed struct {
+			Blocks []TextBlock `json:"blocks"`
+		}
+		if err := json.Unmarshal([]byte(content[start:end+1]), &parsed); err == nil {
+			return parsed.Blocks
+		}
+	}
+
+	var blocks []TextBlock
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.Trim(line, "`"))
+		if line != "" {
+			blocks = append(blocks, TextBlock{Text: line})
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,171 @@
+// Package postprocess massages model answers before they reach callers and
+// sinks: stripping markdown, extracting fields with regular expressions,
+// translating, appending disclaimers. Hooks compose into a Chain that can
+// be set on the client (all calls) or on a single pipeline.
+package postprocess
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Hook transforms answer text
+type Hook interface {
+	Process(ctx context.Context, text string) (string, error)
+}
+
+// HookFunc adapts a function to Hook
+type HookFunc func(ctx context.Context, text string) (string, error)
+
+// Process implements Hook
+func (f HookFunc) Process(ctx context.Context, text string) (string, error) {
+	return f(ctx, text)
+}
+
+// Chain applies hooks in order
+type Chain []Hook
+
+// Apply runs text through every hook; the first error stops the chain
+func (c Chain) Apply(ctx context.Context, text string) (string, error) {
+	for i, h := range c {
+		var err error
+		if text, err = h.Process(ctx, text); err != nil {
+			return "", fmt.Errorf("post-processing hook %d failed: %w", i, err)
+		}
+	}
+	return text, nil
+}
+
+// ApplyResponse rewrites the content of every choice in resp
+func (c Chain) ApplyResponse(ctx context.Context, resp *models.ChatResponse) error {
+	if len(c) == 0 || resp == nil {
+		return nil
+	}
+	for i := range resp.Choices {
+		text, err := c.Apply(ctx, resp.Choices[i].Message.Content)
+		if err != nil {
+			return err
+		}
+		resp.Choices[i].Message.Content = text
+	}
+	return nil
+}
+
+type skipKey struct{}
+
+// WithoutHooks marks ctx so that the client does not post-process calls
+// made with it; hooks that call the model themselves (Translate) use it to
+// avoid recursion
+func WithoutHooks(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+// Skipped reports whether ctx was marked with WithoutHooks
+func Skipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipKey{}).(bool)
+	return skip
+}
+
+var (
+	fencePattern    = regexp.MustCompile("(?m)^```[a-zA-Z0-9_-]*\\s*$")
+	headingPattern  = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	emphasisPattern = regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`)
+	italicPattern   = regexp.MustCompile(`(^|[^*])\*([^*\n]+)\*`)
+	codePattern     = regexp.MustCompile("`([^`\n]+)`")
+	linkPattern     = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	bulletPattern   = regexp.MustCompile(`(?m)^(\s*)[-*+]\s+`)
+	quotePattern    = regexp.MustCompile(`(?m)^>\s?`)
+)
+
+// StripMarkdown removes common markdown syntax (fences, headings,
+// emphasis, inline code, links, bullets, quotes), keeping the text
+func StripMarkdown() Hook {
+	return HookFunc(func(_ context.Context, text string) (string, error) {
+		text = fencePattern.ReplaceAllString(text, "")
+		text = headingPattern.ReplaceAllString(text, "")
+		text = emphasisPattern.ReplaceAllString(text, "$2")
+		text = italicPattern.ReplaceAllString(text, "$1$2")
+		text = codePattern.ReplaceAllString(text, "$1")
+		text = linkPattern.ReplaceAllString(text, "$1")
+		text = bulletPattern.ReplaceAllString(text, "$1")
+		text = quotePattern.ReplaceAllString(text, "")
+		return strings.TrimSpace(text), nil
+	})
+}
+
+// TrimSpace trims surrounding whitespace
+func TrimSpace() Hook {
+	return HookFunc(func(_ context.Context, text string) (string, error) {
+		return strings.TrimSpace(text), nil
+	})
+}
+
+// Replace replaces every match of pattern with repl (regexp.ReplaceAllString syntax)
+func Replace(pattern *regexp.Regexp, repl string) Hook {
+	return HookFunc(func(_ context.Context, text string) (string, error) {
+		return pattern.ReplaceAllString(text, repl), nil
+	})
+}
+
+// Extract replaces the text with template expanded from the first match of
+// pattern (e.g. "$1" or "${count}"); when nothing matches the text is kept,
+// or an error is returned if required is set
+func Extract(pattern *regexp.Regexp, template string, required bool) Hook {
+	return HookFunc(func(_ context.Context, text string) (string, error) {
+		match := pattern.FindStringSubmatchIndex(text)
+		if match == nil {
+			if required {
+				return "", fmt.Errorf("no match for %s", pattern)
+			}
+			return text, nil
+		}
+		return string(pattern.ExpandString(nil, template, text, match)), nil
+	})
+}
+
+// Truncate limits the text to maxRunes characters, appending suffix when cut
+func Truncate(maxRunes int, suffix string) Hook {
+	return HookFunc(func(_ context.Context, text string) (string, error) {
+		if maxRunes <= 0 || utf8.RuneCountInString(text) <= maxRunes {
+			return text, nil
+		}
+		return string([]rune(text)[:maxRunes]) + suffix, nil
+	})
+}
+
+// AppendDisclaimer appends a disclaimer paragraph
+func AppendDisclaimer(disclaimer string) Hook {
+	return HookFunc(func(_ context.Context, text string) (string, error) {
+		return text + "\n\n" + disclaimer, nil
+	})
+}
+
+// Chatter is the subset of the client used by Translate
+type Chatter interface {
+	Chat(ctx context.Context, req *models.ChatRequest) (*models.ChatResponse, error)
+}
+
+// Translate translates the text into language (e.g. "English") with a
+// text-only model call; the call itself is not post-processed
+func Translate(c Chatter, language string) Hook {
+	return HookFunc(func(ctx context.Context, text string) (string, error) {
+		if strings.TrimSpace(text) == "" {
+			return text, nil
+		}
+		req := &models.ChatRequest{Messages: []models.Message{
+			models.SystemMessage(fmt.Sprintf(
+				"Translate the user's text into %s. Output only the translation, preserving line breaks.", language)),
+			models.UserMessage(models.Text(text)),
+		}}
+		resp, err := c.Chat(WithoutHooks(ctx), req)
+		if err != nil {
+			return "", fmt.Errorf("failed to translate: %w", err)
+		}
+		return resp.Text(), nil
+	})
+}
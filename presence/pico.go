@@ -0,0 +1,238 @@
+package presence
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register the JPEG decoder
+	"math"
+	"os"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+// Pico detects faces (or whatever its cascade was trained on) with the
+// PICO pixel-intensity-comparison cascade used by pico and pigo. It needs
+// no native libraries and reads the standard binary cascade files, e.g.
+// pigo's "facefinder" (about 230 KB).
+type Pico struct {
+	MinSize     int     // Smallest face side in pixels (default: 40)
+	MaxSize     int     // Largest face side in pixels (default: the frame's short side)
+	ShiftFactor float64 // Window step as a fraction of its size (default: 0.1)
+	ScaleFactor float64 // Size multiplier between scales (default: 1.1)
+	MinQuality  float32 // Minimum clustered score of a detection (default: 5)
+	MaxWidth    int     // Frames are downscaled to this width before scanning (default: 640)
+	Every       int     // Only scan every n-th frame of a window (default: 1)
+
+	depth      int
+	trees      int
+	codes      []int8
+	preds      []float32
+	thresholds []float32
+}
+
+// Detection is a detected region; Row and Col are its center
+type Detection struct {
+	Row, Col, Size int
+	Quality        float32
+}
+
+// LoadPico reads a cascade file
+func LoadPico(path string) (*Pico, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cascade: %w", err)
+	}
+	return NewPico(data)
+}
+
+// NewPico parses a binary PICO cascade
+func NewPico(cascade []byte) (*Pico, error) {
+	p := &Pico{}
+	r := bytes.NewReader(cascade)
+	var header struct{ _, _, Depth, Trees int32 }
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to read cascade header: %w", err)
+	}
+	if header.Depth <= 0 || header.Depth > 16 || header.Trees <= 0 {
+		return nil, fmt.Errorf("invalid cascade: depth %d, trees %d", header.Depth, header.Trees)
+	}
+	p.depth, p.trees = int(header.Depth), int(header.Trees)
+
+	leaves := 1 << p.depth
+	for t := 0; t < p.trees; t++ {
+		// Node 0 is unused; internal nodes hold four offsets each
+		codes := make([]int8, 4*leaves)
+		if err := binary.Read(r, binary.LittleEndian, codes[4:]); err != nil {
+			return nil, fmt.Errorf("failed to read tree %d: %w", t, err)
+		}
+		preds := make([]float32, leaves)
+		if err := binary.Read(r, binary.LittleEndian, preds); err != nil {
+			return nil, fmt.Errorf("failed to read tree %d: %w", t, err)
+		}
+		var threshold float32
+		if err := binary.Read(r, binary.LittleEndian, &threshold); err != nil {
+			return nil, fmt.Errorf("failed to read tree %d: %w", t, err)
+		}
+		p.codes = append(p.codes, codes...)
+		p.preds = append(p.preds, preds...)
+		p.thresholds = append(p.thresholds, threshold)
+	}
+	return p, nil
+}
+
+// Present implements Detector: true if any scanned frame has a detection
+func (p *Pico) Present(ctx context.Context, frames [][]byte) (bool, error) {
+	every := max(p.Every, 1)
+	for i := 0; i < len(frames); i += every {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		img, _, err := image.Decode(bytes.NewReader(frames[i]))
+		if err != nil {
+			return false, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+		if len(p.Detect(img)) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Detect returns the clustered detections in img, in img's coordinates
+func (p *Pico) Detect(img image.Image) []Detection {
+	gray, scale := p.grayscale(img)
+	b := gray.Bounds()
+	rows, cols := b.Dy(), b.Dx()
+
+	minSize := p.MinSize
+	if minSize <= 0 {
+		minSize = 40
+	}
+	minSize = max(int(float64(minSize)/scale), 8)
+	maxSize := min(rows, cols)
+	if p.MaxSize > 0 {
+		maxSize = min(maxSize, int(float64(p.MaxSize)/scale))
+	}
+	shift, factor := p.ShiftFactor, p.ScaleFactor
+	if shift <= 0 {
+		shift = 0.1
+	}
+	if factor <= 1 {
+		factor = 1.1
+	}
+
+	var raw []Detection
+	for size := minSize; size <= maxSize; size = int(math.Ceil(float64(size) * factor)) {
+		step := max(int(shift*float64(size)), 1)
+		offset := size/2 + 1
+		for row := offset; row <= rows-offset; row += step {
+			for col := offset; col <= cols-offset; col += step {
+				if q := p.classify(row, col, size, gray.Pix, gray.Stride); q > 0 {
+					raw = append(raw, Detection{Row: row, Col: col, Size: size, Quality: q})
+				}
+			}
+		}
+	}
+
+	minQuality := p.MinQuality
+	if minQuality <= 0 {
+		minQuality = 5
+	}
+	var out []Detection
+	for _, d := range cluster(raw) {
+		if d.Quality >= minQuality {
+			d.Row = int(float64(d.Row) * scale)
+			d.Col = int(float64(d.Col) * scale)
+			d.Size = int(float64(d.Size) * scale)
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// classify runs the cascade on the square region of side size centered at
+// (row, col); a positive result is a detection
+func (p *Pico) classify(row, col, size int, pix []uint8, stride int) float32 {
+	leaves := 1 << p.depth
+	row, col = row*256, col*256
+	var out float32
+	root := 0
+	for t := 0; t < p.trees; t++ {
+		idx := 1
+		for d := 0; d < p.depth; d++ {
+			c := p.codes[root+4*idx : root+4*idx+4]
+			x1 := ((row+int(c[0])*size)>>8)*stride + (col+int(c[1])*size)>>8
+			x2 := ((row+int(c[2])*size)>>8)*stride + (col+int(c[3])*size)>>8
+			idx = 2 * idx
+			if pix[x1] <= pix[x2] {
+				idx++
+			}
+		}
+		out += p.preds[leaves*t+idx-leaves]
+		if out <= p.thresholds[t] {
+			return -1
+		}
+		root += 4 * leaves
+	}
+	return out - p.thresholds[p.trees-1]
+}
+
+// grayscale converts img to luma, downscaled to MaxWidth; it returns the
+// factor mapping scanned coordinates back to img
+func (p *Pico) grayscale(img image.Image) (*image.Gray, float64) {
+	b := img.Bounds()
+	maxWidth := p.MaxWidth
+	if maxWidth <= 0 {
+		maxWidth = 640
+	}
+	w, h := b.Dx(), b.Dy()
+	if w > maxWidth {
+		w, h = maxWidth, max(h*maxWidth/w, 1)
+	}
+	gray := image.NewGray(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(gray, gray.Bounds(), img, b, draw.Src, nil)
+	return gray, float64(b.Dx()) / float64(w)
+}
+
+// cluster merges overlapping raw detections, averaging their positions and
+// summing their scores
+func cluster(dets []Detection) []Detection {
+	sort.Slice(dets, func(i, j int) bool { return dets[i].Quality > dets[j].Quality })
+	assigned := make([]bool, len(dets))
+	var out []Detection
+	for i := range dets {
+		if assigned[i] {
+			continue
+		}
+		var row, col, size, n int
+		var q float32
+		for j := i; j < len(dets); j++ {
+			if !assigned[j] && iou(dets[i], dets[j]) > 0.2 {
+				assigned[j] = true
+				row += dets[j].Row
+				col += dets[j].Col
+				size += dets[j].Size
+				q += dets[j].Quality
+				n++
+			}
+		}
+		out = append(out, Detection{Row: row / n, Col: col / n, Size: size / n, Quality: q})
+	}
+	return out
+}
+
+// iou returns the intersection over union of two square detections
+func iou(a, b Detection) float64 {
+	ra := image.Rect(a.Col-a.Size/2, a.Row-a.Size/2, a.Col+a.Size/2, a.Row+a.Size/2)
+	rb := image.Rect(b.Col-b.Size/2, b.Row-b.Size/2, b.Col+b.Size/2, b.Row+b.Size/2)
+	in := ra.Intersect(rb)
+	if in.Empty() {
+		return 0
+	}
+	inter := float64(in.Dx() * in.Dy())
+	return inter / (float64(ra.Dx()*ra.Dy()+rb.Dx()*rb.Dy()) - inter)
+}
@@ -0,0 +1,52 @@
+// Package presence decides locally whether a frame window is worth an API
+// call, so mostly-empty camera feeds only cost money when someone is there.
+//
+// Set a Detector as the client's PreFilter; windows without a detection
+// fail fast with errdefs.ErrSkipped instead of being sent:
+//
+//	faces, _ := presence.LoadPico("facefinder")
+//	c.PreFilter = faces
+//	_, err := c.AnalyzeFrames(prompt, frames)
+//	if errors.Is(err, errdefs.ErrSkipped) {
+//		// nobody in view, nothing spent
+//	}
+//
+// Any other local model (an ONNX person detector, a motion gate) plugs in
+// through DetectorFunc.
+package presence
+
+import (
+	"context"
+)
+
+// Detector reports whether anything of interest is present in a window
+type Detector interface {
+	Present(ctx context.Context, frames [][]byte) (bool, error)
+}
+
+// DetectorFunc adapts a function to the Detector interface
+type DetectorFunc func(ctx context.Context, frames [][]byte) (bool, error)
+
+// Present implements Detector
+func (f DetectorFunc) Present(ctx context.Context, frames [][]byte) (bool, error) {
+	return f(ctx, frames)
+}
+
+// Any is present when at least one detector is (e.g. faces or motion)
+func Any(detectors ...Detector) Detector {
+	return DetectorFunc(func(ctx context.Context, frames [][]byte) (bool, error) {
+		for _, d := range detectors {
+			ok, err := d.Present(ctx, frames)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	})
+}
+
+// Always is a detector that lets every window through (useful to disable
+// a pre-filter from configuration)
+func Always() Detector {
+	return DetectorFunc(func(context.Context, [][]byte) (bool, error) { return true, nil })
+}
@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// DefaultAudioRate is the sample rate audio is decoded at for analysis
+const DefaultAudioRate = 16000
+
+// AudioPCM is decoded mono 16-bit audio
+type AudioPCM struct {
+	Samples []int16
+	Rate    int // Samples per second
+}
+
+// Duration returns the length of the audio
+func (a *AudioPCM) Duration() time.Duration {
+	if a == nil || a.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(len(a.Samples)) * time.Second / time.Duration(a.Rate)
+}
+
+// ExtractAudio decodes the audio track of src to mono PCM at rate
+// (0 uses DefaultAudioRate); sources without audio return
+// errdefs.ErrNoAudio. Live sources are recorded for their window.
+func (sp *StreamProcessor) ExtractAudio(ctx context.Context, src Source, rate int) (*AudioPCM, error) {
+	if rate <= 0 {
+		rate = DefaultAudioRate
+	}
+	if err := sp.RequireTools(); err != nil {
+		return nil, err
+	}
+	s, err := src.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	if s.Format == FormatH264 {
+		return nil, fmt.Errorf("%w: %s is a raw H.264 stream", errdefs.ErrNoAudio, s.Name)
+	}
+
+	args := append([]string{}, s.InputArgs...)
+	args = append(args,
+		"-vn",
+		"-ac", "1",
+		"-ar", strconv.Itoa(rate),
+		"-f", "s16le",
+		"-",
+	)
+	stdout, err := sp.runTool(ctx, "ffmpeg", args, s.Live)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract audio: %w", err)
+	}
+	if len(stdout) < 2 {
+		return nil, fmt.Errorf("%w: %s", errdefs.ErrNoAudio, s.Name)
+	}
+
+	pcm := &AudioPCM{Samples: make([]int16, len(stdout)/2), Rate: rate}
+	for i := range pcm.Samples {
+		pcm.Samples[i] = int16(binary.LittleEndian.Uint16(stdout[2*i:]))
+	}
+	return pcm, nil
+}
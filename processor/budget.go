@@ -0,0 +1,169 @@
+package processor
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// FrameBudget is a token bucket of frames for one stream: it refills at
+// PerHour frames per hour up to Burst, so a camera never sends more than
+// about PerHour*24 frames a day however eventful the day is. It is a
+// Sampler that decodes extra candidates, scores them by motion and spends
+// the budget where the score is highest: quiet windows get fewer frames
+// and may not dip into the reserve, busy windows get up to the requested
+// count. A window that gets no frames fails with errdefs.ErrSkipped.
+//
+//	budget := processor.NewFrameBudget(120, 0) // 120 frames per hour
+//	frames, err := sp.ExtractSource(processor.WithSampler(ctx, budget), src, 8)
+type FrameBudget struct {
+	PerHour  float64          // Refill rate in frames per hour
+	Burst    int              // Bucket capacity (default: 10 minutes of refill, at least 1)
+	MinShare float64          // Share of the requested frames granted to the quietest windows (default 0.25)
+	Now      func() time.Time // Clock (default time.Now; e.g. LiveReplay.Now)
+
+	mu       sync.Mutex
+	tokens   float64
+	last     time.Time
+	activity float64 // Moving average of window motion, the baseline for "busy"
+	spent    int
+	skipped  int
+}
+
+// NewFrameBudget creates a full bucket refilling at perHour frames per
+// hour; burst <= 0 uses the default capacity
+func NewFrameBudget(perHour float64, burst int) *FrameBudget {
+	return &FrameBudget{PerHour: perHour, Burst: burst}
+}
+
+// Filter implements Sampler
+func (b *FrameBudget) Filter(r SampleRequest) string { return "fps=" + r.rate(oversample) }
+
+// Pick implements Sampler: it takes as many tokens as the window's motion
+// justifies and keeps that many of the highest scoring frames
+func (b *FrameBudget) Pick(c [][]byte, r SampleRequest) [][]byte {
+	if len(c) == 0 {
+		return c
+	}
+	thumbs := thumbnails(c)
+	scores := make([]float64, len(c))
+	var total float64
+	for i := 1; i < len(c); i++ {
+		if thumbs[i] != nil && thumbs[i-1] != nil {
+			scores[i] = meanAbsDiff(thumbs[i], thumbs[i-1])
+			total += scores[i]
+		}
+	}
+	want := r.Count
+	if want <= 0 || want > len(c) {
+		want = len(c)
+	}
+
+	n := b.take(total/float64(len(c)), want)
+	if n == 0 {
+		return nil
+	}
+	return topN(c, n, func(i int) float64 { return scores[i] })
+}
+
+// take refills the bucket and removes up to want tokens for a window with
+// the given motion, returning how many were taken
+func (b *FrameBudget) take(motion float64, want int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	capacity := float64(b.capacity())
+	now := time.Now()
+	if b.Now != nil {
+		now = b.Now()
+	}
+	if b.last.IsZero() {
+		b.tokens = capacity
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed.Hours()*b.PerHour)
+	}
+	b.last = now
+
+	// How busy this window is compared with the stream's usual activity
+	relative := 1.0
+	if b.activity > 0 {
+		relative = motion / b.activity
+	}
+	if b.activity == 0 {
+		b.activity = motion
+	} else {
+		b.activity = 0.9*b.activity + 0.1*motion
+	}
+
+	minShare := b.MinShare
+	if minShare <= 0 {
+		minShare = 0.25
+	}
+	share := math.Max(math.Min(relative, 1), minShare)
+	// Quiet windows leave up to half the bucket for busier ones
+	reserve := capacity / 2 * (1 - math.Min(relative, 1))
+
+	n := int(math.Min(math.Ceil(float64(want)*share), math.Floor(b.tokens-reserve)))
+	if n <= 0 {
+		b.skipped++
+		return 0
+	}
+	b.tokens -= float64(n)
+	b.spent += n
+	return n
+}
+
+// capacity returns the bucket size; callers hold b.mu
+func (b *FrameBudget) capacity() int {
+	if b.Burst > 0 {
+		return b.Burst
+	}
+	return max(int(b.PerHour/6), 1)
+}
+
+// Remaining returns the frames currently available
+func (b *FrameBudget) Remaining() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	capacity := float64(b.capacity())
+	if b.last.IsZero() {
+		return capacity
+	}
+	now := time.Now()
+	if b.Now != nil {
+		now = b.Now()
+	}
+	return math.Min(capacity, b.tokens+max(now.Sub(b.last), 0).Hours()*b.PerHour)
+}
+
+// Stats returns the frames spent and the windows skipped so far
+func (b *FrameBudget) Stats() (spent, skipped int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent, b.skipped
+}
+
+// FrameBudgets hands out one FrameBudget per stream, all with the same
+// rate, for pipelines that serve many cameras
+type FrameBudgets struct {
+	PerHour float64
+	Burst   int
+
+	mu      sync.Mutex
+	budgets map[string]*FrameBudget
+}
+
+// For returns the budget of stream, creating it on first use
+func (s *FrameBudgets) For(stream string) *FrameBudget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.budgets == nil {
+		s.budgets = make(map[string]*FrameBudget)
+	}
+	b, ok := s.budgets[stream]
+	if !ok {
+		b = NewFrameBudget(s.PerHour, s.Burst)
+		s.budgets[stream] = b
+	}
+	return b
+}
@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// PlatformCapabilities describes what the current build and platform can do
+type PlatformCapabilities struct {
+	OS            string
+	Arch          string
+	Exec          bool     // Subprocesses can be started (false on js/wasm and wasip1)
+	Rlimits       bool     // ExecLimits CPU/memory limits are enforced
+	FFmpeg        string   // Resolved ffmpeg path, empty if not found
+	FFprobe       string   // Resolved ffprobe path, empty if not found
+	FFmpegVersion string   // First line of "ffmpeg -version"
+	Decoders      []string // Video decoders of interest available in ffmpeg
+	HWAccels      []string // Hardware acceleration methods reported by ffmpeg
+}
+
+// interestingDecoders are the codecs the SDK commonly meets in the wild
+var interestingDecoders = []string{"h264", "hevc", "vp8", "vp9", "av1", "mpeg4", "mjpeg"}
+
+var (
+	capsOnce sync.Once
+	caps     *PlatformCapabilities
+)
+
+// Capabilities probes the platform once and returns the cached result.
+// Probing runs "ffmpeg -decoders" and "ffmpeg -hwaccels" when available.
+func Capabilities() *PlatformCapabilities {
+	capsOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		caps = probeCapabilities(ctx, Toolchain{})
+	})
+	return caps
+}
+
+// probeCapabilities inspects the platform and the given (or looked-up) tools
+func probeCapabilities(ctx context.Context, tools Toolchain) *PlatformCapabilities {
+	c := &PlatformCapabilities{
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Exec:    execSupported,
+		Rlimits: rlimitsSupported,
+	}
+	if !c.Exec {
+		return c
+	}
+
+	sp := &StreamProcessor{Tools: tools}
+	c.FFmpeg, _ = sp.toolPath("ffmpeg")
+	c.FFprobe, _ = sp.toolPath("ffprobe")
+	if c.FFmpeg == "" {
+		return c
+	}
+
+	if out, err := toolCommand(ctx, c.FFmpeg, "-version").Output(); err == nil {
+		c.FFmpegVersion = strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	}
+	if out, err := toolCommand(ctx, c.FFmpeg, "-hide_banner", "-decoders").Output(); err == nil {
+		available := parseDecoders(out)
+		for _, name := range interestingDecoders {
+			if available[name] {
+				c.Decoders = append(c.Decoders, name)
+			}
+		}
+	}
+	if out, err := toolCommand(ctx, c.FFmpeg, "-hide_banner", "-hwaccels").Output(); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(out))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasSuffix(line, ":") {
+				c.HWAccels = append(c.HWAccels, line)
+			}
+		}
+	}
+	return c
+}
+
+// parseDecoders extracts decoder names from "ffmpeg -decoders" output,
+// whose entries look like " V....D h264    H.264 / AVC ..."
+func parseDecoders(out []byte) map[string]bool {
+	names := map[string]bool{}
+	pastHeader := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "---") {
+			pastHeader = true
+			continue
+		}
+		fields := strings.Fields(line)
+		if pastHeader && len(fields) >= 2 {
+			names[fields[1]] = true
+		}
+	}
+	return names
+}
+
+// CanDecode reports whether ffmpeg has a decoder for codec
+func (c *PlatformCapabilities) CanDecode(codec string) bool {
+	for _, d := range c.Decoders {
+		if d == codec {
+			return true
+		}
+	}
+	return false
+}
+
+// String summarizes the capabilities on one line
+func (c *PlatformCapabilities) String() string {
+	ffmpeg := c.FFmpegVersion
+	if c.FFmpeg == "" {
+		ffmpeg = "ffmpeg not found"
+	}
+	return fmt.Sprintf("%s/%s exec=%v rlimits=%v %s decoders=%s hwaccels=%s",
+		c.OS, c.Arch, c.Exec, c.Rlimits, ffmpeg, strings.Join(c.Decoders, ","), strings.Join(c.HWAccels, ","))
+}
+
+// RequireTools checks up front that frame extraction can work here, so
+// high-level pipelines fail fast with guidance instead of mid-way (e.g.
+// after a download). It only looks tools up; it does not run them.
+func (sp *StreamProcessor) RequireTools() error {
+	if !execSupported {
+		return fmt.Errorf("%w: %s/%s cannot start ffmpeg subprocesses; extract frames on a server or in the browser and call AnalyzeFrames with JPEG data",
+			errdefs.ErrUnsupportedOnPlatform, runtime.GOOS, runtime.GOARCH)
+	}
+	for _, tool := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := sp.toolPath(tool); err != nil {
+			return fmt.Errorf("%w; install ffmpeg (e.g. brew install ffmpeg, apt install ffmpeg, winget install ffmpeg) or set %s/%s",
+				err, EnvFFmpegPath, EnvFFprobePath)
+		}
+	}
+	return nil
+}
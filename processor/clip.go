@@ -0,0 +1,214 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ExportClip cuts [start, start+duration) of a video file into an MP4 at
+// out. The footage is re-encoded (H.264/AAC, faststart), so the cut is
+// frame-accurate instead of snapping to the surrounding keyframes.
+func (sp *StreamProcessor) ExportClip(ctx context.Context, input string, start, duration time.Duration, out string) error {
+	if err := sp.RequireTools(); err != nil {
+		return err
+	}
+	if duration <= 0 {
+		return fmt.Errorf("invalid clip duration: %v", duration)
+	}
+	if start < 0 {
+		duration += start
+		start = 0
+	}
+
+	args := []string{"-v", "error", "-ss", formatSeconds(start), "-t", formatSeconds(duration)}
+	if formatFromExt(input) == FormatH264 {
+		args = append(args, "-f", "h264")
+	}
+	args = append(args,
+		"-i", input,
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-crf", "23",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-movflags", "+faststart",
+		"-f", "mp4",
+		"-y", out,
+	)
+	if _, err := sp.runTool(ctx, "ffmpeg", args, false); err != nil {
+		return fmt.Errorf("failed to export clip: %w", err)
+	}
+	return nil
+}
+
+// ExportBuffer exports the footage of a stream buffer between from and to
+// as an MP4 at out. The buffered GOPs are stamped with their arrival time,
+// so the cut is accurate to the stream's delivery jitter.
+func (sp *StreamProcessor) ExportBuffer(ctx context.Context, b *StreamBuffer, from, to time.Time, out string) error {
+	data, start := b.Range(from, to)
+	if len(data) == 0 {
+		return fmt.Errorf("stream buffer holds no footage between %s and %s", from.Format(time.TimeOnly), to.Format(time.TimeOnly))
+	}
+	if offsets := keyframeOffsets(data); len(offsets) == 0 || !isSPS(data[offsets[0]:]) {
+		// GOPs cut at an IDR slice need the processor's parameter sets
+		var err error
+		if data, err = sp.injectSPSPPS(data); err != nil {
+			return fmt.Errorf("failed to inject SPS/PPS: %w", err)
+		}
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(out), ".clip-*.h264")
+	if err != nil {
+		return fmt.Errorf("failed to write buffered footage: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write buffered footage: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write buffered footage: %w", err)
+	}
+	return sp.ExportClip(ctx, f.Name(), from.Sub(start), to.Sub(from), out)
+}
+
+// isSPS reports whether an Annex B stream starts with an SPS NAL unit
+func isSPS(data []byte) bool {
+	for i := 0; i+3 < len(data) && i < 4; i++ {
+		if data[i] == 0 && data[i+1] == 0 && data[i+2] == 1 {
+			return data[i+3]&0x1f == 7
+		}
+	}
+	return false
+}
+
+// StreamBuffer keeps the most recent Window of a live raw H.264 stream in
+// memory, split into GOPs stamped with their arrival time, so the footage
+// around an event can be exported after the event was detected. Feed it
+// with io.TeeReader or by writing every chunk the stream delivers.
+type StreamBuffer struct {
+	Window   time.Duration // Footage kept (default 2 minutes)
+	MaxBytes int64         // Memory cap; the oldest GOPs are dropped first (default 64MB)
+
+	mu        sync.Mutex
+	gops      []bufferedGOP
+	size      int64
+	pending   []byte    // Current, unfinished GOP
+	pendingAt time.Time // Arrival time of the current GOP's keyframe
+	keyed     bool      // pending starts at a keyframe
+	last      time.Time // Time of the last write
+}
+
+// bufferedGOP is one complete GOP of a stream buffer
+type bufferedGOP struct {
+	at   time.Time
+	data []byte
+}
+
+// NewStreamBuffer creates a buffer keeping window of footage
+func NewStreamBuffer(window time.Duration) *StreamBuffer {
+	return &StreamBuffer{Window: window}
+}
+
+// Write implements io.Writer; it never fails
+func (b *StreamBuffer) Write(p []byte) (int, error) {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pending) == 0 {
+		b.pendingAt = now
+	}
+	b.pending = append(b.pending, p...)
+	b.last = now
+
+	cuts := keyframeOffsets(b.pending)
+	if len(cuts) > 0 && cuts[0] == 0 {
+		b.keyed = true
+		cuts = cuts[1:]
+	}
+	prev := 0
+	for _, cut := range cuts {
+		// Data before the first keyframe (joining mid-stream) can't be decoded
+		if b.keyed {
+			b.gops = append(b.gops, bufferedGOP{at: b.pendingAt, data: append([]byte(nil), b.pending[prev:cut]...)})
+			b.size += int64(cut - prev)
+		}
+		prev, b.keyed, b.pendingAt = cut, true, now
+	}
+	if prev > 0 {
+		b.pending = append([]byte(nil), b.pending[prev:]...)
+	}
+	b.trim(now)
+	return len(p), nil
+}
+
+// trim drops GOPs older than Window or beyond MaxBytes; callers hold b.mu
+func (b *StreamBuffer) trim(now time.Time) {
+	window := b.Window
+	if window <= 0 {
+		window = 2 * time.Minute
+	}
+	maxBytes := b.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 64 << 20
+	}
+
+	drop := 0
+	// Keep the GOP straddling the window start, it holds its first frames
+	for drop+1 < len(b.gops) && now.Sub(b.gops[drop+1].at) > window {
+		drop++
+	}
+	for _, g := range b.gops[:drop] {
+		b.size -= int64(len(g.data))
+	}
+	for drop < len(b.gops) && b.size > maxBytes {
+		b.size -= int64(len(b.gops[drop].data))
+		drop++
+	}
+	b.gops = append([]bufferedGOP(nil), b.gops[drop:]...)
+}
+
+// Range returns the buffered footage covering [from, to), starting at the
+// GOP that contains from, and the arrival time of that GOP
+func (b *StreamBuffer) Range(from, to time.Time) ([]byte, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	gops := b.gops
+	if b.keyed && len(b.pending) > 0 {
+		gops = append(gops[:len(gops):len(gops)], bufferedGOP{at: b.pendingAt, data: b.pending})
+	}
+
+	first := 0
+	for i, g := range gops {
+		if !g.at.After(from) {
+			first = i
+		}
+	}
+	var (
+		data  []byte
+		start time.Time
+	)
+	for _, g := range gops[first:] {
+		if !g.at.Before(to) {
+			break
+		}
+		if data == nil {
+			start = g.at
+		}
+		data = append(data, g.data...)
+	}
+	return data, start
+}
+
+// Latest returns when the buffer last received data
+func (b *StreamBuffer) Latest() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.last
+}
@@ -0,0 +1,208 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// DownloadOptions configures Download
+type DownloadOptions struct {
+	MaxBytes     int64             // Size limit (0: no limit)
+	AllowedTypes []string          // Allowed Content-Type prefixes (default: video/ and octet-stream)
+	MaxRetries   int               // Resume attempts after a broken connection (default: 3)
+	Transport    http.RoundTripper // HTTP transport (default: http.DefaultTransport); ctx bounds the download
+}
+
+// DefaultAllowedTypes are the Content-Type prefixes Download accepts by default
+var DefaultAllowedTypes = []string{"video/", "application/octet-stream", "binary/octet-stream"}
+
+// Download fetches url into path. A broken connection is resumed with a
+// Range request guarded by If-Range, so the file is restarted from the
+// beginning instead of spliced when the remote video changed between
+// attempts or a server ignores the requested offset.
+func Download(ctx context.Context, url, path string, o DownloadOptions) error {
+	if len(o.AllowedTypes) == 0 {
+		o.AllowedTypes = DefaultAllowedTypes
+	}
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = 3
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	d := &download{url: url, f: f, opts: o, client: &http.Client{Transport: o.Transport}}
+	var lastErr error
+	for attempt := 0; attempt <= o.MaxRetries; attempt++ {
+		if attempt > 0 {
+			fmt.Printf("下载中断，正在从 %d 字节处续传（第 %d 次重试）...\n", d.offset, attempt)
+			select {
+			case <-time.After(time.Duration(attempt) * time.Second):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err := d.fetch(ctx)
+		if err == nil {
+			return nil
+		}
+		if !retryableDownload(err) {
+			return err
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("download failed after %d retries: %w", o.MaxRetries, lastErr)
+}
+
+// download is the state of one Download across resume attempts
+type download struct {
+	url       string
+	f         *os.File
+	opts      DownloadOptions
+	client    *http.Client
+	offset    int64  // Bytes written so far
+	validator string // ETag or Last-Modified of the partial content, for If-Range
+}
+
+// permanentError is a download error that retrying cannot fix
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
+
+// retryableDownload reports whether a download error may be resumed;
+// ErrInvalidRequest marks refusals such as a transport blocking the host
+func retryableDownload(err error) bool {
+	var permanent permanentError
+	if errors.As(err, &permanent) || errors.Is(err, errdefs.ErrInvalidRequest) {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// fetch requests the rest of the video and appends it; nil means done
+func (d *download) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return permanentError{fmt.Errorf("failed to create request: %w", err)}
+	}
+	if d.offset > 0 && d.validator != "" {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.offset))
+		req.Header.Set("If-Range", d.validator)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// First request, no Range support, or the video changed: start over
+		if err := d.restart(); err != nil {
+			return err
+		}
+		d.validator = validator(resp.Header)
+	case http.StatusPartialContent:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != d.offset || req.Header.Get("Range") == "" {
+			if err := d.restart(); err != nil {
+				return err
+			}
+			return fmt.Errorf("server returned range %q for offset %d", resp.Header.Get("Content-Range"), d.offset)
+		}
+	default:
+		err := fmt.Errorf("download error (status %d)", resp.StatusCode)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return permanentError{err}
+		}
+		return err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !allowedContentType(contentType, d.opts.AllowedTypes) {
+		return permanentError{fmt.Errorf("unsupported content type %q", contentType)}
+	}
+
+	limit := d.opts.MaxBytes
+	total := resp.ContentLength
+	if limit > 0 && total > 0 && d.offset+total > limit {
+		return permanentError{fmt.Errorf("%w: video is %d bytes, limit %d", errdefs.ErrPayloadTooLarge, d.offset+total, limit)}
+	}
+
+	if _, err := d.f.Seek(d.offset, io.SeekStart); err != nil {
+		return permanentError{err}
+	}
+	body := io.Reader(resp.Body)
+	if limit > 0 {
+		// One extra byte detects bodies over the limit
+		body = io.LimitReader(resp.Body, limit-d.offset+1)
+	}
+	n, err := io.Copy(d.f, body)
+	d.offset += n
+	if limit > 0 && d.offset > limit {
+		return permanentError{fmt.Errorf("%w: video exceeds limit %d bytes", errdefs.ErrPayloadTooLarge, limit)}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+	if total > 0 && n < total {
+		return fmt.Errorf("connection closed after %d of %d bytes", n, total)
+	}
+	return nil
+}
+
+// restart discards the partial content
+func (d *download) restart() error {
+	d.offset, d.validator = 0, ""
+	if err := d.f.Truncate(0); err != nil {
+		return permanentError{err}
+	}
+	return nil
+}
+
+// validator returns the strong ETag, or else Last-Modified, identifying
+// the version of the video; weak ETags cannot be used with If-Range
+func validator(h http.Header) string {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return h.Get("Last-Modified")
+}
+
+// contentRangeStart parses the first byte of a "bytes start-end/total"
+// Content-Range header
+func contentRangeStart(h string) (int64, bool) {
+	var start, end int64
+	var total string
+	if _, err := fmt.Sscanf(h, "bytes %d-%d/%s", &start, &end, &total); err != nil {
+		return 0, false
+	}
+	return start, true
+}
+
+// allowedContentType reports whether contentType matches one of the
+// allowed prefixes; a missing Content-Type is accepted
+func allowedContentType(contentType string, allowed []string) bool {
+	if contentType == "" {
+		return true
+	}
+	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	for _, prefix := range allowed {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// corruptMarkers are ffmpeg stderr fragments that indicate undecodable input
+var corruptMarkers = []string{
+	"Invalid data found when processing input",
+	"invalid NAL unit size",
+	"non-existing PPS",
+	"no frame!",
+	"moov atom not found",
+	"Could not find codec parameters",
+}
+
+// wrapFFmpegError classifies an ffmpeg/ffprobe failure into the SDK error taxonomy
+func wrapFFmpegError(tool string, err error, stderr string) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%w: %s: %v", errdefs.ErrFFmpegNotFound, tool, err)
+	}
+	if strings.Contains(stderr, "does not contain any stream") || strings.Contains(stderr, "matches no streams") {
+		return fmt.Errorf("%w: %s error: %v", errdefs.ErrNoAudio, tool, err)
+	}
+	for _, marker := range corruptMarkers {
+		if strings.Contains(stderr, marker) {
+			return fmt.Errorf("%w: %s error: %v, stderr: %s", errdefs.ErrStreamCorrupt, tool, err, stderr)
+		}
+	}
+	return fmt.Errorf("%s error: %w, stderr: %s", tool, err, stderr)
+}
@@ -0,0 +1,23 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || windows)
+
+package processor
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// execSupported is false where the runtime cannot start subprocesses
+var execSupported = runtime.GOOS != "js" && runtime.GOOS != "wasip1"
+
+const rlimitsSupported = false
+
+// configureProcess keeps the default exec behavior
+func configureProcess(cmd *exec.Cmd) {}
+
+// applyRlimits is a no-op on platforms without ulimit; Timeout and
+// MaxOutputBytes still apply
+func (l *ExecLimits) applyRlimits(cmd *exec.Cmd) {}
+
+// applyNice is a no-op on platforms without setpriority
+func (l *ExecLimits) applyNice(cmd *exec.Cmd) {}
@@ -0,0 +1,64 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package processor
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+const (
+	execSupported    = true
+	rlimitsSupported = true
+)
+
+// configureProcess starts the tool in its own process group so that
+// cancellation kills the whole group (including a ulimit shell wrapper)
+// and a terminal Ctrl-C is left to the parent to handle
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		if cmd.Process == nil {
+			return nil
+		}
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// applyRlimits wraps the command in a shell that sets resource limits
+// with ulimit before exec'ing the tool, since Go cannot set rlimits on a
+// child process directly
+func (l *ExecLimits) applyRlimits(cmd *exec.Cmd) {
+	var ulimits []string
+	if l.CPUTime > 0 {
+		seconds := int64(l.CPUTime.Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -t %d", seconds))
+	}
+	if l.MaxMemory > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -v %d", l.MaxMemory/1024))
+	}
+	if len(ulimits) == 0 {
+		return
+	}
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return
+	}
+	// Arguments are passed positionally ("$@"), never interpolated
+	script := strings.Join(ulimits, " && ") + ` && exec "$@"`
+	cmd.Args = append([]string{"sh", "-c", script, "sh", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = sh
+}
+
+// applyNice lowers the scheduling priority of the started process
+func (l *ExecLimits) applyNice(cmd *exec.Cmd) {
+	if l.Nice > 0 && cmd.Process != nil {
+		syscall.Setpriority(syscall.PRIO_PROCESS, cmd.Process.Pid, l.Nice)
+	}
+}
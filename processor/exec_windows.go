@@ -0,0 +1,35 @@
+//go:build windows
+
+package processor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// belowNormalPriorityClass is the Windows BELOW_NORMAL_PRIORITY_CLASS flag
+const belowNormalPriorityClass = 0x00004000
+
+const (
+	execSupported    = true
+	rlimitsSupported = false
+)
+
+// configureProcess starts the tool in a new process group so console
+// Ctrl-C events reach only the parent; cancellation uses the default
+// TerminateProcess-based Kill
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// applyRlimits maps Nice to a below-normal priority class; CPU and memory
+// limits are not available without job objects, Timeout and
+// MaxOutputBytes still apply
+func (l *ExecLimits) applyRlimits(cmd *exec.Cmd) {
+	if l.Nice > 0 && cmd.SysProcAttr != nil {
+		cmd.SysProcAttr.CreationFlags |= belowNormalPriorityClass
+	}
+}
+
+// applyNice is handled at creation time by applyRlimits
+func (l *ExecLimits) applyNice(cmd *exec.Cmd) {}
@@ -0,0 +1,304 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/codec"
+	"golang.org/x/image/draw"
+)
+
+// FrameFilter is one preprocessing step applied to every extracted frame
+// before it is encoded for the model
+type FrameFilter interface {
+	Apply(img image.Image) (image.Image, error)
+}
+
+// FFmpegFilter is implemented by filters that can also run inside ffmpeg;
+// a chain runs its leading ffmpeg-capable filters in the decoder and the
+// rest in Go
+type FFmpegFilter interface {
+	FrameFilter
+	FFmpeg() string
+}
+
+// FilterChain applies filters in order
+type FilterChain []FrameFilter
+
+// Apply runs img through every filter
+func (c FilterChain) Apply(img image.Image) (image.Image, error) {
+	for _, f := range c {
+		var err error
+		if img, err = f.Apply(img); err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+// split returns the ffmpeg expression for the leading ffmpeg-capable
+// filters and the remaining filters that must run in Go
+func (c FilterChain) split() (string, FilterChain) {
+	var exprs []string
+	for i, f := range c {
+		ff, ok := f.(FFmpegFilter)
+		if !ok {
+			return strings.Join(exprs, ","), c[i:]
+		}
+		exprs = append(exprs, ff.FFmpeg())
+	}
+	return strings.Join(exprs, ","), nil
+}
+
+// WithFilters replaces the default Resize+Pad preprocessing chain
+func (sp *StreamProcessor) WithFilters(filters ...FrameFilter) *StreamProcessor {
+	sp.Filters = filters
+	return sp
+}
+
+// filterChain returns sp.Filters, or the default letterbox to the target
+// size, preceded by the OSD filter for ctx
+func (sp *StreamProcessor) filterChain(ctx context.Context) FilterChain {
+	chain := sp.Filters
+	if chain == nil {
+		chain = FilterChain{Resize(sp.TargetWidth, sp.TargetHeight), Pad(sp.TargetWidth, sp.TargetHeight)}
+	}
+	if osd := sp.osdFor(ctx); osd != nil {
+		chain = append(FilterChain{osd}, chain...)
+	}
+	return chain
+}
+
+// FilterFrames returns copies of JPEG frames run through filters, e.g. to
+// downscale already extracted frames for a cheaper request
+func (sp *StreamProcessor) FilterFrames(frames [][]byte, filters ...FrameFilter) ([][]byte, error) {
+	return sp.applyGoFilters(append([][]byte(nil), frames...), filters)
+}
+
+// WithEncoder sets the encoder frames are re-encoded with, e.g. a native
+// encoder from codec.Lookup
+func (sp *StreamProcessor) WithEncoder(e codec.Encoder) *StreamProcessor {
+	sp.Encoder = e
+	return sp
+}
+
+// encoder returns sp.Encoder, or codec.Default
+func (sp *StreamProcessor) encoder() codec.Encoder {
+	if sp.Encoder != nil {
+		return sp.Encoder
+	}
+	return codec.Default()
+}
+
+// applyGoFilters decodes, filters and re-encodes frames at sp.Quality
+func (sp *StreamProcessor) applyGoFilters(frames [][]byte, chain FilterChain) ([][]byte, error) {
+	enc := sp.encoder()
+	for i, frame := range frames {
+		img, _, err := image.Decode(bytes.NewReader(frame))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+		if img, err = chain.Apply(img); err != nil {
+			return nil, fmt.Errorf("failed to filter frame %d: %w", i, err)
+		}
+		if frames[i], err = codec.EncodeBytes(enc, img, sp.Quality); err != nil {
+			return nil, fmt.Errorf("failed to encode frame %d: %w", i, err)
+		}
+	}
+	return frames, nil
+}
+
+// Resize scales frames to fit within width x height, keeping aspect ratio
+func Resize(width, height int) FrameFilter { return resizeFilter{width, height} }
+
+type resizeFilter struct{ w, h int }
+
+func (f resizeFilter) FFmpeg() string {
+	return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", f.w, f.h)
+}
+
+func (f resizeFilter) Apply(img image.Image) (image.Image, error) {
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return img, nil
+	}
+	w, h := f.w, b.Dy()*f.w/b.Dx()
+	if h > f.h {
+		w, h = b.Dx()*f.h/b.Dy(), f.h
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst, nil
+}
+
+// Pad centers frames on a black width x height canvas
+func Pad(width, height int) FrameFilter { return padFilter{width, height} }
+
+type padFilter struct{ w, h int }
+
+func (f padFilter) FFmpeg() string {
+	return fmt.Sprintf("pad=%d:%d:(ow-iw)/2:(oh-ih)/2", f.w, f.h)
+}
+
+func (f padFilter) Apply(img image.Image) (image.Image, error) {
+	b := img.Bounds()
+	if b.Dx() > f.w || b.Dy() > f.h {
+		return nil, fmt.Errorf("cannot pad %dx%d frame to %dx%d", b.Dx(), b.Dy(), f.w, f.h)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, f.w, f.h))
+	draw.Draw(dst, dst.Bounds(), image.Black, image.Point{}, draw.Src)
+	offset := image.Pt((f.w-b.Dx())/2, (f.h-b.Dy())/2)
+	draw.Draw(dst, b.Sub(b.Min).Add(offset), img, b.Min, draw.Src)
+	return dst, nil
+}
+
+// Crop keeps the given rectangle (in the coordinates of the incoming frame)
+func Crop(rect image.Rectangle) FrameFilter { return cropFilter{rect} }
+
+type cropFilter struct{ r image.Rectangle }
+
+func (f cropFilter) FFmpeg() string {
+	return fmt.Sprintf("crop=%d:%d:%d:%d", f.r.Dx(), f.r.Dy(), f.r.Min.X, f.r.Min.Y)
+}
+
+func (f cropFilter) Apply(img image.Image) (image.Image, error) {
+	b := img.Bounds()
+	r := f.r.Add(b.Min).Intersect(b)
+	if r.Empty() {
+		return nil, fmt.Errorf("crop %v is outside the %dx%d frame", f.r, b.Dx(), b.Dy())
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst, nil
+}
+
+// Rotate rotates frames clockwise by 90, 180 or 270 degrees, e.g. for
+// cameras mounted sideways
+func Rotate(degrees int) FrameFilter { return rotateFilter{((degrees % 360) + 360) % 360} }
+
+type rotateFilter struct{ deg int }
+
+func (f rotateFilter) FFmpeg() string {
+	switch f.deg {
+	case 90:
+		return "transpose=1"
+	case 180:
+		return "transpose=1,transpose=1"
+	case 270:
+		return "transpose=2"
+	}
+	return "null"
+}
+
+func (f rotateFilter) Apply(img image.Image) (image.Image, error) {
+	if f.deg%90 != 0 {
+		return nil, fmt.Errorf("unsupported rotation %d", f.deg)
+	}
+	if f.deg == 0 {
+		return img, nil
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	var dst *image.RGBA
+	if f.deg == 180 {
+		dst = image.NewRGBA(image.Rect(0, 0, w, h))
+	} else {
+		dst = image.NewRGBA(image.Rect(0, 0, h, w))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.At(b.Min.X+x, b.Min.Y+y)
+			switch f.deg {
+			case 90:
+				dst.Set(h-1-y, x, c)
+			case 180:
+				dst.Set(w-1-x, h-1-y, c)
+			case 270:
+				dst.Set(y, w-1-x, c)
+			}
+		}
+	}
+	return dst, nil
+}
+
+// Redact fills the given rectangles with black (faces, screens, plates)
+// before frames leave the machine
+func Redact(rects ...image.Rectangle) FrameFilter { return redactFilter{rects} }
+
+type redactFilter struct{ rects []image.Rectangle }
+
+func (f redactFilter) FFmpeg() string {
+	if len(f.rects) == 0 {
+		return "null"
+	}
+	boxes := make([]string, len(f.rects))
+	for i, r := range f.rects {
+		boxes[i] = fmt.Sprintf("drawbox=x=%d:y=%d:w=%d:h=%d:color=black:t=fill", r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	}
+	return strings.Join(boxes, ",")
+}
+
+func (f redactFilter) Apply(img image.Image) (image.Image, error) {
+	dst := toRGBA(img)
+	for _, r := range f.rects {
+		draw.Draw(dst, r.Add(dst.Bounds().Min), image.Black, image.Point{}, draw.Src)
+	}
+	return dst, nil
+}
+
+// Overlay draws an image (logo, timestamp, grid) at the given position;
+// it runs in Go
+func Overlay(overlay image.Image, at image.Point) FrameFilter { return overlayFilter{overlay, at} }
+
+type overlayFilter struct {
+	img image.Image
+	at  image.Point
+}
+
+func (f overlayFilter) Apply(img image.Image) (image.Image, error) {
+	dst := toRGBA(img)
+	ob := f.img.Bounds()
+	draw.Draw(dst, ob.Sub(ob.Min).Add(dst.Bounds().Min.Add(f.at)), f.img, ob.Min, draw.Over)
+	return dst, nil
+}
+
+// Enhance adjusts contrast (1 = unchanged) and brightness (-1..1, 0 =
+// unchanged), e.g. for dim night footage
+func Enhance(contrast, brightness float64) FrameFilter { return enhanceFilter{contrast, brightness} }
+
+type enhanceFilter struct{ contrast, brightness float64 }
+
+func (f enhanceFilter) FFmpeg() string {
+	return fmt.Sprintf("eq=contrast=%.3f:brightness=%.3f", f.contrast, f.brightness)
+}
+
+func (f enhanceFilter) Apply(img image.Image) (image.Image, error) {
+	dst := toRGBA(img)
+	adjust := func(v uint8) uint8 {
+		x := (float64(v)/255-0.5)*f.contrast + 0.5 + f.brightness
+		switch {
+		case x < 0:
+			return 0
+		case x > 1:
+			return 255
+		}
+		return uint8(x*255 + 0.5)
+	}
+	for i := 0; i+3 < len(dst.Pix); i += 4 {
+		dst.Pix[i] = adjust(dst.Pix[i])
+		dst.Pix[i+1] = adjust(dst.Pix[i+1])
+		dst.Pix[i+2] = adjust(dst.Pix[i+2])
+	}
+	return dst, nil
+}
+
+// toRGBA returns a mutable RGBA copy of img
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,155 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FingerprintFrames is the number of frames hashed by Fingerprint
+const FingerprintFrames = 8
+
+// Fingerprint identifies the content of a video independently of its file
+// name, container and encoding: its duration and perceptual hashes of
+// frames sampled at fixed positions. Re-uploads, renames and re-encodes
+// of the same footage produce matching fingerprints.
+type Fingerprint struct {
+	Duration time.Duration
+	Hashes   []uint64 // Difference hashes (dHash) of frames spread uniformly over the video
+}
+
+// Fingerprint computes the fingerprint of a video file. It decodes
+// FingerprintFrames uniformly spaced frames regardless of the configured
+// sampler and of power throttling, so the result only depends on the
+// content.
+func (sp *StreamProcessor) Fingerprint(ctx context.Context, videoPath string) (Fingerprint, error) {
+	duration, err := sp.ProbeDuration(ctx, videoPath)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	frames, err := sp.ExtractVideoSegment(WithSampler(withoutThrottle(ctx), Uniform()), videoPath, 0, duration, FingerprintFrames)
+	if err != nil {
+		return Fingerprint{}, fmt.Errorf("failed to extract fingerprint frames: %w", err)
+	}
+	return Fingerprint{Duration: duration, Hashes: HashFrames(frames)}, nil
+}
+
+// HashFrames returns the difference hash of each frame; undecodable frames
+// hash to 0
+func HashFrames(frames [][]byte) []uint64 {
+	hashes := make([]uint64, len(frames))
+	for i, f := range frames {
+		if img, _, err := image.Decode(bytes.NewReader(f)); err == nil {
+			hashes[i] = dHash(img)
+		}
+	}
+	return hashes
+}
+
+// dHash compares horizontally adjacent cells of a 9x8 luma grid, which is
+// robust to scaling, re-compression and small colour changes
+func dHash(img image.Image) uint64 {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return 0
+	}
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		var prev uint32
+		for x := 0; x < 9; x++ {
+			r, g, bl, _ := img.At(b.Min.X+x*w/9+w/18, b.Min.Y+y*h/8+h/16).RGBA()
+			luma := (299*r + 587*g + 114*bl) / 1000
+			if x > 0 {
+				hash <<= 1
+				if luma > prev {
+					hash |= 1
+				}
+			}
+			prev = luma
+		}
+	}
+	return hash
+}
+
+// Distance returns the mean Hamming distance (0-64) between the frame
+// hashes of f and g, or -1 when they cannot be compared
+func (f Fingerprint) Distance(g Fingerprint) float64 {
+	if len(f.Hashes) == 0 || len(f.Hashes) != len(g.Hashes) {
+		return -1
+	}
+	total := 0
+	for i := range f.Hashes {
+		total += bits.OnesCount64(f.Hashes[i] ^ g.Hashes[i])
+	}
+	return float64(total) / float64(len(f.Hashes))
+}
+
+// Matches reports whether f and g are the same content: durations within
+// one second (or 1%) and frame hashes within maxDistance bits on average
+// (<= 0 uses 6, which tolerates re-encoding but not different footage)
+func (f Fingerprint) Matches(g Fingerprint, maxDistance float64) bool {
+	if maxDistance <= 0 {
+		maxDistance = 6
+	}
+	diff := f.Duration - g.Duration
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > max(time.Second, f.Duration/100) {
+		return false
+	}
+	d := f.Distance(g)
+	return d >= 0 && d <= maxDistance
+}
+
+// String encodes the fingerprint as "<milliseconds>:<hex hash>,<hex hash>..."
+func (f Fingerprint) String() string {
+	parts := make([]string, len(f.Hashes))
+	for i, h := range f.Hashes {
+		parts[i] = fmt.Sprintf("%016x", h)
+	}
+	return strconv.FormatInt(f.Duration.Milliseconds(), 10) + ":" + strings.Join(parts, ",")
+}
+
+// ParseFingerprint parses the String form
+func ParseFingerprint(s string) (Fingerprint, error) {
+	ms, hashes, ok := strings.Cut(s, ":")
+	if !ok {
+		return Fingerprint{}, fmt.Errorf("invalid fingerprint %q", s)
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return Fingerprint{}, fmt.Errorf("invalid fingerprint duration %q: %w", ms, err)
+	}
+	f := Fingerprint{Duration: time.Duration(n) * time.Millisecond}
+	if hashes == "" {
+		return f, nil
+	}
+	for _, part := range strings.Split(hashes, ",") {
+		h, err := strconv.ParseUint(part, 16, 64)
+		if err != nil {
+			return Fingerprint{}, fmt.Errorf("invalid fingerprint hash %q: %w", part, err)
+		}
+		f.Hashes = append(f.Hashes, h)
+	}
+	return f, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (f Fingerprint) MarshalText() ([]byte, error) { return []byte(f.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (f *Fingerprint) UnmarshalText(b []byte) error {
+	parsed, err := ParseFingerprint(string(b))
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
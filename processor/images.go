@@ -0,0 +1,86 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ExtractImages processes H.264 stream data and returns decoded frames as
+// image.Image, for downstream Go CV code (gocv, custom filters) that would
+// otherwise re-decode the JPEG output
+// parallel: number of decoding goroutines (0 uses GOMAXPROCS, 1 decodes serially)
+func (sp *StreamProcessor) ExtractImages(ctx context.Context, h264Data []byte, parallel int) ([]image.Image, error) {
+	base64Frames, err := sp.ProcessH264StreamWithContext(ctx, h264Data)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([][]byte, len(base64Frames))
+	for i, b64 := range base64Frames {
+		frames[i], err = base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+	}
+
+	return DecodeFrames(frames, parallel)
+}
+
+// ExtractVideoSegmentImages is ExtractVideoSegment returning decoded images
+func (sp *StreamProcessor) ExtractVideoSegmentImages(ctx context.Context, videoPath string, start, duration time.Duration, maxFrames, parallel int) ([]image.Image, error) {
+	frames, err := sp.ExtractVideoSegment(ctx, videoPath, start, duration, maxFrames)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeFrames(frames, parallel)
+}
+
+// DecodeFrames decodes JPEG frames into images, preserving order
+// parallel: number of decoding goroutines (0 uses GOMAXPROCS, 1 decodes serially)
+func DecodeFrames(frames [][]byte, parallel int) ([]image.Image, error) {
+	if parallel <= 0 {
+		parallel = runtime.GOMAXPROCS(0)
+	}
+	if parallel > len(frames) {
+		parallel = len(frames)
+	}
+
+	images := make([]image.Image, len(frames))
+	errs := make([]error, len(frames))
+
+	if parallel <= 1 {
+		for i, frame := range frames {
+			images[i], _, errs[i] = image.Decode(bytes.NewReader(frame))
+		}
+	} else {
+		var wg sync.WaitGroup
+		next := make(chan int)
+		for w := 0; w < parallel; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					images[i], _, errs[i] = image.Decode(bytes.NewReader(frames[i]))
+				}
+			}()
+		}
+		for i := range frames {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode frame %d: %w", i, err)
+		}
+	}
+	return images, nil
+}
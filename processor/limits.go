@@ -0,0 +1,160 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// InputLimits are hard limits on input videos, enforced when a video is
+// probed so oversized uploads are rejected before any decoding work.
+// Zero fields are not enforced.
+type InputLimits struct {
+	MaxBytes    int64         // Max file (or raw stream) size
+	MaxDuration time.Duration // Max video duration
+	MaxWidth    int           // Max frame width in pixels
+	MaxHeight   int           // Max frame height in pixels
+}
+
+// DefaultInputLimits returns limits suited to a public server: 200MB,
+// 10 minutes, up to 4096x4096 (4K in either orientation)
+func DefaultInputLimits() *InputLimits {
+	return &InputLimits{
+		MaxBytes:    200 << 20,
+		MaxDuration: 10 * time.Minute,
+		MaxWidth:    4096,
+		MaxHeight:   4096,
+	}
+}
+
+// InputLimitError reports which input limit was exceeded; it wraps
+// errdefs.ErrPayloadTooLarge
+type InputLimitError struct {
+	Limit  string // "bytes", "duration" or "resolution"
+	Actual string
+	Max    string
+}
+
+// Error implements error
+func (e *InputLimitError) Error() string {
+	return fmt.Sprintf("input %s %s exceeds limit %s", e.Limit, e.Actual, e.Max)
+}
+
+// Unwrap maps the error to errdefs.ErrPayloadTooLarge
+func (e *InputLimitError) Unwrap() error {
+	return errdefs.ErrPayloadTooLarge
+}
+
+// VideoInfo is the result of probing a video file
+type VideoInfo struct {
+	Size     int64
+	Duration time.Duration
+	Width    int
+	Height   int
+}
+
+// WithInputLimits sets the limits checked by Probe, ProbeDuration and the
+// H.264 stream entry points
+func (sp *StreamProcessor) WithInputLimits(limits *InputLimits) *StreamProcessor {
+	sp.InputLimits = limits
+	return sp
+}
+
+// Probe returns the size, duration and resolution of a video file and
+// checks them against sp.InputLimits
+func (sp *StreamProcessor) Probe(ctx context.Context, videoPath string) (*VideoInfo, error) {
+	info := &VideoInfo{}
+	if st, err := os.Stat(videoPath); err == nil {
+		info.Size = st.Size()
+	}
+	// Check size before spending an ffprobe run on the file
+	if err := sp.InputLimits.checkBytes(info.Size); err != nil {
+		return nil, err
+	}
+
+	if err := sp.probeInto(ctx, videoPath, info); err != nil {
+		return nil, err
+	}
+
+	if err := sp.InputLimits.check(info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// probeInto fills duration and resolution with one ffprobe run, without
+// enforcing limits
+func (sp *StreamProcessor) probeInto(ctx context.Context, videoPath string, info *VideoInfo) error {
+	stream, err := sp.videoStream(ctx, []string{"-i", videoPath}, false)
+	if err != nil {
+		return err
+	}
+	stdout, err := sp.runTool(ctx, "ffprobe", []string{
+		"-v", "error",
+		"-select_streams", stream,
+		"-show_entries", "format=duration:stream=width,height",
+		"-of", "default=noprint_wrappers=1",
+		"-i", videoPath,
+	}, false)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(stdout), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "width":
+			info.Width, _ = strconv.Atoi(value)
+		case "height":
+			info.Height, _ = strconv.Atoi(value)
+		case "duration":
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse duration %q: %w", value, err)
+			}
+			info.Duration = time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return nil
+}
+
+// checkBytes enforces MaxBytes; a nil receiver enforces nothing
+func (l *InputLimits) checkBytes(size int64) error {
+	if l == nil || l.MaxBytes <= 0 || size <= l.MaxBytes {
+		return nil
+	}
+	return &InputLimitError{
+		Limit:  "bytes",
+		Actual: strconv.FormatInt(size, 10),
+		Max:    strconv.FormatInt(l.MaxBytes, 10),
+	}
+}
+
+// check enforces all limits against probed info
+func (l *InputLimits) check(info *VideoInfo) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.checkBytes(info.Size); err != nil {
+		return err
+	}
+	if l.MaxDuration > 0 && info.Duration > l.MaxDuration {
+		return &InputLimitError{Limit: "duration", Actual: info.Duration.String(), Max: l.MaxDuration.String()}
+	}
+	if (l.MaxWidth > 0 && info.Width > l.MaxWidth) || (l.MaxHeight > 0 && info.Height > l.MaxHeight) {
+		return &InputLimitError{
+			Limit:  "resolution",
+			Actual: fmt.Sprintf("%dx%d", info.Width, info.Height),
+			Max:    fmt.Sprintf("%dx%d", l.MaxWidth, l.MaxHeight),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// LiveReplay feeds a recorded file into the live pipeline as if it came
+// from a camera: frames are decoded at sp.FPS and emitted at real-time (or
+// Speed times faster) pace with synthetic capture times, so monitoring
+// rules, alerting and summaries can be tried on historical footage before
+// going live.
+//
+//	lr := processor.NewLiveReplay(sp, "yesterday.mp4")
+//	lr.Speed = 10
+//	events := m.Run(ctx, lr.Frames(ctx)) // with monitor.Config{Now: lr.Now}
+type LiveReplay struct {
+	Path  string
+	Speed float64       // Playback speed (default 1, real time; 10 plays ten times faster)
+	Start time.Time     // Synthetic wall time of the recording's first frame (default: when Run starts)
+	Loop  bool          // Start over at the end instead of stopping
+	Chunk time.Duration // Footage decoded per ffmpeg run (default 10s)
+
+	sp      *StreamProcessor
+	mu      sync.Mutex
+	started time.Time // Real time playback started
+	start   time.Time // Synthetic time at started
+	recent  [][]byte  // Last emitted frames, for Window
+	err     error
+}
+
+// ReplayFrame is one frame of a live replay
+type ReplayFrame struct {
+	Data   []byte
+	Offset time.Duration // Playback position, growing across loops
+	Time   time.Time     // Synthetic capture time (Start + Offset)
+}
+
+// maxRecent bounds the frames kept for Window
+const maxRecent = 64
+
+// NewLiveReplay creates a real-time replay of a recorded video file
+func NewLiveReplay(sp *StreamProcessor, path string) *LiveReplay {
+	return &LiveReplay{Path: path, Speed: 1, sp: sp}
+}
+
+// Run plays the recording, calling fn with each frame when it is due,
+// until the end of the recording (unless Loop is set), ctx is done or fn
+// returns an error. A replay can only be run once at a time.
+func (lr *LiveReplay) Run(ctx context.Context, fn func(ReplayFrame) error) error {
+	if err := lr.sp.RequireTools(); err != nil {
+		return err
+	}
+	if formatFromExt(lr.Path) == FormatH264 {
+		return fmt.Errorf("live replay needs a recorded container, got raw H.264 %s", lr.Path)
+	}
+	duration, err := lr.sp.ProbeDuration(ctx, lr.Path)
+	if err != nil {
+		return fmt.Errorf("failed to probe video duration: %w", err)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("recording %s has no duration", lr.Path)
+	}
+
+	speed := lr.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	chunk := lr.Chunk
+	if chunk <= 0 {
+		chunk = 10 * time.Second
+	}
+
+	lr.mu.Lock()
+	lr.started = time.Now()
+	lr.start = lr.Start
+	if lr.start.IsZero() {
+		lr.start = lr.started
+	}
+	lr.recent = nil
+	started, start := lr.started, lr.start
+	lr.mu.Unlock()
+
+	for base := time.Duration(0); ; base += duration {
+		for pos := time.Duration(0); pos < duration; pos += chunk {
+			length := min(chunk, duration-pos)
+			frames, err := lr.sp.ExtractVideoSegment(ctx, lr.Path, pos, length, 0)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decode %s at %s: %w", lr.Path, formatSeconds(pos), err)
+			}
+
+			for i, data := range frames {
+				offset := base + pos + length*time.Duration(i)/time.Duration(len(frames))
+				due := started.Add(time.Duration(float64(offset) / speed))
+				if wait := time.Until(due); wait > 0 {
+					timer := time.NewTimer(wait)
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+						return nil
+					case <-timer.C:
+					}
+				}
+
+				lr.remember(data)
+				if err := fn(ReplayFrame{Data: data, Offset: offset, Time: start.Add(offset)}); err != nil {
+					return err
+				}
+			}
+		}
+		if !lr.Loop {
+			return nil
+		}
+	}
+}
+
+// Frames runs the replay in the background and returns its frames, e.g.
+// for monitor.Monitor.Run. The channel is closed when the replay ends; Err
+// then reports why it stopped.
+func (lr *LiveReplay) Frames(ctx context.Context) <-chan []byte {
+	frames := make(chan []byte, 16)
+	go func() {
+		defer close(frames)
+		err := lr.Run(ctx, func(f ReplayFrame) error {
+			select {
+			case frames <- f.Data:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		lr.mu.Lock()
+		lr.err = err
+		lr.mu.Unlock()
+	}()
+	return frames
+}
+
+// Err returns the error that stopped a Frames replay, if any
+func (lr *LiveReplay) Err() error {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.err
+}
+
+// Now returns the current synthetic time: Start advanced by the elapsed
+// real time times Speed. Before Run it returns Start (or the real time).
+func (lr *LiveReplay) Now() time.Time {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	if lr.started.IsZero() {
+		if lr.Start.IsZero() {
+			return time.Now()
+		}
+		return lr.Start
+	}
+	speed := lr.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	return lr.start.Add(time.Duration(float64(time.Since(lr.started)) * speed))
+}
+
+// Clock returns a wall clock mapping playback offsets to synthetic times,
+// for AnalysisResult.ApplyClock and alert timestamps
+func (lr *LiveReplay) Clock() *models.WallClock {
+	lr.mu.Lock()
+	start := lr.start
+	lr.mu.Unlock()
+	if start.IsZero() {
+		start = lr.Now()
+	}
+	return models.NewWallClock(start)
+}
+
+// Window returns up to n of the most recently emitted frames, for
+// scheduler grabbers that sample the replay like a camera snapshot
+func (lr *LiveReplay) Window(n int) [][]byte {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	if n <= 0 || n > len(lr.recent) {
+		n = len(lr.recent)
+	}
+	return append([][]byte(nil), lr.recent[len(lr.recent)-n:]...)
+}
+
+// remember keeps data for Window
+func (lr *LiveReplay) remember(data []byte) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.recent = append(lr.recent, data)
+	if len(lr.recent) > maxRecent {
+		lr.recent = lr.recent[len(lr.recent)-maxRecent:]
+	}
+}
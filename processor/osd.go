@@ -0,0 +1,181 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// OSDRegion is an area of burned-in camera text (timestamp, camera name,
+// channel logo) as fractions of the frame, so one configuration fits every
+// resolution a camera streams at
+type OSDRegion struct {
+	X, Y, W, H float64 // Top-left corner and size, 0-1
+}
+
+// Typical OSD placements of IP cameras and NVRs
+var (
+	OSDTopLeft     = OSDRegion{X: 0, Y: 0, W: 0.45, H: 0.08}
+	OSDTopRight    = OSDRegion{X: 0.55, Y: 0, W: 0.45, H: 0.08}
+	OSDBottomLeft  = OSDRegion{X: 0, Y: 0.92, W: 0.45, H: 0.08}
+	OSDBottomRight = OSDRegion{X: 0.55, Y: 0.92, W: 0.45, H: 0.08}
+	OSDTop         = OSDRegion{X: 0, Y: 0, W: 1, H: 0.08}
+	OSDBottom      = OSDRegion{X: 0, Y: 0.92, W: 1, H: 0.08}
+)
+
+// osdRegions maps the names accepted by ParseOSD
+var osdRegions = map[string]OSDRegion{
+	"top-left": OSDTopLeft, "top-right": OSDTopRight,
+	"bottom-left": OSDBottomLeft, "bottom-right": OSDBottomRight,
+	"top": OSDTop, "bottom": OSDBottom,
+}
+
+// rect converts the region to pixels of a w x h frame
+func (r OSDRegion) rect(w, h int) image.Rectangle {
+	return image.Rect(int(r.X*float64(w)), int(r.Y*float64(h)),
+		int((r.X+r.W)*float64(w)+0.5), int((r.Y+r.H)*float64(h)+0.5))
+}
+
+// MaskOSD fills OSD regions with black, so on-screen text does not draw
+// the model's attention away from the scene. Place it before Resize/Pad
+// (WithOSD does), since regions are relative to the camera frame.
+func MaskOSD(regions ...OSDRegion) FrameFilter { return osdMaskFilter{regions} }
+
+type osdMaskFilter struct{ regions []OSDRegion }
+
+func (f osdMaskFilter) FFmpeg() string {
+	if len(f.regions) == 0 {
+		return "null"
+	}
+	boxes := make([]string, len(f.regions))
+	for i, r := range f.regions {
+		boxes[i] = fmt.Sprintf("drawbox=x=iw*%.4f:y=ih*%.4f:w=iw*%.4f:h=ih*%.4f:color=black:t=fill", r.X, r.Y, r.W, r.H)
+	}
+	return strings.Join(boxes, ",")
+}
+
+func (f osdMaskFilter) Apply(img image.Image) (image.Image, error) {
+	dst := toRGBA(img)
+	b := dst.Bounds()
+	for _, r := range f.regions {
+		draw.Draw(dst, r.rect(b.Dx(), b.Dy()), image.Black, image.Point{}, draw.Src)
+	}
+	return dst, nil
+}
+
+// CropOSD crops OSD regions away instead of masking them: each region is
+// removed together with the band between it and the nearest frame edge,
+// choosing the cut that keeps the most picture. It suits text in corners
+// or edge strips; masking suits text over the middle of the picture.
+func CropOSD(regions ...OSDRegion) FrameFilter {
+	keep := OSDRegion{W: 1, H: 1}
+	for _, r := range regions {
+		keep = keep.without(r)
+	}
+	return osdCropFilter{keep}
+}
+
+type osdCropFilter struct{ keep OSDRegion }
+
+// without shrinks k by the edge band that removes r at the least cost
+func (k OSDRegion) without(r OSDRegion) OSDRegion {
+	if r.X >= k.X+k.W || r.X+r.W <= k.X || r.Y >= k.Y+k.H || r.Y+r.H <= k.Y {
+		return k // Already outside
+	}
+	top, bottom := k, k
+	top.Y, top.H = r.Y+r.H, k.Y+k.H-(r.Y+r.H)
+	bottom.H = r.Y - k.Y
+	left, right := k, k
+	left.X, left.W = r.X+r.W, k.X+k.W-(r.X+r.W)
+	right.W = r.X - k.X
+
+	best := OSDRegion{}
+	for _, c := range []OSDRegion{top, bottom, left, right} {
+		if c.W > 0 && c.H > 0 && c.W*c.H > best.W*best.H {
+			best = c
+		}
+	}
+	return best
+}
+
+func (f osdCropFilter) FFmpeg() string {
+	return fmt.Sprintf("crop=w=iw*%.4f:h=ih*%.4f:x=iw*%.4f:y=ih*%.4f", f.keep.W, f.keep.H, f.keep.X, f.keep.Y)
+}
+
+func (f osdCropFilter) Apply(img image.Image) (image.Image, error) {
+	b := img.Bounds()
+	r := f.keep.rect(b.Dx(), b.Dy())
+	if r.Empty() {
+		return nil, fmt.Errorf("OSD regions leave nothing of the %dx%d frame", b.Dx(), b.Dy())
+	}
+	return cropFilter{r}.Apply(img)
+}
+
+// ParseOSD parses an OSD configuration for flags and config files: an
+// optional "mask:" (default) or "crop:" prefix followed by comma-separated
+// region names (top-left, top-right, bottom-left, bottom-right, top,
+// bottom) or "x/y/w/h" fractions, e.g. "crop:top-left,bottom-right"
+func ParseOSD(spec string) (FrameFilter, error) {
+	mode, list, ok := strings.Cut(spec, ":")
+	if !ok {
+		mode, list = "mask", spec
+	}
+	var regions []OSDRegion
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if r, ok := osdRegions[name]; ok {
+			regions = append(regions, r)
+			continue
+		}
+		parts := strings.Split(name, "/")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("unknown OSD region %q", name)
+		}
+		var v [4]float64
+		for i, p := range parts {
+			f, err := strconv.ParseFloat(p, 64)
+			if err != nil || f < 0 || f > 1 {
+				return nil, fmt.Errorf("invalid OSD region %q: values must be fractions 0-1", name)
+			}
+			v[i] = f
+		}
+		regions = append(regions, OSDRegion{X: v[0], Y: v[1], W: v[2], H: v[3]})
+	}
+	switch mode {
+	case "mask":
+		return MaskOSD(regions...), nil
+	case "crop":
+		return CropOSD(regions...), nil
+	}
+	return nil, fmt.Errorf("unknown OSD mode %q (want mask or crop)", mode)
+}
+
+type osdKey struct{}
+
+// WithOSD overrides the processor's OSD filter for calls made with ctx,
+// e.g. per camera; nil disables it
+func WithOSD(ctx context.Context, f FrameFilter) context.Context {
+	return context.WithValue(ctx, osdKey{}, osdOverride{f})
+}
+
+// osdOverride wraps the ctx value so a nil filter still overrides
+type osdOverride struct{ f FrameFilter }
+
+// WithOSD sets a MaskOSD or CropOSD filter run ahead of the preprocessing
+// chain (default or WithFilters), on the frame as the camera sent it
+func (sp *StreamProcessor) WithOSD(f FrameFilter) *StreamProcessor {
+	sp.OSD = f
+	return sp
+}
+
+// osdFor returns the OSD filter from ctx or sp.OSD, or nil
+func (sp *StreamProcessor) osdFor(ctx context.Context) FrameFilter {
+	if o, ok := ctx.Value(osdKey{}).(osdOverride); ok {
+		return o.f
+	}
+	return sp.OSD
+}
@@ -0,0 +1,211 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Load is a reading of the device's thermal and CPU state
+type Load struct {
+	Temperature float64 // Hottest thermal zone in °C (0: unknown)
+	CPU         float64 // 1-minute load average per core (1: all cores busy, 0: unknown)
+}
+
+// Sensor reads the device load, e.g. from sysfs or a board-specific tool
+// such as tegrastats or vcgencmd
+type Sensor interface {
+	Read(ctx context.Context) (Load, error)
+}
+
+// SensorFunc adapts a function to Sensor
+type SensorFunc func(ctx context.Context) (Load, error)
+
+// Read implements Sensor
+func (f SensorFunc) Read(ctx context.Context) (Load, error) {
+	return f(ctx)
+}
+
+// SystemSensor reads the hottest /sys/class/thermal zone and /proc/loadavg
+// (Linux, including Jetson and Raspberry Pi)
+func SystemSensor() Sensor {
+	return SensorFunc(func(context.Context) (Load, error) {
+		var l Load
+		zones, _ := filepath.Glob("/sys/class/thermal/thermal_zone*/temp")
+		for _, zone := range zones {
+			data, err := os.ReadFile(zone)
+			if err != nil {
+				continue
+			}
+			milli, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+			if err != nil {
+				continue
+			}
+			l.Temperature = max(l.Temperature, milli/1000)
+		}
+
+		if data, err := os.ReadFile("/proc/loadavg"); err == nil {
+			if fields := strings.Fields(string(data)); len(fields) > 0 {
+				if avg, err := strconv.ParseFloat(fields[0], 64); err == nil {
+					l.CPU = avg / float64(runtime.NumCPU())
+				}
+			}
+		}
+
+		if l.Temperature == 0 && l.CPU == 0 {
+			return l, fmt.Errorf("no thermal zone or load average available")
+		}
+		return l, nil
+	})
+}
+
+// PowerProfile throttles frame extraction on edge devices: it caps ffmpeg
+// threads and lowers the sampling rate while the device is hot or busy, so
+// Jetson/RPi deployments degrade gracefully instead of thermally throttling.
+// Zero thresholds are not enforced.
+type PowerProfile struct {
+	Threads      int           // Cap on ffmpeg threads, applied on top of Limits.Threads
+	Sensor       Sensor        // Load sensor (nil: only the thread cap applies)
+	HotTemp      float64       // Start lowering the rate above this temperature (°C)
+	CriticalTemp float64       // Temperature at which the rate reaches MinScale (°C)
+	HighLoad     float64       // Start lowering the rate above this per-core load
+	MinScale     float64       // Lowest fraction of the configured rate kept (default 0.25)
+	Interval     time.Duration // How long a sensor reading is reused (default 10s)
+
+	mu    sync.Mutex
+	load  Load
+	scale float64
+	read  time.Time
+}
+
+// LowPower returns a profile for fanless ARM boards: one ffmpeg thread,
+// throttling from 70°C (floor at 85°C) or above 90% CPU load
+func LowPower() *PowerProfile {
+	return &PowerProfile{
+		Threads:      1,
+		Sensor:       SystemSensor(),
+		HotTemp:      70,
+		CriticalTemp: 85,
+		HighLoad:     0.9,
+		MinScale:     0.25,
+		Interval:     10 * time.Second,
+	}
+}
+
+// WithPower applies a power profile to every extraction
+func (sp *StreamProcessor) WithPower(p *PowerProfile) *StreamProcessor {
+	sp.Power = p
+	return sp
+}
+
+// Scale returns the fraction (MinScale to 1) of the configured frame rate
+// to extract at under the current load. Sensor errors keep the last scale.
+func (p *PowerProfile) Scale(ctx context.Context) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	interval := p.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	if p.Sensor == nil {
+		return 1
+	}
+	if !p.read.IsZero() && time.Since(p.read) < interval {
+		return p.scale
+	}
+
+	p.read = time.Now()
+	load, err := p.Sensor.Read(ctx)
+	if err != nil {
+		if p.scale == 0 {
+			p.scale = 1
+		}
+		return p.scale
+	}
+	p.load = load
+	p.scale = p.scaleFor(load)
+	return p.scale
+}
+
+// Load returns the last sensor reading
+func (p *PowerProfile) Load() Load {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.load
+}
+
+// scaleFor maps a reading to a rate fraction: linear between HotTemp and
+// CriticalTemp, proportional to HighLoad/CPU above HighLoad
+func (p *PowerProfile) scaleFor(l Load) float64 {
+	floor := p.MinScale
+	if floor <= 0 {
+		floor = 0.25
+	}
+
+	scale := 1.0
+	if p.HotTemp > 0 && l.Temperature > p.HotTemp {
+		if p.CriticalTemp <= p.HotTemp || l.Temperature >= p.CriticalTemp {
+			scale = floor
+		} else {
+			scale = 1 - (1-floor)*(l.Temperature-p.HotTemp)/(p.CriticalTemp-p.HotTemp)
+		}
+	}
+	if p.HighLoad > 0 && l.CPU > p.HighLoad {
+		scale = min(scale, p.HighLoad/l.CPU)
+	}
+	return min(max(scale, floor), 1)
+}
+
+type unthrottledKey struct{}
+
+// withoutThrottle exempts extractions made with ctx from the power profile's
+// rate scaling, for callers that need an exact frame count
+func withoutThrottle(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unthrottledKey{}, true)
+}
+
+// throttle lowers the sampling rate of r according to the power profile.
+// Requests without a frame count are converted to one so that rates below
+// 1 fps are possible.
+func (sp *StreamProcessor) throttle(ctx context.Context, r SampleRequest) SampleRequest {
+	if sp.Power == nil || ctx.Value(unthrottledKey{}) != nil {
+		return r
+	}
+	scale := sp.Power.Scale(ctx)
+	if scale >= 1 {
+		return r
+	}
+
+	if r.Count <= 0 && r.Duration > 0 {
+		r.Count = int(math.Ceil(r.Duration.Seconds() * float64(max(r.FPS, 1))))
+	}
+	if r.Count > 1 {
+		r.Count = max(1, int(math.Round(float64(r.Count)*scale)))
+	}
+	r.FPS = max(1, int(math.Round(float64(max(r.FPS, 1))*scale)))
+	return r
+}
+
+// execLimits returns sp.Limits with the power profile's thread cap applied
+func (sp *StreamProcessor) execLimits() *ExecLimits {
+	p := sp.Power
+	if p == nil || p.Threads <= 0 {
+		return sp.Limits
+	}
+	limits := ExecLimits{}
+	if sp.Limits != nil {
+		limits = *sp.Limits
+	}
+	if limits.Threads == 0 || limits.Threads > p.Threads {
+		limits.Threads = p.Threads
+	}
+	return &limits
+}
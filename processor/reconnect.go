@@ -0,0 +1,187 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/events"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// ConnectionState is the health of a live source as seen by a
+// ReconnectPolicy
+type ConnectionState string
+
+const (
+	StateConnected    ConnectionState = "connected"    // A capture succeeded
+	StateDegraded     ConnectionState = "degraded"     // The stream dropped mid-capture; the frames decoded before were kept
+	StateReconnecting ConnectionState = "reconnecting" // A capture failed and will be retried after Delay
+	StateGaveUp       ConnectionState = "gave-up"      // MaxRetries captures failed in a row
+)
+
+// ConnectionEvent reports a connection lifecycle change of a live source
+type ConnectionEvent struct {
+	Source  string          `json:"source"` // Stream URL
+	State   ConnectionState `json:"state"`
+	Attempt int             `json:"attempt,omitempty"` // Retry number for StateReconnecting, failed attempts for StateGaveUp
+	Delay   time.Duration   `json:"delay,omitempty"`   // Wait before the retry (StateReconnecting)
+	Err     error           `json:"-"`                 // Why the capture failed
+	Time    time.Time       `json:"time"`
+	Labels  models.Labels   `json:"labels,omitempty"`
+}
+
+// ReconnectPolicy retries captures of live sources (RTSP, RTMP, SRT, HLS)
+// that fail to connect or drop, with exponential backoff and jitter, and
+// reports connection events so supervisors can track camera health:
+//
+//	policy := &processor.ReconnectPolicy{MaxRetries: 10, OnEvent: func(e processor.ConnectionEvent) {
+//		log.Printf("%s: %s", e.Source, e.State)
+//	}}
+//	src := processor.Reconnecting(processor.RTSPSource(url, 10*time.Second), policy)
+//
+// A policy keeps the last state of every source it saw and may be shared
+// by many sources.
+type ReconnectPolicy struct {
+	MaxRetries int           // Retries per capture (default 5; negative retries until ctx is done)
+	Initial    time.Duration // First backoff (default 1s)
+	Max        time.Duration // Backoff cap (default 30s)
+	Multiplier float64       // Backoff growth per retry (default 2)
+	Jitter     float64       // Random ± fraction of each backoff (default 0.2; negative disables)
+
+	// OnEvent is called for every lifecycle event (optional). Connected is
+	// reported when a source recovers or is captured for the first time.
+	OnEvent func(ConnectionEvent)
+
+	mu     sync.Mutex
+	states map[string]ConnectionState
+}
+
+// Reconnecting applies policy to the live captures of src; other sources
+// are unaffected
+func Reconnecting(src Source, policy *ReconnectPolicy) Source {
+	return reconnectingSource{Source: src, policy: policy}
+}
+
+type reconnectingSource struct {
+	Source
+	policy *ReconnectPolicy
+}
+
+// Open implements Source
+func (s reconnectingSource) Open(ctx context.Context) (*Stream, error) {
+	st, err := s.Source.Open(ctx)
+	if st != nil {
+		st.Reconnect = s.policy
+	}
+	return st, err
+}
+
+// Labels returns the labels of the wrapped source
+func (s reconnectingSource) Labels() models.Labels {
+	return SourceLabels(s.Source)
+}
+
+// State returns the last connection state of source, or "" if the policy
+// has not captured it yet
+func (p *ReconnectPolicy) State(source string) ConnectionState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.states[source]
+}
+
+// backoff returns the wait before retry attempt (1-based)
+func (p *ReconnectPolicy) backoff(attempt int) time.Duration {
+	initial, maxWait, mult, jitter := p.Initial, p.Max, p.Multiplier, p.Jitter
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if maxWait <= 0 {
+		maxWait = 30 * time.Second
+	}
+	if mult < 1 {
+		mult = 2
+	}
+	if jitter == 0 {
+		jitter = 0.2
+	}
+
+	d := float64(initial)
+	for i := 1; i < attempt && d < float64(maxWait); i++ {
+		d *= mult
+	}
+	d = min(d, float64(maxWait))
+	if jitter > 0 {
+		d += d * jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// emit records the state of source and reports the event
+func (p *ReconnectPolicy) emit(ctx context.Context, e ConnectionEvent) {
+	p.mu.Lock()
+	if p.states == nil {
+		p.states = make(map[string]ConnectionState)
+	}
+	prev := p.states[e.Source]
+	p.states[e.Source] = e.State
+	p.mu.Unlock()
+
+	if e.State == StateConnected && prev == StateConnected {
+		return // Only transitions are worth reporting
+	}
+	if p.OnEvent != nil {
+		e.Time = time.Now()
+		e.Labels = models.LabelsFrom(ctx)
+		p.OnEvent(e)
+	}
+}
+
+// capture runs fn until it succeeds, fails permanently or the retries are
+// exhausted. A capture that dropped after decoding some frames counts as
+// degraded and returns them.
+func (p *ReconnectPolicy) capture(ctx context.Context, source string, fn func() ([][]byte, error)) ([][]byte, error) {
+	limit := p.MaxRetries
+	if limit == 0 {
+		limit = 5
+	}
+	for attempt := 1; ; attempt++ {
+		frames, err := fn()
+		if err == nil {
+			p.emit(ctx, ConnectionEvent{Source: source, State: StateConnected})
+			return frames, nil
+		}
+		if pe, ok := errdefs.AsPartial(err); ok && len(pe.Frames) > 0 {
+			p.emit(ctx, ConnectionEvent{Source: source, State: StateDegraded, Err: pe.Err})
+			return pe.Frames, nil
+		}
+		if ctx.Err() != nil || !reconnectable(err) {
+			return nil, err
+		}
+		if limit > 0 && attempt > limit {
+			p.emit(ctx, ConnectionEvent{Source: source, State: StateGaveUp, Attempt: attempt, Err: err})
+			return nil, err
+		}
+
+		wait := p.backoff(attempt)
+		p.emit(ctx, ConnectionEvent{Source: source, State: StateReconnecting, Attempt: attempt, Delay: wait, Err: err})
+		events.Publish(ctx, events.FFmpegRestarted{Source: source, Attempt: attempt, Delay: wait, Err: err})
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// reconnectable reports whether a failed live capture may succeed on retry:
+// missing tools and input limits won't change, network failures might
+func reconnectable(err error) bool {
+	return !errors.Is(err, errdefs.ErrFFmpegNotFound) &&
+		!errors.Is(err, errdefs.ErrPayloadTooLarge) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
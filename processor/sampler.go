@@ -0,0 +1,355 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// SampleRequest describes what a sampler should produce for one segment
+type SampleRequest struct {
+	Duration time.Duration // Segment length (0 when unknown, e.g. raw H.264)
+	Count    int           // Desired number of frames (0: no limit, sample at FPS)
+	FPS      int           // Processor FPS, used when Count or Duration is 0
+}
+
+// rate returns an ffmpeg fps value producing about factor*Count frames;
+// without a Count every frame at FPS is wanted, so there is no oversampling
+func (r SampleRequest) rate(factor int) string {
+	fps := r.FPS
+	if fps <= 0 {
+		fps = 1
+	}
+	switch {
+	case r.Count <= 0:
+		return strconv.Itoa(fps)
+	case r.Duration <= 0:
+		return strconv.Itoa(fps * factor)
+	}
+	return strconv.FormatFloat(float64(r.Count*factor)/r.Duration.Seconds(), 'f', 6, 64)
+}
+
+// Sampler is a frame sampling policy. Filter returns the ffmpeg filter
+// that decodes candidate frames (placed before scaling); Pick chooses the
+// frames to keep among the candidates, in temporal order.
+type Sampler interface {
+	Filter(r SampleRequest) string
+	Pick(candidates [][]byte, r SampleRequest) [][]byte
+}
+
+// Uniform samples evenly spaced frames (the default policy)
+func Uniform() Sampler { return uniformSampler{} }
+
+type uniformSampler struct{}
+
+func (uniformSampler) Filter(r SampleRequest) string { return "fps=" + r.rate(1) }
+
+func (uniformSampler) Pick(c [][]byte, r SampleRequest) [][]byte { return evenN(c, r.Count) }
+
+// Keyframe samples only I-frames, evenly thinned to Count
+func Keyframe() Sampler { return keyframeSampler{} }
+
+type keyframeSampler struct{}
+
+func (keyframeSampler) Filter(SampleRequest) string { return `select=eq(pict_type\,I)` }
+
+func (keyframeSampler) Pick(c [][]byte, r SampleRequest) [][]byte { return evenN(c, r.Count) }
+
+// SceneChange samples frames whose scene score exceeds threshold (0-1,
+// typically 0.2-0.4), evenly thinned to Count
+func SceneChange(threshold float64) Sampler { return sceneSampler{threshold} }
+
+type sceneSampler struct{ threshold float64 }
+
+func (s sceneSampler) Filter(SampleRequest) string {
+	return `select=gt(scene\,` + strconv.FormatFloat(s.threshold, 'f', 3, 64) + `)`
+}
+
+func (sceneSampler) Pick(c [][]byte, r SampleRequest) [][]byte { return evenN(c, r.Count) }
+
+// oversample is how many candidates per wanted frame scoring samplers decode
+const oversample = 4
+
+// Motion decodes extra candidates and keeps the Count frames that differ
+// most from their predecessor
+func Motion() Sampler { return motionSampler{} }
+
+type motionSampler struct{}
+
+func (motionSampler) Filter(r SampleRequest) string { return "fps=" + r.rate(oversample) }
+
+func (motionSampler) Pick(c [][]byte, r SampleRequest) [][]byte {
+	thumbs := thumbnails(c)
+	return topN(c, r.Count, func(i int) float64 {
+		if i == 0 || thumbs[i] == nil || thumbs[i-1] == nil {
+			return 0
+		}
+		return meanAbsDiff(thumbs[i], thumbs[i-1])
+	})
+}
+
+// TopNSharpest decodes extra candidates and keeps the Count sharpest
+// frames (highest Laplacian variance), skipping motion-blurred ones
+func TopNSharpest() Sampler { return sharpSampler{} }
+
+type sharpSampler struct{}
+
+func (sharpSampler) Filter(r SampleRequest) string { return "fps=" + r.rate(oversample) }
+
+func (sharpSampler) Pick(c [][]byte, r SampleRequest) [][]byte {
+	return topN(c, r.Count, func(i int) float64 { return sharpness(c[i]) })
+}
+
+// Random decodes extra candidates and keeps a random subset; the same seed
+// always picks the same frames, which keeps evaluations reproducible
+func Random(seed int64) Sampler { return randomSampler{seed} }
+
+type randomSampler struct{ seed int64 }
+
+func (randomSampler) Filter(r SampleRequest) string { return "fps=" + r.rate(oversample) }
+
+func (s randomSampler) Pick(c [][]byte, r SampleRequest) [][]byte {
+	if r.Count <= 0 || len(c) <= r.Count {
+		return c
+	}
+	idx := rand.New(rand.NewSource(s.seed)).Perm(len(c))[:r.Count]
+	sort.Ints(idx)
+	out := make([][]byte, len(idx))
+	for i, j := range idx {
+		out[i] = c[j]
+	}
+	return out
+}
+
+// ParseSampler maps a name ("uniform", "keyframe", "scene", "motion",
+// "sharpest", "random", "pts") to a sampler, for flags and config files
+func ParseSampler(name string) (Sampler, error) {
+	switch name {
+	case "", "uniform":
+		return Uniform(), nil
+	case "keyframe":
+		return Keyframe(), nil
+	case "scene":
+		return SceneChange(0.3), nil
+	case "motion":
+		return Motion(), nil
+	case "sharpest":
+		return TopNSharpest(), nil
+	case "random":
+		return Random(1), nil
+	case "pts":
+		return PTS(), nil
+	}
+	return nil, fmt.Errorf("unknown sampler %q", name)
+}
+
+type samplerKey struct{}
+
+// WithSampler overrides the processor's sampler for calls made with ctx,
+// so client methods can pick a policy per call
+func WithSampler(ctx context.Context, s Sampler) context.Context {
+	return context.WithValue(ctx, samplerKey{}, s)
+}
+
+type fpsKey struct{}
+
+// WithFPS overrides the processor's frame rate for calls made with ctx
+func WithFPS(ctx context.Context, fps int) context.Context {
+	return context.WithValue(ctx, fpsKey{}, fps)
+}
+
+// samplerFor returns the sampler from ctx, sp.Sampler, or Uniform
+func (sp *StreamProcessor) samplerFor(ctx context.Context) Sampler {
+	if s, ok := ctx.Value(samplerKey{}).(Sampler); ok && s != nil {
+		return s
+	}
+	if sp.Sampler != nil {
+		return sp.Sampler
+	}
+	return Uniform()
+}
+
+// WithSampler sets the default sampling policy
+func (sp *StreamProcessor) WithSampler(s Sampler) *StreamProcessor {
+	sp.Sampler = s
+	return sp
+}
+
+// sampleFrames decodes candidates with the sampler for ctx and picks the
+// final frames; selective samplers that find nothing (e.g. no scene cuts in
+// a static shot) fall back to uniform sampling. A power profile may lower
+// the rate first.
+func (sp *StreamProcessor) sampleFrames(ctx context.Context, inputArgs []string, r SampleRequest, live bool) ([][]byte, error) {
+	frames, _, err := sp.sampleTimedFrames(ctx, inputArgs, r, live)
+	return frames, err
+}
+
+// sampleTimedFrames is sampleFrames that also returns the offset of each
+// picked frame in seconds, derived from its candidate index; samplers that
+// select candidates by content (keyframes, scene cuts) have no fixed
+// candidate rate and return nil offsets
+func (sp *StreamProcessor) sampleTimedFrames(ctx context.Context, inputArgs []string, r SampleRequest, live bool) ([][]byte, []float64, error) {
+	s := sp.samplerFor(ctx)
+	if fps, ok := ctx.Value(fpsKey{}).(int); ok && fps > 0 {
+		r.FPS = fps
+	}
+	r = sp.throttle(ctx, r)
+	frames, err := sp.runFFmpegFrames(ctx, inputArgs, s.Filter(r), live)
+	if errors.Is(err, errdefs.ErrNoFrames) && !live {
+		if _, uniform := s.(uniformSampler); !uniform {
+			s = Uniform()
+			frames, err = sp.runFFmpegFrames(ctx, inputArgs, s.Filter(r), live)
+		}
+	}
+	if pe, ok := errdefs.AsPartial(err); ok {
+		pe.Frames = s.Pick(pe.Frames, r)
+		return nil, nil, pe
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	picked := s.Pick(frames, r)
+	if _, budgeted := s.(*FrameBudget); budgeted && len(picked) == 0 {
+		return nil, nil, fmt.Errorf("%w: frame budget exhausted", errdefs.ErrSkipped)
+	}
+	return picked, pickedOffsets(frames, picked, s.Filter(r)), nil
+}
+
+// pickedOffsets maps picked frames back to their candidate index; an
+// "fps=" filter decodes candidate i at i/rate seconds
+func pickedOffsets(candidates, picked [][]byte, filter string) []float64 {
+	rate, err := strconv.ParseFloat(strings.TrimPrefix(filter, "fps="), 64)
+	if !strings.HasPrefix(filter, "fps=") || err != nil || rate <= 0 {
+		return nil
+	}
+	offsets := make([]float64, 0, len(picked))
+	j := 0
+	for _, p := range picked {
+		// Pick keeps candidates in temporal order without copying them
+		for j < len(candidates) && !sameFrame(candidates[j], p) {
+			j++
+		}
+		if j == len(candidates) {
+			return nil
+		}
+		offsets = append(offsets, float64(j)/rate)
+		j++
+	}
+	return offsets
+}
+
+// sameFrame reports whether a and b are the same decoded frame
+func sameFrame(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// evenN keeps n evenly spaced frames
+func evenN(frames [][]byte, n int) [][]byte {
+	if n <= 0 || len(frames) <= n {
+		return frames
+	}
+	out := make([][]byte, n)
+	for i := range out {
+		out[i] = frames[i*len(frames)/n]
+	}
+	return out
+}
+
+// topN keeps the n highest scoring frames in their original order
+func topN(frames [][]byte, n int, score func(i int) float64) [][]byte {
+	if n <= 0 || len(frames) <= n {
+		return frames
+	}
+	scores := make([]float64, len(frames))
+	idx := make([]int, len(frames))
+	for i := range frames {
+		scores[i] = score(i)
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+	idx = idx[:n]
+	sort.Ints(idx)
+
+	out := make([][]byte, n)
+	for i, j := range idx {
+		out[i] = frames[j]
+	}
+	return out
+}
+
+// thumbSize is the edge length of grayscale thumbnails used for scoring
+const thumbSize = 32
+
+// thumbnails decodes frames into small luma grids; undecodable frames are nil
+func thumbnails(frames [][]byte) [][]uint8 {
+	out := make([][]uint8, len(frames))
+	for i, f := range frames {
+		if img, _, err := image.Decode(bytes.NewReader(f)); err == nil {
+			out[i] = grayGrid(img, thumbSize)
+		}
+	}
+	return out
+}
+
+// grayGrid point-samples an image into a size x size luma grid
+func grayGrid(img image.Image, size int) []uint8 {
+	b := img.Bounds()
+	out := make([]uint8, size*size)
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return out
+	}
+	for ty := 0; ty < size; ty++ {
+		for tx := 0; tx < size; tx++ {
+			r, g, bl, _ := img.At(b.Min.X+tx*w/size+w/(2*size), b.Min.Y+ty*h/size+h/(2*size)).RGBA()
+			out[ty*size+tx] = uint8(((299*r + 587*g + 114*bl) / 1000) >> 8)
+		}
+	}
+	return out
+}
+
+// meanAbsDiff returns the mean absolute difference of two grids
+func meanAbsDiff(a, b []uint8) float64 {
+	var sum int
+	for i := range a {
+		d := int(a[i]) - int(b[i])
+		if d < 0 {
+			d = -d
+		}
+		sum += d
+	}
+	return float64(sum) / float64(len(a))
+}
+
+// sharpness returns the variance of the Laplacian of a 128x128 luma grid
+func sharpness(frame []byte) float64 {
+	img, _, err := image.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return 0
+	}
+	const size = 128
+	g := grayGrid(img, size)
+
+	var sum, sumSq float64
+	n := 0
+	for y := 1; y < size-1; y++ {
+		for x := 1; x < size-1; x++ {
+			i := y*size + x
+			lap := float64(4*int(g[i]) - int(g[i-1]) - int(g[i+1]) - int(g[i-size]) - int(g[i+size]))
+			sum += lap
+			sumSq += lap * lap
+			n++
+		}
+	}
+	mean := sum / float64(n)
+	return sumSq/float64(n) - mean*mean
+}
@@ -0,0 +1,222 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// ExecLimits hardens ffmpeg/ffprobe invocations against untrusted input
+// (decoder exploits, decompression bombs, endless streams). Zero fields
+// are not enforced.
+type ExecLimits struct {
+	Timeout        time.Duration // Wall-clock limit per invocation
+	CPUTime        time.Duration // CPU time limit (RLIMIT_CPU, Unix only)
+	MaxMemory      int64         // Address space limit in bytes (RLIMIT_AS, Unix only)
+	MaxOutputBytes int64         // Max bytes ffmpeg may write to stdout; exceeding kills it
+	Nice           int           // Scheduling niceness 1-19 (Unix only)
+	Threads        int           // Decoder/encoder thread count (-threads)
+
+	// Protocols is the ffmpeg protocol whitelist for file inputs; live
+	// captures additionally allow network protocols (default: file,pipe)
+	Protocols []string
+}
+
+// DefaultExecLimits returns conservative limits for untrusted uploads
+func DefaultExecLimits() *ExecLimits {
+	return &ExecLimits{
+		Timeout:        2 * time.Minute,
+		CPUTime:        2 * time.Minute,
+		MaxMemory:      2 << 30,
+		MaxOutputBytes: 512 << 20,
+		Nice:           10,
+		Threads:        2,
+	}
+}
+
+// WithLimits applies exec limits to every ffmpeg/ffprobe invocation
+func (sp *StreamProcessor) WithLimits(limits *ExecLimits) *StreamProcessor {
+	sp.Limits = limits
+	return sp
+}
+
+const (
+	// maxStderrBytes caps the diagnostic output kept from a failing tool
+	maxStderrBytes = 64 << 10
+	// waitDelay bounds how long Wait waits for pipes after a kill
+	waitDelay = 5 * time.Second
+)
+
+// networkProtocols are additionally allowed for live captures
+var networkProtocols = []string{"rtsp", "rtsps", "rtp", "rtmp", "rtmps", "srtp", "udp", "tcp", "tls", "http", "https", "hls", "crypto", "data"}
+
+// allowedOptions is the allowlist of options the processor generates;
+// anything else (e.g. an input path starting with "-") is rejected
+var allowedOptions = map[string]bool{
+	"-v": true, "-nostdin": true, "-threads": true, "-protocol_whitelist": true,
+	"-f": true, "-i": true, "-ss": true, "-t": true, "-vf": true,
+	"-vcodec": true, "-q:v": true, "-vsync": true, "-rtsp_transport": true,
+	"-show_entries": true, "-of": true, "-select_streams": true,
+	"-vn": true, "-ac": true, "-ar": true,
+	"-c:v": true, "-c:a": true, "-preset": true, "-crf": true,
+	"-pix_fmt": true, "-movflags": true, "-y": true, "-map": true,
+}
+
+// checkArgs verifies that every option in args is on the allowlist
+func checkArgs(args []string) error {
+	for i, arg := range args {
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		if _, err := strconv.ParseFloat(arg, 64); err == nil {
+			continue // Negative number value
+		}
+		if i > 0 && args[i-1] == "-i" {
+			return fmt.Errorf("%w: input %q must not start with \"-\"", errdefs.ErrInvalidRequest, arg)
+		}
+		if i > 0 && (args[i-1] == "-vf" || args[i-1] == "-show_entries" || args[i-1] == "-of") {
+			continue
+		}
+		if !allowedOptions[arg] {
+			return fmt.Errorf("%w: ffmpeg argument %q is not allowed", errdefs.ErrInvalidRequest, arg)
+		}
+	}
+	return nil
+}
+
+// runTool runs ffmpeg or ffprobe under sp.Limits and returns its stdout.
+// live marks network captures, which may use network protocols.
+func (sp *StreamProcessor) runTool(ctx context.Context, tool string, args []string, live bool) ([]byte, error) {
+	if err := checkArgs(args); err != nil {
+		return nil, err
+	}
+
+	limits := sp.execLimits()
+	if limits == nil {
+		limits = &ExecLimits{}
+	} else {
+		args = limits.hardenArgs(tool, args, live)
+	}
+
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	path, err := sp.toolPath(tool)
+	if err != nil {
+		return nil, err
+	}
+	cmd := toolCommand(ctx, path, args...)
+	limits.applyRlimits(cmd)
+
+	stdout := &cappedBuffer{max: limits.MaxOutputBytes, onOverflow: cancel}
+	stderr := &cappedBuffer{max: maxStderrBytes, truncate: true}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, wrapFFmpegError(tool, err, "")
+	}
+	limits.applyNice(cmd)
+	err = cmd.Wait()
+
+	switch {
+	case stdout.overflow:
+		return nil, fmt.Errorf("%w: %s output exceeded %d bytes", errdefs.ErrPayloadTooLarge, tool, limits.MaxOutputBytes)
+	case err != nil && limits.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return stdout.Bytes(), fmt.Errorf("%s exceeded time limit %v: %w", tool, limits.Timeout, context.DeadlineExceeded)
+	case err != nil && ctx.Err() != nil:
+		// Cancelled by the caller: report that rather than "signal: killed"
+		return stdout.Bytes(), fmt.Errorf("%s interrupted: %w", tool, context.Cause(ctx))
+	case err != nil:
+		// The output produced before the failure is returned for salvaging
+		return stdout.Bytes(), wrapFFmpegError(tool, err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+// toolCommand prepares a tool invocation bound to ctx: cancellation kills
+// the tool's whole process group, and Wait gives up on pipes held open by
+// stray children after waitDelay, so callers that Wait always reap it
+func toolCommand(ctx context.Context, path string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, path, args...)
+	configureProcess(cmd)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// hardenArgs prepends the protocol whitelist and thread limit
+func (l *ExecLimits) hardenArgs(tool string, args []string, live bool) []string {
+	protocols := l.Protocols
+	if len(protocols) == 0 {
+		protocols = []string{"file", "pipe"}
+	}
+	if live {
+		protocols = append(append([]string{}, protocols...), networkProtocols...)
+	}
+
+	hardened := []string{"-nostdin", "-protocol_whitelist", strings.Join(protocols, ",")}
+	if tool == "ffprobe" {
+		hardened = hardened[1:] // ffprobe has no -nostdin
+	}
+	if l.Threads > 0 {
+		hardened = append(hardened, "-threads", strconv.Itoa(l.Threads))
+	}
+	return append(hardened, args...)
+}
+
+// cappedBuffer collects output up to max bytes (0: unlimited). When full
+// it either truncates silently or flags overflow and calls onOverflow.
+type cappedBuffer struct {
+	mu         sync.Mutex
+	buf        bytes.Buffer
+	max        int64
+	truncate   bool
+	overflow   bool
+	onOverflow func()
+}
+
+// Write implements io.Writer
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.max > 0 && int64(b.buf.Len()+len(p)) > b.max {
+		if !b.truncate {
+			b.overflow = true
+			if b.onOverflow != nil {
+				b.onOverflow()
+			}
+			return 0, errdefs.ErrPayloadTooLarge
+		}
+		if room := int(b.max) - b.buf.Len(); room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// Bytes returns the collected output
+func (b *cappedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
+
+// String returns the collected output as a string
+func (b *cappedBuffer) String() string {
+	return string(b.Bytes())
+}
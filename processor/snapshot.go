@@ -0,0 +1,86 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// Latest asks Snapshot for the most recent picture: the end of a file or
+// the live edge of a stream
+const Latest time.Duration = -1
+
+// snapshotWindow is the span searched for the sharpest frame around the
+// requested time
+const snapshotWindow = time.Second
+
+// Snapshot returns one normalized JPEG (after the preprocessing chain)
+// from src at offset at, or at Latest. The sharpest frame within about a
+// second is chosen, so a single motion-blurred frame doesn't become the
+// thumbnail. Live sources are captured for their window (a second or two
+// is enough) and ignore at.
+func (sp *StreamProcessor) Snapshot(ctx context.Context, src Source, at time.Duration) ([]byte, error) {
+	if err := sp.RequireTools(); err != nil {
+		return nil, err
+	}
+	s, err := src.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	ctx = WithSampler(ctx, TopNSharpest())
+	var frames [][]byte
+	switch {
+	case s.Live:
+		frames, err = s.capture(ctx, func() ([][]byte, error) {
+			return sp.sampleFrames(ctx, s.InputArgs, SampleRequest{Duration: s.Window, Count: 1, FPS: sp.FPS}, true)
+		})
+
+	case s.Format == FormatH264:
+		data, rerr := os.ReadFile(s.Path)
+		if rerr != nil {
+			return nil, fmt.Errorf("failed to read h264 stream: %w", rerr)
+		}
+		if frames, err = sp.h264Frames(ctx, data, 0); err == nil {
+			frames = sp.h264Snapshot(frames, at)
+		}
+
+	default:
+		info, perr := sp.Probe(ctx, s.Path)
+		if perr != nil {
+			return nil, fmt.Errorf("failed to probe %s: %w", s.Name, perr)
+		}
+		if at == Latest || at > info.Duration-snapshotWindow {
+			at = max(info.Duration-snapshotWindow, 0)
+		}
+		window := min(snapshotWindow, info.Duration-at)
+		if window <= 0 {
+			window = snapshotWindow
+		}
+		args := append([]string{"-ss", formatSeconds(at), "-t", formatSeconds(window)}, s.InputArgs...)
+		frames, err = sp.sampleFrames(ctx, args, SampleRequest{Duration: window, Count: 1, FPS: sp.FPS}, false)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take snapshot of %s: %w", s.Name, err)
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("failed to take snapshot of %s: %w", s.Name, errdefs.ErrNoFrames)
+	}
+	return frames[0], nil
+}
+
+// h264Snapshot picks the sharpest of the frames decoded at sp.FPS in the
+// second ending at at (raw H.264 has no timestamps to seek to)
+func (sp *StreamProcessor) h264Snapshot(frames [][]byte, at time.Duration) [][]byte {
+	fps := max(sp.FPS, 1)
+	end := len(frames)
+	if at != Latest {
+		end = min(int(at.Seconds()*float64(fps))+1, len(frames))
+	}
+	start := max(end-fps, 0)
+	return TopNSharpest().Pick(frames[start:end], SampleRequest{Count: 1})
+}
@@ -0,0 +1,345 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/events"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// FormatH264 marks a raw H.264 Annex B elementary stream, which has no
+// container and therefore no duration to probe
+const FormatH264 = "h264"
+
+// Source is a video input: a file, in-memory data, a reader, a remote URL
+// or a live stream. Open prepares it for decoding; the returned Stream must
+// be closed to release temporary files.
+type Source interface {
+	Open(ctx context.Context) (*Stream, error)
+}
+
+// SourceFunc adapts a function to a Source
+type SourceFunc func(ctx context.Context) (*Stream, error)
+
+// Open implements Source
+func (f SourceFunc) Open(ctx context.Context) (*Stream, error) { return f(ctx) }
+
+// Stream is an opened Source
+type Stream struct {
+	Name      string           // Human readable origin (path or URL) for logs and results
+	InputArgs []string         // ffmpeg input arguments: demuxer options followed by -i
+	Path      string           // Local file backing the stream; empty for live input
+	Format    string           // FormatH264 for raw elementary streams, empty for containers
+	Live      bool             // Network stream captured for Window instead of probed
+	Window    time.Duration    // Capture window for live streams
+	Labels    models.Labels    // Origin tags attached with Labeled
+	Reconnect *ReconnectPolicy // Retry policy for live captures, attached with Reconnecting
+	close     func() error
+}
+
+// Close releases resources held by the stream (e.g. spooled temp files)
+func (s *Stream) Close() error {
+	if s == nil || s.close == nil {
+		return nil
+	}
+	return s.close()
+}
+
+// FileSource reads a local video file; .h264/.264 files are treated as raw
+// H.264 elementary streams
+func FileSource(path string) Source {
+	return SourceFunc(func(ctx context.Context) (*Stream, error) {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("failed to open video: %w", err)
+		}
+		return fileStream(path, path, formatFromExt(path), nil), nil
+	})
+}
+
+// BytesSource reads a video held in memory; name is used for logs and its
+// extension selects the format like FileSource
+func BytesSource(data []byte, name string) Source {
+	return SourceFunc(func(ctx context.Context) (*Stream, error) {
+		return spool(ctx, bytes.NewReader(data), name, formatFromExt(name))
+	})
+}
+
+// ReaderSource reads a video from r (an upload, a pipe); r is consumed
+// into a temporary file because most containers need to seek
+func ReaderSource(r io.Reader, name string) Source {
+	return SourceFunc(func(ctx context.Context) (*Stream, error) {
+		return spool(ctx, r, name, formatFromExt(name))
+	})
+}
+
+// RawH264 reads a raw H.264 Annex B elementary stream held in memory;
+// the processor's SPS/PPS are injected before decoding
+func RawH264(data []byte) Source {
+	return SourceFunc(func(ctx context.Context) (*Stream, error) {
+		return spool(ctx, bytes.NewReader(data), "stream.h264", FormatH264)
+	})
+}
+
+// URLSource downloads a remote video over HTTP(S) into a temporary file
+// before decoding, resuming broken connections and checking the content
+// type like Download; maxBytes <= 0 disables the size limit (the
+// processor's InputLimits still apply once downloaded)
+func URLSource(url string, maxBytes int64) Source {
+	return SourceFunc(func(ctx context.Context) (*Stream, error) {
+		f, err := os.CreateTemp("", "zhipu-source-*"+sourceExt(url))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp file: %w", err)
+		}
+		path := f.Name()
+		f.Close()
+		if err := Download(ctx, url, path, DownloadOptions{MaxBytes: maxBytes}); err != nil {
+			os.Remove(path)
+			return nil, fmt.Errorf("failed to download video: %w", err)
+		}
+		return fileStream(url, path, formatFromExt(url), func() error { return os.Remove(path) }), nil
+	})
+}
+
+// RTSPSource captures window of an RTSP camera stream over TCP
+func RTSPSource(url string, window time.Duration) Source {
+	return SourceFunc(func(ctx context.Context) (*Stream, error) {
+		return liveStream(url, window, "-rtsp_transport", "tcp")
+	})
+}
+
+// LiveSource captures window of any live input ffmpeg can read (RTMP,
+// SRT, HLS, HTTP-FLV, RTSP with default transport)
+func LiveSource(url string, window time.Duration) Source {
+	return SourceFunc(func(ctx context.Context) (*Stream, error) {
+		return liveStream(url, window)
+	})
+}
+
+// Labeled attaches labels (camera, site, tenant) to src. They are set on
+// the opened Stream and on the context used to open it, and consumers such
+// as client.Analyze copy them into their results.
+func Labeled(src Source, labels models.Labels) Source {
+	return labeledSource{Source: src, labels: labels}
+}
+
+type labeledSource struct {
+	Source
+	labels models.Labels
+}
+
+// Open implements Source
+func (s labeledSource) Open(ctx context.Context) (*Stream, error) {
+	st, err := s.Source.Open(models.WithLabels(ctx, s.labels))
+	if st != nil {
+		st.Labels = st.Labels.Merge(s.labels)
+	}
+	return st, err
+}
+
+// Labels returns the labels of the source, including those of wrapped sources
+func (s labeledSource) Labels() models.Labels {
+	return SourceLabels(s.Source).Merge(s.labels)
+}
+
+// SourceLabels returns the labels attached to src with Labeled, or nil
+func SourceLabels(src Source) models.Labels {
+	if l, ok := src.(interface{ Labels() models.Labels }); ok {
+		return l.Labels()
+	}
+	return nil
+}
+
+// ParseSource picks a Source for a path or URL: RTSP cameras and other
+// live protocols (RTMP, SRT, UDP, RTP, HLS playlists) are captured for window,
+// other HTTP(S) URLs are downloaded and anything else is a local file
+func ParseSource(uri string, window time.Duration) Source {
+	scheme, _, _ := strings.Cut(uri, "://")
+	switch strings.ToLower(scheme) {
+	case "rtsp", "rtsps":
+		return RTSPSource(uri, window)
+	case "rtmp", "rtmps", "srt", "udp", "rtp":
+		return LiveSource(uri, window)
+	case "http", "https":
+		if sourceExt(uri) == ".m3u8" {
+			return LiveSource(uri, window)
+		}
+		return URLSource(uri, 0)
+	}
+	return FileSource(uri)
+}
+
+// ExtractSource opens src and returns up to maxFrames frames chosen by
+// the Sampler: whole files are probed (enforcing InputLimits) and sampled
+// across their duration, live streams over their capture window, and raw
+// H.264 at sp.FPS
+func (sp *StreamProcessor) ExtractSource(ctx context.Context, src Source, maxFrames int) (frames [][]byte, err error) {
+	if err := sp.RequireTools(); err != nil {
+		return nil, err
+	}
+	s, err := src.Open(ctx)
+	if err != nil {
+		events.Publish(ctx, events.SourceError{Err: err})
+		return nil, err
+	}
+	defer s.Close()
+	start := time.Now()
+	defer func() { publishExtract(ctx, s.Name, frames, err, time.Since(start)) }()
+
+	switch {
+	case s.Live:
+		return s.capture(ctx, func() ([][]byte, error) {
+			window, err := liveWindow(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+			r := SampleRequest{Duration: window, Count: maxFrames, FPS: sp.FPS}
+			return sp.sampleFrames(ctx, s.InputArgs, r, true)
+		})
+	case s.Format == FormatH264:
+		data, err := os.ReadFile(s.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read h264 stream: %w", err)
+		}
+		return sp.h264Frames(ctx, data, maxFrames)
+	}
+
+	info, err := sp.Probe(ctx, s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe %s: %w", s.Name, err)
+	}
+	r := SampleRequest{Duration: info.Duration, Count: maxFrames, FPS: sp.FPS}
+	return sp.sampleFrames(ctx, s.InputArgs, r, false)
+}
+
+// publishExtract reports the outcome of an extraction on the events bus;
+// skipped windows are neither frames nor errors
+func publishExtract(ctx context.Context, source string, frames [][]byte, err error, took time.Duration) {
+	switch {
+	case err == nil:
+		events.Publish(ctx, events.FrameExtracted{Source: source, Frames: len(frames), Duration: took})
+	case !errors.Is(err, errdefs.ErrSkipped) && ctx.Err() == nil:
+		events.Publish(ctx, events.SourceError{Source: source, Err: err})
+	}
+}
+
+// capture runs a live capture under the stream's reconnect policy, if any
+func (s *Stream) capture(ctx context.Context, fn func() ([][]byte, error)) ([][]byte, error) {
+	if s.Reconnect == nil {
+		return fn()
+	}
+	return s.Reconnect.capture(ctx, s.Name, fn)
+}
+
+// liveConnectTime is the time allowed for connecting to a live stream and
+// flushing the last frames after the capture window
+const liveConnectTime = 2 * time.Second
+
+// liveWindow shortens the capture window of a live stream so it ends before
+// the ctx deadline, instead of ffmpeg being killed with nothing captured
+func liveWindow(ctx context.Context, s *Stream) (time.Duration, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return s.Window, nil
+	}
+	left := time.Until(deadline) - liveConnectTime
+	if left >= s.Window {
+		return s.Window, nil
+	}
+	if left < time.Second {
+		return 0, fmt.Errorf("not enough time to capture %s before deadline: %w", s.Name, context.DeadlineExceeded)
+	}
+	return left.Truncate(100 * time.Millisecond), nil
+}
+
+// fileStream describes a local file as a Stream
+func fileStream(name, path, format string, closeFn func() error) *Stream {
+	args := []string{"-i", path}
+	if format == FormatH264 {
+		args = []string{"-f", FormatH264, "-i", path}
+	}
+	return &Stream{Name: name, InputArgs: args, Path: path, Format: format, close: closeFn}
+}
+
+// spool copies r into a temporary file removed when the stream is closed;
+// cancelling ctx stops the copy and removes the partial file
+func spool(ctx context.Context, r io.Reader, name, format string) (*Stream, error) {
+	f, err := os.CreateTemp("", "zhipu-source-*"+sourceExt(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := io.Copy(f, contextReader(ctx, r)); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to read video: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	path := f.Name()
+	return fileStream(name, path, format, func() error { return os.Remove(path) }), nil
+}
+
+// contextReader returns a reader that fails with ctx's error once ctx is
+// done. A Read already blocked in r returns only when r does, so readers
+// that can hang (sockets, pipes) should also be closed or given deadlines.
+func contextReader(ctx context.Context, r io.Reader) io.Reader {
+	if ctx.Done() == nil {
+		return r
+	}
+	return readerFunc(func(p []byte) (int, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return r.Read(p)
+	})
+}
+
+// readerFunc adapts a function to io.Reader
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+// liveStream describes a network stream captured for window
+func liveStream(url string, window time.Duration, demuxerArgs ...string) (*Stream, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("invalid capture window: %v", window)
+	}
+	args := append(demuxerArgs, "-t", formatSeconds(window), "-i", url)
+	return &Stream{Name: url, InputArgs: args, Live: true, Window: window}, nil
+}
+
+// formatFromExt detects raw H.264 by file extension
+func formatFromExt(name string) string {
+	switch sourceExt(name) {
+	case ".h264", ".264":
+		return FormatH264
+	}
+	return ""
+}
+
+// sourceExt returns the lowercase extension of a path or URL, ignoring any
+// query string; extensions with unusual characters are dropped
+func sourceExt(name string) string {
+	if strings.Contains(name, "://") {
+		if i := strings.IndexAny(name, "?#"); i >= 0 {
+			name = name[:i]
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, r := range ext[min(1, len(ext)):] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
@@ -7,21 +7,34 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/codec"
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
 )
 
 // StreamProcessor handles real-time H.264/AVC video stream processing
 // Similar to the reference implementation in glm-realtime-sdk-video
 type StreamProcessor struct {
-	FPS          int    // Frames per second to extract (recommended: 2)
-	TargetWidth  int    // Target frame width (default: 1120, must be divisible by 28)
-	TargetHeight int    // Target frame height (default: 1120, must be divisible by 28)
-	Quality      int    // JPEG quality (1-100, recommended: 85-95)
-	SPS          string // H.264 SPS (Sequence Parameter Set) in base64
-	PPS          string // H.264 PPS (Picture Parameter Set) in base64
+	FPS          int           // Frames per second to extract (recommended: 2)
+	TargetWidth  int           // Target frame width (default: 1120, must be divisible by 28)
+	TargetHeight int           // Target frame height (default: 1120, must be divisible by 28)
+	Quality      int           // JPEG quality (1-100, recommended: 85-95)
+	SPS          string        // H.264 SPS (Sequence Parameter Set) in base64
+	PPS          string        // H.264 PPS (Picture Parameter Set) in base64
+	Limits       *ExecLimits   // Optional hardening applied to every ffmpeg/ffprobe run
+	InputLimits  *InputLimits  // Optional size/duration/resolution limits checked at probe time
+	Tools        Toolchain     // Optional explicit ffmpeg/ffprobe paths (default: LookTool)
+	Sampler      Sampler       // Frame sampling policy (default: Uniform; override per call with WithSampler)
+	Filters      FilterChain   // Preprocessing chain (default: Resize+Pad to the target resolution)
+	Power        *PowerProfile // Optional thread cap and thermal throttling for edge devices
+	Encoder      codec.Encoder // Optional frame encoder; frames are re-encoded with it after extraction
+	Track        TrackSelector // Video track of multi-program/multi-track inputs (default: ffmpeg's pick)
+	OSD          FrameFilter   // Optional MaskOSD/CropOSD filter run before Filters (override per call with WithOSD)
 	tempDir      string
 	mu           sync.Mutex
 }
@@ -89,6 +102,41 @@ func (sp *StreamProcessor) ProcessH264Stream(h264Data []byte) ([]string, error)
 
 // ProcessH264StreamWithContext processes H.264 stream with context support
 func (sp *StreamProcessor) ProcessH264StreamWithContext(ctx context.Context, h264Data []byte) ([]string, error) {
+	frames, err := sp.h264Frames(ctx, h264Data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert frames to base64
+	fmt.Printf("帧提取完成，共 %d 帧，正在转换为 base64...\n", len(frames))
+	base64Frames := make([]string, len(frames))
+	for i, frame := range frames {
+		base64Frames[i] = base64.StdEncoding.EncodeToString(frame)
+	}
+
+	return base64Frames, nil
+}
+
+// h264Frames injects SPS/PPS into a raw H.264 stream and decodes up to
+// maxFrames frames at sp.FPS (maxFrames <= 0 keeps all)
+func (sp *StreamProcessor) h264Frames(ctx context.Context, h264Data []byte, maxFrames int) ([][]byte, error) {
+	frames, _, err := sp.h264TimedFrames(ctx, h264Data, maxFrames)
+	return frames, err
+}
+
+// ExtractH264Timed decodes a raw H.264 stream like ProcessH264StreamWithContext
+// and also returns the offset of each frame in seconds (nil when the
+// sampler selects frames by content)
+func (sp *StreamProcessor) ExtractH264Timed(ctx context.Context, h264Data []byte) ([][]byte, []float64, error) {
+	return sp.h264TimedFrames(ctx, h264Data, 0)
+}
+
+// h264TimedFrames is h264Frames that also returns frame offsets
+func (sp *StreamProcessor) h264TimedFrames(ctx context.Context, h264Data []byte, maxFrames int) ([][]byte, []float64, error) {
+	if err := sp.InputLimits.checkBytes(int64(len(h264Data))); err != nil {
+		return nil, nil, err
+	}
+
 	sp.mu.Lock()
 	defer sp.mu.Unlock()
 
@@ -96,7 +144,7 @@ func (sp *StreamProcessor) ProcessH264StreamWithContext(ctx context.Context, h26
 	if sp.tempDir == "" {
 		tempDir, err := os.MkdirTemp("", "h264stream-*")
 		if err != nil {
-			return nil, fmt.Errorf("failed to create temp dir: %w", err)
+			return nil, nil, fmt.Errorf("failed to create temp dir: %w", err)
 		}
 		sp.tempDir = tempDir
 	}
@@ -105,32 +153,24 @@ func (sp *StreamProcessor) ProcessH264StreamWithContext(ctx context.Context, h26
 	fmt.Println("正在注入 SPS/PPS 参数...")
 	fixedData, err := sp.injectSPSPPS(h264Data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to inject SPS/PPS: %w", err)
+		return nil, nil, fmt.Errorf("failed to inject SPS/PPS: %w", err)
 	}
 
 	// 2. Write H.264 data to temp file
 	fmt.Println("正在写入临时文件...")
 	h264Path := filepath.Join(sp.tempDir, fmt.Sprintf("stream_%d.h264", time.Now().UnixNano()))
 	if err := os.WriteFile(h264Path, fixedData, 0644); err != nil {
-		return nil, fmt.Errorf("failed to write h264 file: %w", err)
+		return nil, nil, fmt.Errorf("failed to write h264 file: %w", err)
 	}
 	defer os.Remove(h264Path)
 
 	// 3. Extract frames using ffmpeg
 	fmt.Println("正在使用 ffmpeg 提取帧...")
-	frames, err := sp.extractFramesFromH264(ctx, h264Path)
+	frames, offsets, err := sp.extractFramesFromH264(ctx, h264Path, maxFrames)
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract frames: %w", err)
-	}
-
-	// 4. Convert frames to base64
-	fmt.Printf("帧提取完成，共 %d 帧，正在转换为 base64...\n", len(frames))
-	base64Frames := make([]string, len(frames))
-	for i, frame := range frames {
-		base64Frames[i] = base64.StdEncoding.EncodeToString(frame)
+		return nil, nil, fmt.Errorf("failed to extract frames: %w", err)
 	}
-
-	return base64Frames, nil
+	return frames, offsets, nil
 }
 
 // injectSPSPPS injects SPS and PPS NAL units into H.264 stream
@@ -168,7 +208,19 @@ func (sp *StreamProcessor) injectSPSPPS(h264Data []byte) ([]byte, error) {
 }
 
 // extractFramesFromH264 uses ffmpeg to decode H.264 and extract JPEG frames
-func (sp *StreamProcessor) extractFramesFromH264(ctx context.Context, h264Path string) ([][]byte, error) {
+func (sp *StreamProcessor) extractFramesFromH264(ctx context.Context, h264Path string, maxFrames int) ([][]byte, []float64, error) {
+	// Similar to reference implementation
+	inputArgs := []string{
+		"-f", "h264", // Input format: raw H.264
+		"-i", h264Path,
+	}
+	return sp.sampleTimedFrames(ctx, inputArgs, SampleRequest{Count: maxFrames, FPS: sp.FPS}, false)
+}
+
+// runFFmpegFrames runs ffmpeg with the given input arguments, applies the
+// sampling filter (e.g. "fps=2") followed by the preprocessing chain and
+// returns the JPEG frames; live allows network input protocols
+func (sp *StreamProcessor) runFFmpegFrames(ctx context.Context, inputArgs []string, filter string, live bool) ([][]byte, error) {
 	// Convert quality to qscale
 	qscale := 31 - int(float64(sp.Quality-1)/99.0*29.0)
 	if qscale < 2 {
@@ -178,35 +230,60 @@ func (sp *StreamProcessor) extractFramesFromH264(ctx context.Context, h264Path s
 		qscale = 31
 	}
 
+	// The sampling filter runs first, then the ffmpeg-capable part of the
+	// preprocessing chain; the rest of the chain runs in Go afterwards
+	vf, goFilters := sp.filterChain(ctx).split()
+	if vf != "" {
+		vf = filter + "," + vf
+	} else {
+		vf = filter
+	}
+
 	// Build ffmpeg command
-	// Similar to reference implementation
-	args := []string{
-		"-f", "h264", // Input format: raw H.264
-		"-i", h264Path,
-		"-vf", fmt.Sprintf("fps=%d,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2",
-			sp.FPS, sp.TargetWidth, sp.TargetHeight, sp.TargetWidth, sp.TargetHeight),
+	args := append([]string{}, inputArgs...)
+	track, ok, err := sp.selectTrack(ctx, inputArgs, live)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		args = append(args, "-map", "0:"+strconv.Itoa(track.Index))
+	}
+	args = append(args, "-vf", vf)
+	if strings.HasPrefix(filter, "select") {
+		// Selective filters drop frames; don't let ffmpeg duplicate them back
+		args = append(args, "-vsync", "vfr")
+	}
+	args = append(args,
 		"-f", "image2pipe",
 		"-vcodec", "mjpeg",
 		"-q:v", fmt.Sprintf("%d", qscale),
 		"-",
-	}
-
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("ffmpeg error: %w, stderr: %s", err, stderr.String())
+	stdout, runErr := sp.runTool(ctx, "ffmpeg", args, live)
+	if runErr != nil && len(stdout) == 0 {
+		return nil, runErr
 	}
 
 	// Split JPEG frames
-	frames, err := sp.splitJPEGFrames(stdout.Bytes())
+	frames, err := sp.splitJPEGFrames(stdout)
 	if err != nil {
+		if runErr != nil {
+			return nil, runErr
+		}
 		return nil, fmt.Errorf("failed to split frames: %w", err)
 	}
 
+	if len(goFilters) > 0 || sp.Encoder != nil {
+		if frames, err = sp.applyGoFilters(frames, goFilters); err != nil {
+			return nil, err
+		}
+	}
+	if runErr != nil {
+		// ffmpeg failed partway (corrupt tail, killed at a deadline): hand
+		// back the frames decoded until then
+		return nil, &errdefs.PartialError{Stage: errdefs.StageExtract, Err: runErr, Frames: frames}
+	}
 	return frames, nil
 }
 
@@ -243,17 +320,18 @@ func (sp *StreamProcessor) splitJPEGFrames(data []byte) ([][]byte, error) {
 	}
 
 	if len(frames) == 0 {
-		return nil, fmt.Errorf("no valid JPEG frames found")
+		return nil, fmt.Errorf("%w: no valid JPEG frames found", errdefs.ErrNoFrames)
 	}
 
 	return frames, nil
 }
 
 // ProcessH264StreamReader processes H.264 stream from an io.Reader
-// Useful for reading from network connections or pipes
+// Useful for reading from network connections or pipes; reading stops
+// when ctx is done
 func (sp *StreamProcessor) ProcessH264StreamReader(ctx context.Context, reader io.Reader) ([]string, error) {
 	// Read all data from reader
-	data, err := io.ReadAll(reader)
+	data, err := io.ReadAll(contextReader(ctx, reader))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read stream: %w", err)
 	}
@@ -270,11 +348,93 @@ type StreamFrameExtractor struct {
 	ctx          context.Context
 	cancel       context.CancelFunc
 	wg           sync.WaitGroup
+
+	mu      sync.Mutex
+	current StreamUpdate  // Settings in effect
+	pending *StreamUpdate // Settings waiting for the next keyframe
+}
+
+// StreamUpdate changes the settings of a running StreamFrameExtractor;
+// zero fields keep the current value
+type StreamUpdate struct {
+	FPS     int     // Frames per second to extract
+	Sampler Sampler // Frame sampling policy
+}
+
+// merge overlays the non-zero fields of u
+func (s StreamUpdate) merge(u StreamUpdate) StreamUpdate {
+	if u.FPS > 0 {
+		s.FPS = u.FPS
+	}
+	if u.Sampler != nil {
+		s.Sampler = u.Sampler
+	}
+	return s
+}
+
+// Update changes the extraction settings without restarting the stream.
+// It is safe to call from any goroutine; the change takes effect at the
+// next keyframe so no GOP is decoded with mixed settings. Updates made
+// before that keyframe are combined.
+func (sfe *StreamFrameExtractor) Update(u StreamUpdate) {
+	sfe.mu.Lock()
+	defer sfe.mu.Unlock()
+	next := sfe.current
+	if sfe.pending != nil {
+		next = *sfe.pending
+	}
+	next = next.merge(u)
+	sfe.pending = &next
+}
+
+// settings returns a context carrying the settings in effect
+func (sfe *StreamFrameExtractor) settings() context.Context {
+	sfe.mu.Lock()
+	cur := sfe.current
+	sfe.mu.Unlock()
+	ctx := sfe.ctx
+	if cur.FPS > 0 {
+		ctx = WithFPS(ctx, cur.FPS)
+	}
+	if cur.Sampler != nil {
+		ctx = WithSampler(ctx, cur.Sampler)
+	}
+	return ctx
+}
+
+// applyAt returns the offset of the keyframe in chunk at which a pending
+// update takes effect, or -1 if there is none
+func (sfe *StreamFrameExtractor) applyAt(chunk []byte) int {
+	sfe.mu.Lock()
+	defer sfe.mu.Unlock()
+	if sfe.pending == nil {
+		return -1
+	}
+	if cuts := keyframeOffsets(chunk); len(cuts) > 0 {
+		return cuts[0]
+	}
+	return -1
+}
+
+// apply makes the pending update current
+func (sfe *StreamFrameExtractor) apply() {
+	sfe.mu.Lock()
+	defer sfe.mu.Unlock()
+	if sfe.pending != nil {
+		sfe.current, sfe.pending = *sfe.pending, nil
+	}
 }
 
 // NewStreamFrameExtractor creates a new continuous stream frame extractor
 func NewStreamFrameExtractor(processor *StreamProcessor) *StreamFrameExtractor {
-	ctx, cancel := context.WithCancel(context.Background())
+	return NewStreamFrameExtractorContext(context.Background(), processor)
+}
+
+// NewStreamFrameExtractorContext creates a stream frame extractor that
+// stops, like Stop, when ctx is done; values carried by ctx (sampler,
+// exec limits...) apply to every chunk
+func NewStreamFrameExtractorContext(ctx context.Context, processor *StreamProcessor) *StreamFrameExtractor {
+	ctx, cancel := context.WithCancel(ctx)
 	return &StreamFrameExtractor{
 		processor:    processor,
 		frameChannel: make(chan []byte, 100),
@@ -284,13 +444,18 @@ func NewStreamFrameExtractor(processor *StreamProcessor) *StreamFrameExtractor {
 	}
 }
 
-// Start begins processing H.264 stream chunks
+// Start begins processing H.264 stream chunks. If streamReader is an
+// io.Closer it is closed on Stop, so a Read blocked on a quiet network
+// stream returns instead of holding Stop up.
 func (sfe *StreamFrameExtractor) Start(streamReader io.Reader) {
 	sfe.wg.Add(1)
 	go func() {
 		defer sfe.wg.Done()
 		defer close(sfe.frameChannel)
 		defer close(sfe.errorChannel)
+		if c, ok := streamReader.(io.Closer); ok {
+			defer context.AfterFunc(sfe.ctx, func() { c.Close() })()
+		}
 
 		// Read stream in chunks
 		buffer := make([]byte, 64*1024) // 64KB chunks
@@ -301,28 +466,25 @@ func (sfe *StreamFrameExtractor) Start(streamReader io.Reader) {
 			default:
 				n, err := streamReader.Read(buffer)
 				if err != nil {
-					if err != io.EOF {
-						sfe.errorChannel <- err
+					if err != io.EOF && sfe.ctx.Err() == nil {
+						sfe.sendError(err)
 					}
 					return
 				}
 
 				if n > 0 {
-					// Process this chunk
-					frames, err := sfe.processor.ProcessH264StreamWithContext(sfe.ctx, buffer[:n])
-					if err != nil {
-						sfe.errorChannel <- err
-						continue
-					}
-
-					// Send frames to channel
-					for _, frameB64 := range frames {
-						frameData, _ := base64.StdEncoding.DecodeString(frameB64)
-						select {
-						case sfe.frameChannel <- frameData:
-						case <-sfe.ctx.Done():
+					chunk := buffer[:n]
+					// A pending update starts at the first keyframe; the data
+					// before it still belongs to the previous GOP
+					if at := sfe.applyAt(chunk); at >= 0 {
+						if at > 0 && !sfe.process(chunk[:at]) {
 							return
 						}
+						sfe.apply()
+						chunk = chunk[at:]
+					}
+					if !sfe.process(chunk) {
+						return
 					}
 				}
 			}
@@ -330,6 +492,40 @@ func (sfe *StreamFrameExtractor) Start(streamReader io.Reader) {
 	}()
 }
 
+// process extracts the frames of one chunk with the current settings and
+// sends them; it returns false when the extractor was stopped
+func (sfe *StreamFrameExtractor) process(chunk []byte) bool {
+	frames, err := sfe.processor.ProcessH264StreamWithContext(sfe.settings(), chunk)
+	if err != nil {
+		if sfe.ctx.Err() != nil {
+			return false // ffmpeg was killed by Stop
+		}
+		return sfe.sendError(err)
+	}
+
+	// Send frames to channel
+	for _, frameB64 := range frames {
+		frameData, _ := base64.StdEncoding.DecodeString(frameB64)
+		select {
+		case sfe.frameChannel <- frameData:
+		case <-sfe.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// sendError reports err unless the extractor is stopped first, so Stop
+// never waits on a full error channel nobody reads
+func (sfe *StreamFrameExtractor) sendError(err error) bool {
+	select {
+	case sfe.errorChannel <- err:
+		return true
+	case <-sfe.ctx.Done():
+		return false
+	}
+}
+
 // GetFrameChannel returns the channel for receiving extracted frames
 func (sfe *StreamFrameExtractor) GetFrameChannel() <-chan []byte {
 	return sfe.frameChannel
@@ -0,0 +1,258 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// TailFile follows a recording that is still being written (e.g. by an
+// NVR) and extracts frames from each newly appended part. Containers that
+// can be probed while growing (MPEG-TS, MKV, fragmented MP4) are cut by
+// duration; raw H.264 files are cut at keyframe boundaries, so every
+// segment holds only complete, newly appended GOPs.
+type TailFile struct {
+	Path       string
+	Poll       time.Duration // How often to check for growth (default 2s)
+	MinSegment time.Duration // Minimum new footage per container segment (default 10s)
+	Idle       time.Duration // Stop once the file hasn't grown for this long (0: until ctx is done)
+	MaxFrames  int           // Frames per segment (0 uses sp.FPS)
+	FromStart  bool          // Also process what is already on disk instead of only new data
+}
+
+// TailSegment is one incremental part of a growing recording
+type TailSegment struct {
+	Start  time.Duration // Position in the recording (containers only)
+	End    time.Duration
+	Offset int64 // Byte range (raw H.264 only)
+	Size   int64
+	Frames [][]byte
+}
+
+// TailFile polls t.Path and calls fn with each new segment until ctx is
+// done, the file stays idle for t.Idle (the remainder is then flushed as a
+// last segment), or fn returns an error. Size and duration limits are not
+// applied to the recording as a whole.
+func (sp *StreamProcessor) TailFile(ctx context.Context, t TailFile, fn func(TailSegment) error) error {
+	if err := sp.RequireTools(); err != nil {
+		return err
+	}
+	if t.Poll <= 0 {
+		t.Poll = 2 * time.Second
+	}
+	if t.MinSegment <= 0 {
+		t.MinSegment = 10 * time.Second
+	}
+
+	next := sp.tailContainer
+	if formatFromExt(t.Path) == FormatH264 {
+		next = sp.tailH264
+	}
+	state := &tailState{first: true}
+
+	ticker := time.NewTicker(t.Poll)
+	defer ticker.Stop()
+	lastGrowth := time.Now()
+	for {
+		st, err := os.Stat(t.Path)
+		if err != nil {
+			return fmt.Errorf("failed to stat recording: %w", err)
+		}
+		if state.stat != nil && (!os.SameFile(state.stat, st) || st.Size() < state.size) {
+			// The recording was truncated or rotated: start over at the
+			// beginning of the new content
+			state.reset()
+		}
+		state.stat = st
+		grew := st.Size() != state.size
+		if grew {
+			state.size = st.Size()
+			lastGrowth = time.Now()
+		}
+		if grew || state.err != nil {
+			seg, err := next(ctx, t, state)
+			switch {
+			case ctx.Err() != nil:
+				return nil
+			case err != nil:
+				// A growing file is often briefly unreadable at its tail;
+				// keep the error and retry on the next poll
+				state.err = err
+			case seg != nil:
+				state.err = nil
+				if err := fn(*seg); err != nil {
+					return err
+				}
+			}
+		}
+		if !grew && t.Idle > 0 && time.Since(lastGrowth) >= t.Idle {
+			// The recording is finished: flush the remainder held back
+			// as possibly incomplete
+			state.final = true
+			seg, err := next(ctx, t, state)
+			if err != nil {
+				return err
+			}
+			if seg != nil {
+				return fn(*seg)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// tailState tracks how much of the recording has been processed
+type tailState struct {
+	first  bool
+	final  bool          // File stopped growing; process everything left
+	size   int64         // Last observed file size
+	pos    time.Duration // Containers: processed duration
+	offset int64         // Raw H.264: processed bytes
+	err    error         // Last extraction error
+	stat   os.FileInfo   // Last observed file, to detect rotation
+}
+
+// reset processes the recording from its beginning, after it was
+// truncated or replaced
+func (s *tailState) reset() {
+	s.first = false
+	s.size, s.pos, s.offset = 0, 0, 0
+	s.err = nil
+}
+
+// tailContainer extracts the footage between the processed position and
+// the current duration once at least MinSegment is available
+func (sp *StreamProcessor) tailContainer(ctx context.Context, t TailFile, s *tailState) (*TailSegment, error) {
+	info := &VideoInfo{}
+	if err := sp.probeInto(ctx, t.Path, info); err != nil {
+		return nil, err
+	}
+	if err := sp.InputLimits.check(&VideoInfo{Width: info.Width, Height: info.Height}); err != nil {
+		return nil, err
+	}
+	if s.first {
+		s.first = false
+		if !t.FromStart {
+			s.pos = info.Duration
+			return nil, nil
+		}
+	}
+	if info.Duration < s.pos {
+		// Rewritten in place with the same file and size
+		s.pos = 0
+	}
+	if info.Duration-s.pos < t.MinSegment && !(s.final && info.Duration > s.pos) {
+		return nil, nil
+	}
+
+	frames, err := sp.ExtractVideoSegment(ctx, t.Path, s.pos, info.Duration-s.pos, t.MaxFrames)
+	if err != nil {
+		return nil, err
+	}
+	seg := &TailSegment{Start: s.pos, End: info.Duration, Frames: frames}
+	s.pos = info.Duration
+	return seg, nil
+}
+
+// tailH264 extracts the complete GOPs appended since the last segment;
+// the trailing, possibly unfinished GOP is left for the next poll
+func (sp *StreamProcessor) tailH264(ctx context.Context, t TailFile, s *tailState) (*TailSegment, error) {
+	if s.first {
+		s.first = false
+		if !t.FromStart {
+			s.offset = s.size
+		}
+	}
+	data, err := readRange(t.Path, s.offset, s.size)
+	if err != nil {
+		return nil, err
+	}
+
+	cuts := keyframeOffsets(data)
+	if len(cuts) == 0 {
+		return nil, nil
+	}
+	// Skip a partial GOP when joining mid-stream
+	if cuts[0] > 0 {
+		s.offset += int64(cuts[0])
+		data, cuts = data[cuts[0]:], shift(cuts[1:], cuts[0])
+	} else {
+		cuts = cuts[1:]
+	}
+	end := len(data)
+	if !s.final {
+		if len(cuts) == 0 {
+			return nil, nil
+		}
+		end = cuts[len(cuts)-1]
+	}
+
+	frames, err := sp.h264Frames(ctx, data[:end], t.MaxFrames)
+	if err != nil {
+		return nil, err
+	}
+	seg := &TailSegment{Offset: s.offset, Size: int64(end), Frames: frames}
+	s.offset += int64(end)
+	return seg, nil
+}
+
+// readRange reads bytes [from, to) of a file
+func readRange(path string, from, to int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer f.Close()
+	if to <= from {
+		return nil, nil
+	}
+	buf := make([]byte, to-from)
+	n, err := f.ReadAt(buf, from)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return buf[:n], nil
+}
+
+// keyframeOffsets returns the offsets at which a new GOP starts in an
+// Annex B stream: an SPS, or an IDR slice not preceded by parameter sets
+func keyframeOffsets(data []byte) []int {
+	var cuts []int
+	prev := -1
+	for i := 0; i+3 < len(data); i++ {
+		if data[i] != 0 || data[i+1] != 0 || data[i+2] != 1 {
+			continue
+		}
+		start := i
+		if i > 0 && data[i-1] == 0 {
+			start = i - 1
+		}
+		nal := int(data[i+3] & 0x1f)
+		switch {
+		case nal == 7:
+			cuts = append(cuts, start)
+		case nal == 5 && prev != 5 && prev != 6 && prev != 7 && prev != 8 && prev != 9:
+			cuts = append(cuts, start)
+		}
+		prev = nal
+		i += 3
+	}
+	return cuts
+}
+
+// shift subtracts n from every offset
+func shift(offsets []int, n int) []int {
+	for i := range offsets {
+		offsets[i] -= n
+	}
+	return offsets
+}
@@ -0,0 +1,273 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Timing describes the real presentation timestamps of a video. Phones
+// and some IP cameras record at a variable frame rate and drop out under
+// load, so frame numbers and duration*fps arithmetic drift from real time;
+// sampling by Timing keeps frame timestamps exact and reports the gaps.
+type Timing struct {
+	Start    time.Duration   // PTS of the first frame
+	Duration time.Duration   // From the first to the last frame
+	PTS      []time.Duration // Timestamp of every frame, sorted, relative to Start
+	FPS      float64         // Mean frame rate over the covered time
+	Interval time.Duration   // Median frame interval
+	Variable bool            // Frame intervals vary by more than 20%
+	Gaps     []models.Gap    // Intervals without frames, in seconds from Start
+}
+
+// GapThreshold is the shortest missing interval reported as a gap, unless
+// the stream's own frame interval is longer
+const GapThreshold = time.Second
+
+// ProbeTiming reads the timestamps of every video packet with ffprobe
+// (no decoding, so it is fast even for long files)
+func (sp *StreamProcessor) ProbeTiming(ctx context.Context, videoPath string) (*Timing, error) {
+	stream, err := sp.videoStream(ctx, []string{"-i", videoPath}, false)
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := sp.runTool(ctx, "ffprobe", []string{
+		"-v", "error",
+		"-select_streams", stream,
+		"-show_entries", "packet=pts_time",
+		"-of", "csv=p=0",
+		"-i", videoPath,
+	}, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe timestamps: %w", err)
+	}
+	var pts []time.Duration
+	for _, line := range strings.Split(string(stdout), "\n") {
+		line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+		seconds, err := strconv.ParseFloat(line, 64)
+		if err != nil {
+			continue // N/A for packets without a timestamp
+		}
+		pts = append(pts, time.Duration(seconds*float64(time.Second)))
+	}
+	if len(pts) == 0 {
+		return nil, fmt.Errorf("no video timestamps in %s", videoPath)
+	}
+	return AnalyzeTimestamps(pts), nil
+}
+
+// AnalyzeTimestamps derives the frame rate, variability and gaps of a
+// stream from its frame timestamps, in any order
+func AnalyzeTimestamps(pts []time.Duration) *Timing {
+	sorted := append([]time.Duration(nil), pts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	t := &Timing{Start: sorted[0], PTS: make([]time.Duration, len(sorted))}
+	for i, p := range sorted {
+		t.PTS[i] = p - t.Start
+	}
+	t.Duration = t.PTS[len(t.PTS)-1]
+	if len(t.PTS) < 2 {
+		return t
+	}
+
+	intervals := make([]time.Duration, 0, len(t.PTS)-1)
+	for i := 1; i < len(t.PTS); i++ {
+		if d := t.PTS[i] - t.PTS[i-1]; d > 0 {
+			intervals = append(intervals, d)
+		}
+	}
+	if len(intervals) == 0 {
+		return t
+	}
+	byLength := append([]time.Duration(nil), intervals...)
+	sort.Slice(byLength, func(i, j int) bool { return byLength[i] < byLength[j] })
+	t.Interval = byLength[len(byLength)/2]
+
+	// A gap is a missing stretch well beyond the normal frame interval
+	threshold := max(GapThreshold, 4*t.Interval)
+	var missing time.Duration
+	for i := 1; i < len(t.PTS); i++ {
+		if d := t.PTS[i] - t.PTS[i-1]; d > threshold {
+			t.Gaps = append(t.Gaps, models.Gap{Start: t.PTS[i-1].Seconds(), End: t.PTS[i].Seconds()})
+			missing += d
+		}
+	}
+	if covered := t.Duration - missing; covered > 0 {
+		t.FPS = float64(len(t.PTS)-1-len(t.Gaps)) / covered.Seconds()
+	}
+
+	// Compare the 10th and 90th percentile intervals; gaps and the odd
+	// late frame fall outside them
+	lo, hi := byLength[len(byLength)/10], byLength[len(byLength)*9/10]
+	t.Variable = hi > lo+lo/5
+	return t
+}
+
+// Select returns the indices of up to n frames spread evenly over the
+// time the stream actually covers: gaps are skipped instead of being
+// spent on, and each target time is matched to the nearest real frame
+func (t *Timing) Select(n int) []int {
+	if n <= 0 || n >= len(t.PTS) {
+		all := make([]int, len(t.PTS))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+
+	// Covered spans between gaps
+	type span struct{ start, end time.Duration }
+	var spans []span
+	from := time.Duration(0)
+	var covered time.Duration
+	for _, g := range t.Gaps {
+		gs, ge := seconds(g.Start), seconds(g.End)
+		spans = append(spans, span{from, gs})
+		covered += gs - from
+		from = ge
+	}
+	spans = append(spans, span{from, t.Duration})
+	covered += t.Duration - from
+
+	picked := make([]int, 0, n)
+	for k := 0; k < n; k++ {
+		// Midpoints of n equal slices of the covered time
+		offset := time.Duration((float64(k) + 0.5) / float64(n) * float64(covered))
+		target := t.Duration
+		for _, s := range spans {
+			if offset <= s.end-s.start {
+				target = s.start + offset
+				break
+			}
+			offset -= s.end - s.start
+		}
+		i := t.nearest(target)
+		if len(picked) > 0 && i <= picked[len(picked)-1] {
+			i = picked[len(picked)-1] + 1 // Dense selections must not repeat a frame
+		}
+		if i >= len(t.PTS) {
+			break
+		}
+		picked = append(picked, i)
+	}
+	return picked
+}
+
+// nearest returns the index of the frame closest to target
+func (t *Timing) nearest(target time.Duration) int {
+	i := sort.Search(len(t.PTS), func(i int) bool { return t.PTS[i] >= target })
+	if i == len(t.PTS) || (i > 0 && target-t.PTS[i-1] < t.PTS[i]-target) {
+		i--
+	}
+	return i
+}
+
+// seconds converts float seconds to a Duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ExtractByTimestamps extracts up to maxFrames frames of a video chosen by
+// their real timestamps (see Timing.Select) and returns them with their
+// offsets in seconds from the first frame, which stay exact for variable
+// frame rate sources and across gaps. timing may be nil, in which case it
+// is probed.
+func (sp *StreamProcessor) ExtractByTimestamps(ctx context.Context, videoPath string, timing *Timing, maxFrames int) ([][]byte, []float64, error) {
+	if err := sp.RequireTools(); err != nil {
+		return nil, nil, err
+	}
+	if timing == nil {
+		var err error
+		if timing, err = sp.ProbeTiming(ctx, videoPath); err != nil {
+			return nil, nil, err
+		}
+	}
+	indices := timing.Select(maxFrames)
+
+	// Frames are numbered in presentation order, like timing.PTS
+	terms := make([]string, len(indices))
+	for i, n := range indices {
+		terms[i] = fmt.Sprintf(`eq(n\,%d)`, n)
+	}
+	frames, err := sp.runFFmpegFrames(ctx, []string{"-i", videoPath}, "select="+strings.Join(terms, "+"), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(frames) != len(indices) {
+		// Packet and decoded frame counts disagree (corrupt frames); fall
+		// back to spreading the timestamps over what was decoded
+		picked := make([]int, len(frames))
+		for i, pos := range evenIndices(len(indices), len(frames)) {
+			picked[i] = indices[pos]
+		}
+		indices = picked
+	}
+	timestamps := make([]float64, len(frames))
+	for i := range frames {
+		timestamps[i] = timing.PTS[min(indices[i], len(timing.PTS)-1)].Seconds()
+	}
+	return frames, timestamps, nil
+}
+
+// evenIndices picks n indices evenly spaced over 0..total-1
+func evenIndices(total, n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		if n > 1 {
+			out[i] = i * (total - 1) / (n - 1)
+		}
+	}
+	return out
+}
+
+// PTS samples frames by their timestamps rather than with the fps filter,
+// which assumes a constant rate and duplicates frames into dropouts: a
+// frame is kept when at least Duration/Count has passed since the last
+// kept one, so variable frame rate sources and gaps yield real frames only
+func PTS() Sampler { return ptsSampler{} }
+
+type ptsSampler struct{}
+
+func (ptsSampler) Filter(r SampleRequest) string {
+	step := 1 / float64(max(r.FPS, 1))
+	if r.Count > 0 && r.Duration > 0 {
+		step = r.Duration.Seconds() / float64(r.Count)
+	}
+	return fmt.Sprintf(`select=isnan(prev_selected_t)+gte(t-prev_selected_t\,%s)`, strconv.FormatFloat(step, 'f', 6, 64))
+}
+
+func (ptsSampler) Pick(c [][]byte, r SampleRequest) [][]byte { return evenN(c, r.Count) }
+
+// ExtractTimed extracts up to maxFrames frames of a video file together
+// with their offsets in seconds. With the uniform or PTS sampler frames are
+// chosen by real timestamps (ExtractByTimestamps) and the returned Timing
+// reports variable frame rate and gaps; other samplers choose frames by
+// content, their offsets are estimated and Timing is nil.
+func (sp *StreamProcessor) ExtractTimed(ctx context.Context, videoPath string, duration time.Duration, maxFrames int) ([][]byte, []float64, *Timing, error) {
+	switch sp.samplerFor(ctx).(type) {
+	case uniformSampler, ptsSampler:
+		if timing, err := sp.ProbeTiming(ctx, videoPath); err == nil && len(timing.PTS) > 1 {
+			frames, timestamps, err := sp.ExtractByTimestamps(ctx, videoPath, timing, maxFrames)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			return frames, timestamps, timing, nil
+		}
+	}
+
+	frames, err := sp.ExtractVideoSegment(ctx, videoPath, 0, duration, maxFrames)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	timestamps := make([]float64, len(frames))
+	step := duration.Seconds() / float64(max(maxFrames, len(frames), 1))
+	for i := range frames {
+		timestamps[i] = float64(i) * step
+	}
+	return frames, timestamps, nil, nil
+}
@@ -0,0 +1,86 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// Environment variables that override where ffmpeg and ffprobe are found
+const (
+	EnvFFmpegPath  = "FFMPEG_PATH"
+	EnvFFprobePath = "FFPROBE_PATH"
+)
+
+// Toolchain holds explicit paths to the ffmpeg and ffprobe executables;
+// empty fields are resolved with LookTool
+type Toolchain struct {
+	FFmpeg  string
+	FFprobe string
+}
+
+// WithTools sets explicit tool paths, e.g. a bundled ffmpeg.exe
+func (sp *StreamProcessor) WithTools(tools Toolchain) *StreamProcessor {
+	sp.Tools = tools
+	return sp
+}
+
+// toolPath resolves the executable for "ffmpeg" or "ffprobe"
+func (sp *StreamProcessor) toolPath(tool string) (string, error) {
+	switch {
+	case tool == "ffmpeg" && sp.Tools.FFmpeg != "":
+		return sp.Tools.FFmpeg, nil
+	case tool == "ffprobe" && sp.Tools.FFprobe != "":
+		return sp.Tools.FFprobe, nil
+	}
+	return LookTool(tool)
+}
+
+// LookTool locates ffmpeg or ffprobe: the FFMPEG_PATH / FFPROBE_PATH
+// environment variable, then PATH (exec.LookPath appends .exe and the
+// other PATHEXT extensions on Windows), then the directory of the running
+// executable, where Windows installs commonly ship ffmpeg.exe
+func LookTool(name string) (string, error) {
+	env := EnvFFmpegPath
+	if name == "ffprobe" {
+		env = EnvFFprobePath
+	}
+	if p := os.Getenv(env); p != "" {
+		path, err := exec.LookPath(p)
+		if err != nil && !errors.Is(err, exec.ErrDot) {
+			return "", fmt.Errorf("%w: %s=%s: %v", errdefs.ErrFFmpegNotFound, env, p, err)
+		}
+		return path, nil
+	}
+
+	path, err := exec.LookPath(name)
+	if err == nil {
+		return path, nil
+	}
+	if errors.Is(err, exec.ErrDot) {
+		// Found in the current directory; accept it explicitly, as
+		// Windows users often drop ffmpeg.exe next to their data
+		return path, nil
+	}
+
+	if self, selfErr := os.Executable(); selfErr == nil {
+		candidate := filepath.Join(filepath.Dir(self), executableName(name, runtime.GOOS))
+		if st, statErr := os.Stat(candidate); statErr == nil && !st.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s: %v", errdefs.ErrFFmpegNotFound, name, err)
+}
+
+// executableName returns the file name of a tool on goos
+func executableName(name, goos string) string {
+	if goos == "windows" && filepath.Ext(name) == "" {
+		return name + ".exe"
+	}
+	return name
+}
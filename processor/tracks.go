@@ -0,0 +1,305 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// Track is a video stream of an input. MPEG-TS from broadcast encoders,
+// NVRs and multiviewers often carries several programs or video tracks;
+// ffmpeg then picks the highest resolution one, which is not necessarily
+// the camera or channel wanted.
+type Track struct {
+	Index    int    // Stream index in the input (what -map 0:<Index> selects)
+	PID      int    // MPEG-TS PID (the stream id); 0 when the container has none
+	Program  int    // Program number; 0 outside multi-program transport streams
+	Codec    string // e.g. "h264", "hevc"
+	Width    int
+	Height   int
+	Language string // ISO 639-2 code from the stream tags, e.g. "eng"
+	Default  bool   // Marked as the default track
+}
+
+// String describes the track for logs and CLI listings
+func (t Track) String() string {
+	s := fmt.Sprintf("#%d %s %dx%d", t.Index, t.Codec, t.Width, t.Height)
+	if t.PID > 0 {
+		s += fmt.Sprintf(" pid=0x%x", t.PID)
+	}
+	if t.Program > 0 {
+		s += fmt.Sprintf(" program=%d", t.Program)
+	}
+	if t.Language != "" {
+		s += " lang=" + t.Language
+	}
+	if t.Default {
+		s += " default"
+	}
+	return s
+}
+
+// TrackSelector narrows the candidate video tracks of an input; the first
+// remaining track is decoded
+type TrackSelector interface {
+	Select(tracks []Track) []Track
+}
+
+// TrackSelectorFunc adapts a function to TrackSelector
+type TrackSelectorFunc func(tracks []Track) []Track
+
+// Select implements TrackSelector
+func (f TrackSelectorFunc) Select(tracks []Track) []Track { return f(tracks) }
+
+// matching returns a selector keeping the tracks accepted by keep
+func matching(keep func(Track) bool) TrackSelector {
+	return TrackSelectorFunc(func(tracks []Track) []Track {
+		var out []Track
+		for _, t := range tracks {
+			if keep(t) {
+				out = append(out, t)
+			}
+		}
+		return out
+	})
+}
+
+// TrackIndex selects the stream with the given input index (see ProbeTracks)
+func TrackIndex(index int) TrackSelector {
+	return matching(func(t Track) bool { return t.Index == index })
+}
+
+// TrackPID selects the stream with the given MPEG-TS PID
+func TrackPID(pid int) TrackSelector {
+	return matching(func(t Track) bool { return t.PID == pid })
+}
+
+// TrackProgram selects the video tracks of an MPEG-TS program
+func TrackProgram(program int) TrackSelector {
+	return matching(func(t Track) bool { return t.Program == program })
+}
+
+// TrackLanguage selects tracks tagged with a language (case-insensitive)
+func TrackLanguage(lang string) TrackSelector {
+	return matching(func(t Track) bool { return strings.EqualFold(t.Language, lang) })
+}
+
+// TrackResolution orders tracks by how close their pixel count is to
+// width x height, so the closest one is decoded; 0x0 prefers the highest
+// resolution
+func TrackResolution(width, height int) TrackSelector {
+	return TrackSelectorFunc(func(tracks []Track) []Track {
+		want := width * height
+		out := append([]Track(nil), tracks...)
+		sort.SliceStable(out, func(i, j int) bool {
+			a, b := out[i].Width*out[i].Height, out[j].Width*out[j].Height
+			if want <= 0 {
+				return a > b
+			}
+			return abs(a-want) < abs(b-want)
+		})
+		return out
+	})
+}
+
+// AllTracks combines selectors, e.g. a program and then a resolution
+func AllTracks(selectors ...TrackSelector) TrackSelector {
+	return TrackSelectorFunc(func(tracks []Track) []Track {
+		for _, s := range selectors {
+			tracks = s.Select(tracks)
+		}
+		return tracks
+	})
+}
+
+// ParseTrack parses a comma-separated track selection for flags and
+// config files: "index:2", "pid:0x101", "program:3", "lang:eng",
+// "res:1920x1080" or "res:max", e.g. "program:3,res:max"
+func ParseTrack(spec string) (TrackSelector, error) {
+	var selectors []TrackSelector
+	for _, part := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid track selection %q", part)
+		}
+		switch key {
+		case "index", "pid", "program":
+			n, err := strconv.ParseInt(value, 0, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid track %s %q: %w", key, value, err)
+			}
+			switch key {
+			case "index":
+				selectors = append(selectors, TrackIndex(int(n)))
+			case "pid":
+				selectors = append(selectors, TrackPID(int(n)))
+			default:
+				selectors = append(selectors, TrackProgram(int(n)))
+			}
+		case "lang":
+			selectors = append(selectors, TrackLanguage(value))
+		case "res":
+			if value == "max" {
+				selectors = append(selectors, TrackResolution(0, 0))
+				break
+			}
+			w, h, ok := strings.Cut(value, "x")
+			width, errW := strconv.Atoi(w)
+			height, errH := strconv.Atoi(h)
+			if !ok || errW != nil || errH != nil {
+				return nil, fmt.Errorf("invalid track resolution %q", value)
+			}
+			selectors = append(selectors, TrackResolution(width, height))
+		default:
+			return nil, fmt.Errorf("unknown track selection %q", key)
+		}
+	}
+	return AllTracks(selectors...), nil
+}
+
+// ffprobeTracks is the subset of ffprobe's JSON output ProbeTracks reads
+type ffprobeTracks struct {
+	Programs []struct {
+		ProgramID int `json:"program_id"`
+		Streams   []struct {
+			Index int `json:"index"`
+		} `json:"streams"`
+	} `json:"programs"`
+	Streams []struct {
+		Index       int    `json:"index"`
+		ID          string `json:"id"`
+		CodecName   string `json:"codec_name"`
+		CodecType   string `json:"codec_type"`
+		Width       int    `json:"width"`
+		Height      int    `json:"height"`
+		Disposition struct {
+			Default int `json:"default"`
+		} `json:"disposition"`
+		Tags struct {
+			Language string `json:"language"`
+		} `json:"tags"`
+	} `json:"streams"`
+}
+
+// ProbeTracks lists the video tracks of a file or stream URL with their
+// program, PID, language and resolution
+func (sp *StreamProcessor) ProbeTracks(ctx context.Context, input string) ([]Track, error) {
+	return sp.probeTracks(ctx, []string{"-i", input}, strings.Contains(input, "://"))
+}
+
+// probeTracks runs ffprobe on ffmpeg input arguments; seek and window
+// options are dropped since they do not apply to probing
+func (sp *StreamProcessor) probeTracks(ctx context.Context, inputArgs []string, live bool) ([]Track, error) {
+	args := []string{
+		"-v", "error",
+		"-show_entries", "program=program_id:program_stream=index:stream=index,id,codec_name,codec_type,width,height:stream_disposition=default:stream_tags=language",
+		"-of", "json",
+	}
+	for i := 0; i < len(inputArgs); i++ {
+		if (inputArgs[i] == "-ss" || inputArgs[i] == "-t") && i+1 < len(inputArgs) {
+			i++
+			continue
+		}
+		args = append(args, inputArgs[i])
+	}
+	stdout, err := sp.runTool(ctx, "ffprobe", args, live)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe tracks: %w", err)
+	}
+
+	var probed ffprobeTracks
+	if err := json.Unmarshal(stdout, &probed); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	programs := map[int]int{}
+	for _, p := range probed.Programs {
+		for _, s := range p.Streams {
+			programs[s.Index] = p.ProgramID
+		}
+	}
+	var tracks []Track
+	for _, s := range probed.Streams {
+		if s.CodecType != "video" {
+			continue
+		}
+		pid, _ := strconv.ParseInt(s.ID, 0, 32)
+		tracks = append(tracks, Track{
+			Index:    s.Index,
+			PID:      int(pid),
+			Program:  programs[s.Index],
+			Codec:    s.CodecName,
+			Width:    s.Width,
+			Height:   s.Height,
+			Language: s.Tags.Language,
+			Default:  s.Disposition.Default == 1,
+		})
+	}
+	return tracks, nil
+}
+
+type trackKey struct{}
+
+// WithTrack overrides the processor's track selection for calls made with ctx
+func WithTrack(ctx context.Context, sel TrackSelector) context.Context {
+	return context.WithValue(ctx, trackKey{}, sel)
+}
+
+// WithTrack sets the default track selection for multi-track inputs;
+// without one ffmpeg picks the track itself
+func (sp *StreamProcessor) WithTrack(sel TrackSelector) *StreamProcessor {
+	sp.Track = sel
+	return sp
+}
+
+// trackFor returns the track selector from ctx or sp.Track, or nil
+func (sp *StreamProcessor) trackFor(ctx context.Context) TrackSelector {
+	if sel, ok := ctx.Value(trackKey{}).(TrackSelector); ok && sel != nil {
+		return sel
+	}
+	return sp.Track
+}
+
+// selectTrack resolves the track selection for an input by probing its
+// tracks; ok is false when no selection applies and ffmpeg should pick
+func (sp *StreamProcessor) selectTrack(ctx context.Context, inputArgs []string, live bool) (track Track, ok bool, err error) {
+	sel := sp.trackFor(ctx)
+	if sel == nil {
+		return Track{}, false, nil
+	}
+	tracks, err := sp.probeTracks(ctx, inputArgs, live)
+	if err != nil {
+		return Track{}, false, err
+	}
+	picked := sel.Select(tracks)
+	if len(picked) == 0 {
+		names := make([]string, len(tracks))
+		for i, t := range tracks {
+			names[i] = t.String()
+		}
+		return Track{}, false, fmt.Errorf("%w: no video track matches the selection (available: %s)", errdefs.ErrInvalidRequest, strings.Join(names, "; "))
+	}
+	return picked[0], true, nil
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// videoStream returns the ffprobe stream specifier of the video track to
+// inspect: the selected track, or the first video stream
+func (sp *StreamProcessor) videoStream(ctx context.Context, inputArgs []string, live bool) (string, error) {
+	track, ok, err := sp.selectTrack(ctx, inputArgs, live)
+	if err != nil || !ok {
+		return "v:0", err
+	}
+	return strconv.Itoa(track.Index), nil
+}
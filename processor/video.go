@@ -0,0 +1,60 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProbeDuration returns the duration of a video file using ffprobe,
+// enforcing sp.InputLimits
+func (sp *StreamProcessor) ProbeDuration(ctx context.Context, videoPath string) (time.Duration, error) {
+	info, err := sp.Probe(ctx, videoPath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Duration, nil
+}
+
+// ExtractVideoSegment extracts JPEG frames from a segment of a video file
+// (any container/codec ffmpeg can read), chosen by the processor's Sampler
+// (evenly spaced by default; see WithSampler)
+// start: segment start offset
+// duration: segment length
+// maxFrames: number of frames to sample from the segment (0 uses sp.FPS)
+func (sp *StreamProcessor) ExtractVideoSegment(ctx context.Context, videoPath string, start, duration time.Duration, maxFrames int) ([][]byte, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("invalid segment duration: %v", duration)
+	}
+
+	inputArgs := []string{
+		"-ss", formatSeconds(start),
+		"-t", formatSeconds(duration),
+		"-i", videoPath,
+	}
+
+	return sp.sampleFrames(ctx, inputArgs, SampleRequest{Duration: duration, Count: maxFrames, FPS: sp.FPS}, false)
+}
+
+// formatSeconds formats a duration as seconds for ffmpeg arguments
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// CaptureStream records a window of a live source (RTSP/RTMP/HTTP URL or
+// device) with ffmpeg and returns up to maxFrames frames chosen by the Sampler
+func (sp *StreamProcessor) CaptureStream(ctx context.Context, url string, window time.Duration, maxFrames int) ([][]byte, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("invalid capture window: %v", window)
+	}
+
+	var inputArgs []string
+	if strings.HasPrefix(url, "rtsp://") || strings.HasPrefix(url, "rtsps://") {
+		inputArgs = append(inputArgs, "-rtsp_transport", "tcp")
+	}
+	inputArgs = append(inputArgs, "-t", formatSeconds(window), "-i", url)
+
+	return sp.sampleFrames(ctx, inputArgs, SampleRequest{Duration: window, Count: maxFrames, FPS: sp.FPS}, true)
+}
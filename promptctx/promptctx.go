@@ -0,0 +1,275 @@
+// Package promptctx prepends structured job context (camera name,
+// location, time of day, weather...) to prompts, so answers can be
+// specific ("the loading dock at night in rain") without every caller
+// formatting that context into its prompt strings:
+//
+//	c.PromptContext = promptctx.NewInjector(
+//		promptctx.FromLabels(nil),
+//		promptctx.TimeOfDay(nil),
+//		promptctx.Weather(func(ctx context.Context, location string) (string, error) {
+//			return weatherAPI.Current(ctx, location)
+//		}),
+//	)
+//	ctx = promptctx.WithFields(ctx, promptctx.Field{Name: promptctx.FieldCamera, Value: "东门"})
+//
+// Providers are queried for every call; per-job values travel on the
+// context like models.Labels.
+package promptctx
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Well-known field names, used as the line titles of the injected block
+const (
+	FieldCamera    = "摄像头"
+	FieldLocation  = "位置"
+	FieldTimeOfDay = "时段"
+	FieldWeather   = "天气"
+)
+
+// Field is one line of context
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Provider supplies context fields for a call
+type Provider interface {
+	Fields(ctx context.Context) ([]Field, error)
+}
+
+// ProviderFunc adapts a function to Provider
+type ProviderFunc func(ctx context.Context) ([]Field, error)
+
+// Fields implements Provider
+func (f ProviderFunc) Fields(ctx context.Context) ([]Field, error) { return f(ctx) }
+
+// DefaultTemplate places the context block before the prompt
+const DefaultTemplate = "背景信息：\n{context}\n\n{prompt}"
+
+// Injector renders the fields of its providers, and those carried by the
+// context, into prompts
+type Injector struct {
+	Providers []Provider
+	Template  string // Must contain {context} and {prompt} (default: DefaultTemplate)
+
+	// Strict fails the call when a provider fails; by default the failing
+	// provider's fields are left out, since context only refines a prompt
+	Strict bool
+}
+
+// NewInjector creates an injector querying providers in order
+func NewInjector(providers ...Provider) *Injector {
+	return &Injector{Providers: providers}
+}
+
+// Apply returns prompt with the context block injected. Fields are listed
+// in provider order, context fields (WithFields) last; a later field with
+// the same name replaces an earlier one in place, and empty values are
+// omitted. A prompt is returned unchanged when there is no context or ctx
+// was marked with Without.
+func (inj *Injector) Apply(ctx context.Context, prompt string) (string, error) {
+	if inj == nil || Skipped(ctx) {
+		return prompt, nil
+	}
+	fields, err := inj.collect(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(fields) == 0 {
+		return prompt, nil
+	}
+
+	lines := make([]string, len(fields))
+	for i, f := range fields {
+		lines[i] = "- " + f.Name + "：" + f.Value
+	}
+	template := inj.Template
+	if template == "" {
+		template = DefaultTemplate
+	}
+	return strings.NewReplacer("{context}", strings.Join(lines, "\n"), "{prompt}", prompt).Replace(template), nil
+}
+
+// collect gathers the fields of every provider and of ctx
+func (inj *Injector) collect(ctx context.Context) ([]Field, error) {
+	var fields []Field
+	index := map[string]int{}
+	add := func(fs []Field) {
+		for _, f := range fs {
+			if f.Name == "" || strings.TrimSpace(f.Value) == "" {
+				continue
+			}
+			if i, ok := index[f.Name]; ok {
+				fields[i] = f
+				continue
+			}
+			index[f.Name] = len(fields)
+			fields = append(fields, f)
+		}
+	}
+
+	for i, p := range inj.Providers {
+		fs, err := p.Fields(withCollected(ctx, fields))
+		if err != nil {
+			if inj.Strict {
+				return nil, fmt.Errorf("failed to get prompt context from provider %d: %w", i, err)
+			}
+			continue
+		}
+		add(fs)
+	}
+	add(FieldsFrom(ctx))
+	return fields, nil
+}
+
+// Static always supplies fields, e.g. a site-wide location
+func Static(fields ...Field) Provider {
+	return ProviderFunc(func(context.Context) ([]Field, error) { return fields, nil })
+}
+
+// FromLabels maps source labels to fields, from label key to field name.
+// A nil mapping turns the camera and site labels into the camera and
+// location fields.
+func FromLabels(mapping map[string]string) Provider {
+	m := mapping
+	if m == nil {
+		m = map[string]string{models.LabelCamera: FieldCamera, models.LabelSite: FieldLocation}
+	}
+	// Label key order keeps the prompt stable
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return ProviderFunc(func(ctx context.Context) ([]Field, error) {
+		labels := models.LabelsFrom(ctx)
+		var fields []Field
+		for _, key := range keys {
+			if v := labels[key]; v != "" {
+				fields = append(fields, Field{Name: m[key], Value: v})
+			}
+		}
+		return fields, nil
+	})
+}
+
+// TimeOfDay supplies the period of the day and the clock time of the
+// capture (WithTime) or of the call, in loc (nil uses the local zone)
+func TimeOfDay(loc *time.Location) Provider {
+	return ProviderFunc(func(ctx context.Context) ([]Field, error) {
+		t := TimeFrom(ctx)
+		if loc != nil {
+			t = t.In(loc)
+		}
+		return []Field{{Name: FieldTimeOfDay, Value: fmt.Sprintf("%s（%s）", period(t.Hour()), t.Format("15:04"))}}, nil
+	})
+}
+
+// period names the part of the day containing hour
+func period(hour int) string {
+	switch {
+	case hour < 5:
+		return "凌晨"
+	case hour < 8:
+		return "早晨"
+	case hour < 11:
+		return "上午"
+	case hour < 13:
+		return "中午"
+	case hour < 17:
+		return "下午"
+	case hour < 19:
+		return "傍晚"
+	case hour < 23:
+		return "晚上"
+	default:
+		return "深夜"
+	}
+}
+
+// Weather supplies the current weather from a callback, given the
+// location field collected so far (empty if none)
+func Weather(fn func(ctx context.Context, location string) (string, error)) Provider {
+	return ProviderFunc(func(ctx context.Context) ([]Field, error) {
+		w, err := fn(ctx, Collected(ctx, FieldLocation))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get weather: %w", err)
+		}
+		return []Field{{Name: FieldWeather, Value: w}}, nil
+	})
+}
+
+type (
+	fieldsKey    struct{}
+	timeKey      struct{}
+	skipKey      struct{}
+	collectedKey struct{}
+)
+
+// WithFields returns a context carrying per-job fields in addition to
+// those already on ctx
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	merged := append(append([]Field(nil), FieldsFrom(ctx)...), fields...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FieldsFrom returns the fields set on ctx by WithFields
+func FieldsFrom(ctx context.Context) []Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	return fields
+}
+
+// WithTime sets the capture time used by TimeOfDay, e.g. for recordings
+func WithTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, timeKey{}, t)
+}
+
+// TimeFrom returns the time set by WithTime, or the current time
+func TimeFrom(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(timeKey{}).(time.Time); ok && !t.IsZero() {
+		return t
+	}
+	return time.Now()
+}
+
+// Without marks ctx so that no context is injected, for internal calls
+// such as merging or judging previous answers
+func Without(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+// Skipped reports whether ctx was marked with Without
+func Skipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipKey{}).(bool)
+	return skip
+}
+
+// withCollected exposes the fields gathered so far to later providers
+func withCollected(ctx context.Context, fields []Field) context.Context {
+	return context.WithValue(ctx, collectedKey{}, append([]Field(nil), fields...))
+}
+
+// Collected returns the value of the named field set with WithFields or
+// gathered by earlier providers, for providers depending on it
+func Collected(ctx context.Context, name string) string {
+	collected, _ := ctx.Value(collectedKey{}).([]Field)
+	for _, fields := range [][]Field{FieldsFrom(ctx), collected} {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if fields[i].Name == name {
+				return fields[i].Value
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,208 @@
+package ptz
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"sort"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	maxComposite = 4480 // Longest side of a combined image (a multiple of 28)
+	gridCell     = 560  // Default grid cell width
+	matchWidth   = 160  // Width of the luma thumbnails used to estimate overlap
+	maxMatchDiff = 30.0 // Mean luma difference above which frames are considered unrelated
+)
+
+// Grid tiles the views into a single JPEG with each cell labeled by pose
+func Grid(views []View, columns, quality int) ([]byte, error) {
+	imgs, err := decodeViews(views)
+	if err != nil {
+		return nil, err
+	}
+	if columns <= 0 {
+		columns = int(math.Ceil(math.Sqrt(float64(len(imgs)))))
+	}
+	rows := (len(imgs) + columns - 1) / columns
+
+	cellW := min(gridCell, maxComposite/columns)
+	b := imgs[0].Bounds()
+	cellH := b.Dy() * cellW / b.Dx()
+
+	dst := image.NewRGBA(image.Rect(0, 0, cellW*columns, cellH*rows))
+	draw.Draw(dst, dst.Bounds(), image.Black, image.Point{}, draw.Src)
+	for i, img := range imgs {
+		cell := image.Rect(0, 0, cellW, cellH).Add(image.Pt(i%columns*cellW, i/columns*cellH))
+		draw.ApproxBiLinear.Scale(dst, fitInside(img.Bounds(), cell), img, img.Bounds(), draw.Src, nil)
+		drawLabel(dst, cell.Min, views[i].label())
+	}
+	return encode(dst, quality)
+}
+
+// Mosaic stitches the views left to right in pan order into one panorama.
+// With hfov > 0 frames are placed by their pan angle; otherwise the overlap
+// of neighboring frames is estimated by matching their edges. It fails when
+// neighbors don't overlap, so callers can fall back to Grid.
+func Mosaic(views []View, hfov float64, quality int) ([]byte, error) {
+	sorted := append([]View(nil), views...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pose.Pan < sorted[j].Pose.Pan })
+	imgs, err := decodeViews(sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	// Normalize to the height of the first frame
+	h := imgs[0].Bounds().Dy()
+	for i, img := range imgs {
+		b := img.Bounds()
+		if b.Dy() != h {
+			scaled := image.NewRGBA(image.Rect(0, 0, b.Dx()*h/b.Dy(), h))
+			draw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), img, b, draw.Src, nil)
+			imgs[i] = scaled
+		}
+	}
+
+	// x offset of every frame in the panorama
+	xs := make([]int, len(imgs))
+	for i := 1; i < len(imgs); i++ {
+		prev := imgs[i-1].Bounds().Dx()
+		var overlap int
+		if hfov > 0 {
+			step := (sorted[i].Pose.Pan - sorted[i-1].Pose.Pan) / hfov
+			if step >= 1 {
+				return nil, fmt.Errorf("views at %s and %s don't overlap", sorted[i-1].Pose, sorted[i].Pose)
+			}
+			overlap = int(float64(prev) * (1 - step))
+		} else {
+			overlap, err = estimateOverlap(imgs[i-1], imgs[i])
+			if err != nil {
+				return nil, fmt.Errorf("views at %s and %s: %w", sorted[i-1].Pose, sorted[i].Pose, err)
+			}
+		}
+		xs[i] = xs[i-1] + prev - overlap
+	}
+
+	last := imgs[len(imgs)-1]
+	pano := image.NewRGBA(image.Rect(0, 0, xs[len(xs)-1]+last.Bounds().Dx(), h))
+	for i, img := range imgs {
+		b := img.Bounds()
+		draw.Draw(pano, image.Rect(xs[i], 0, xs[i]+b.Dx(), h), img, b.Min, draw.Src)
+	}
+
+	// Keep the panorama within what the model accepts
+	var out image.Image = pano
+	if w := pano.Bounds().Dx(); w > maxComposite {
+		scaled := image.NewRGBA(image.Rect(0, 0, maxComposite, h*maxComposite/w))
+		draw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), pano, pano.Bounds(), draw.Src, nil)
+		out = scaled
+	}
+	return encode(out, quality)
+}
+
+// estimateOverlap finds how many pixels of a's right edge reappear at b's
+// left edge by minimizing the luma difference over candidate overlaps
+// between 10% and 80% of the frame width
+func estimateOverlap(a, b image.Image) (int, error) {
+	ga, wa, ha := luma(a)
+	gb, wb, _ := luma(b)
+	w := min(wa, wb)
+
+	best, bestDiff := 0, math.MaxFloat64
+	for ov := w / 10; ov <= w*8/10; ov++ {
+		var sum, n int
+		for y := 0; y < ha; y++ {
+			for x := 0; x < ov; x++ {
+				d := int(ga[y*wa+wa-ov+x]) - int(gb[y*wb+x])
+				if d < 0 {
+					d = -d
+				}
+				sum += d
+				n++
+			}
+		}
+		if diff := float64(sum) / float64(n); diff < bestDiff {
+			best, bestDiff = ov, diff
+		}
+	}
+	if bestDiff > maxMatchDiff {
+		return 0, fmt.Errorf("no overlap found (difference %.1f)", bestDiff)
+	}
+	return best * a.Bounds().Dx() / wa, nil
+}
+
+// luma returns a matchWidth-wide grayscale thumbnail of img
+func luma(img image.Image) ([]uint8, int, int) {
+	b := img.Bounds()
+	w := matchWidth
+	h := max(b.Dy()*w/b.Dx(), 1)
+	small := image.NewGray(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, b, draw.Src, nil)
+	return small.Pix, w, h
+}
+
+// fitInside returns the largest rectangle with src's aspect ratio centered
+// in cell
+func fitInside(src, cell image.Rectangle) image.Rectangle {
+	w, h := cell.Dx(), src.Dy()*cell.Dx()/src.Dx()
+	if h > cell.Dy() {
+		w, h = src.Dx()*cell.Dy()/src.Dy(), cell.Dy()
+	}
+	x := cell.Min.X + (cell.Dx()-w)/2
+	y := cell.Min.Y + (cell.Dy()-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// drawLabel writes text on a dark background at the top-left of a cell
+func drawLabel(img *image.RGBA, at image.Point, text string) {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil()
+	bg := image.Rect(at.X, at.Y, at.X+width+8, at.Y+face.Height+6)
+	draw.Draw(img, bg, image.NewUniform(color.RGBA{0, 0, 0, 180}), image.Point{}, draw.Over)
+
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.White,
+		Face: face,
+		Dot:  fixed.P(at.X+4, at.Y+face.Ascent+3),
+	}
+	d.DrawString(text)
+}
+
+// decodeViews decodes the JPEG frames of the views
+func decodeViews(views []View) ([]image.Image, error) {
+	if len(views) == 0 {
+		return nil, fmt.Errorf("sweep has no views")
+	}
+	imgs := make([]image.Image, len(views))
+	for i, v := range views {
+		img, _, err := image.Decode(bytes.NewReader(v.Frame))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode view %d: %w", i, err)
+		}
+		if img.Bounds().Empty() {
+			return nil, fmt.Errorf("view %d is empty", i)
+		}
+		imgs[i] = img
+	}
+	return imgs, nil
+}
+
+// encode encodes a composite as JPEG (default quality 90)
+func encode(img image.Image, quality int) ([]byte, error) {
+	if quality <= 0 {
+		quality = 90
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode composite: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,231 @@
+// Package ptz analyzes PTZ camera sweeps as one scene.
+//
+// No single frame of a pan/tilt/zoom camera covers a whole yard, so a
+// sweep is collected first (by driving the camera through presets or by
+// sampling an automatic patrol), then combined into a mosaic or a labeled
+// grid and analyzed as a single view:
+//
+//	views, _ := ptz.Sweep{Poses: ptz.Pan(-90, 90, 5), Mover: onvif, Capture: grab}.Collect(ctx)
+//	resp, _ := ptz.Analyze(ctx, c, views, "描述整个院子的情况", nil)
+package ptz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Analyzer sends frames to the model
+type Analyzer interface {
+	AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *client.ChatOptions) (*models.ChatResponse, error)
+}
+
+// Pose is a camera orientation in degrees
+type Pose struct {
+	Pan  float64
+	Tilt float64
+}
+
+// String formats the pose for labels and prompts
+func (p Pose) String() string {
+	return fmt.Sprintf("pan %.0f tilt %.0f", p.Pan, p.Tilt)
+}
+
+// Pan returns n poses evenly spaced from pan from to pan to at tilt 0
+func Pan(from, to float64, n int) []Pose {
+	if n <= 1 {
+		return []Pose{{Pan: from}}
+	}
+	poses := make([]Pose, n)
+	for i := range poses {
+		poses[i].Pan = from + (to-from)*float64(i)/float64(n-1)
+	}
+	return poses
+}
+
+// View is one frame of a sweep and the pose it was taken at
+type View struct {
+	Frame []byte // JPEG data
+	Pose  Pose
+	Label string // Optional caption (default: the pose)
+}
+
+// label returns the caption drawn on grid cells
+func (v View) label() string {
+	if v.Label != "" {
+		return v.Label
+	}
+	return v.Pose.String()
+}
+
+// Mover points the camera, e.g. through ONVIF or a vendor HTTP API
+type Mover interface {
+	MoveTo(ctx context.Context, pose Pose) error
+}
+
+// MoverFunc adapts a function to the Mover interface
+type MoverFunc func(ctx context.Context, pose Pose) error
+
+// MoveTo implements Mover
+func (f MoverFunc) MoveTo(ctx context.Context, pose Pose) error {
+	return f(ctx, pose)
+}
+
+// Sweep drives the camera through Poses and captures one frame at each
+type Sweep struct {
+	Poses   []Pose
+	Mover   Mover
+	Capture func(ctx context.Context) ([]byte, error) // Grabs the current picture (e.g. via SnapshotCapture)
+	Settle  time.Duration                             // Wait after each move for the motors and focus to settle (default: 2s)
+}
+
+// Collect visits every pose and returns the captured views
+func (s Sweep) Collect(ctx context.Context) ([]View, error) {
+	if s.Mover == nil || s.Capture == nil {
+		return nil, fmt.Errorf("sweep mover and capture are required")
+	}
+	settle := s.Settle
+	if settle <= 0 {
+		settle = 2 * time.Second
+	}
+
+	views := make([]View, 0, len(s.Poses))
+	for _, pose := range s.Poses {
+		if err := s.Mover.MoveTo(ctx, pose); err != nil {
+			return nil, fmt.Errorf("failed to move to %s: %w", pose, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(settle):
+		}
+		frame, err := s.Capture(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture at %s: %w", pose, err)
+		}
+		views = append(views, View{Frame: frame, Pose: pose})
+	}
+	return views, nil
+}
+
+// SnapshotCapture returns a Sweep capture function taking the latest
+// picture of a live source
+func SnapshotCapture(sp *processor.StreamProcessor, src processor.Source) func(ctx context.Context) ([]byte, error) {
+	return func(ctx context.Context) ([]byte, error) {
+		return sp.Snapshot(ctx, src, processor.Latest)
+	}
+}
+
+// Patrol samples n frames from a camera running its own patrol for the
+// duration of one sweep, assigning poses by interpolating linearly
+// between from and to
+func Patrol(ctx context.Context, sp *processor.StreamProcessor, src processor.Source, n int, from, to Pose) ([]View, error) {
+	frames, err := sp.ExtractSource(ctx, src, n)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]View, len(frames))
+	for i, frame := range frames {
+		t := 0.0
+		if len(frames) > 1 {
+			t = float64(i) / float64(len(frames)-1)
+		}
+		views[i] = View{Frame: frame, Pose: Pose{
+			Pan:  from.Pan + (to.Pan-from.Pan)*t,
+			Tilt: from.Tilt + (to.Tilt-from.Tilt)*t,
+		}}
+	}
+	return views, nil
+}
+
+// Mode selects how a sweep is combined before analysis
+type Mode int
+
+const (
+	ModeMosaic Mode = iota // Stitch into one panorama, falling back to a grid
+	ModeGrid               // Tile into a grid with pose labels
+	ModeFrames             // Send the frames separately, listing poses in the prompt
+)
+
+// Options controls Analyze
+type Options struct {
+	Mode        Mode
+	Columns     int     // Grid columns (default: square-ish)
+	HFOV        float64 // Horizontal field of view in degrees; places mosaic frames by pose instead of image matching
+	Quality     int     // JPEG quality of the combined image (default: 90)
+	ChatOptions *client.ChatOptions
+}
+
+// Analyze combines the views according to opts.Mode and asks prompt about
+// the whole scene
+func Analyze(ctx context.Context, a Analyzer, views []View, prompt string, opts *Options) (*models.ChatResponse, error) {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if len(views) == 0 {
+		return nil, fmt.Errorf("sweep has no views")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		frames   [][]byte
+		preamble string
+	)
+	switch o.Mode {
+	case ModeMosaic:
+		mosaic, err := Mosaic(views, o.HFOV, o.Quality)
+		if err == nil {
+			frames = [][]byte{mosaic}
+			preamble = fmt.Sprintf("下图是 PTZ 摄像头从水平角 %.0f° 到 %.0f° 巡视后拼接的全景图，请把它当作同一个场景来理解。\n",
+				minPan(views), maxPan(views))
+			break
+		}
+		fallthrough
+	case ModeGrid:
+		grid, err := Grid(views, o.Columns, o.Quality)
+		if err != nil {
+			return nil, err
+		}
+		frames = [][]byte{grid}
+		preamble = "下图是 PTZ 摄像头巡视时在不同角度拍摄的画面拼成的网格，每格左上角标注了水平角（pan）与俯仰角（tilt），相邻格的画面可能有重叠，请把它们当作同一个场景来理解。\n"
+	case ModeFrames:
+		var b strings.Builder
+		b.WriteString("以下图片是 PTZ 摄像头巡视时依次拍摄的画面，对应角度为：\n")
+		for i, v := range views {
+			frames = append(frames, v.Frame)
+			fmt.Fprintf(&b, "- 第 %d 张：%s\n", i+1, v.label())
+		}
+		b.WriteString("相邻画面可能有重叠，请把它们当作同一个场景来理解。\n")
+		preamble = b.String()
+	default:
+		return nil, fmt.Errorf("unknown sweep mode %d", o.Mode)
+	}
+
+	return a.AnalyzeFramesWithOptions(preamble+prompt, frames, o.ChatOptions)
+}
+
+// minPan returns the smallest pan angle of the views
+func minPan(views []View) float64 {
+	m := views[0].Pose.Pan
+	for _, v := range views[1:] {
+		m = min(m, v.Pose.Pan)
+	}
+	return m
+}
+
+// maxPan returns the largest pan angle of the views
+func maxPan(views []View) float64 {
+	m := views[0].Pose.Pan
+	for _, v := range views[1:] {
+		m = max(m, v.Pose.Pan)
+	}
+	return m
+}
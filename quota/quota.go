@@ -0,0 +1,331 @@
+// Package quota enforces token and currency budgets per API key, per
+// tenant or globally. The client consults a Manager before each request
+// and records the actual usage afterwards.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Unit is what a budget limits
+type Unit int
+
+const (
+	Tokens   Unit = iota // Total tokens
+	Currency             // Cost from the model registry pricing (CNY)
+)
+
+// String returns the unit name
+func (u Unit) String() string {
+	if u == Currency {
+		return "currency"
+	}
+	return "tokens"
+}
+
+// Policy decides what happens to a request that would exceed a budget
+type Policy int
+
+const (
+	Reject Policy = iota // Fail with errdefs.ErrQuotaExceeded
+	Wait                 // Block until the budget period resets or ctx is done
+)
+
+// Global is the scope matching every request
+const Global = "*"
+
+// KeyScope returns the scope of an API key
+func KeyScope(apiKey string) string {
+	return "key:" + apiKey
+}
+
+// TenantScope returns the scope of a tenant tag
+func TenantScope(tenant string) string {
+	return "tenant:" + tenant
+}
+
+// Budget limits consumption of one scope over a period
+type Budget struct {
+	Scope  string        // Global, KeyScope(...) or TenantScope(...)
+	Unit   Unit          // Tokens or Currency
+	Limit  float64       // Maximum consumption per period
+	Period time.Duration // Budget period (default: 24h, reset at local midnight)
+}
+
+// Remaining is the state of a budget in the current period
+type Remaining struct {
+	Budget    Budget
+	Used      float64
+	Remaining float64
+	ResetsAt  time.Time
+}
+
+// usage is the consumption of a budget within one period
+type usage struct {
+	start time.Time
+	used  float64
+}
+
+// Manager tracks consumption against budgets. Acquire reserves the
+// client-side estimate of a request, so concurrent requests cannot all pass
+// the same check; the reservation is replaced by the actual usage when the
+// request completes.
+type Manager struct {
+	Policy   Policy
+	Location *time.Location // Time zone of daily resets (default: time.Local)
+
+	mu      sync.Mutex
+	budgets []Budget
+	usage   map[int]*usage
+	now     func() time.Time
+}
+
+// NewManager creates a manager rejecting over-budget requests
+func NewManager(budgets ...Budget) *Manager {
+	m := &Manager{usage: map[int]*usage{}, now: time.Now}
+	for _, b := range budgets {
+		m.AddBudget(b)
+	}
+	return m
+}
+
+// WithPolicy sets the over-budget policy
+func (m *Manager) WithPolicy(p Policy) *Manager {
+	m.Policy = p
+	return m
+}
+
+// AddBudget registers a budget
+func (m *Manager) AddBudget(b Budget) {
+	if b.Period <= 0 {
+		b.Period = 24 * time.Hour
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.budgets = append(m.budgets, b)
+}
+
+// Reservation is the estimate of an admitted request held against its
+// budgets until Commit or Release; both are no-ops on a nil or finished
+// reservation
+type Reservation struct {
+	m      *Manager
+	model  string
+	scopes []string
+	held   []held
+	done   bool
+}
+
+// held is the amount reserved from one budget period
+type held struct {
+	budget int
+	start  time.Time
+	amount float64
+}
+
+// Acquire reserves a request with the estimated prompt tokens against all
+// budgets of the given scopes (Global always applies). With the Wait
+// policy it blocks until the request fits or ctx is done; a request larger
+// than a whole budget is rejected at once, since it would never fit. The
+// caller must Commit or Release the reservation.
+func (m *Manager) Acquire(ctx context.Context, model string, estimatedTokens int, scopes ...string) (*Reservation, error) {
+	for {
+		r, resetsAt, exceeded := m.reserve(model, estimatedTokens, scopes)
+		if exceeded == nil {
+			return r, nil
+		}
+		if m.Policy != Wait || resetsAt.IsZero() {
+			return nil, exceeded
+		}
+
+		timer := time.NewTimer(time.Until(resetsAt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w (gave up waiting: %v)", exceeded, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve adds the estimate to every matching budget if it fits all of
+// them; otherwise it returns the first exceeded budget and when it resets
+// (zero when the request exceeds the budget's limit by itself)
+func (m *Manager) reserve(model string, estimatedTokens int, scopes []string) (*Reservation, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	r := &Reservation{m: m, model: model, scopes: scopes}
+	for i, b := range m.budgets {
+		if !matches(b.Scope, scopes) {
+			continue
+		}
+		u := m.current(i, now)
+		need := float64(estimatedTokens)
+		if b.Unit == Currency {
+			need = models.ModelCost(model, estimatedTokens, 0)
+		}
+		if u.used+need > b.Limit {
+			err := fmt.Errorf("%w: %s budget of %s (%.4g %s per %v) has %.4g left, request needs %.4g",
+				errdefs.ErrQuotaExceeded, b.Unit, b.Scope, b.Limit, b.Unit, b.Period, b.Limit-u.used, need)
+			if need > b.Limit {
+				return nil, time.Time{}, err
+			}
+			return nil, m.periodStart(b, now).Add(b.Period), err
+		}
+		r.held = append(r.held, held{budget: i, start: u.start, amount: need})
+	}
+	for _, h := range r.held {
+		m.usage[h.budget].used += h.amount
+	}
+	return r, time.Time{}, nil
+}
+
+// Commit replaces the reservation with the actual usage of the request
+func (r *Reservation) Commit(u models.Usage) {
+	if r == nil || r.done {
+		return
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.release()
+	r.m.record(r.model, u, r.scopes)
+}
+
+// Release returns the reservation of a request that failed or was not sent
+func (r *Reservation) Release() {
+	if r == nil || r.done {
+		return
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.release()
+}
+
+// release undoes the held amounts still in their period; m.mu must be held
+func (r *Reservation) release() {
+	r.done = true
+	for _, h := range r.held {
+		if u, ok := r.m.usage[h.budget]; ok && u.start.Equal(h.start) {
+			u.used -= h.amount
+		}
+	}
+}
+
+// Record adds the actual usage of a request made without a reservation to
+// all matching budgets
+func (m *Manager) Record(model string, u models.Usage, scopes ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.record(model, u, scopes)
+}
+
+// record adds usage to the current periods; m.mu must be held
+func (m *Manager) record(model string, u models.Usage, scopes []string) {
+	now := m.now()
+	for i, b := range m.budgets {
+		if !matches(b.Scope, scopes) {
+			continue
+		}
+		cur := m.current(i, now)
+		if b.Unit == Currency {
+			cur.used += models.ModelCost(model, u.PromptTokens, u.CompletionTokens)
+		} else {
+			cur.used += float64(u.TotalTokens)
+		}
+	}
+}
+
+// Remaining returns the state of all budgets of a scope; Global returns
+// the global budgets
+func (m *Manager) Remaining(scope string) []Remaining {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var out []Remaining
+	for i, b := range m.budgets {
+		if b.Scope != scope {
+			continue
+		}
+		u := m.current(i, now)
+		out = append(out, Remaining{
+			Budget:    b,
+			Used:      u.used,
+			Remaining: b.Limit - u.used,
+			ResetsAt:  u.start.Add(b.Period),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Budget.Unit < out[j].Budget.Unit })
+	return out
+}
+
+// current returns the usage of budget i in the period containing now,
+// resetting it when a new period started
+func (m *Manager) current(i int, now time.Time) *usage {
+	start := m.periodStart(m.budgets[i], now)
+	u, ok := m.usage[i]
+	if !ok || !u.start.Equal(start) {
+		u = &usage{start: start}
+		m.usage[i] = u
+	}
+	return u
+}
+
+// periodStart aligns daily budgets to local midnight and others to
+// multiples of their period
+func (m *Manager) periodStart(b Budget, now time.Time) time.Time {
+	if b.Period == 24*time.Hour {
+		loc := m.Location
+		if loc == nil {
+			loc = time.Local
+		}
+		t := now.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	return now.Truncate(b.Period)
+}
+
+// matches reports whether a budget scope applies to a request's scopes
+func matches(budgetScope string, scopes []string) bool {
+	if budgetScope == Global {
+		return true
+	}
+	for _, s := range scopes {
+		if s == budgetScope {
+			return true
+		}
+	}
+	return false
+}
+
+type tenantKey struct{}
+
+// WithTenant tags a context with a tenant, so requests made with it are
+// charged to that tenant's budgets
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant tag of a context, or ""
+func TenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// Scopes returns the scopes of a request made with ctx and apiKey
+func Scopes(ctx context.Context, apiKey string) []string {
+	scopes := []string{KeyScope(apiKey)}
+	if tenant := TenantFrom(ctx); tenant != "" {
+		scopes = append(scopes, TenantScope(tenant))
+	}
+	return scopes
+}
@@ -0,0 +1,144 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// htmlTemplate renders a self-contained report (inline CSS, data URI images)
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ts":    func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"money": func(v float64) string { return fmt.Sprintf("%.4f", v) },
+	"inc":   func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body{font-family:-apple-system,"PingFang SC","Microsoft YaHei",sans-serif;max-width:1080px;margin:2em auto;color:#222;padding:0 1em}
+table{border-collapse:collapse}td,th{border:1px solid #ddd;padding:4px 10px;text-align:left}
+.entry{border-top:2px solid #eee;margin-top:2em;padding-top:1em}
+.frames{display:flex;flex-wrap:wrap;gap:8px}.frames figure{margin:0}
+.frames img{width:240px;border:1px solid #ccc}figcaption{font-size:12px;color:#666}
+pre{white-space:pre-wrap;background:#f7f7f7;padding:1em;border-radius:4px}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><th>来源</th><td>{{.Source}}</td></tr>
+<tr><th>模型</th><td>{{.Model}}</td></tr>
+<tr><th>开始时间</th><td>{{ts .StartedAt}}</td></tr>
+<tr><th>调用次数</th><td>{{.Totals.Calls}}</td></tr>
+<tr><th>帧数</th><td>{{.Totals.Frames}}</td></tr>
+<tr><th>Token</th><td>{{.Totals.TotalTokens}}（输入 {{.Totals.PromptTokens}} / 输出 {{.Totals.CompletionTokens}}）</td></tr>
+<tr><th>费用</th><td>{{money .Totals.Cost}} {{.Currency}}</td></tr>
+<tr><th>总耗时</th><td>{{.Totals.Latency}}</td></tr>
+</table>
+{{range $i, $e := .Entries}}
+<div class="entry">
+<h2>#{{inc $i}} {{ts $e.Time}}</h2>
+<p><strong>提示词：</strong>{{$e.Prompt}}</p>
+<div class="frames">
+{{range $e.Thumbs}}<figure><img src="{{.URI}}" alt="frame"><figcaption>{{.When}} {{.Label}}</figcaption></figure>
+{{end}}</div>
+<p><strong>回答：</strong></p>
+<pre>{{$e.Answer}}</pre>
+<p>Token：{{$e.TotalTokens}} · 耗时：{{$e.Latency}} · 费用：{{money $e.Cost}}</p>
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+// thumb is a rendered thumbnail
+type thumb struct {
+	URI       template.URL
+	Timestamp time.Duration
+	When      string // Offset or wall-clock label
+	Label     string
+}
+
+// htmlEntry is an entry prepared for the HTML template
+type htmlEntry struct {
+	Entry
+	Thumbs []thumb
+}
+
+// WriteHTML renders the run as a self-contained HTML document
+func WriteHTML(w io.Writer, run *Run) error {
+	entries := make([]htmlEntry, len(run.Entries))
+	for i, e := range run.Entries {
+		entries[i].Entry = e
+		for _, f := range e.Frames {
+			uri, err := thumbnailURI(f.Data)
+			if err != nil {
+				return err
+			}
+			entries[i].Thumbs = append(entries[i].Thumbs, thumb{
+				URI:       template.URL(uri),
+				Timestamp: f.Timestamp,
+				When:      run.stamp(f.Timestamp),
+				Label:     f.Label,
+			})
+		}
+	}
+
+	data := struct {
+		*Run
+		Currency string
+		Totals   Totals
+		Entries  []htmlEntry
+	}{run, run.currency(), run.Totals(), entries}
+
+	return htmlTemplate.Execute(w, data)
+}
+
+// WriteMarkdown renders the run as Markdown with thumbnails embedded as
+// data URIs, so the file is self-contained
+func WriteMarkdown(w io.Writer, run *Run) error {
+	t := run.Totals()
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", run.Title)
+	b.WriteString("| 项目 | 值 |\n|---|---|\n")
+	fmt.Fprintf(&b, "| 来源 | %s |\n", mdCell(run.Source))
+	fmt.Fprintf(&b, "| 模型 | %s |\n", mdCell(run.Model))
+	fmt.Fprintf(&b, "| 开始时间 | %s |\n", run.StartedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "| 调用次数 | %d |\n", t.Calls)
+	fmt.Fprintf(&b, "| 帧数 | %d |\n", t.Frames)
+	fmt.Fprintf(&b, "| Token | %d（输入 %d / 输出 %d） |\n", t.TotalTokens, t.PromptTokens, t.CompletionTokens)
+	fmt.Fprintf(&b, "| 费用 | %.4f %s |\n", t.Cost, run.currency())
+	fmt.Fprintf(&b, "| 总耗时 | %s |\n\n", t.Latency)
+
+	for i, e := range run.Entries {
+		fmt.Fprintf(&b, "## #%d %s\n\n", i+1, e.Time.Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(&b, "**提示词：** %s\n\n", e.Prompt)
+
+		for _, f := range e.Frames {
+			uri, err := thumbnailURI(f.Data)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&b, "![%s %s](%s) ", run.stamp(f.Timestamp), f.Label, uri)
+		}
+		if len(e.Frames) > 0 {
+			b.WriteString("\n\n")
+		}
+
+		fmt.Fprintf(&b, "**回答：**\n\n%s\n\n", e.Answer)
+		fmt.Fprintf(&b, "Token：%d · 耗时：%s · 费用：%.4f\n\n", e.TotalTokens, e.Latency, e.Cost)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mdCell escapes pipe characters inside a Markdown table cell
+func mdCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,137 @@
+package report
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"time"
+
+	"golang.org/x/image/draw"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Frame is a frame included in the report
+type Frame struct {
+	Data      []byte        // JPEG data
+	Timestamp time.Duration // Offset in the source
+	Label     string        // Optional caption
+}
+
+// Entry is one prompt/answer exchange of an analysis run
+type Entry struct {
+	Prompt           string
+	Answer           string
+	Frames           []Frame
+	Time             time.Time
+	Latency          time.Duration
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Cost             float64 // Optional cost of this call, in Currency
+}
+
+// Run is a complete analysis run rendered into a report
+type Run struct {
+	Title     string
+	Source    string
+	Model     string
+	StartedAt time.Time
+	Currency  string            // Currency label for costs (default: CNY)
+	Clock     *models.WallClock // Optional: label frames with wall-clock time instead of offsets
+	Entries   []Entry
+}
+
+// NewEntry builds an entry from a chat response
+func NewEntry(prompt string, frames []Frame, resp *models.ChatResponse, latency time.Duration) Entry {
+	e := Entry{
+		Prompt:  prompt,
+		Frames:  frames,
+		Time:    time.Now(),
+		Latency: latency,
+	}
+	if resp != nil {
+		e.PromptTokens = resp.Usage.PromptTokens
+		e.CompletionTokens = resp.Usage.CompletionTokens
+		e.TotalTokens = resp.Usage.TotalTokens
+		e.Answer = resp.Text()
+	}
+	return e
+}
+
+// Totals aggregates token, cost and latency statistics of the run
+type Totals struct {
+	Calls            int
+	Frames           int
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Cost             float64
+	Latency          time.Duration
+}
+
+// Totals computes aggregate statistics
+func (r *Run) Totals() Totals {
+	var t Totals
+	for _, e := range r.Entries {
+		t.Calls++
+		t.Frames += len(e.Frames)
+		t.PromptTokens += e.PromptTokens
+		t.CompletionTokens += e.CompletionTokens
+		t.TotalTokens += e.TotalTokens
+		t.Cost += e.Cost
+		t.Latency += e.Latency
+	}
+	return t
+}
+
+// currency returns the currency label
+func (r *Run) currency() string {
+	if r.Currency == "" {
+		return "CNY"
+	}
+	return r.Currency
+}
+
+// thumbnailWidth is the width of embedded frame thumbnails
+const thumbnailWidth = 320
+
+// thumbnailURI downsizes a JPEG frame and returns it as a data URI
+func thumbnailURI(frame []byte) (string, error) {
+	src, _, err := image.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode frame: %w", err)
+	}
+
+	b := src.Bounds()
+	data := frame
+	if b.Dx() > thumbnailWidth {
+		h := b.Dy() * thumbnailWidth / b.Dx()
+		dst := image.NewRGBA(image.Rect(0, 0, thumbnailWidth, h))
+		draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 75}); err != nil {
+			return "", fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// stamp labels a frame offset, as wall-clock time when the run has a clock
+func (r *Run) stamp(d time.Duration) string {
+	if t := r.Clock.At(d); !t.IsZero() {
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return formatOffset(d)
+}
+
+// formatOffset formats a source offset as HH:MM:SS
+func formatOffset(d time.Duration) string {
+	total := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
@@ -0,0 +1,249 @@
+// Package retention enforces data-retention policies on stored frames and
+// results. Stores that keep data (the audit store, frame dump directories,
+// usage exports) implement Target, and a Janitor applies one Policy to all
+// of them in the background:
+//
+//	j := retention.NewJanitor(retention.Policy{Frames: 7 * 24 * time.Hour, Results: 90 * 24 * time.Hour})
+//	j.Add("audit", auditStore)
+//	j.Add("frames", retention.Dir("./frames", retention.KindFrames))
+//	go j.Run(ctx)
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Policy says how long data is kept. Zero values keep data forever.
+type Policy struct {
+	Frames   time.Duration // Max age of stored images (frame dumps, thumbnails in records)
+	Results  time.Duration // Max age of results and records
+	MaxBytes int64         // Max size of each target; the oldest data is removed first
+}
+
+// FrameCutoff returns the time before which frames must be removed, or
+// the zero time when frames are kept forever
+func (p Policy) FrameCutoff(now time.Time) time.Time {
+	return cutoff(now, p.Frames)
+}
+
+// ResultCutoff returns the time before which results must be removed, or
+// the zero time when results are kept forever
+func (p Policy) ResultCutoff(now time.Time) time.Time {
+	return cutoff(now, p.Results)
+}
+
+// cutoff returns now-age, or the zero time for age <= 0
+func cutoff(now time.Time, age time.Duration) time.Time {
+	if age <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-age)
+}
+
+// Stats describes what one enforcement pass removed
+type Stats struct {
+	Removed  int   // Files or records deleted
+	Stripped int   // Records whose frames were removed but that were kept
+	Freed    int64 // Bytes freed (approximate for records)
+}
+
+// Add accumulates other into s
+func (s *Stats) Add(other Stats) {
+	s.Removed += other.Removed
+	s.Stripped += other.Stripped
+	s.Freed += other.Freed
+}
+
+// Target is storage that can enforce a retention policy
+type Target interface {
+	Enforce(ctx context.Context, p Policy, now time.Time) (Stats, error)
+}
+
+// TargetFunc adapts a function to the Target interface
+type TargetFunc func(ctx context.Context, p Policy, now time.Time) (Stats, error)
+
+// Enforce implements Target
+func (f TargetFunc) Enforce(ctx context.Context, p Policy, now time.Time) (Stats, error) {
+	return f(ctx, p, now)
+}
+
+// Kind selects which age of a Policy applies to a directory
+type Kind int
+
+const (
+	KindFrames  Kind = iota // Image files: Policy.Frames applies
+	KindResults             // Result files (reports, manifests, usage exports): Policy.Results applies
+)
+
+// Dir returns a Target that deletes files under root by modification
+// time: files older than the kind's age first, then the oldest files until
+// the tree fits in MaxBytes. Emptied subdirectories are removed.
+func Dir(root string, kind Kind) Target {
+	return TargetFunc(func(ctx context.Context, p Policy, now time.Time) (Stats, error) {
+		limit := p.FrameCutoff(now)
+		if kind == KindResults {
+			limit = p.ResultCutoff(now)
+		}
+
+		type file struct {
+			path string
+			size int64
+			mod  time.Time
+		}
+		var (
+			files []file
+			dirs  []string
+			stats Stats
+			total int64
+		)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root {
+					dirs = append(dirs, path)
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil // Removed concurrently
+			}
+			if !limit.IsZero() && info.ModTime().Before(limit) {
+				if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return fmt.Errorf("failed to remove %s: %w", path, err)
+				}
+				stats.Removed++
+				stats.Freed += info.Size()
+				return nil
+			}
+			files = append(files, file{path, info.Size(), info.ModTime()})
+			total += info.Size()
+			return nil
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to walk %s: %w", root, err)
+		}
+
+		if p.MaxBytes > 0 && total > p.MaxBytes {
+			sort.Slice(files, func(i, j int) bool { return files[i].mod.Before(files[j].mod) })
+			for _, f := range files {
+				if total <= p.MaxBytes {
+					break
+				}
+				if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return stats, fmt.Errorf("failed to remove %s: %w", f.path, err)
+				}
+				total -= f.size
+				stats.Removed++
+				stats.Freed += f.size
+			}
+		}
+
+		// Deepest first, so parents empty out too; non-empty dirs fail harmlessly
+		sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+		for _, d := range dirs {
+			os.Remove(d)
+		}
+		return stats, nil
+	})
+}
+
+// Janitor periodically enforces a policy on its targets
+type Janitor struct {
+	Policy    Policy
+	Interval  time.Duration                  // Time between passes (default: 1h)
+	OnError   func(target string, err error) // Optional error callback
+	OnEnforce func(target string, s Stats)   // Optional callback after each target pass
+
+	mu      sync.Mutex
+	names   []string
+	targets map[string]Target
+}
+
+// NewJanitor creates a janitor enforcing p
+func NewJanitor(p Policy) *Janitor {
+	return &Janitor{Policy: p, Interval: time.Hour, targets: make(map[string]Target)}
+}
+
+// Add registers a target under a name used in callbacks
+func (j *Janitor) Add(name string, t Target) *Janitor {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.targets[name]; !ok {
+		j.names = append(j.names, name)
+	}
+	j.targets[name] = t
+	return j
+}
+
+// RunOnce enforces the policy on every target and returns the combined
+// stats and the errors of failed targets
+func (j *Janitor) RunOnce(ctx context.Context) (Stats, error) {
+	j.mu.Lock()
+	names := append([]string(nil), j.names...)
+	targets := make([]Target, len(names))
+	for i, name := range names {
+		targets[i] = j.targets[name]
+	}
+	j.mu.Unlock()
+
+	var (
+		total Stats
+		errs  []error
+	)
+	now := time.Now()
+	for i, t := range targets {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		s, err := t.Enforce(ctx, j.Policy, now)
+		total.Add(s)
+		if err != nil {
+			err = fmt.Errorf("retention %s: %w", names[i], err)
+			errs = append(errs, err)
+			if j.OnError != nil {
+				j.OnError(names[i], err)
+			}
+			continue
+		}
+		if j.OnEnforce != nil {
+			j.OnEnforce(names[i], s)
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// Run enforces the policy immediately and then every Interval until ctx
+// is done
+func (j *Janitor) Run(ctx context.Context) {
+	interval := j.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		j.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
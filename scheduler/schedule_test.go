@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestCronNext(t *testing.T) {
+	load := func(name string) *time.Location {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return loc
+	}
+	kolkata := load("Asia/Kolkata")        // +05:30
+	adelaide := load("Australia/Adelaide") // +09:30 / +10:30
+	newYork := load("America/New_York")    // DST 2026-03-08 and 2026-11-01
+	kathmandu := load("Asia/Kathmandu")    // +05:45
+	santiago := load("America/Santiago")   // DST starts at midnight, 2026-09-06
+
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{"utc daily", "0 11 * * *", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"half-hour offset", "0 11 * * *", time.Date(2026, 1, 1, 9, 0, 0, 0, kolkata), time.Date(2026, 1, 1, 11, 0, 0, 0, kolkata)},
+		{"half-hour offset next day", "0 11 * * *", time.Date(2026, 1, 1, 11, 0, 0, 0, kolkata), time.Date(2026, 1, 2, 11, 0, 0, 0, kolkata)},
+		{"quarter-hour offset", "15 6 * * 1", time.Date(2026, 1, 1, 0, 0, 0, 0, kathmandu), time.Date(2026, 1, 5, 6, 15, 0, 0, kathmandu)},
+		{"half-hour offset with dst", "0 8 * * *", time.Date(2026, 10, 3, 23, 0, 0, 0, adelaide), time.Date(2026, 10, 4, 8, 0, 0, 0, adelaide)},
+		{"spring forward", "0 3 * * *", time.Date(2026, 3, 8, 0, 30, 0, 0, newYork), time.Date(2026, 3, 8, 3, 0, 0, 0, newYork)},
+		{"fall back", "0 2 * * *", time.Date(2026, 11, 1, 0, 30, 0, 0, newYork), time.Date(2026, 11, 1, 2, 0, 0, 0, newYork)},
+		{"midnight gap", "0 1 * * *", time.Date(2026, 9, 5, 23, 30, 0, 0, santiago), time.Date(2026, 9, 6, 1, 0, 0, 0, santiago)},
+		{"hourly across spring forward", "0 * * * *", time.Date(2026, 3, 8, 1, 30, 0, 0, newYork), time.Date(2026, 3, 8, 3, 0, 0, 0, newYork)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.after, got, tt.want)
+			}
+		})
+	}
+}
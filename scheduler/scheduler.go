@@ -0,0 +1,372 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/postprocess"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Analyzer is the subset of client.Client used by the scheduler
+type Analyzer interface {
+	AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *client.ChatOptions) (*models.ChatResponse, error)
+}
+
+// Grabber captures a window of JPEG frames from a stream
+type Grabber func(ctx context.Context) ([][]byte, error)
+
+// Task describes a periodic snapshot analysis of one stream
+type Task struct {
+	Name     string              // Unique task name (e.g. camera or stream ID)
+	Prompt   string              // Prompt sent with every frame window
+	Schedule Schedule            // When to run (Every or ParseCron)
+	Grab     Grabber             // How to capture the frame window
+	Options  *client.ChatOptions // Optional chat options
+	Labels   models.Labels       // Origin tags (camera, site, tenant) added to the run's context and result
+
+	// PostProcess massages the answer of this task only, after any
+	// client-wide OnResult hooks
+	PostProcess postprocess.Chain
+}
+
+// Result is the outcome of one scheduled run
+type Result struct {
+	Task      string               `json:"task"`
+	StartedAt time.Time            `json:"started_at"`
+	Duration  time.Duration        `json:"duration"`
+	Frames    int                  `json:"frames"`
+	Content   string               `json:"content,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	Skipped   bool                 `json:"skipped,omitempty"`   // The client's pre-filter found nothing worth analyzing
+	Duplicate bool                 `json:"duplicate,omitempty"` // Repeats the previous result (set by dedup.Deduplicator)
+	Labels    models.Labels        `json:"labels,omitempty"`
+	Response  *models.ChatResponse `json:"response,omitempty"`
+}
+
+// Recorder stores scheduled analysis results
+type Recorder interface {
+	Record(result Result) error
+}
+
+// RecorderFunc adapts a function to the Recorder interface
+type RecorderFunc func(result Result) error
+
+// Record implements Recorder
+func (f RecorderFunc) Record(result Result) error {
+	return f(result)
+}
+
+// JSONLRecorder appends results as JSON lines to a writer
+type JSONLRecorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLRecorder creates a recorder writing JSON lines to w
+func NewJSONLRecorder(w io.Writer) *JSONLRecorder {
+	return &JSONLRecorder{w: w}
+}
+
+// OpenJSONLRecorder opens (or creates) a file and appends results to it
+func OpenJSONLRecorder(path string) (*JSONLRecorder, *os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open result file: %w", err)
+	}
+	return NewJSONLRecorder(f), f, nil
+}
+
+// Record implements Recorder
+func (r *JSONLRecorder) Record(result Result) error {
+	line, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.w.Write(append(line, '\n'))
+	return err
+}
+
+// Scheduler runs periodic snapshot analyses for a set of streams
+type Scheduler struct {
+	analyzer Analyzer
+	recorder Recorder
+	tasks    []Task
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+// NewScheduler creates a scheduler that analyzes with the given analyzer
+// (usually a *client.Client) and stores results with recorder
+func NewScheduler(analyzer Analyzer, recorder Recorder) *Scheduler {
+	return &Scheduler{
+		analyzer: analyzer,
+		recorder: recorder,
+		now:      time.Now,
+	}
+}
+
+// WithClock sets the clock for result start times, e.g. a
+// processor.LiveReplay's synthetic time; schedules still run in real time
+func (s *Scheduler) WithClock(now func() time.Time) *Scheduler {
+	s.now = now
+	return s
+}
+
+// Add registers a task; it must be called before Run
+func (s *Scheduler) Add(task Task) error {
+	if task.Name == "" {
+		return fmt.Errorf("task name is required")
+	}
+	if task.Schedule == nil {
+		return fmt.Errorf("task %s: schedule is required", task.Name)
+	}
+	if task.Grab == nil {
+		return fmt.Errorf("task %s: grabber is required", task.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tasks {
+		if t.Name == task.Name {
+			return fmt.Errorf("task %s already registered", task.Name)
+		}
+	}
+	s.tasks = append(s.tasks, task)
+	return nil
+}
+
+// Run starts all tasks and blocks until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	tasks := append([]Task(nil), s.tasks...)
+	s.mu.Unlock()
+
+	if len(tasks) == 0 {
+		return fmt.Errorf("no tasks registered")
+	}
+
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task Task) {
+			defer wg.Done()
+			s.loop(ctx, task)
+		}(task)
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+// loop waits for each activation of a task and runs it
+func (s *Scheduler) loop(ctx context.Context, task Task) {
+	for {
+		next := task.Schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		result := s.RunOnce(ctx, task)
+		if s.recorder != nil {
+			if err := s.recorder.Record(result); err != nil {
+				fmt.Printf("记录任务 %s 结果失败: %v\n", task.Name, err)
+			}
+		}
+	}
+}
+
+// RunOnce grabs a frame window for the task and analyzes it immediately
+func (s *Scheduler) RunOnce(ctx context.Context, task Task) (result Result) {
+	ctx = models.WithLabels(ctx, task.Labels)
+	start := time.Now()
+	result = Result{
+		Task:      task.Name,
+		StartedAt: s.now(),
+		Labels:    models.LabelsFrom(ctx).Clone(),
+	}
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	frames, err := task.Grab(ctx)
+	if errors.Is(err, errdefs.ErrSkipped) {
+		// E.g. the stream's frame budget is spent
+		result.Skipped = true
+		return result
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to grab frames: %v", err)
+		return result
+	}
+	result.Frames = len(frames)
+
+	resp, err := s.analyzer.AnalyzeFramesWithOptions(task.Prompt, frames, task.Options)
+	if errors.Is(err, errdefs.ErrSkipped) {
+		result.Skipped = true
+		return result
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if err := task.PostProcess.ApplyResponse(ctx, resp); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if resp.Labels == nil {
+		resp.Labels = result.Labels
+	}
+	result.Response = resp
+	result.Content = resp.Text()
+	return result
+}
+
+// StreamGrabber returns a Grabber that opens a live H.264 stream, reads it
+// for the given window and extracts frames with the stream processor.
+// At most maxFrames frames are kept (evenly spaced); 0 keeps all.
+func StreamGrabber(sp *processor.StreamProcessor, open func(ctx context.Context) (io.ReadCloser, error), window time.Duration, maxFrames int) Grabber {
+	return func(ctx context.Context) ([][]byte, error) {
+		rc, err := open(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open stream: %w", err)
+		}
+
+		data, err := readWindow(ctx, rc, window)
+		if err != nil {
+			return nil, err
+		}
+
+		base64Frames, err := sp.ProcessH264StreamWithContext(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+
+		frames := make([][]byte, 0, len(base64Frames))
+		for _, idx := range spread(len(base64Frames), maxFrames) {
+			frame, err := base64.StdEncoding.DecodeString(base64Frames[idx])
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode frame %d: %w", idx, err)
+			}
+			frames = append(frames, frame)
+		}
+		return frames, nil
+	}
+}
+
+// ReplayGrabber returns a Grabber that takes the last maxFrames frames
+// (0: all buffered) played by a live replay, so scheduled tasks can be
+// tried on recorded footage
+func ReplayGrabber(lr *processor.LiveReplay, maxFrames int) Grabber {
+	return func(ctx context.Context) ([][]byte, error) {
+		frames := lr.Window(maxFrames)
+		if len(frames) == 0 {
+			return nil, errdefs.ErrNoFrames
+		}
+		return frames, nil
+	}
+}
+
+// readWindow reads from rc until the window elapses, EOF or ctx is done,
+// and closes rc afterwards
+func readWindow(ctx context.Context, rc io.ReadCloser, window time.Duration) ([]byte, error) {
+	type chunk struct {
+		data []byte
+		err  error
+	}
+
+	chunks := make(chan chunk, 16)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(chunks)
+		send := func(c chunk) bool {
+			select {
+			case chunks <- c:
+				return true
+			case <-done:
+				return false
+			}
+		}
+
+		buffer := make([]byte, 64*1024)
+		for {
+			n, err := rc.Read(buffer)
+			if n > 0 && !send(chunk{data: append([]byte(nil), buffer[:n]...)}) {
+				return
+			}
+			if err != nil {
+				if err != io.EOF {
+					send(chunk{err: err})
+				}
+				return
+			}
+		}
+	}()
+
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+
+	var data []byte
+	for {
+		select {
+		case c, ok := <-chunks:
+			if !ok {
+				rc.Close()
+				return data, nil
+			}
+			if c.err != nil {
+				rc.Close()
+				return nil, fmt.Errorf("failed to read stream: %w", c.err)
+			}
+			data = append(data, c.data...)
+		case <-timer.C:
+			rc.Close()
+			return data, nil
+		case <-ctx.Done():
+			rc.Close()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// spread picks up to max evenly spaced indexes from [0, n)
+func spread(n, max int) []int {
+	if max <= 0 || n <= max {
+		idx := make([]int, n)
+		for i := range idx {
+			idx[i] = i
+		}
+		return idx
+	}
+
+	idx := make([]int, max)
+	for i := range idx {
+		idx[i] = i * n / max
+	}
+	return idx
+}
@@ -0,0 +1,123 @@
+// Package search builds a semantic index over video frames: sampled frames
+// are captioned by the vision model, captions are embedded with the Zhipu
+// embedding endpoint and stored in a pluggable vector store, so queries
+// like "when does the forklift appear" return matching timestamps.
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+const (
+	DefaultEmbeddingURL   = "https://open.bigmodel.cn/api/paas/v4/embeddings"
+	DefaultEmbeddingModel = "embedding-3"
+)
+
+// Embedder turns texts into vectors
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// ZhipuEmbedder calls the Zhipu embeddings API
+type ZhipuEmbedder struct {
+	APIKey     string
+	Keys       client.APIKeyProvider // Optional dynamic key source, preferred over APIKey
+	URL        string
+	Model      string
+	Dimensions int // Optional output dimensions (embedding-3 supports 256-2048)
+	HTTPClient *http.Client
+}
+
+// NewZhipuEmbedder creates an embedder sharing the API key (including any
+// rotating key provider) and HTTP client of c
+func NewZhipuEmbedder(c *client.Client) *ZhipuEmbedder {
+	return &ZhipuEmbedder{
+		APIKey:     c.APIKey,
+		Keys:       client.APIKeyFunc(c.ResolveAPIKey),
+		URL:        DefaultEmbeddingURL,
+		Model:      DefaultEmbeddingModel,
+		HTTPClient: c.HTTPClient,
+	}
+}
+
+// embeddingRequest is the embeddings API request
+type embeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+// embeddingResponse is the embeddings API response
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed implements Embedder
+func (e *ZhipuEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(embeddingRequest{Model: e.Model, Input: texts, Dimensions: e.Dimensions})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	key := e.APIKey
+	if e.Keys != nil {
+		if key, err = e.Keys.Key(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+key)
+
+	resp, err := e.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errdefs.NewAPIError(resp.StatusCode, resp.Header, data)
+	}
+
+	var parsed embeddingResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range parsed.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
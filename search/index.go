@@ -0,0 +1,157 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Analyzer is the subset of client.Client used to caption frames
+type Analyzer interface {
+	AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *client.ChatOptions) (*models.ChatResponse, error)
+}
+
+// CaptionPrompt asks for a dense, searchable frame description
+const CaptionPrompt = "用一到两句话详细描述这张监控画面：出现的人、车辆、物体及其颜色、动作和位置。不要输出其他内容。"
+
+// Frame is a frame to index
+type Frame struct {
+	Data      []byte        // JPEG data
+	Timestamp time.Duration // Offset in the source
+}
+
+// Index captions, embeds and stores frames, and answers text queries
+type Index struct {
+	Analyzer    Analyzer
+	Embedder    Embedder
+	Store       Store
+	Prompt      string // Caption prompt (default: CaptionPrompt)
+	Concurrency int    // Parallel caption calls (default: 4)
+	BatchSize   int    // Captions embedded per request (default: 16)
+}
+
+// NewIndex creates an index captioning with c, embedding with the Zhipu
+// embeddings API and storing vectors in memory
+func NewIndex(c *client.Client) *Index {
+	return &Index{
+		Analyzer:    c,
+		Embedder:    NewZhipuEmbedder(c),
+		Store:       NewMemoryStore(),
+		Prompt:      CaptionPrompt,
+		Concurrency: 4,
+		BatchSize:   16,
+	}
+}
+
+// AddFrames captions and indexes frames of a source
+func (x *Index) AddFrames(ctx context.Context, source string, frames []Frame) error {
+	captions := make([]string, len(frames))
+	errs := make([]error, len(frames))
+
+	n := x.Concurrency
+	if n <= 0 {
+		n = 4
+	}
+	prompt := x.Prompt
+	if prompt == "" {
+		prompt = CaptionPrompt
+	}
+
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, f := range frames {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, f Frame) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := x.Analyzer.AnalyzeFramesWithOptions(prompt, [][]byte{f.Data}, nil)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to caption frame at %v: %w", f.Timestamp, err)
+				return
+			}
+			captions[i] = strings.TrimSpace(resp.Text())
+		}(i, f)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	batch := x.BatchSize
+	if batch <= 0 {
+		batch = 16
+	}
+	for start := 0; start < len(frames); start += batch {
+		end := min(start+batch, len(frames))
+		vectors, err := x.Embedder.Embed(ctx, captions[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to embed captions: %w", err)
+		}
+
+		items := make([]Item, 0, end-start)
+		for i, v := range vectors {
+			f := frames[start+i]
+			items = append(items, Item{
+				ID:        fmt.Sprintf("%s@%d", source, f.Timestamp.Milliseconds()),
+				Source:    source,
+				Timestamp: f.Timestamp,
+				Caption:   captions[start+i],
+				Vector:    v,
+			})
+		}
+		if err := x.Store.Add(ctx, items...); err != nil {
+			return fmt.Errorf("failed to store vectors: %w", err)
+		}
+	}
+	return nil
+}
+
+// AddVideo samples one frame per interval from a video file and indexes it
+func (x *Index) AddVideo(ctx context.Context, sp *processor.StreamProcessor, path string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid sampling interval: %v", interval)
+	}
+	duration, err := sp.ProbeDuration(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to probe video: %w", err)
+	}
+
+	count := int(duration / interval)
+	if count < 1 {
+		count = 1
+	}
+	data, err := sp.ExtractVideoSegment(ctx, path, 0, duration, count)
+	if err != nil {
+		return fmt.Errorf("failed to extract frames: %w", err)
+	}
+
+	frames := make([]Frame, len(data))
+	for i, d := range data {
+		frames[i] = Frame{Data: d, Timestamp: time.Duration(i) * duration / time.Duration(count)}
+	}
+	return x.AddFrames(ctx, path, frames)
+}
+
+// Search returns the k frames whose captions best match the query
+func (x *Index) Search(ctx context.Context, query string, k int) ([]Hit, error) {
+	vectors, err := x.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	return x.Store.Search(ctx, vectors[0], k)
+}
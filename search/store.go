@@ -0,0 +1,121 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Item is one indexed frame
+type Item struct {
+	ID        string        `json:"id"`
+	Source    string        `json:"source"`
+	Timestamp time.Duration `json:"timestamp"` // Offset of the frame in the source
+	Caption   string        `json:"caption"`
+	Vector    []float32     `json:"vector"`
+}
+
+// Hit is a search match
+type Hit struct {
+	Item  Item
+	Score float64 // Cosine similarity
+}
+
+// Store persists vectors and runs nearest-neighbour queries. Adapters for
+// external vector databases (Milvus, Qdrant, pgvector...) implement it.
+type Store interface {
+	Add(ctx context.Context, items ...Item) error
+	Search(ctx context.Context, vector []float32, k int) ([]Hit, error)
+}
+
+// MemoryStore is an exact in-memory store, suitable for hours of footage
+// sampled every few seconds
+type MemoryStore struct {
+	mu    sync.RWMutex
+	items []Item
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Add implements Store
+func (s *MemoryStore) Add(_ context.Context, items ...Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, items...)
+	return nil
+}
+
+// Search implements Store
+func (s *MemoryStore) Search(_ context.Context, vector []float32, k int) ([]Hit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hits := make([]Hit, 0, len(s.items))
+	for _, item := range s.items {
+		hits = append(hits, Hit{Item: item, Score: cosine(vector, item.Vector)})
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if k > 0 && len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits, nil
+}
+
+// Len returns the number of stored items
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.items)
+}
+
+// Save writes the store to a JSON file
+func (s *MemoryStore) Save(path string) error {
+	s.mu.RLock()
+	data, err := json.Marshal(s.items)
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// LoadMemoryStore reads a store written by Save
+func LoadMemoryStore(path string) (*MemoryStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	s := &MemoryStore{}
+	if err := json.Unmarshal(data, &s.items); err != nil {
+		return nil, fmt.Errorf("failed to decode index: %w", err)
+	}
+	return s, nil
+}
+
+// cosine returns the cosine similarity of two vectors
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
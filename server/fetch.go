@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+)
+
+// videoTransport returns the transport used for video_url downloads. Video
+// URLs come from clients, so connections to loopback, private, link-local
+// (including cloud metadata at 169.254.169.254) and other non-public
+// addresses are refused after DNS resolution, which also covers redirects
+// and names that resolve to internal addresses. Hosts in VideoHosts are
+// the only ones allowed when it is set, and may be internal.
+func (s *Server) videoTransport() http.RoundTripper {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy: nil, // A proxy would connect on our behalf and bypass the address check
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			allowed := s.allowedVideoHost(host)
+			if len(s.VideoHosts) > 0 && !allowed {
+				return nil, fmt.Errorf("%w: video host %q is not allowed", errdefs.ErrInvalidRequest, host)
+			}
+			ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			for _, ip := range ips {
+				if !allowed && !publicIP(ip.IP) {
+					return nil, fmt.Errorf("%w: video host %q resolves to non-public address %s", errdefs.ErrInvalidRequest, host, ip.IP)
+				}
+			}
+			if len(ips) == 0 {
+				return nil, fmt.Errorf("video host %q has no addresses", host)
+			}
+			// Dial the checked address, not the name, so a second lookup
+			// cannot return a different one
+			return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+		},
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+	}
+}
+
+// allowedVideoHost reports whether host matches VideoHosts; an entry
+// starting with "." also matches subdomains
+func (s *Server) allowedVideoHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, h := range s.VideoHosts {
+		h = strings.ToLower(h)
+		if host == h || (strings.HasPrefix(h, ".") && strings.HasSuffix(host, h)) {
+			return true
+		}
+	}
+	return false
+}
+
+// publicIP reports whether ip is a globally routable unicast address
+func publicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		// Carrier-grade NAT (100.64.0.0/10) is not covered by IsPrivate
+		if ip[0] == 100 && ip[1]&0xc0 == 64 {
+			return false
+		}
+	}
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
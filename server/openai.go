@@ -0,0 +1,337 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/errdefs"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// openAIRequest is the OpenAI chat completions request shape
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	TopP        *float64        `json:"top_p,omitempty"`
+	MaxTokens   *int            `json:"max_tokens,omitempty"`
+	Stream      bool            `json:"stream,omitempty"`
+	Metadata    models.Labels   `json:"metadata,omitempty"` // Becomes the call's labels (usage, audit)
+}
+
+// openAIMessage accepts both string and array content
+type openAIMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// openAIPart is one element of array content. Besides the standard text and
+// image_url parts, video_url parts carry a video (http(s) URL or data URI)
+// which is extracted locally into frames.
+type openAIPart struct {
+	Type     string     `json:"type"`
+	Text     string     `json:"text,omitempty"`
+	ImageURL *urlObject `json:"image_url,omitempty"`
+	VideoURL *urlObject `json:"video_url,omitempty"`
+}
+
+// urlObject is {"url": "...", "detail": "..."}
+type urlObject struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// openAIError is the OpenAI error envelope
+type openAIError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// handleChatCompletions implements POST /v1/chat/completions
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+		return
+	}
+
+	maxBody := s.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = 100 << 20
+	}
+
+	var in openAIRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&in); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid request body: "+err.Error())
+		return
+	}
+
+	ctx := models.WithLabels(r.Context(), in.Metadata)
+	req, cleanup, err := s.convertRequest(ctx, &in)
+	defer cleanup()
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errdefs.ErrPayloadTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeOpenAIError(w, status, "invalid_request_error", err.Error())
+		return
+	}
+
+	if in.Stream {
+		s.streamChat(ctx, w, req)
+		return
+	}
+
+	resp, err := s.Client.Chat(ctx, req)
+	if err != nil {
+		status, errType := upstreamStatus(err)
+		writeOpenAIError(w, status, errType, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(openAIResponse(resp))
+}
+
+// convertRequest maps an OpenAI request to a Zhipu request, extracting
+// frames from any video parts
+func (s *Server) convertRequest(ctx context.Context, in *openAIRequest) (*models.ChatRequest, func(), error) {
+	var tempFiles []string
+	cleanup := func() {
+		for _, f := range tempFiles {
+			os.Remove(f)
+		}
+	}
+
+	req := &models.ChatRequest{
+		Model:       s.mapModel(in.Model),
+		Temperature: in.Temperature,
+		TopP:        in.TopP,
+		MaxTokens:   in.MaxTokens,
+	}
+
+	for _, m := range in.Messages {
+		role, err := models.ParseRole(m.Role)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		msg := models.Message{Role: role}
+
+		var text string
+		if err := json.Unmarshal(m.Content, &text); err == nil {
+			msg.Content = []models.Content{{Type: models.ContentTypeText, Text: text}}
+			req.Messages = append(req.Messages, msg)
+			continue
+		}
+
+		var parts []openAIPart
+		if err := json.Unmarshal(m.Content, &parts); err != nil {
+			return nil, cleanup, fmt.Errorf("invalid message content: %w", err)
+		}
+
+		for _, p := range parts {
+			switch p.Type {
+			case "text":
+				msg.Content = append(msg.Content, models.Content{Type: models.ContentTypeText, Text: p.Text})
+			case "image_url":
+				if p.ImageURL == nil {
+					return nil, cleanup, fmt.Errorf("image_url part without url")
+				}
+				msg.Content = append(msg.Content, models.Content{
+					Type:     models.ContentTypeImageURL,
+					ImageURL: &models.ImageURL{URL: p.ImageURL.URL, Detail: models.Detail(p.ImageURL.Detail)},
+				})
+			case "video_url":
+				if p.VideoURL == nil {
+					return nil, cleanup, fmt.Errorf("video_url part without url")
+				}
+				path, err := s.materializeVideo(ctx, p.VideoURL.URL)
+				if path != "" {
+					tempFiles = append(tempFiles, path)
+				}
+				if err != nil {
+					return nil, cleanup, err
+				}
+
+				frames, err := s.extractFrames(ctx, path)
+				if err != nil {
+					return nil, cleanup, err
+				}
+				for _, frame := range frames {
+					msg.Content = append(msg.Content, models.Content{
+						Type: models.ContentTypeImageURL,
+						ImageURL: &models.ImageURL{
+							URL:    "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(frame),
+							Detail: models.DetailHigh,
+						},
+					})
+				}
+			default:
+				return nil, cleanup, fmt.Errorf("unsupported content part type %q", p.Type)
+			}
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+
+	if len(req.Messages) == 0 {
+		return nil, cleanup, fmt.Errorf("messages must not be empty")
+	}
+	return req, cleanup, nil
+}
+
+// materializeVideo stores a video URL or data URI in a temp file
+func (s *Server) materializeVideo(ctx context.Context, url string) (string, error) {
+	f, err := os.CreateTemp("", "proxy-video-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := f.Name()
+
+	if strings.HasPrefix(url, "data:") {
+		idx := strings.Index(url, ";base64,")
+		if idx == -1 {
+			f.Close()
+			return path, fmt.Errorf("video data URI must be base64 encoded")
+		}
+		data, err := base64.StdEncoding.DecodeString(url[idx+len(";base64,"):])
+		if err != nil {
+			f.Close()
+			return path, fmt.Errorf("invalid video data URI: %w", err)
+		}
+		_, err = f.Write(data)
+		f.Close()
+		return path, err
+	}
+	f.Close()
+
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return path, fmt.Errorf("unsupported video URL %q", url)
+	}
+	return path, processor.Download(ctx, url, path, processor.DownloadOptions{
+		MaxBytes:  s.MaxVideoBytes,
+		Transport: s.videoTransport(),
+	})
+}
+
+// extractFrames samples frames from a local video
+func (s *Server) extractFrames(ctx context.Context, path string) ([][]byte, error) {
+	duration, err := s.Client.StreamProcessor.ProbeDuration(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video: %w", err)
+	}
+	return s.Client.StreamProcessor.ExtractVideoSegment(ctx, path, 0, duration, s.FramesPerVideo)
+}
+
+// mapModel passes through GLM model names and maps anything else (e.g.
+// "gpt-4o" from existing OpenAI tooling) to the client's default model
+func (s *Server) mapModel(model string) string {
+	if strings.HasPrefix(strings.ToLower(model), "glm") {
+		return model
+	}
+	return s.Client.Model
+}
+
+// openAIResponse converts a Zhipu response into the OpenAI shape
+func openAIResponse(resp *models.ChatResponse) map[string]interface{} {
+	choices := make([]map[string]interface{}, len(resp.Choices))
+	for i, c := range resp.Choices {
+		choices[i] = map[string]interface{}{
+			"index":         c.Index,
+			"message":       map[string]string{"role": string(c.Message.Role), "content": c.Message.Content},
+			"finish_reason": c.FinishReason,
+		}
+	}
+
+	return map[string]interface{}{
+		"id":      resp.ID,
+		"object":  "chat.completion",
+		"created": resp.Created,
+		"model":   resp.Model,
+		"choices": choices,
+		"usage":   resp.Usage,
+	}
+}
+
+// streamChat relays the upstream event stream as OpenAI chunks while it is
+// generated. Errors before the first chunk get a regular error response;
+// later ones end the stream with an error event.
+func (s *Server) streamChat(ctx context.Context, w http.ResponseWriter, req *models.ChatRequest) {
+	flusher, _ := w.(http.Flusher)
+	started := false
+	_, err := s.Client.ChatStream(ctx, req, func(chunk *models.ChatCompletionChunk) error {
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			started = true
+		}
+		out := *chunk
+		out.Object = "chat.completion.chunk"
+		if out.Created == 0 {
+			out.Created = time.Now().Unix()
+		}
+		data, err := json.Marshal(out)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		status, errType := upstreamStatus(err)
+		if !started {
+			writeOpenAIError(w, status, errType, err.Error())
+			return
+		}
+		var e openAIError
+		e.Error.Message, e.Error.Type = err.Error(), errType
+		data, _ := json.Marshal(e)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+	if !started {
+		w.Header().Set("Content-Type", "text/event-stream")
+	}
+	fmt.Fprintf(w, "data: %s\n\n", models.StreamDone)
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// upstreamStatus maps an upstream failure to an OpenAI status and error type
+func upstreamStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errdefs.ErrRateLimited):
+		return http.StatusTooManyRequests, "rate_limit_error"
+	case errors.Is(err, errdefs.ErrContentFiltered), errors.Is(err, errdefs.ErrInvalidRequest):
+		return http.StatusBadRequest, "invalid_request_error"
+	case errors.Is(err, errdefs.ErrPayloadTooLarge):
+		return http.StatusRequestEntityTooLarge, "invalid_request_error"
+	}
+	// Auth and quota failures concern the proxy's own upstream key, not the caller
+	return http.StatusBadGateway, "api_error"
+}
+
+// writeOpenAIError writes an OpenAI-style error
+func writeOpenAIError(w http.ResponseWriter, status int, errType, message string) {
+	var e openAIError
+	e.Error.Message = message
+	e.Error.Type = errType
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(e)
+}
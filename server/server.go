@@ -0,0 +1,105 @@
+// Package server runs the SDK as an HTTP service: health probes and an
+// OpenAI-compatible chat completions endpoint with video support.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/health"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Server serves the HTTP API
+type Server struct {
+	Client         *client.Client
+	Addr           string   // Listen address (default: :8080)
+	Tokens         []string // Accepted inbound bearer tokens (empty: no auth)
+	FramesPerVideo int      // Frames sampled from each video attachment (default: 8)
+	MaxVideoBytes  int64    // Max size of a downloaded video (default: 200MB)
+	MaxBodyBytes   int64    // Max request body size (default: 100MB)
+
+	// VideoHosts restricts video_url downloads to these hosts (".example.com"
+	// also matches subdomains). Listed hosts may resolve to internal
+	// addresses; otherwise only public addresses are fetched.
+	VideoHosts []string
+
+	httpServer *http.Server
+}
+
+// New creates a server for the client. Uploaded videos are untrusted, so
+// the client's processor gets DefaultExecLimits and DefaultInputLimits
+// unless limits are already set.
+func New(c *client.Client) *Server {
+	if sp := c.StreamProcessor; sp != nil {
+		if sp.Limits == nil {
+			sp.WithLimits(processor.DefaultExecLimits())
+		}
+		if sp.InputLimits == nil {
+			sp.WithInputLimits(processor.DefaultInputLimits())
+		}
+	}
+	return &Server{
+		Client:         c,
+		Addr:           ":8080",
+		FramesPerVideo: 8,
+		MaxVideoBytes:  200 << 20,
+		MaxBodyBytes:   100 << 20,
+	}
+}
+
+// Handler returns the HTTP routes:
+//
+//	GET  /healthz              readiness report
+//	POST /v1/chat/completions  OpenAI-compatible chat completions
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", health.Handler(s.Client, health.Options{}))
+	mux.Handle("/v1/chat/completions", s.authenticate(http.HandlerFunc(s.handleChatCompletions)))
+	return mux
+}
+
+// ListenAndServe starts serving until Shutdown is called
+func (s *Server) ListenAndServe() error {
+	s.httpServer = &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	err := s.httpServer.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown gracefully stops the server
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Shutdown(ctx)
+}
+
+// authenticate checks inbound bearer tokens when configured
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.Tokens) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		for _, t := range s.Tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		writeOpenAIError(w, http.StatusUnauthorized, "authentication_error", "invalid API key")
+	})
+}
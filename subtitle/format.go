@@ -0,0 +1,60 @@
+package subtitle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Cue is a single timed subtitle entry
+type Cue struct {
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+	Text  string        `json:"text"`
+}
+
+// WriteSRT writes cues in SubRip (.srt) format
+func WriteSRT(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	for i, cue := range cues {
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n",
+			i+1, formatTime(cue.Start, ","), formatTime(cue.End, ","), cleanText(cue.Text))
+	}
+	return bw.Flush()
+}
+
+// WriteVTT writes cues in WebVTT (.vtt) format
+func WriteVTT(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+	for i, cue := range cues {
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n",
+			i+1, formatTime(cue.Start, "."), formatTime(cue.End, "."), cleanText(cue.Text))
+	}
+	return bw.Flush()
+}
+
+// formatTime formats a duration as HH:MM:SS<sep>mmm
+func formatTime(d time.Duration, sep string) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// cleanText removes blank lines (which terminate a cue in both formats)
+// and the "-->" arrow which is reserved by the cue timing syntax
+func cleanText(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimSpace(strings.ReplaceAll(line, "-->", "->"))
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
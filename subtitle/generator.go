@@ -0,0 +1,132 @@
+package subtitle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/client"
+	"github.com/t8y2/zhipu-video-sdk/models"
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Analyzer is the subset of client.Client used to caption frames
+type Analyzer interface {
+	AnalyzeFramesWithOptions(prompt string, frames [][]byte, options *client.ChatOptions) (*models.ChatResponse, error)
+}
+
+// Transcriber produces speech cues (ASR) for a video; it is optional and
+// used to give the captioner dialogue context
+type Transcriber interface {
+	Transcribe(ctx context.Context, videoPath string) ([]Cue, error)
+}
+
+// DefaultPrompt asks for a short, subtitle-friendly description
+const DefaultPrompt = "请用一句简洁的话描述这一帧画面中的内容，适合作为无障碍字幕，不超过 30 个字。"
+
+// Generator captions sampled frames of a video and aligns them into cues
+type Generator struct {
+	Analyzer    Analyzer
+	Processor   *processor.StreamProcessor
+	Interval    time.Duration       // Sampling interval, one caption per interval (default: 5s)
+	Prompt      string              // Caption prompt (default: DefaultPrompt)
+	Transcriber Transcriber         // Optional ASR source fused into captions
+	IncludeASR  bool                // Append transcribed speech to caption text
+	Options     *client.ChatOptions // Optional chat options
+}
+
+// NewGenerator creates a generator using the client's stream processor
+func NewGenerator(c *client.Client) *Generator {
+	return &Generator{
+		Analyzer:  c,
+		Processor: c.StreamProcessor,
+		Interval:  5 * time.Second,
+		Prompt:    DefaultPrompt,
+	}
+}
+
+// Generate captions the video and returns aligned cues. Consecutive
+// identical captions are merged into one cue.
+func (g *Generator) Generate(ctx context.Context, videoPath string) ([]Cue, error) {
+	interval := g.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	prompt := g.Prompt
+	if prompt == "" {
+		prompt = DefaultPrompt
+	}
+
+	duration, err := g.Processor.ProbeDuration(ctx, videoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video duration: %w", err)
+	}
+
+	var speech []Cue
+	if g.Transcriber != nil {
+		speech, err = g.Transcriber.Transcribe(ctx, videoPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transcribe audio: %w", err)
+		}
+	}
+
+	var cues []Cue
+	for start := time.Duration(0); start < duration; start += interval {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		end := start + interval
+		if end > duration {
+			end = duration
+		}
+
+		frames, err := g.Processor.ExtractVideoSegment(ctx, videoPath, start, end-start, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract frame at %s: %w", formatTime(start, "."), err)
+		}
+
+		dialogue := overlapping(speech, start, end)
+		framePrompt := prompt
+		if dialogue != "" {
+			framePrompt = fmt.Sprintf("%s\n该时间段内的对白供参考：%s", prompt, dialogue)
+		}
+
+		resp, err := g.Analyzer.AnalyzeFramesWithOptions(framePrompt, frames, g.Options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to caption frame at %s: %w", formatTime(start, "."), err)
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+
+		text := strings.TrimSpace(resp.Choices[0].Message.Content)
+		if g.IncludeASR && dialogue != "" {
+			text = text + "\n" + dialogue
+		}
+		if text == "" {
+			continue
+		}
+
+		// Merge with the previous cue when the caption did not change
+		if n := len(cues); n > 0 && cues[n-1].Text == text && cues[n-1].End == start {
+			cues[n-1].End = end
+			continue
+		}
+		cues = append(cues, Cue{Start: start, End: end, Text: text})
+	}
+
+	return cues, nil
+}
+
+// overlapping joins the text of speech cues that overlap [start, end)
+func overlapping(cues []Cue, start, end time.Duration) string {
+	var parts []string
+	for _, c := range cues {
+		if c.Start < end && c.End > start {
+			parts = append(parts, strings.TrimSpace(c.Text))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,272 @@
+// Package usage exports per-call usage records (tokens, cost, latency,
+// status) to rotating CSV or JSONL files, so spend and capacity can be
+// tracked with a spreadsheet instead of a metrics stack.
+package usage
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/t8y2/zhipu-video-sdk/models"
+)
+
+// Format is the on-disk format of exported records
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+// Rotation controls how often a new export file is started
+type Rotation int
+
+const (
+	RotateDaily   Rotation = iota // One file per day (default)
+	RotateHourly                  // One file per hour
+	RotateMonthly                 // One file per month
+	RotateNever                   // A single file
+)
+
+// layout returns the time layout used in file names for the rotation period
+func (r Rotation) layout() string {
+	switch r {
+	case RotateHourly:
+		return "2006-01-02T15"
+	case RotateMonthly:
+		return "2006-01"
+	case RotateNever:
+		return ""
+	}
+	return "2006-01-02"
+}
+
+// Status values recorded for a call
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Record is the usage of one model call
+type Record struct {
+	Time             time.Time     `json:"time"`
+	Source           string        `json:"source,omitempty"`
+	Model            string        `json:"model"`
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	TotalTokens      int           `json:"total_tokens"`
+	Cost             float64       `json:"cost"`
+	Latency          time.Duration `json:"latency_ns"`
+	Status           string        `json:"status"`
+	StatusCode       int           `json:"status_code,omitempty"`
+	Error            string        `json:"error,omitempty"`
+	Labels           models.Labels `json:"labels,omitempty"`
+}
+
+// csvHeader is the header row of CSV exports, matching csvRow
+var csvHeader = []string{
+	"time", "source", "model", "prompt_tokens", "completion_tokens", "total_tokens",
+	"cost", "latency_ms", "status", "status_code", "error", "labels",
+}
+
+// csvRow formats a record as a CSV row
+func (r *Record) csvRow() []string {
+	code := ""
+	if r.StatusCode != 0 {
+		code = strconv.Itoa(r.StatusCode)
+	}
+	return []string{
+		r.Time.Format(time.RFC3339),
+		r.Source,
+		r.Model,
+		strconv.Itoa(r.PromptTokens),
+		strconv.Itoa(r.CompletionTokens),
+		strconv.Itoa(r.TotalTokens),
+		strconv.FormatFloat(r.Cost, 'f', 6, 64),
+		strconv.FormatInt(r.Latency.Milliseconds(), 10),
+		r.Status,
+		code,
+		r.Error,
+		r.Labels.String(),
+	}
+}
+
+// NewRecord builds a record from a call's outcome; resp may be nil on failure
+func NewRecord(ctx context.Context, model string, resp *models.ChatResponse, statusCode int, err error, latency time.Duration) *Record {
+	rec := &Record{
+		Time:       time.Now(),
+		Source:     SourceFrom(ctx),
+		Model:      model,
+		Latency:    latency,
+		Status:     StatusOK,
+		StatusCode: statusCode,
+		Labels:     models.LabelsFrom(ctx),
+	}
+	if resp != nil {
+		rec.PromptTokens = resp.Usage.PromptTokens
+		rec.CompletionTokens = resp.Usage.CompletionTokens
+		rec.TotalTokens = resp.Usage.TotalTokens
+	}
+	if err != nil {
+		rec.Status = StatusError
+		rec.Error = err.Error()
+	}
+	return rec
+}
+
+// Exporter appends records to files in Dir named <Prefix>-<period>.<format>,
+// starting a new file whenever the rotation period changes
+type Exporter struct {
+	Dir      string
+	Prefix   string
+	Format   Format
+	Rotation Rotation
+	Cost     func(model string, promptTokens, completionTokens int) float64 // Fills Record.Cost when zero
+
+	mu     sync.Mutex
+	period string
+	f      *os.File
+	csv    *csv.Writer
+}
+
+// NewExporter creates a daily-rotating exporter that prices calls with the
+// model registry
+func NewExporter(dir string, format Format) *Exporter {
+	return &Exporter{
+		Dir:      dir,
+		Prefix:   "usage",
+		Format:   format,
+		Rotation: RotateDaily,
+		Cost:     models.ModelCost,
+	}
+}
+
+// WithRotation sets the rotation schedule
+func (e *Exporter) WithRotation(r Rotation) *Exporter {
+	e.Rotation = r
+	return e
+}
+
+// Export appends one record, rotating the output file if needed
+func (e *Exporter) Export(rec *Record) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	if rec.Cost == 0 && e.Cost != nil && rec.TotalTokens > 0 {
+		rec.Cost = e.Cost(rec.Model, rec.PromptTokens, rec.CompletionTokens)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.rotate(rec.Time); err != nil {
+		return err
+	}
+
+	if e.Format == FormatCSV {
+		e.csv.Write(rec.csvRow())
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return fmt.Errorf("failed to write usage record: %w", err)
+		}
+		return nil
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage record: %w", err)
+	}
+	if _, err := e.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write usage record: %w", err)
+	}
+	return nil
+}
+
+// Path returns the file that records at time t are written to
+func (e *Exporter) Path(t time.Time) string {
+	name := e.Prefix
+	if layout := e.Rotation.layout(); layout != "" {
+		name += "-" + t.Format(layout)
+	}
+	ext := string(e.Format)
+	if ext == "" {
+		ext = string(FormatJSONL)
+	}
+	return filepath.Join(e.Dir, name+"."+ext)
+}
+
+// rotate makes sure the file for t's period is open; callers hold e.mu
+func (e *Exporter) rotate(t time.Time) error {
+	path := e.Path(t)
+	if e.f != nil && path == e.period {
+		return nil
+	}
+	if err := e.closeFile(); err != nil {
+		return err
+	}
+
+	if e.Dir != "" {
+		if err := os.MkdirAll(e.Dir, 0755); err != nil {
+			return fmt.Errorf("failed to create usage dir: %w", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open usage file: %w", err)
+	}
+	e.f, e.period = f, path
+
+	if e.Format == FormatCSV {
+		e.csv = csv.NewWriter(f)
+		if info, err := f.Stat(); err == nil && info.Size() == 0 {
+			e.csv.Write(csvHeader)
+		}
+	}
+	return nil
+}
+
+// closeFile closes the current file; callers hold e.mu
+func (e *Exporter) closeFile() error {
+	if e.f == nil {
+		return nil
+	}
+	if e.csv != nil {
+		e.csv.Flush()
+		e.csv = nil
+	}
+	err := e.f.Close()
+	e.f = nil
+	if err != nil {
+		return fmt.Errorf("failed to close usage file: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the current file
+func (e *Exporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.closeFile()
+}
+
+type sourceKey struct{}
+
+// WithSource tags ctx with the source (camera, file, tenant...) that calls
+// made with it should be attributed to; finer-grained tags go in
+// models.WithLabels and are exported in the labels column
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFrom returns the source set by WithSource, or ""
+func SourceFrom(ctx context.Context) string {
+	s, _ := ctx.Value(sourceKey{}).(string)
+	return s
+}
@@ -0,0 +1,260 @@
+package vcr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// Mode selects whether the transport records or replays
+type Mode int
+
+const (
+	ModeAuto        Mode = iota // Replay if the cassette exists, record otherwise
+	ModeRecord                  // Always hit the network and record
+	ModeReplay                  // Never hit the network; fail on unknown requests
+	ModePassthrough             // Neither record nor replay
+)
+
+// ErrNoInteraction is returned in replay mode when no recorded interaction
+// matches a request
+var ErrNoInteraction = errors.New("vcr: no recorded interaction matches request")
+
+// scrubbedHeaders are replaced with a placeholder before saving
+var scrubbedHeaders = []string{"Authorization", "X-Api-Key", "Cookie", "Set-Cookie"}
+
+// dataURIPattern matches inline base64 images in request bodies
+var dataURIPattern = regexp.MustCompile(`data:(image/[a-zA-Z0-9.+-]+);base64,[A-Za-z0-9+/=]+`)
+
+// RecordedRequest is the stored form of a request
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body"`
+}
+
+// RecordedResponse is the stored form of a response
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Interaction is one request/response pair
+type Interaction struct {
+	Key      string           `json:"key"`
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is the fixture file content
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Transport is an http.RoundTripper that records and replays API calls.
+// API keys are scrubbed and inline frames are replaced by their SHA-256, so
+// fixtures are safe to commit and small.
+type Transport struct {
+	Path  string            // Cassette file path
+	Mode  Mode              // Effective mode
+	Inner http.RoundTripper // Real transport (default: http.DefaultTransport)
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     map[int]bool
+	dirty    bool
+}
+
+// New creates a transport for the cassette at path. In ModeAuto the mode
+// becomes ModeReplay if the file exists and ModeRecord otherwise.
+func New(path string, mode Mode) (*Transport, error) {
+	t := &Transport{
+		Path:  path,
+		Mode:  mode,
+		Inner: http.DefaultTransport,
+		used:  make(map[int]bool),
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &t.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+		if mode == ModeAuto {
+			t.Mode = ModeReplay
+		}
+	case os.IsNotExist(err):
+		if mode == ModeReplay {
+			return nil, fmt.Errorf("cassette %s not found", path)
+		}
+		if mode == ModeAuto {
+			t.Mode = ModeRecord
+		}
+	default:
+		return nil, fmt.Errorf("failed to read cassette %s: %w", path, err)
+	}
+
+	return t, nil
+}
+
+// Client returns an http.Client using this transport
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("vcr: failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	normalized := NormalizeBody(body)
+	key := requestKey(req.Method, req.URL.String(), normalized)
+
+	switch t.Mode {
+	case ModeReplay:
+		return t.replay(req, key)
+	case ModePassthrough:
+		return t.inner().RoundTrip(req)
+	}
+
+	resp, err := t.inner().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("vcr: failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{
+		Key: key,
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: scrub(req.Header),
+			Body:    normalized,
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    scrub(resp.Header),
+			Body:       string(respBody),
+		},
+	})
+	t.dirty = true
+	t.mu.Unlock()
+
+	return resp, nil
+}
+
+// replay returns the first unused interaction with a matching key
+func (t *Transport) replay(req *http.Request, key string) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, in := range t.cassette.Interactions {
+		if in.Key != key || t.used[i] {
+			continue
+		}
+		t.used[i] = true
+
+		header := in.Response.Headers.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewBufferString(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL)
+}
+
+// Save writes recorded interactions to the cassette file
+func (t *Transport) Save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(t.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette dir: %w", err)
+	}
+	if err := os.WriteFile(t.Path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	t.dirty = false
+	return nil
+}
+
+// inner returns the real transport
+func (t *Transport) inner() http.RoundTripper {
+	if t.Inner != nil {
+		return t.Inner
+	}
+	return http.DefaultTransport
+}
+
+// NormalizeBody replaces inline base64 images with their SHA-256 hash so
+// fixtures stay small and requests with identical frames match
+func NormalizeBody(body []byte) string {
+	return dataURIPattern.ReplaceAllStringFunc(string(body), func(uri string) string {
+		m := dataURIPattern.FindStringSubmatch(uri)
+		sum := sha256.Sum256([]byte(uri))
+		return fmt.Sprintf("data:%s;sha256,%s", m[1], hex.EncodeToString(sum[:]))
+	})
+}
+
+// requestKey identifies a request for matching
+func requestKey(method, url, body string) string {
+	sum := sha256.Sum256([]byte(method + " " + url + "\n" + body))
+	return hex.EncodeToString(sum[:16])
+}
+
+// scrub copies headers replacing secrets with a placeholder
+func scrub(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range scrubbedHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, "[REDACTED]")
+		}
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+package vcr_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/t8y2/zhipu-video-sdk/clienttest"
+	"github.com/t8y2/zhipu-video-sdk/vcr"
+	"github.com/t8y2/zhipu-video-sdk/videotest"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	frames, err := videotest.Frames(videotest.Options{Frames: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	srv := clienttest.NewServer()
+	srv.APIKey = "secret-key"
+	srv.Respond("一辆车驶过")
+
+	rec, err := vcr.New(path, vcr.ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode != vcr.ModeRecord {
+		t.Fatalf("mode = %v without a cassette, want ModeRecord", rec.Mode)
+	}
+	c := srv.Client()
+	rec.Inner = c.HTTPClient.Transport
+	c.HTTPClient = rec.Client()
+	if _, err := c.AnalyzeFramesWithContext(ctx, "描述画面", frames, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	apiURL := c.APIURL
+	srv.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Error("cassette contains the API key")
+	}
+	if strings.Contains(string(data), ";base64,") {
+		t.Error("cassette contains inline frames")
+	}
+
+	play, err := vcr.New(path, vcr.ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if play.Mode != vcr.ModeReplay {
+		t.Fatalf("mode = %v with a cassette, want ModeReplay", play.Mode)
+	}
+	c = srv.Client()
+	c.APIURL = apiURL
+	c.HTTPClient = play.Client()
+	resp, err := c.AnalyzeFramesWithContext(ctx, "描述画面", frames, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Text(); got != "一辆车驶过" {
+		t.Errorf("replayed Text() = %q", got)
+	}
+
+	// Each interaction is replayed once; a changed prompt never matches
+	for _, prompt := range []string{"描述画面", "另一个问题"} {
+		_, err := c.AnalyzeFramesWithContext(ctx, prompt, frames, nil)
+		if !errors.Is(err, vcr.ErrNoInteraction) {
+			t.Errorf("prompt %q: err = %v, want ErrNoInteraction", prompt, err)
+		}
+	}
+}
+
+func TestReplayWithoutCassette(t *testing.T) {
+	if _, err := vcr.New(filepath.Join(t.TempDir(), "missing.json"), vcr.ModeReplay); err == nil {
+		t.Fatal("expected an error for a missing cassette in ModeReplay")
+	}
+}
+
+func TestNormalizeBody(t *testing.T) {
+	a := vcr.NormalizeBody([]byte(`{"url":"data:image/jpeg;base64,AAAA"}`))
+	b := vcr.NormalizeBody([]byte(`{"url":"data:image/jpeg;base64,AAAA"}`))
+	c := vcr.NormalizeBody([]byte(`{"url":"data:image/jpeg;base64,BBBB"}`))
+	if a != b {
+		t.Errorf("identical frames normalized differently: %s, %s", a, b)
+	}
+	if a == c {
+		t.Error("different frames normalized to the same body")
+	}
+	if !strings.Contains(a, "data:image/jpeg;sha256,") {
+		t.Errorf("NormalizeBody = %s", a)
+	}
+}
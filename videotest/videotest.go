@@ -0,0 +1,184 @@
+// Package videotest generates small deterministic synthetic videos for
+// tests, so processor tests and CI do not depend on binary fixtures.
+package videotest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"os/exec"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+// Pattern selects the frame content
+type Pattern int
+
+const (
+	PatternBarsAndBox Pattern = iota // Color bars with a moving white box (default)
+	PatternColorBars                 // Static SMPTE-like color bars
+	PatternMovingBox                 // Moving box on a gray background
+	PatternSolid                     // Solid gray (no motion)
+)
+
+// Options configures generated videos
+type Options struct {
+	Width        int     // Frame width (default: 224, divisible by 28)
+	Height       int     // Frame height (default: 224)
+	Frames       int     // Number of frames (default: 10)
+	FPS          int     // Frame rate for encoded output (default: 2)
+	Pattern      Pattern // Frame content
+	FrameNumbers bool    // Burn the frame number into the top-left corner
+	Quality      int     // JPEG quality (default: 90)
+}
+
+// withDefaults fills zero values
+func (o Options) withDefaults() Options {
+	if o.Width <= 0 {
+		o.Width = 224
+	}
+	if o.Height <= 0 {
+		o.Height = 224
+	}
+	if o.Frames <= 0 {
+		o.Frames = 10
+	}
+	if o.FPS <= 0 {
+		o.FPS = 2
+	}
+	if o.Quality <= 0 {
+		o.Quality = 90
+	}
+	return o
+}
+
+// barColors are the classic 75% color bars
+var barColors = []color.RGBA{
+	{191, 191, 191, 255},
+	{191, 191, 0, 255},
+	{0, 191, 191, 255},
+	{0, 191, 0, 255},
+	{191, 0, 191, 255},
+	{191, 0, 0, 255},
+	{0, 0, 191, 255},
+}
+
+// Frame renders frame i; the output is identical for identical arguments
+func Frame(opts Options, i int) image.Image {
+	o := opts.withDefaults()
+	img := image.NewRGBA(image.Rect(0, 0, o.Width, o.Height))
+
+	switch o.Pattern {
+	case PatternColorBars, PatternBarsAndBox:
+		bar := (o.Width + len(barColors) - 1) / len(barColors)
+		for j, c := range barColors {
+			r := image.Rect(j*bar, 0, (j+1)*bar, o.Height)
+			draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	default:
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{96, 96, 96, 255}), image.Point{}, draw.Src)
+	}
+
+	if o.Pattern == PatternBarsAndBox || o.Pattern == PatternMovingBox {
+		size := o.Height / 5
+		span := o.Width - size
+		x := 0
+		if o.Frames > 1 && span > 0 {
+			// Bounce left to right and back across the frames
+			pos := i * 2 * span / o.Frames
+			if pos > span {
+				pos = 2*span - pos
+			}
+			x = pos
+		}
+		y := (o.Height - size) / 2
+		draw.Draw(img, image.Rect(x, y, x+size, y+size), image.White, image.Point{}, draw.Src)
+	}
+
+	if o.FrameNumbers {
+		label := fmt.Sprintf("#%04d", i)
+		face := basicfont.Face7x13
+		w := font.MeasureString(face, label).Ceil() + 6
+		draw.Draw(img, image.Rect(0, 0, w, face.Height+4), image.Black, image.Point{}, draw.Src)
+		d := &font.Drawer{Dst: img, Src: image.White, Face: face, Dot: fixed.P(3, face.Height)}
+		d.DrawString(label)
+	}
+
+	return img
+}
+
+// Frames renders all frames as JPEG data
+func Frames(opts Options) ([][]byte, error) {
+	o := opts.withDefaults()
+	frames := make([][]byte, o.Frames)
+	for i := range frames {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, Frame(o, i), &jpeg.Options{Quality: o.Quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode frame %d: %w", i, err)
+		}
+		frames[i] = buf.Bytes()
+	}
+	return frames, nil
+}
+
+// WriteMJPEG writes the frames as a concatenated MJPEG stream, readable by
+// ffmpeg with "-f mjpeg" and by processor JPEG splitting
+func WriteMJPEG(w io.Writer, opts Options) error {
+	frames, err := Frames(opts)
+	if err != nil {
+		return err
+	}
+	for _, f := range frames {
+		if _, err := w.Write(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// H264 encodes the frames into a raw Annex B H.264 stream using ffmpeg
+// (libx264, baseline profile). It requires ffmpeg (see processor.LookTool).
+func H264(ctx context.Context, opts Options) ([]byte, error) {
+	o := opts.withDefaults()
+
+	var mjpeg bytes.Buffer
+	if err := WriteMJPEG(&mjpeg, o); err != nil {
+		return nil, err
+	}
+
+	ffmpeg, err := processor.LookTool("ffmpeg")
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-f", "mjpeg",
+		"-framerate", fmt.Sprintf("%d", o.FPS),
+		"-i", "-",
+		"-c:v", "libx264",
+		"-profile:v", "baseline",
+		"-pix_fmt", "yuv420p",
+		"-g", fmt.Sprintf("%d", o.FPS),
+		"-f", "h264",
+		"-",
+	)
+	cmd.Stdin = &mjpeg
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg error: %w, stderr: %s", err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
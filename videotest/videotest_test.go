@@ -0,0 +1,91 @@
+package videotest
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"testing"
+
+	"github.com/t8y2/zhipu-video-sdk/processor"
+)
+
+func TestFrames(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          Options
+		frames        int
+		width, height int
+	}{
+		{"defaults", Options{}, 10, 224, 224},
+		{"custom size", Options{Width: 336, Height: 168, Frames: 3}, 3, 336, 168},
+		{"solid with numbers", Options{Frames: 2, Pattern: PatternSolid, FrameNumbers: true}, 2, 224, 224},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames, err := Frames(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(frames) != tt.frames {
+				t.Fatalf("got %d frames, want %d", len(frames), tt.frames)
+			}
+			for i, f := range frames {
+				cfg, err := jpeg.DecodeConfig(bytes.NewReader(f))
+				if err != nil {
+					t.Fatalf("frame %d: %v", i, err)
+				}
+				if cfg.Width != tt.width || cfg.Height != tt.height {
+					t.Errorf("frame %d is %dx%d, want %dx%d", i, cfg.Width, cfg.Height, tt.width, tt.height)
+				}
+			}
+
+			again, err := Frames(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range frames {
+				if !bytes.Equal(frames[i], again[i]) {
+					t.Errorf("frame %d is not deterministic", i)
+				}
+			}
+		})
+	}
+}
+
+func TestMovingBoxChangesFrames(t *testing.T) {
+	frames, err := Frames(Options{Frames: 2, Pattern: PatternMovingBox})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(frames[0], frames[1]) {
+		t.Error("moving box frames are identical")
+	}
+}
+
+func TestWriteMJPEG(t *testing.T) {
+	opts := Options{Frames: 3}
+	frames, err := Frames(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteMJPEG(&buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), bytes.Join(frames, nil)) {
+		t.Error("MJPEG stream is not the concatenated frames")
+	}
+}
+
+func TestH264(t *testing.T) {
+	if _, err := processor.LookTool("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not available")
+	}
+	data, err := H264(context.Background(), Options{Frames: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte{0, 0, 0, 1}) && !bytes.HasPrefix(data, []byte{0, 0, 1}) {
+		t.Errorf("output does not start with an Annex B start code: % x", data[:min(len(data), 8)])
+	}
+}